@@ -0,0 +1,78 @@
+package statetrooper
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+)
+
+// MetadataAborted is the metadata key marking the audit entries written for transitions that failed after
+// being audited, e.g. because the history store failed, see WithAuditWriter
+const MetadataAborted = "aborted"
+
+// AuditFailurePolicy selects what happens when a transition can't be written to the audit writer
+type AuditFailurePolicy int
+
+const (
+	// AuditFailTransition fails the transition with an AuditError, leaving the FSM unchanged
+	AuditFailTransition AuditFailurePolicy = iota
+	// AuditLogAndContinue logs the error with the standard logger and commits the transition anyway
+	AuditLogAndContinue
+)
+
+// WithAuditWriter writes every committed transition to w as a JSON line before the transition returns,
+// giving an append-only audit trail independent from the history size
+// Each transition is written with a single Write call in the JSON Lines format of WriteHistoryJSONL,
+// and redacted metadata keys are redacted. Writes happen under the FSM's lock, so w needn't be safe
+// for concurrent use unless it is shared with other FSMs
+// The transition is written before it is recorded in the history, so that AuditFailTransition leaves the FSM
+// unchanged. If recording it fails, it is written again with MetadataAborted set to "true"
+// DEFAULT: no audit trail is written
+func WithAuditWriter[T comparable](w io.Writer, policy AuditFailurePolicy) FSMOption[T] {
+	return func(fsm *FSM[T]) {
+		fsm.auditWriter = w
+		fsm.auditPolicy = policy
+	}
+}
+
+// audit writes the transition to the audit writer
+// An error is only returned if the transition must fail according to the failure policy
+func (fsm *FSM[T]) audit(transition Transition[T]) error {
+	err := fsm.writeAudit(transition)
+	if err == nil {
+		return nil
+	}
+
+	if fsm.auditPolicy == AuditLogAndContinue {
+		log.Printf("statetrooper: failed to write transition from %v to %v to the audit log: %v",
+			transition.FromState, transition.ToState, err)
+
+		return nil
+	}
+
+	return AuditError{Err: err}
+}
+
+// auditAborted writes the audited transition again with MetadataAborted set, as it failed to commit
+// A failed write is logged, the transition failing regardless
+func (fsm *FSM[T]) auditAborted(transition Transition[T]) {
+	transition.Metadata = withMetadata(transition.Metadata, MetadataAborted, "true")
+
+	err := fsm.writeAudit(transition)
+	if err != nil {
+		log.Printf("statetrooper: failed to write aborted transition from %v to %v to the audit log: %v",
+			transition.FromState, transition.ToState, err)
+	}
+}
+
+// writeAudit writes the transition to the audit writer as a JSON line
+func (fsm *FSM[T]) writeAudit(transition Transition[T]) error {
+	line, err := json.Marshal(fsm.redact(transition))
+	if err != nil {
+		return err
+	}
+
+	_, err = fsm.auditWriter.Write(append(line, '\n'))
+
+	return err
+}
@@ -0,0 +1,98 @@
+package statetrooper
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func Test_withAuditWriter(t *testing.T) {
+	var buf bytes.Buffer
+
+	// the history only keeps the last transition, the audit trail keeps them all
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 1,
+		WithAuditWriter[CustomStateEnum](&buf, AuditFailTransition),
+		WithRedactedMetadataKeys[CustomStateEnum]("token"),
+	)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumC)
+
+	fsm.Transition(CustomStateEnumB, map[string]string{"token": "secret"})
+	fsm.Transition(CustomStateEnumC, nil)
+	fsm.Transition(CustomStateEnumA, nil) // not allowed, not audited
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 audited transitions, got %d: %s", len(lines), buf.String())
+	}
+
+	var transition Transition[CustomStateEnum]
+	if err := json.Unmarshal([]byte(lines[0]), &transition); err != nil {
+		t.Fatalf("failed to decode audit line: %v", err)
+	}
+
+	if transition.ToState != CustomStateEnumB || transition.Metadata["token"] != RedactedValue {
+		t.Errorf("unexpected audited transition: %+v", transition)
+	}
+}
+
+func Test_withAuditWriterFailurePolicy(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10,
+		WithAuditWriter[CustomStateEnum](failingWriter{}, AuditFailTransition))
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+
+	var auditErr AuditError
+
+	_, err := fsm.Transition(CustomStateEnumB, nil)
+	if !errors.As(err, &auditErr) {
+		t.Fatalf("expected an AuditError, got %v", err)
+	}
+
+	if fsm.CurrentState() != CustomStateEnumA || fsm.Version() != 0 || len(fsm.Transitions()) != 0 {
+		t.Errorf("expected a failed audit to leave the FSM unchanged")
+	}
+
+	fsm = NewFSM[CustomStateEnum](CustomStateEnumA, 10,
+		WithAuditWriter[CustomStateEnum](failingWriter{}, AuditLogAndContinue))
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+
+	if _, err := fsm.Transition(CustomStateEnumB, nil); err != nil {
+		t.Errorf("expected the transition to succeed with AuditLogAndContinue: %v", err)
+	}
+}
+
+func Test_withAuditWriterHistoryFailure(t *testing.T) {
+	var buf bytes.Buffer
+
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10,
+		WithAuditWriter[CustomStateEnum](&buf, AuditFailTransition),
+		WithHistoryStore[CustomStateEnum](&failingHistoryStore[CustomStateEnum]{}),
+	)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+
+	if _, err := fsm.Transition(CustomStateEnumB, nil); err == nil {
+		t.Fatalf("expected the failing history store to fail the transition")
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected the transition and its abort to be audited, got %d lines: %s", len(lines), buf.String())
+	}
+
+	var aborted Transition[CustomStateEnum]
+	if err := json.Unmarshal([]byte(lines[1]), &aborted); err != nil {
+		t.Fatalf("failed to decode audit line: %v", err)
+	}
+
+	if aborted.ToState != CustomStateEnumB || aborted.Version != 1 || aborted.Metadata[MetadataAborted] != "true" {
+		t.Errorf("expected the transition to be audited as aborted, got %+v", aborted)
+	}
+}
@@ -0,0 +1,70 @@
+package statetrooper
+
+import "context"
+
+// actorKey is the context key of the actor set with ContextWithActor
+type actorKey struct{}
+
+// ContextWithActor returns a copy of ctx carrying the actor, e.g. the authenticated user of a request
+// Transitions made with WithContext(ctx) are recorded with the actor unless set with WithActor
+func ContextWithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor set with ContextWithActor, or an empty string
+func ActorFromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey{}).(string)
+
+	return actor
+}
+
+// WithContext passes ctx to the authorizer set with WithAuthorizer. The actor set with ContextWithActor
+// is recorded unless set with WithActor
+// DEFAULT: context.Background()
+func WithContext(ctx context.Context) TransitionOption {
+	return func(opts *transitionOptions) {
+		opts.ctx = ctx
+	}
+}
+
+// WithAuthorizer sets a function consulted before any transition, forced ones included, with the context
+// set with WithContext and the actor set with WithActor or ContextWithActor
+// Transitions made by the FSM itself, such as timeouts, have no actor. If the authorizer returns an error,
+// the transition fails with an AuthorizationError and is recorded in RejectedAttempts if enabled
+// DEFAULT: nil, every transition is authorized
+func WithAuthorizer[T comparable](authorizer func(ctx context.Context, actor string, from, to T) error) FSMOption[T] {
+	return func(fsm *FSM[T]) {
+		fsm.authorizer = authorizer
+	}
+}
+
+// authorize consults the authorizer
+// The caller must hold the lock
+func (fsm *FSM[T]) authorize(targetState T, options *transitionOptions) error {
+	ctx := options.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var err error
+
+	if panicErr := fsm.runHook(func() {
+		err = fsm.authorizer(ctx, options.actor, fsm.currentState, targetState)
+	}); panicErr != nil {
+		err = panicErr
+	}
+
+	if err == nil {
+		return nil
+	}
+
+	return AuthorizationError[T]{Actor: options.actor, FromState: fsm.currentState, ToState: targetState, Err: err}
+}
+
+// hasAuthorizer reports whether an authorizer is set with WithAuthorizer
+func (fsm *FSM[T]) hasAuthorizer() bool {
+	fsm.rlock()
+	defer fsm.runlock()
+
+	return fsm.authorizer != nil
+}
@@ -0,0 +1,57 @@
+package statetrooper
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func Test_withAuthorizer(t *testing.T) {
+	errForbidden := errors.New("forbidden")
+
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10,
+		WithRejectedAttempts[CustomStateEnum](1),
+		WithAuthorizer[CustomStateEnum](func(ctx context.Context, actor string, from, to CustomStateEnum) error {
+			if to == CustomStateEnumC && actor != "admin" {
+				return errForbidden
+			}
+
+			return nil
+		}),
+	)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumC)
+
+	if _, err := fsm.Transition(CustomStateEnumB, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, err := fsm.Transition(CustomStateEnumC, nil, WithActor("user:42"))
+
+	var authErr AuthorizationError[CustomStateEnum]
+	if !errors.As(err, &authErr) || authErr.Actor != "user:42" || !errors.Is(err, errForbidden) {
+		t.Fatalf("expected an AuthorizationError, got %v", err)
+	}
+
+	// forced transitions are authorized too
+	if _, err := fsm.ForceTransition(CustomStateEnumC, nil); !errors.As(err, &authErr) {
+		t.Fatalf("expected an AuthorizationError, got %v", err)
+	}
+
+	rejected := fsm.RejectedAttempts()
+	if len(rejected) != 1 || rejected[0].ToState != CustomStateEnumC || rejected[0].Actor != "" {
+		t.Errorf("expected the last denial to be kept, got %+v", rejected)
+	}
+
+	// the actor is taken from the context
+	ctx := ContextWithActor(context.Background(), "admin")
+
+	if _, err := fsm.Transition(CustomStateEnumC, nil, WithContext(ctx)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	transitions := fsm.Transitions()
+	if actor := transitions[len(transitions)-1].Actor; actor != "admin" {
+		t.Errorf("expected the actor from the context to be recorded, got %q", actor)
+	}
+}
@@ -0,0 +1,127 @@
+package statetrooper
+
+import "sort"
+
+// MetadataAutomatic is the metadata key marking the transitions made along automatic edges, see SetAutomatic
+const MetadataAutomatic = "automatic"
+
+// automaticEdge is an edge the FSM transitions along on its own once it enters the edge's source state
+type automaticEdge[T comparable] struct {
+	to       T
+	priority int
+	guard    func(from, to T) bool
+}
+
+// SetAutomatic marks the edge from fromState to toState as automatic: right after a transition into fromState,
+// the FSM transitions to toState on its own, e.g. to move through pass-through states without an external loop
+// The automatic edges of a state are tried from the highest priority down, the first one whose guard returns true,
+// or that has no guard, and whose transition succeeds being taken. Each hop is recorded as a transition with
+// MetadataAutomatic set to "true" and the actor of the transition that triggered it, and hops chain until
+// a state has no automatic edge to take. The transition that triggered the chain returns the state it ends in
+// A guard that panics skips its edge, the recovered panic being returned by the transition as a PanicError
+// Setting an edge again replaces its priority and guard. A TransitionError is returned if the ruleset doesn't
+// allow the transition
+func (fsm *FSM[T]) SetAutomatic(fromState, toState T, priority int, guard func(from, to T) bool) error {
+	fsm.lock()
+	defer fsm.unlock()
+
+	if !fsm.canTransition(&fromState, &toState) {
+		return TransitionError[T]{FromState: fromState, ToState: toState}
+	}
+
+	fsm.removeAutomatic(fromState, toState)
+
+	if fsm.automatic == nil {
+		fsm.automatic = make(map[T][]automaticEdge[T])
+	}
+
+	edges := append(fsm.automatic[fromState], automaticEdge[T]{to: toState, priority: priority, guard: guard})
+
+	// edges of equal priority are tried in the order they were set
+	sort.SliceStable(edges, func(i, j int) bool {
+		return edges[i].priority > edges[j].priority
+	})
+
+	fsm.automatic[fromState] = edges
+	fsm.automaticCount++
+
+	return nil
+}
+
+// ClearAutomatic removes the automatic marking of the edge from fromState to toState
+func (fsm *FSM[T]) ClearAutomatic(fromState, toState T) {
+	fsm.lock()
+	defer fsm.unlock()
+
+	fsm.removeAutomatic(fromState, toState)
+}
+
+// removeAutomatic removes the automatic edge, if set
+// The caller must hold the lock
+func (fsm *FSM[T]) removeAutomatic(fromState, toState T) {
+	edges := fsm.automatic[fromState]
+
+	for i, e := range edges {
+		if e.to == toState {
+			fsm.automatic[fromState] = append(edges[:i:i], edges[i+1:]...)
+			fsm.automaticCount--
+
+			return
+		}
+	}
+}
+
+// advance takes the first automatic edge of the current state that its guard and the transition allow
+// options are those of the transition that entered the current state
+// The hop's own transition advances further. A chain longer than the number of automatic edges has taken
+// an edge twice and is stopped with ErrAutomaticCycle
+// The caller must hold the lock
+func (fsm *FSM[T]) advance(options transitionOptions) error {
+	edges := fsm.automatic[fsm.currentState]
+	if len(edges) == 0 {
+		return nil
+	}
+
+	if options.automaticHops >= fsm.automaticCount {
+		return ErrAutomaticCycle
+	}
+
+	opts := []TransitionOption{
+		WithActor(options.actor),
+		func(o *transitionOptions) {
+			o.ctx = options.ctx
+			o.automaticHops = options.automaticHops + 1
+		},
+	}
+
+	from, version := fsm.currentState, fsm.version
+
+	// guards that panicked are reported, their edges skipped
+	var panicErrs error
+
+	for _, e := range edges {
+		if e.guard != nil {
+			allowed := false
+
+			if panicErr := fsm.runHook(func() {
+				allowed = e.guard(from, e.to)
+			}); panicErr != nil {
+				panicErrs = appendError(panicErrs, panicErr)
+
+				continue
+			}
+
+			if !allowed {
+				continue
+			}
+		}
+
+		_, err := fsm.transition(e.to, map[string]string{MetadataAutomatic: "true"}, opts)
+		if fsm.version != version {
+			// errors of callbacks run after the commit of the hop
+			return appendError(panicErrs, err)
+		}
+	}
+
+	return panicErrs
+}
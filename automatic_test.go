@@ -0,0 +1,155 @@
+package statetrooper
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func Test_setAutomatic(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumC, CustomStateEnumD)
+	fsm.AddRule(CustomStateEnumC, CustomStateEnumD)
+
+	express := false
+
+	if err := fsm.SetAutomatic(CustomStateEnumB, CustomStateEnumC, 1, nil); err != nil {
+		t.Fatalf("SetAutomatic() returned an error: %v", err)
+	}
+
+	if err := fsm.SetAutomatic(CustomStateEnumB, CustomStateEnumD, 2, func(from, to CustomStateEnum) bool {
+		return express
+	}); err != nil {
+		t.Fatalf("SetAutomatic() returned an error: %v", err)
+	}
+
+	if err := fsm.SetAutomatic(CustomStateEnumC, CustomStateEnumD, 0, nil); err != nil {
+		t.Fatalf("SetAutomatic() returned an error: %v", err)
+	}
+
+	var transitionErr TransitionError[CustomStateEnum]
+	if err := fsm.SetAutomatic(CustomStateEnumD, CustomStateEnumA, 0, nil); !errors.As(err, &transitionErr) {
+		t.Errorf("SetAutomatic() returned %v, expected a TransitionError", err)
+	}
+
+	state, err := fsm.Transition(CustomStateEnumB, nil, WithActor("alice"))
+	if err != nil {
+		t.Fatalf("Transition() returned an error: %v", err)
+	}
+
+	if state != CustomStateEnumD || fsm.CurrentState() != CustomStateEnumD {
+		t.Errorf("expected the FSM to advance to D, got %v and %v", state, fsm.CurrentState())
+	}
+
+	transitions := fsm.Transitions()
+
+	var path []CustomStateEnum
+	for _, transition := range transitions {
+		path = append(path, transition.ToState)
+	}
+
+	if expected := []CustomStateEnum{CustomStateEnumB, CustomStateEnumC, CustomStateEnumD}; !reflect.DeepEqual(path, expected) {
+		t.Fatalf("expected the hops %v to be recorded, got %v", expected, path)
+	}
+
+	if _, ok := transitions[0].Metadata[MetadataAutomatic]; ok {
+		t.Error("expected the triggering transition not to be marked automatic")
+	}
+
+	for _, transition := range transitions[1:] {
+		if transition.Metadata[MetadataAutomatic] != "true" || transition.Actor != "alice" {
+			t.Errorf("expected the hop to be marked automatic with the actor alice, got %+v", transition)
+		}
+	}
+
+	express = true
+
+	fsm, err = fsm.Clone()
+	if err != nil {
+		t.Fatalf("Clone() returned an error: %v", err)
+	}
+
+	if _, err := fsm.ForceTransition(CustomStateEnumB, nil); err != nil {
+		t.Fatalf("ForceTransition() returned an error: %v", err)
+	}
+
+	if last := fsm.Transitions()[len(fsm.Transitions())-1]; last.FromState != CustomStateEnumB || last.ToState != CustomStateEnumD {
+		t.Errorf("expected the higher priority edge to D to be taken once its guard allows it, got %+v", last)
+	}
+
+	fsm.ClearAutomatic(CustomStateEnumB, CustomStateEnumD)
+	fsm.ClearAutomatic(CustomStateEnumB, CustomStateEnumC)
+
+	if _, err := fsm.ForceTransition(CustomStateEnumB, nil); err != nil || fsm.CurrentState() != CustomStateEnumB {
+		t.Errorf("expected the FSM to stay in B once its edges are cleared, got %v, %v", fsm.CurrentState(), err)
+	}
+}
+
+func Test_automaticFallsBackOnRejection(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10, WithMetadataValidator(
+		func(from, to CustomStateEnum, metadata map[string]string) error {
+			if to == CustomStateEnumC {
+				return errors.New("C is closed")
+			}
+
+			return nil
+		},
+	))
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumC, CustomStateEnumD)
+
+	_ = fsm.SetAutomatic(CustomStateEnumB, CustomStateEnumC, 2, nil)
+	_ = fsm.SetAutomatic(CustomStateEnumB, CustomStateEnumD, 1, nil)
+
+	if state, err := fsm.Transition(CustomStateEnumB, nil); err != nil || state != CustomStateEnumD {
+		t.Errorf("expected the FSM to fall back to D, got %v, %v", state, err)
+	}
+}
+
+func Test_automaticCycle(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, UnlimitedHistory)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumC)
+	fsm.AddRule(CustomStateEnumC, CustomStateEnumB)
+
+	_ = fsm.SetAutomatic(CustomStateEnumB, CustomStateEnumC, 0, nil)
+	_ = fsm.SetAutomatic(CustomStateEnumC, CustomStateEnumB, 0, nil)
+
+	_, err := fsm.Transition(CustomStateEnumB, nil)
+	if !errors.Is(err, ErrAutomaticCycle) {
+		t.Fatalf("Transition() returned %v, expected ErrAutomaticCycle", err)
+	}
+
+	if n := len(fsm.Transitions()); n != 3 {
+		t.Errorf("expected the chain to stop after taking each automatic edge once, got %d transitions", n)
+	}
+}
+
+func Test_automaticGuardPanic(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumC, CustomStateEnumD)
+
+	if err := fsm.SetAutomatic(CustomStateEnumB, CustomStateEnumC, 1, func(from, to CustomStateEnum) bool {
+		panic("boom")
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := fsm.SetAutomatic(CustomStateEnumB, CustomStateEnumD, 0, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the edge of the panicking guard is skipped and its panic returned
+	state, err := fsm.Transition(CustomStateEnumB, nil)
+
+	var panicErr PanicError
+	if !errors.As(err, &panicErr) || panicErr.Value != "boom" {
+		t.Errorf("expected the guard's panic to be returned, got %v", err)
+	}
+
+	if state != CustomStateEnumD {
+		t.Errorf("expected the next edge to be taken, got %v", state)
+	}
+}
@@ -0,0 +1,49 @@
+package statetrooper
+
+// StateSetter is implemented by entities whose state field is kept in sync with an FSM, see BindEntity
+type StateSetter[T comparable] interface {
+	SetState(state T)
+}
+
+// Bind keeps the state field of an entity in sync with the FSM: the current state is written to it at once,
+// then every time the state changes, e.g. on transitions, resets, restores and migrations, under the FSM's lock
+// so the FSM and the domain object don't drift. The field should only be read concurrently through the FSM,
+// e.g. with CurrentState. Binding replaces any previous binding and clones aren't bound
+func (fsm *FSM[T]) Bind(state *T) {
+	fsm.lock()
+	defer fsm.unlock()
+
+	fsm.binding = func(newState T) {
+		*state = newState
+	}
+
+	fsm.binding(fsm.currentState)
+}
+
+// BindEntity is like Bind for entities setting their state with a method, e.g. to also update a timestamp
+// SetState is called while the FSM is locked and must not call the FSM's methods
+func (fsm *FSM[T]) BindEntity(entity StateSetter[T]) {
+	fsm.lock()
+	defer fsm.unlock()
+
+	fsm.binding = entity.SetState
+	fsm.binding(fsm.currentState)
+}
+
+// Unbind stops syncing the entity bound with Bind or BindEntity
+func (fsm *FSM[T]) Unbind() {
+	fsm.lock()
+	defer fsm.unlock()
+
+	fsm.binding = nil
+}
+
+// setState changes the current state and writes it to the bound entity, if any
+// The caller must hold the lock
+func (fsm *FSM[T]) setState(state T) {
+	fsm.currentState = state
+
+	if fsm.binding != nil {
+		fsm.binding(state)
+	}
+}
@@ -0,0 +1,85 @@
+package statetrooper
+
+import "testing"
+
+type boundOrder struct {
+	state   CustomStateEnum
+	changes int
+}
+
+func (o *boundOrder) SetState(state CustomStateEnum) {
+	o.state = state
+	o.changes++
+}
+
+func Test_bind(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+
+	var state CustomStateEnum
+
+	fsm.Bind(&state)
+
+	if state != CustomStateEnumA {
+		t.Errorf("expected the field to be set to the current state A, got %v", state)
+	}
+
+	if _, err := fsm.Transition(CustomStateEnumB, nil); err != nil {
+		t.Fatalf("Transition() returned an error: %v", err)
+	}
+
+	if state != CustomStateEnumB {
+		t.Errorf("expected the field to follow the transition to B, got %v", state)
+	}
+
+	if _, err := fsm.Transition(CustomStateEnumC, nil); err == nil {
+		t.Fatal("Transition() to C is expected to fail")
+	}
+
+	if state != CustomStateEnumB {
+		t.Errorf("expected a failed transition to leave the field unchanged, got %v", state)
+	}
+
+	if err := fsm.Reset(CustomStateEnumA); err != nil {
+		t.Fatalf("Reset() returned an error: %v", err)
+	}
+
+	if state != CustomStateEnumA {
+		t.Errorf("expected the field to follow the reset to A, got %v", state)
+	}
+
+	fsm.Unbind()
+
+	if _, err := fsm.Transition(CustomStateEnumB, nil); err != nil {
+		t.Fatalf("Transition() returned an error: %v", err)
+	}
+
+	if state != CustomStateEnumA {
+		t.Errorf("expected an unbound field to be left unchanged, got %v", state)
+	}
+}
+
+func Test_bindEntity(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+
+	order := &boundOrder{}
+	fsm.BindEntity(order)
+
+	snapshot, err := fsm.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot() returned an error: %v", err)
+	}
+
+	if _, err := fsm.Transition(CustomStateEnumB, nil); err != nil {
+		t.Fatalf("Transition() returned an error: %v", err)
+	}
+
+	if err := fsm.Restore(snapshot); err != nil {
+		t.Fatalf("Restore() returned an error: %v", err)
+	}
+
+	if order.state != CustomStateEnumA || order.changes != 3 {
+		t.Errorf("expected the entity to be set on bind, transition and restore, got %+v", order)
+	}
+}
@@ -0,0 +1,175 @@
+package statetrooper
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Builder builds a Definition with a fluent API, reporting every configuration problem at once when built
+//
+//	def, err := statetrooper.NewBuilder[OrderStatusEnum]().
+//		From(StatusCreated).To(StatusPicked, StatusCanceled).
+//		From(StatusPicked).To(StatusShipped).
+//		Terminal(StatusShipped, StatusCanceled).
+//		Initial(StatusCreated).
+//		Build()
+type Builder[T comparable] struct {
+	def        Definition[T]
+	declared   map[T]bool
+	edges      map[Rule[T]]bool
+	from       *T
+	initialSet bool
+	errs       []error
+}
+
+// NewBuilder creates an empty Builder
+func NewBuilder[T comparable]() *Builder[T] {
+	return &Builder[T]{
+		declared: make(map[T]bool),
+		edges:    make(map[Rule[T]]bool),
+	}
+}
+
+// From sets the source state of the rules added by the next calls to To
+func (b *Builder[T]) From(state T) *Builder[T] {
+	b.declare(state)
+	b.from = &state
+
+	return b
+}
+
+// To allows the source state set by the last call to From to transition to the states
+func (b *Builder[T]) To(states ...T) *Builder[T] {
+	if b.from == nil {
+		b.invalid(fmt.Sprintf("To(%s)", joinStates(states)), "no source state, To must follow From")
+
+		return b
+	}
+
+	from := *b.from
+
+	for _, state := range states {
+		b.declare(state)
+
+		edge := Rule[T]{From: from, To: state}
+		if b.edges[edge] {
+			b.invalid(fmt.Sprintf("From(%v).To(%v)", from, state), "duplicate rule from %v to %v", from, state)
+
+			continue
+		}
+
+		b.edges[edge] = true
+
+		// rules of the same source state are merged, even if From is called again
+		b.def.Rules = appendRule(b.def.Rules, from, state)
+	}
+
+	return b
+}
+
+// Terminal declares the states as terminal, so they must not have outgoing rules
+func (b *Builder[T]) Terminal(states ...T) *Builder[T] {
+	for _, state := range states {
+		b.declare(state)
+
+		if !b.def.IsTerminal(state) {
+			b.def.Terminal = append(b.def.Terminal, state)
+		}
+	}
+
+	return b
+}
+
+// Initial sets the state new FSMs start in, which must be the source or target of a rule or terminal
+func (b *Builder[T]) Initial(state T) *Builder[T] {
+	b.def.Initial = state
+	b.initialSet = true
+
+	return b
+}
+
+// Build returns the definition, or every configuration problem joined together, each one being a DefinitionError:
+// a missing or unknown initial state, duplicate rules, terminal states with outgoing rules, states that can't
+// be reached from the initial state, and states without outgoing rules that aren't terminal
+func (b *Builder[T]) Build() (*Definition[T], error) {
+	errs := append([]error(nil), b.errs...)
+
+	invalid := func(path string, format string, args ...any) {
+		errs = append(errs, DefinitionError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	def := b.def
+	def.States = append([]T(nil), b.def.States...)
+	def.Rules = append([]RuleDefinition[T](nil), b.def.Rules...)
+	def.Terminal = append([]T(nil), b.def.Terminal...)
+
+	switch {
+	case !b.initialSet:
+		invalid("Initial", "no initial state")
+	case !b.declared[def.Initial]:
+		invalid(fmt.Sprintf("Initial(%v)", def.Initial), "unknown initial state %v, it isn't part of any rule nor terminal", def.Initial)
+	default:
+		for _, state := range def.Unreachable() {
+			invalid(fmt.Sprintf("From(%v)", state), "state %v can't be reached from the initial state %v", state, def.Initial)
+		}
+	}
+
+	rs := def.Ruleset()
+
+	for _, state := range def.Terminal {
+		if len(rs[state]) > 0 {
+			invalid(fmt.Sprintf("Terminal(%v)", state), "terminal state %v has outgoing rules", state)
+		}
+	}
+
+	for _, state := range def.DeadEnds() {
+		invalid(fmt.Sprintf("To(%v)", state), "state %v has no outgoing rules but isn't terminal", state)
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return &def, nil
+}
+
+// declare adds the state to the states of the definition, in the order they're first used
+func (b *Builder[T]) declare(state T) {
+	if !b.declared[state] {
+		b.declared[state] = true
+		b.def.States = append(b.def.States, state)
+	}
+}
+
+// invalid records a configuration problem reported by Build
+func (b *Builder[T]) invalid(path string, format string, args ...any) {
+	b.errs = append(b.errs, DefinitionError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// appendRule adds the target state to the rule of the source state, creating it if needed
+func appendRule[T comparable](rules []RuleDefinition[T], from, to T) []RuleDefinition[T] {
+	for i := range rules {
+		if rules[i].From == from {
+			rules[i].To = append(rules[i].To, to)
+
+			return rules
+		}
+	}
+
+	return append(rules, RuleDefinition[T]{From: from, To: []T{to}})
+}
+
+// joinStates formats the states separated by commas
+func joinStates[T comparable](states []T) string {
+	s := ""
+
+	for i, state := range states {
+		if i > 0 {
+			s += ", "
+		}
+
+		s += fmt.Sprint(state)
+	}
+
+	return s
+}
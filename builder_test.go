@@ -0,0 +1,125 @@
+package statetrooper
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func Test_builder(t *testing.T) {
+	def, err := NewBuilder[string]().
+		From("created").To("picked", "canceled").
+		From("picked").To("packed", "canceled").
+		From("packed").To("shipped").
+		Terminal("shipped", "canceled").
+		Initial("created").
+		Build()
+	if err != nil {
+		t.Fatalf("Build() returned an error: %v", err)
+	}
+
+	if err := def.Validate(); err != nil {
+		t.Errorf("Validate() returned an error: %v", err)
+	}
+
+	if strings.Join(def.States, ",") != "created,picked,canceled,packed,shipped" {
+		t.Errorf("States = %v, expected the states in the order they're used", def.States)
+	}
+
+	fsm := def.NewFSM(10)
+
+	_, err = fsm.Transition("picked", nil)
+	if err != nil {
+		t.Errorf("Transition(picked) returned an error: %v", err)
+	}
+
+	if fsm.CanTransition("shipped") {
+		t.Errorf("CanTransition(shipped) = true, expected false")
+	}
+}
+
+func Test_builderMergesRulesOfTheSameState(t *testing.T) {
+	def, err := NewBuilder[string]().
+		From("a").To("b").
+		From("b").To("c").
+		From("a").To("c").
+		Terminal("c").
+		Initial("a").
+		Build()
+	if err != nil {
+		t.Fatalf("Build() returned an error: %v", err)
+	}
+
+	if len(def.Rules) != 2 || strings.Join(def.Rules[0].To, ",") != "b,c" {
+		t.Errorf("Rules = %v, expected the rules of a to be merged", def.Rules)
+	}
+}
+
+func Test_builderErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *Builder[string]
+		paths   []string
+	}{
+		{
+			"no initial state",
+			NewBuilder[string]().From("a").To("b").Terminal("b"),
+			[]string{"Initial"},
+		},
+		{
+			"unknown initial state",
+			NewBuilder[string]().From("a").To("b").Terminal("b").Initial("x"),
+			[]string{"Initial(x)"},
+		},
+		{
+			"duplicate rules",
+			NewBuilder[string]().From("a").To("b", "b").From("a").To("b").Terminal("b").Initial("a"),
+			[]string{"From(a).To(b)", "From(a).To(b)"},
+		},
+		{
+			"unreachable states",
+			NewBuilder[string]().From("a").To("b").From("c").To("b").Terminal("b").Initial("a"),
+			[]string{"From(c)"},
+		},
+		{
+			"terminal state with rules and dead end",
+			NewBuilder[string]().From("a").To("b").From("b").To("c").Terminal("b").Initial("a"),
+			[]string{"Terminal(b)", "To(c)"},
+		},
+		{
+			"To without From",
+			NewBuilder[string]().To("a").From("a").To("b").Terminal("b").Initial("a"),
+			[]string{"To(a)"},
+		},
+		{
+			"all problems at once",
+			NewBuilder[string]().From("a").To("b", "b").From("c").To("d").Initial("x"),
+			[]string{"From(a).To(b)", "Initial(x)", "To(b)", "To(d)"},
+		},
+	}
+
+	for _, test := range tests {
+		def, err := test.builder.Build()
+		if err == nil {
+			t.Errorf("%s: Build() did not return an error", test.name)
+
+			continue
+		}
+
+		if def != nil {
+			t.Errorf("%s: Build() returned a definition with an error", test.name)
+		}
+
+		var paths []string
+		for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+			var defErr DefinitionError
+			if errors.As(e, &defErr) {
+				paths = append(paths, defErr.Path)
+			}
+		}
+
+		if strings.Join(paths, ",") != strings.Join(test.paths, ",") {
+			t.Errorf("%s: Build() returned errors for %v, expected %v: %v", test.name, paths, test.paths, err)
+		}
+	}
+}
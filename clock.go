@@ -0,0 +1,69 @@
+package statetrooper
+
+import "time"
+
+// Clock provides the current time and the timers used by the FSM, so that timestamps, timeouts,
+// SLAs, scheduled transitions and retries can all be driven by a fake clock in tests
+// Its shape follows common fake clock packages such as jonboulle/clockwork, which can be adapted
+// with a thin wrapper
+type Clock interface {
+	// Now returns the current time
+	Now() time.Time
+	// NewTimer creates a timer sending the current time on its channel after d
+	NewTimer(d time.Duration) Timer
+	// After waits for d and then sends the current time on the returned channel
+	After(d time.Duration) <-chan time.Time
+	// AfterFunc waits for d and then calls f in its own goroutine
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer is a timer created by a Clock
+type Timer interface {
+	// Chan returns the channel on which the time is sent, or nil for timers created by AfterFunc
+	Chan() <-chan time.Time
+	// Stop prevents the timer from firing, returning false if it already fired or was stopped
+	Stop() bool
+	// Reset changes the timer to expire after d, returning false if it already fired or was stopped
+	Reset(d time.Duration) bool
+}
+
+// RealClock is the Clock backed by the time package
+var RealClock Clock = realClock{}
+
+// realClock implements Clock with the time package
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+func (realClock) AfterFunc(d time.Duration, f func()) Timer {
+	return realTimer{time.AfterFunc(d, f)}
+}
+
+// realTimer implements Timer with a time.Timer
+type realTimer struct {
+	*time.Timer
+}
+
+func (t realTimer) Chan() <-chan time.Time {
+	return t.C
+}
+
+// WithClock sets the clock of the FSM, which provides the time of transitions like WithTimeProvider
+// as well as the timers of timeouts, SLAs, scheduled transitions and retries
+// DEFAULT: RealClock
+func WithClock[T comparable](clock Clock) FSMOption[T] {
+	return func(fsm *FSM[T]) {
+		fsm.clock = clock
+		fsm.timeProvider = clock.Now
+	}
+}
@@ -0,0 +1,169 @@
+package statetrooper
+
+import (
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a Clock whose time only moves with Advance
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+type fakeTimer struct {
+	clock  *fakeClock
+	at     time.Time
+	ch     chan time.Time
+	fn     func()
+	active bool
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *fakeClock) NewTimer(d time.Duration) Timer {
+	return c.add(d, make(chan time.Time, 1), nil)
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	return c.NewTimer(d).Chan()
+}
+
+func (c *fakeClock) AfterFunc(d time.Duration, f func()) Timer {
+	return c.add(d, nil, f)
+}
+
+func (c *fakeClock) add(d time.Duration, ch chan time.Time, fn func()) *fakeTimer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &fakeTimer{clock: c, at: c.now.Add(d), ch: ch, fn: fn, active: true}
+	c.timers = append(c.timers, t)
+
+	return t
+}
+
+// Advance moves the time forward, firing the timers that expire in order
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+
+	var due []*fakeTimer
+
+	for _, t := range c.timers {
+		if t.active && !t.at.After(c.now) {
+			t.active = false
+			due = append(due, t)
+		}
+	}
+
+	now := c.now
+	c.mu.Unlock()
+
+	sort.Slice(due, func(i, j int) bool { return due[i].at.Before(due[j].at) })
+
+	for _, t := range due {
+		if t.fn != nil {
+			t.fn()
+		} else {
+			t.ch <- now
+		}
+	}
+}
+
+// waitIdle waits until no timer is active, e.g. once a timer is stopped on another goroutine,
+// reporting false if timers are still active after the timeout
+func (c *fakeClock) waitIdle(timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+
+	for {
+		c.mu.Lock()
+		active := 0
+
+		for _, t := range c.timers {
+			if t.active {
+				active++
+			}
+		}
+		c.mu.Unlock()
+
+		if active == 0 {
+			return true
+		}
+
+		if time.Now().After(deadline) {
+			return false
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func (t *fakeTimer) Chan() <-chan time.Time {
+	return t.ch
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	active := t.active
+	t.active = false
+
+	return active
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	active := t.active
+	t.active = true
+	t.at = t.clock.now.Add(d)
+
+	return active
+}
+
+func Test_withClock(t *testing.T) {
+	clock := newFakeClock(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10, WithClock[CustomStateEnum](clock))
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumC)
+
+	if err := fsm.SetStateTimeout(CustomStateEnumB, time.Hour, CustomStateEnumC); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	fsm.Transition(CustomStateEnumB, nil)
+
+	clock.Advance(30 * time.Minute)
+
+	if fsm.CurrentState() != CustomStateEnumB || fsm.DwellTime() != 30*time.Minute {
+		t.Fatalf("expected to dwell in %v for 30m, got %v for %v", CustomStateEnumB, fsm.CurrentState(), fsm.DwellTime())
+	}
+
+	// the timeout fires without waiting
+	clock.Advance(30 * time.Minute)
+
+	if fsm.CurrentState() != CustomStateEnumC {
+		t.Fatalf("expected the timeout to fire, got %v", fsm.CurrentState())
+	}
+
+	transitions := fsm.Transitions()
+	if ts := transitions[len(transitions)-1].Timestamp; !ts.Equal(clock.Now()) {
+		t.Errorf("expected the timeout to be recorded at %v, got %v", clock.Now(), ts)
+	}
+}
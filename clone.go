@@ -0,0 +1,182 @@
+package statetrooper
+
+// MetadataReset is the metadata key marking the synthetic transitions recorded by Reset
+const MetadataReset = "reset"
+
+// Clone returns a deep copy of the FSM's state, version, rules and history, e.g. to try transitions
+// without affecting the original
+// The clone keeps its history in memory, even if the FSM uses a HistoryStore, and has the FSM's options,
+// idempotency keys and rejected attempts, except for those with side effects or tied to the original,
+// which are left out: subscribers, asynchronous subscribers, edge hooks set with OnTransition, the binding
+// set with Bind, the audit writer, metrics, transition stats, timeouts, SLAs and the SLA breach handler,
+// rate limits, links, deferred transitions and the deferred dropped handler, and the eviction and schedule
+// skipped handlers. Transitions scheduled on the FSM aren't made on the clone
+func (fsm *FSM[T]) Clone() (*FSM[T], error) {
+	fsm.rlock()
+	defer fsm.runlock()
+
+	transitions, err := fsm.history().List()
+	if err != nil {
+		return nil, err
+	}
+
+	clone := FSM[T]{
+		currentState:        fsm.currentState,
+		ruleset:             fsm.ruleset.clone(),
+		maxHistory:          fsm.maxHistory,
+		noLocking:           fsm.noLocking,
+		compiled:            fsm.compiled,
+		version:             fsm.version,
+		timeProvider:        fsm.timeProvider,
+		clock:               fsm.clock,
+		checkRulesetHash:    fsm.checkRulesetHash,
+		idempotentSameState: fsm.idempotentSameState,
+		strictUnmarshal:     fsm.strictUnmarshal,
+		importTruncation:    fsm.importTruncation,
+		rulesInJSON:         fsm.rulesInJSON,
+		idempotencyWindow:   fsm.idempotencyWindow,
+		defaultMetadata:     fsm.defaultMetadata,
+		metadataValidator:   fsm.metadataValidator,
+		authorizer:          fsm.authorizer,
+		maxRejected:         fsm.maxRejected,
+		redactedKeys:        fsm.redactedKeys,
+		migrations:          fsm.migrations,
+		enteredAt:           fsm.enteredAt,
+		enteredMono:         fsm.enteredMono,
+		entries:             copyEntries(fsm.entries),
+		customTimeProvider:  fsm.customTimeProvider,
+		location:            fsm.location,
+		compaction:          fsm.compaction,
+		compactKeepLast:     fsm.compactKeepLast,
+		maxHistoryAge:       fsm.maxHistoryAge,
+	}
+
+	if fsm.idempotencyKeys != nil {
+		clone.idempotencyKeys = make(map[string]idempotentResult[T], len(fsm.idempotencyKeys))
+		for key, result := range fsm.idempotencyKeys {
+			clone.idempotencyKeys[key] = result
+		}
+
+		clone.idempotencyOrder = append([]rememberedKey(nil), fsm.idempotencyOrder...)
+	}
+
+	if fsm.rejected != nil {
+		clone.rejected = append([]RejectedAttempt[T](nil), fsm.rejected...)
+	}
+
+	if fsm.compensations != nil {
+		clone.compensations = make(map[edge[T]]T, len(fsm.compensations))
+		for e, state := range fsm.compensations {
+			clone.compensations[e] = state
+		}
+	}
+
+	if fsm.automatic != nil {
+		clone.automatic = make(map[T][]automaticEdge[T], len(fsm.automatic))
+		for state, edges := range fsm.automatic {
+			clone.automatic[state] = append([]automaticEdge[T](nil), edges...)
+		}
+
+		clone.automaticCount = fsm.automaticCount
+	}
+
+	if fsm.weights != nil {
+		clone.weights = make(map[edge[T]]float64, len(fsm.weights))
+		for e, weight := range fsm.weights {
+			clone.weights[e] = weight
+		}
+	}
+
+	capacity := len(transitions)
+	if fsm.maxHistory > capacity {
+		capacity = fsm.maxHistory
+	}
+
+	clone.memoryHistory.buf = make([]Transition[T], capacity)
+
+	for _, transition := range transitions {
+		transition.Metadata = copyMetadata(transition.Metadata)
+
+		_ = clone.memoryHistory.Append(transition)
+	}
+
+	return &clone, nil
+}
+
+// ResetOption is a function that sets an option on Reset
+type ResetOption func(*resetOptions)
+
+// resetOptions holds the options for Reset
+type resetOptions struct {
+	record   bool
+	metadata map[string]string
+}
+
+// WithResetTransition records the reset as a transition from the previous state whose metadata
+// has MetadataReset set to "true"
+func WithResetTransition(metadata map[string]string) ResetOption {
+	return func(o *resetOptions) {
+		o.record = true
+		o.metadata = metadata
+	}
+}
+
+// Reset clears the history and the deferred transitions and sets the FSM's state, bypassing the ruleset
+// The version is incremented, so copies of the FSM taken before the reset are conflict checked
+// A reset recorded with WithResetTransition is written to the audit writer and passed to the subscribers
+// like other transitions, the panics recovered from subscribers being returned although the FSM was reset
+func (fsm *FSM[T]) Reset(initialState T, opts ...ResetOption) error {
+	var options resetOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	fsm.lock()
+	defer fsm.unlock()
+
+	tn := fsm.now()
+
+	record := Transition[T]{
+		FromState: fsm.currentState,
+		ToState:   initialState,
+		Timestamp: tn,
+		EventTime: tn,
+		Metadata:  withMetadata(options.metadata, MetadataReset, "true"),
+		Version:   fsm.version + 1,
+	}
+
+	if options.record && fsm.auditWriter != nil {
+		err := fsm.audit(record)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := fsm.replaceHistory(nil)
+	if err == nil && options.record && fsm.maxHistory != 0 {
+		err = fsm.recordTransition(record)
+	}
+
+	if err != nil {
+		if options.record && fsm.auditWriter != nil {
+			fsm.auditAborted(record)
+		}
+
+		return err
+	}
+
+	fsm.setState(initialState)
+	fsm.version++
+	fsm.enter(tn, true)
+	fsm.entries = nil
+	fsm.entered(initialState)
+	fsm.deferred = nil
+
+	fsm.armTimers()
+
+	if !options.record {
+		return nil
+	}
+
+	return fsm.notify(record)
+}
@@ -0,0 +1,190 @@
+package statetrooper
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_clone(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumC)
+
+	if _, err := fsm.Transition(CustomStateEnumB, map[string]string{"k": "v"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	clone, err := fsm.Clone()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if clone.CurrentState() != CustomStateEnumB || clone.Version() != 1 || len(clone.Transitions()) != 1 {
+		t.Fatalf("unexpected clone %v", clone)
+	}
+
+	if _, err := clone.Transition(CustomStateEnumC, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	clone.AddRule(CustomStateEnumC, CustomStateEnumA)
+	clone.Transitions()[0].Metadata["k"] = "changed"
+
+	if fsm.CurrentState() != CustomStateEnumB || fsm.Version() != 1 || len(fsm.Transitions()) != 1 {
+		t.Errorf("expected the original to be unaffected by the clone, got %v", fsm)
+	}
+
+	if fsm.canTransition(&clone.currentState, &fsm.currentState) {
+		t.Errorf("expected the rules of the original to be unaffected by the clone")
+	}
+}
+
+func Test_cloneLeavesOutSideEffects(t *testing.T) {
+	var (
+		audit     bytes.Buffer
+		evicted   int
+		notified  int
+		edgeCalls int
+		bound     CustomStateEnum
+	)
+
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 1,
+		WithAuditWriter[CustomStateEnum](&audit, AuditFailTransition),
+		WithEvictionHandler(func(Transition[CustomStateEnum]) { evicted++ }),
+		WithTransitionStats[CustomStateEnum](10),
+		WithRateLimit[CustomStateEnum](2, time.Hour),
+		WithRejectedAttempts[CustomStateEnum](10),
+	)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumC)
+	fsm.AddRule(CustomStateEnumC, CustomStateEnumA)
+
+	if _, err := fsm.Transition(CustomStateEnumB, nil, WithIdempotencyKey("k")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// rejected, as A isn't allowed from B
+	fsm.Transition(CustomStateEnumA, nil)
+
+	// applied once the FSM reaches C
+	if _, err := fsm.DeferUntilValid(CustomStateEnumA, nil, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	linked := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	linked.AddRule(CustomStateEnumA, CustomStateEnumB)
+	Link(fsm, CustomStateEnumC, linked, CustomStateEnumB, nil)
+
+	fsm.Subscribe(func(Transition[CustomStateEnum]) { notified++ })
+	fsm.OnTransition(CustomStateEnumB, CustomStateEnumC, func(Transition[CustomStateEnum]) error {
+		edgeCalls++
+
+		return nil
+	})
+	fsm.Bind(&bound)
+
+	clone, err := fsm.Clone()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the idempotency key is remembered by the clone
+	if state, err := clone.Transition(CustomStateEnumB, nil, WithIdempotencyKey("k")); err != nil || state != CustomStateEnumB || clone.Version() != 1 {
+		t.Fatalf("expected the clone to deduplicate the key, got %v, %v at version %d", state, err, clone.Version())
+	}
+
+	if len(clone.RejectedAttempts()) != 1 {
+		t.Errorf("expected the clone to keep the rejected attempts, got %v", clone.RejectedAttempts())
+	}
+
+	// more transitions than the rate limit allows, through the linked and deferred states
+	for _, state := range []CustomStateEnum{CustomStateEnumC, CustomStateEnumA, CustomStateEnumB} {
+		if _, err := clone.Transition(state, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if clone.CurrentState() != CustomStateEnumB || clone.Version() != 4 {
+		t.Errorf("expected the clone to take no deferred transition, got %v at version %d", clone.CurrentState(), clone.Version())
+	}
+
+	if lines := strings.Count(audit.String(), "\n"); lines != 1 || evicted != 0 || notified != 0 || edgeCalls != 0 {
+		t.Errorf("expected the clone not to audit, evict, notify or run hooks, got %d, %d, %d and %d", lines, evicted, notified, edgeCalls)
+	}
+
+	if bound != CustomStateEnumB || linked.CurrentState() != CustomStateEnumA {
+		t.Errorf("expected the clone to be neither bound nor linked, got %v and %v", bound, linked.CurrentState())
+	}
+
+	if stats := clone.TransitionStats(); stats.Count != 0 {
+		t.Errorf("expected the clone not to keep transition stats, got %v", stats)
+	}
+}
+
+func Test_resetNotifies(t *testing.T) {
+	var (
+		audit    bytes.Buffer
+		notified []Transition[CustomStateEnum]
+	)
+
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10, WithAuditWriter[CustomStateEnum](&audit, AuditFailTransition))
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+	fsm.Subscribe(func(transition Transition[CustomStateEnum]) {
+		notified = append(notified, transition)
+	})
+
+	if _, err := fsm.Transition(CustomStateEnumB, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// an unrecorded reset has no transition to pass on
+	if err := fsm.Reset(CustomStateEnumA); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := fsm.Reset(CustomStateEnumB, WithResetTransition(nil)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(notified) != 2 || notified[1].ToState != CustomStateEnumB || notified[1].Metadata[MetadataReset] != "true" || notified[1].Version != 3 {
+		t.Errorf("expected the subscriber to be passed the reset, got %v", notified)
+	}
+
+	if lines := strings.Split(strings.TrimSpace(audit.String()), "\n"); len(lines) != 2 || !strings.Contains(lines[1], `"reset":"true"`) {
+		t.Errorf("expected the reset to be audited, got %q", audit.String())
+	}
+}
+
+func Test_reset(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+
+	if _, err := fsm.Transition(CustomStateEnumB, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := fsm.Reset(CustomStateEnumA); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if fsm.CurrentState() != CustomStateEnumA || fsm.Version() != 2 || len(fsm.Transitions()) != 0 {
+		t.Errorf("unexpected state %v, version %d and %d transitions after Reset",
+			fsm.CurrentState(), fsm.Version(), len(fsm.Transitions()))
+	}
+
+	if _, err := fsm.Transition(CustomStateEnumB, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := fsm.Reset(CustomStateEnumA, WithResetTransition(map[string]string{"by": "test"})); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	transitions := fsm.Transitions()
+	if len(transitions) != 1 || transitions[0].FromState != CustomStateEnumB || transitions[0].ToState != CustomStateEnumA ||
+		transitions[0].Metadata[MetadataReset] != "true" || transitions[0].Metadata["by"] != "test" {
+		t.Errorf("expected the reset to be recorded, got %v", transitions)
+	}
+}
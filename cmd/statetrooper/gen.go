@@ -0,0 +1,283 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/hishamk/statetrooper"
+)
+
+// runGen generates a Go file with typed state constants, the ruleset and an FSM constructor,
+// along with a test validating the ruleset
+func runGen(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("gen", flag.ContinueOnError)
+
+	var (
+		in       = flags.String("in", "", "definition file (required)")
+		out      = flags.String("out", "", "output Go file (default: <type in snake case>_states.go next to the definition)")
+		pkg      = flags.String("pkg", os.Getenv("GOPACKAGE"), "package name of the generated code (default: $GOPACKAGE)")
+		typeName = flags.String("type", "State", "name of the generated state type")
+		initial  = flags.String("initial", "", "initial state, overriding the definition")
+		noTest   = flags.Bool("notest", false, "don't generate the validation test")
+	)
+
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if *in == "" {
+		return fmt.Errorf("-in is required")
+	}
+
+	if *pkg == "" {
+		return fmt.Errorf("-pkg is required outside of go generate")
+	}
+
+	def, err := loadDefinition(*in, *initial)
+	if err != nil {
+		return err
+	}
+
+	code, err := generateCode(def, *pkg, *typeName)
+	if err != nil {
+		return err
+	}
+
+	if *out == "" {
+		*out = filepath.Join(filepath.Dir(*in), snakeCase(*typeName)+"_states.go")
+	}
+
+	err = os.WriteFile(*out, code, 0o644)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(stdout, "wrote", *out)
+
+	if *noTest {
+		return nil
+	}
+
+	test, err := generateTest(*pkg, *typeName)
+	if err != nil {
+		return err
+	}
+
+	testOut := strings.TrimSuffix(*out, ".go") + "_test.go"
+
+	err = os.WriteFile(testOut, test, 0o644)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(stdout, "wrote", testOut)
+
+	return nil
+}
+
+// genState is a state as rendered in the generated code
+type genState struct {
+	Ident string
+	Value string
+}
+
+// genRule is a rule as rendered in the generated code
+type genRule struct {
+	From string
+	To   []string
+}
+
+var codeTemplate = template.Must(template.New("code").Parse(`// Code generated by statetrooper gen; DO NOT EDIT.
+
+package {{.Package}}
+
+import "github.com/hishamk/statetrooper"
+
+// {{.Type}} represents the states of the state machine
+type {{.Type}} string
+
+// Enum values for {{.Type}}
+const (
+{{- range .States}}
+	{{.Ident}} {{$.Type}} = {{printf "%q" .Value}}
+{{- end}}
+)
+
+// All{{.Type}}s returns every declared state
+func All{{.Type}}s() []{{.Type}} {
+	return []{{.Type}}{
+{{- range .States}}
+		{{.Ident}},
+{{- end}}
+	}
+}
+
+func (s {{.Type}}) String() string {
+	return string(s)
+}
+
+// {{.Type}}Ruleset returns the valid transitions between the states
+func {{.Type}}Ruleset() statetrooper.Ruleset[{{.Type}}] {
+	return statetrooper.Ruleset[{{.Type}}]{
+{{- range .Rules}}
+		{{.From}}: { {{- range $i, $to := .To}}{{if $i}}, {{end}}{{$to}}{{end -}} },
+{{- end}}
+	}
+}
+
+// New{{.Type}}FSM creates a new FSM in the initial state {{.Initial}} with all rules installed
+func New{{.Type}}FSM(maxHistory int, opts ...statetrooper.FSMOption[{{.Type}}]) *statetrooper.FSM[{{.Type}}] {
+	return statetrooper.NewFSMWithRuleset[{{.Type}}]({{.Initial}}, maxHistory, {{.Type}}Ruleset(), opts...)
+}
+`))
+
+var testTemplate = template.Must(template.New("test").Parse(`// Code generated by statetrooper gen; DO NOT EDIT.
+
+package {{.Package}}
+
+import "testing"
+
+func Test{{.Type}}Validate(t *testing.T) {
+	rs := {{.Type}}Ruleset()
+
+	if err := rs.Validate(); err != nil {
+		t.Errorf("{{.Type}}Ruleset() is invalid: %v", err)
+	}
+
+	declared := make(map[{{.Type}}]bool)
+	for _, state := range All{{.Type}}s() {
+		declared[state] = true
+	}
+
+	for fromState, toStates := range rs {
+		if !declared[fromState] {
+			t.Errorf("rule from undeclared state %v", fromState)
+		}
+
+		for _, toState := range toStates {
+			if !declared[toState] {
+				t.Errorf("rule from %v to undeclared state %v", fromState, toState)
+			}
+		}
+	}
+
+	if New{{.Type}}FSM(0).CurrentState() == "" {
+		t.Errorf("New{{.Type}}FSM() has no initial state")
+	}
+}
+`))
+
+// generateCode renders the Go code for the definition
+func generateCode(def *statetrooper.Definition[string], pkg string, typeName string) ([]byte, error) {
+	idents := make(map[string]string, len(def.States))
+	states := make([]genState, 0, len(def.States))
+	taken := make(map[string]string, len(def.States))
+
+	for _, state := range def.States {
+		ident := typeName + exportedName(state)
+
+		if other, ok := taken[ident]; ok {
+			return nil, fmt.Errorf("states %q and %q both map to the identifier %s", other, state, ident)
+		}
+
+		taken[ident] = state
+		idents[state] = ident
+		states = append(states, genState{Ident: ident, Value: state})
+	}
+
+	rules := make([]genRule, 0, len(def.Rules))
+
+	for _, rule := range def.Rules {
+		r := genRule{From: idents[rule.From]}
+
+		for _, toState := range rule.To {
+			r.To = append(r.To, idents[toState])
+		}
+
+		rules = append(rules, r)
+	}
+
+	return render(codeTemplate, map[string]any{
+		"Package": pkg,
+		"Type":    typeName,
+		"States":  states,
+		"Rules":   rules,
+		"Initial": idents[def.Initial],
+	})
+}
+
+// generateTest renders the validation test for the generated code
+func generateTest(pkg string, typeName string) ([]byte, error) {
+	return render(testTemplate, map[string]any{
+		"Package": pkg,
+		"Type":    typeName,
+	})
+}
+
+// render executes the template and formats the result as Go code
+func render(tmpl *template.Template, data any) ([]byte, error) {
+	var buf bytes.Buffer
+
+	err := tmpl.Execute(&buf, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return format.Source(buf.Bytes())
+}
+
+// exportedName converts a state name such as "on hold" or "in-transit" into an exported Go identifier
+func exportedName(name string) string {
+	var sb strings.Builder
+
+	upper := true
+
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+
+			continue
+		}
+
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+
+		sb.WriteRune(r)
+	}
+
+	if sb.Len() == 0 {
+		return "Unnamed"
+	}
+
+	return sb.String()
+}
+
+// snakeCase converts a Go identifier such as OrderState into order_state
+func snakeCase(name string) string {
+	var sb strings.Builder
+
+	for i, r := range name {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				sb.WriteByte('_')
+			}
+
+			r = unicode.ToLower(r)
+		}
+
+		sb.WriteRune(r)
+	}
+
+	return sb.String()
+}
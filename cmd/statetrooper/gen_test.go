@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const orderYAML = `states: [created, picked, "on hold", shipped]
+initial: created
+rules:
+  - from: created
+    to: [picked, on hold]
+  - from: on hold
+    to: [picked]
+  - from: picked
+    to: [shipped]
+terminal: [shipped]
+`
+
+const orderMermaid = `graph LR;
+created --> picked;
+picked --> shipped;
+`
+
+func Test_runGen(t *testing.T) {
+	dir := t.TempDir()
+
+	in := filepath.Join(dir, "order.yaml")
+	err := os.WriteFile(in, []byte(orderYAML), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+
+	code := run([]string{"gen", "-in", in, "-pkg", "orders", "-type", "OrderState"}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("gen exited with %d: %s", code, stderr.String())
+	}
+
+	generated, err := os.ReadFile(filepath.Join(dir, "order_state_states.go"))
+	if err != nil {
+		t.Fatalf("gen did not write the code: %v", err)
+	}
+
+	for _, expected := range []string{
+		"package orders",
+		`OrderStateOnHold OrderState = "on hold"`,
+		"OrderStateCreated: {OrderStatePicked, OrderStateOnHold},",
+		"statetrooper.NewFSMWithRuleset[OrderState](OrderStateCreated, maxHistory, OrderStateRuleset(), opts...)",
+	} {
+		if !strings.Contains(collapseSpaces(string(generated)), expected) {
+			t.Errorf("generated code does not contain %q:\n%s", expected, generated)
+		}
+	}
+
+	test, err := os.ReadFile(filepath.Join(dir, "order_state_states_test.go"))
+	if err != nil {
+		t.Fatalf("gen did not write the test: %v", err)
+	}
+
+	if !strings.Contains(string(test), "func TestOrderStateValidate(t *testing.T)") {
+		t.Errorf("generated test does not contain the validation test:\n%s", test)
+	}
+}
+
+func Test_runGenMermaid(t *testing.T) {
+	dir := t.TempDir()
+
+	in := filepath.Join(dir, "order.mmd")
+	err := os.WriteFile(in, []byte(orderMermaid), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := filepath.Join(dir, "states.go")
+
+	var stdout, stderr bytes.Buffer
+
+	// Mermaid diagrams don't define an initial state
+	code := run([]string{"gen", "-in", in, "-out", out, "-pkg", "orders"}, &stdout, &stderr)
+	if code == 0 {
+		t.Errorf("gen without an initial state exited with 0")
+	}
+
+	stderr.Reset()
+
+	code = run([]string{"gen", "-in", in, "-out", out, "-pkg", "orders", "-initial", "created", "-notest"}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("gen exited with %d: %s", code, stderr.String())
+	}
+
+	generated, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("gen did not write the code: %v", err)
+	}
+
+	if !strings.Contains(collapseSpaces(string(generated)), "StatePicked: {StateShipped},") {
+		t.Errorf("generated code does not contain the rules:\n%s", generated)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "states_test.go")); err == nil {
+		t.Errorf("gen wrote a test despite -notest")
+	}
+}
+
+// collapseSpaces collapses the alignment added by gofmt
+func collapseSpaces(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func Test_exportedName(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"created", "Created"},
+		{"on hold", "OnHold"},
+		{"in-transit", "InTransit"},
+		{"READY_2_SHIP", "READY2SHIP"},
+		{"--", "Unnamed"},
+	}
+
+	for _, test := range tests {
+		actual := exportedName(test.input)
+		if actual != test.expected {
+			t.Errorf("exportedName(%q) = %q, expected %q", test.input, actual, test.expected)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/hishamk/statetrooper"
+	"gopkg.in/yaml.v3"
+)
+
+// loadDefinition reads a state machine definition, picking the format from the file extension
+// initial overrides the initial state of the definition, and is required for Mermaid files
+func loadDefinition(path string, initial string) (*statetrooper.Definition[string], error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var def *statetrooper.Definition[string]
+
+	switch ext := filepath.Ext(path); ext {
+	case ".yaml", ".yml":
+		def, err = statetrooper.LoadRuleset[string](f, statetrooper.Format(yaml.Unmarshal))
+	case ".json":
+		def, err = statetrooper.LoadRuleset[string](f, statetrooper.FormatJSON)
+	case ".mmd", ".mermaid":
+		def, err = loadMermaid(path)
+	default:
+		return nil, fmt.Errorf("unsupported definition format %q", ext)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	if initial != "" {
+		def.Initial = initial
+	}
+
+	if def.Initial == "" {
+		return nil, fmt.Errorf("%s: no initial state, use -initial", path)
+	}
+
+	err = def.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return def, nil
+}
+
+// loadMermaid builds a definition from the rules of a Mermaid diagram
+func loadMermaid(path string) (*statetrooper.Definition[string], error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	rs, err := statetrooper.ParseMermaidRules[string](string(data))
+	if err != nil {
+		return nil, err
+	}
+
+	def := statetrooper.Definition[string]{}
+	declared := make(map[string]bool)
+
+	declare := func(state string) {
+		if !declared[state] {
+			declared[state] = true
+			def.States = append(def.States, state)
+		}
+	}
+
+	fromStates := make([]string, 0, len(rs))
+	for fromState := range rs {
+		fromStates = append(fromStates, fromState)
+	}
+
+	sort.Strings(fromStates)
+
+	for _, fromState := range fromStates {
+		declare(fromState)
+
+		for _, toState := range rs[fromState] {
+			declare(toState)
+		}
+
+		def.Rules = append(def.Rules, statetrooper.RuleDefinition[string]{
+			From: fromState,
+			To:   rs[fromState],
+		})
+	}
+
+	return &def, nil
+}
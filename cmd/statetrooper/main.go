@@ -0,0 +1,87 @@
+/*
+Command statetrooper works with state machine definitions outside of Go code.
+
+Usage:
+
+	statetrooper <command> [flags]
+
+The commands are:
+
+	gen       generate Go code with typed states and rules from a definition
+	validate  check a definition for unreachable states and non-terminal dead ends
+	render    print a Mermaid, DOT or PlantUML diagram of the rules of a definition
+	simulate  apply a sequence of transitions from the initial state and print the result
+
+Definitions are read from YAML (.yaml, .yml), JSON (.json) or Mermaid (.mmd, .mermaid) files.
+YAML and JSON files use the statetrooper.Definition format; Mermaid files are parsed with
+statetrooper.ParseMermaidRules and require the initial state to be passed with -initial.
+
+The gen command is meant to be used with go generate:
+
+	//go:generate go run github.com/hishamk/statetrooper/cmd/statetrooper gen -in order.yaml -type OrderState
+*/
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// command is a statetrooper subcommand
+type command struct {
+	name  string
+	usage string
+	run   func(args []string, stdout io.Writer) error
+}
+
+var commands = []command{
+	{"gen", "generate Go code with typed states and rules from a definition", runGen},
+	{"validate", "check a definition for unreachable states and non-terminal dead ends", runValidate},
+	{"render", "print a Mermaid, DOT or PlantUML diagram of the rules of a definition", runRender},
+	{"simulate", "apply a sequence of transitions from the initial state and print the result", runSimulate},
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run runs the subcommand named by the first argument and returns the exit code
+func run(args []string, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+
+		return 2
+	}
+
+	for _, cmd := range commands {
+		if cmd.name != args[0] {
+			continue
+		}
+
+		err := cmd.run(args[1:], stdout)
+		if err != nil {
+			fmt.Fprintf(stderr, "statetrooper %s: %v\n", cmd.name, err)
+
+			return 1
+		}
+
+		return 0
+	}
+
+	fmt.Fprintf(stderr, "statetrooper: unknown command %q\n", args[0])
+	usage(stderr)
+
+	return 2
+}
+
+// usage prints the list of commands
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: statetrooper <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", cmd.name, cmd.usage)
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeDefinition writes a definition file into a temporary directory and returns its path
+func writeDefinition(t *testing.T, name string, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+
+	err := os.WriteFile(path, []byte(content), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func Test_run(t *testing.T) {
+	tests := []struct {
+		args []string
+		code int
+	}{
+		{nil, 2},                 // No command
+		{[]string{"unknown"}, 2}, // Unknown command
+		{[]string{"gen"}, 1},     // Missing flags
+	}
+
+	for _, test := range tests {
+		var stdout, stderr bytes.Buffer
+
+		code := run(test.args, &stdout, &stderr)
+		if code != test.code {
+			t.Errorf("run(%v) = %d, expected %d", test.args, code, test.code)
+		}
+
+		if code == 2 && !strings.Contains(stderr.String(), "Usage:") {
+			t.Errorf("run(%v) did not print the usage: %s", test.args, stderr.String())
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+)
+
+// runRender prints a diagram of the rules of a definition
+func runRender(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("render", flag.ContinueOnError)
+
+	var (
+		in      = flags.String("in", "", "definition file (required)")
+		initial = flags.String("initial", "", "initial state, overriding the definition")
+		format  = flags.String("format", "mermaid", "diagram format: mermaid, dot, plantuml or ascii")
+	)
+
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if *in == "" {
+		return fmt.Errorf("-in is required")
+	}
+
+	def, err := loadDefinition(*in, *initial)
+	if err != nil {
+		return err
+	}
+
+	fsm := def.NewFSM(0)
+
+	var diagram string
+
+	switch *format {
+	case "mermaid":
+		diagram, err = fsm.GenerateMermaidRulesDiagram()
+	case "dot":
+		diagram, err = fsm.GenerateDOTRulesDiagram()
+	case "plantuml":
+		diagram, err = fsm.GeneratePlantUMLRulesDiagram()
+	case "ascii":
+		diagram, err = fsm.GenerateASCIIDiagram()
+	default:
+		return fmt.Errorf("unsupported diagram format %q", *format)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprint(stdout, diagram)
+
+	return nil
+}
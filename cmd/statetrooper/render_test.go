@@ -0,0 +1,35 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func Test_runRender(t *testing.T) {
+	in := writeDefinition(t, "order.mmd", orderMermaid)
+
+	tests := []struct {
+		format   string
+		expected string
+		code     int
+	}{
+		{"mermaid", "graph LR;\ncreated\npicked\ncreated --> picked;\npicked --> shipped;\n", 0},
+		{"dot", "digraph {\n\trankdir=LR;\n\t\"created\";\n\t\"picked\";\n\t\"created\" -> \"picked\";\n\t\"picked\" -> \"shipped\";\n}\n", 0},
+		{"plantuml", "@startuml\ncreated --> picked\npicked --> shipped\n@enduml\n", 0},
+		{"ascii", "created\n  `--> picked\npicked\n  `--> shipped\nshipped (final)\n", 0},
+		{"svg", "", 1},
+	}
+
+	for _, test := range tests {
+		var stdout, stderr bytes.Buffer
+
+		code := run([]string{"render", "-in", in, "-initial", "created", "-format", test.format}, &stdout, &stderr)
+		if code != test.code {
+			t.Errorf("render -format %s exited with %d, expected %d: %s", test.format, code, test.code, stderr.String())
+		}
+
+		if stdout.String() != test.expected {
+			t.Errorf("render -format %s printed:\n%s\nexpected:\n%s", test.format, stdout.String(), test.expected)
+		}
+	}
+}
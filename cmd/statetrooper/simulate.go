@@ -0,0 +1,67 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/hishamk/statetrooper"
+)
+
+// runSimulate applies a sequence of transitions to a definition starting from its initial state
+func runSimulate(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("simulate", flag.ContinueOnError)
+
+	var (
+		in      = flags.String("in", "", "definition file (required)")
+		initial = flags.String("initial", "", "initial state, overriding the definition")
+	)
+
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: statetrooper simulate -in <definition> <state>...")
+		flags.PrintDefaults()
+	}
+
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if *in == "" {
+		return fmt.Errorf("-in is required")
+	}
+
+	if flags.NArg() == 0 {
+		return fmt.Errorf("no target states given")
+	}
+
+	def, err := loadDefinition(*in, *initial)
+	if err != nil {
+		return err
+	}
+
+	fsm := def.NewFSM(statetrooper.UnlimitedHistory)
+
+	fmt.Fprintf(stdout, "start: %s\n", fsm.CurrentState())
+
+	for i, targetState := range flags.Args() {
+		fromState := fsm.CurrentState()
+
+		_, err = fsm.Transition(targetState, nil)
+		if err != nil {
+			return fmt.Errorf("step %d: %w", i+1, err)
+		}
+
+		fmt.Fprintf(stdout, "%d: %s -> %s\n", i+1, fromState, targetState)
+	}
+
+	fmt.Fprintf(stdout, "final: %s", fsm.CurrentState())
+
+	if def.IsTerminal(fsm.CurrentState()) {
+		fmt.Fprint(stdout, " (terminal)")
+	}
+
+	fmt.Fprintln(stdout)
+
+	return nil
+}
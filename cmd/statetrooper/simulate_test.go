@@ -0,0 +1,35 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func Test_runSimulate(t *testing.T) {
+	in := writeDefinition(t, "order.yaml", orderYAML)
+
+	var stdout, stderr bytes.Buffer
+
+	code := run([]string{"simulate", "-in", in, "on hold", "picked", "shipped"}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("simulate exited with %d: %s", code, stderr.String())
+	}
+
+	expected := "start: created\n1: created -> on hold\n2: on hold -> picked\n3: picked -> shipped\nfinal: shipped (terminal)\n"
+
+	if stdout.String() != expected {
+		t.Errorf("simulate printed:\n%s\nexpected:\n%s", stdout.String(), expected)
+	}
+
+	stdout.Reset()
+
+	code = run([]string{"simulate", "-in", in, "picked", "created"}, &stdout, &stderr)
+	if code != 1 {
+		t.Errorf("simulate of an invalid transition exited with %d, expected 1", code)
+	}
+
+	if !strings.Contains(stderr.String(), "step 2: invalid state transition from picked to created") {
+		t.Errorf("simulate did not report the invalid step: %s", stderr.String())
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+)
+
+// runValidate checks a definition for unreachable states and non-terminal dead ends
+func runValidate(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("validate", flag.ContinueOnError)
+
+	var (
+		in      = flags.String("in", "", "definition file (required)")
+		initial = flags.String("initial", "", "initial state, overriding the definition")
+	)
+
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if *in == "" {
+		return fmt.Errorf("-in is required")
+	}
+
+	def, err := loadDefinition(*in, *initial)
+	if err != nil {
+		return err
+	}
+
+	problems := 0
+
+	for _, state := range def.Unreachable() {
+		fmt.Fprintf(stdout, "unreachable: state %q can't be reached from the initial state %q\n", state, def.Initial)
+		problems++
+	}
+
+	for _, state := range def.DeadEnds() {
+		fmt.Fprintf(stdout, "dead end: state %q has no outgoing rules and is not declared terminal\n", state)
+		problems++
+	}
+
+	if problems > 0 {
+		return fmt.Errorf("%s: %d problem(s) found", *in, problems)
+	}
+
+	fmt.Fprintf(stdout, "%s: ok\n", *in)
+
+	return nil
+}
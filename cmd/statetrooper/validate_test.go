@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func Test_runValidate(t *testing.T) {
+	tests := []struct {
+		definition string
+		code       int
+		output     []string
+	}{
+		{orderYAML, 0, []string{"ok"}},
+		{
+			`{"states": ["a", "b", "c", "d"], "initial": "a", "rules": [{"from": "a", "to": ["b"]}, {"from": "c", "to": ["a"]}], "terminal": ["b"]}`,
+			1,
+			[]string{`unreachable: state "c"`, `unreachable: state "d"`, `dead end: state "d"`},
+		},
+	}
+
+	for i, test := range tests {
+		name := "definition.json"
+		if strings.HasPrefix(test.definition, "states:") {
+			name = "definition.yaml"
+		}
+
+		in := writeDefinition(t, name, test.definition)
+
+		var stdout, stderr bytes.Buffer
+
+		code := run([]string{"validate", "-in", in}, &stdout, &stderr)
+		if code != test.code {
+			t.Errorf("test %d: validate exited with %d, expected %d: %s", i, code, test.code, stderr.String())
+		}
+
+		for _, expected := range test.output {
+			if !strings.Contains(stdout.String(), expected) {
+				t.Errorf("test %d: validate output does not contain %q:\n%s", i, expected, stdout.String())
+			}
+		}
+	}
+}
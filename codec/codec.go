@@ -0,0 +1,155 @@
+/*
+Package codec encodes statetrooper transitions and FSM snapshots with MessagePack or CBOR, for event
+buses where the JSON round-trip costs too many bytes and too much CPU.
+
+	data, err := codec.MarshalTransition(codec.CBOR, transition)
+
+	transition, err := codec.UnmarshalTransition[OrderStatus](codec.CBOR, data)
+
+FSMs are encoded as their statetrooper.Snapshot, so they are decoded into an FSM that already has its rules.
+
+The package lives in its own module, so the root module doesn't depend on the MessagePack and CBOR libraries.
+Field names follow the JSON encoding, e.g. from_state and to_state.
+*/
+package codec
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/hishamk/statetrooper"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Format is a binary encoding format
+type Format struct {
+	name      string
+	marshal   func(v any) ([]byte, error)
+	unmarshal func(data []byte, v any) error
+}
+
+// String returns the name of the format
+func (f Format) String() string {
+	return f.name
+}
+
+// cborEncMode keeps the full precision and the location of timestamps
+var cborEncMode, _ = cbor.EncOptions{
+	Time:    cbor.TimeRFC3339Nano,
+	TimeTag: cbor.EncTagRequired,
+}.EncMode()
+
+var (
+	// MessagePack is the MessagePack format
+	MessagePack = Format{
+		name: "msgpack",
+		marshal: func(v any) ([]byte, error) {
+			var buf bytes.Buffer
+
+			enc := msgpack.NewEncoder(&buf)
+			enc.SetCustomStructTag("json")
+
+			err := enc.Encode(v)
+
+			return buf.Bytes(), err
+		},
+		unmarshal: func(data []byte, v any) error {
+			dec := msgpack.NewDecoder(bytes.NewReader(data))
+			dec.SetCustomStructTag("json")
+
+			return dec.Decode(v)
+		},
+	}
+
+	// CBOR is the CBOR format
+	CBOR = Format{
+		name:      "cbor",
+		marshal:   cborEncMode.Marshal,
+		unmarshal: cbor.Unmarshal,
+	}
+)
+
+// transition mirrors statetrooper.Transition without its methods, so the formats encode its fields
+// instead of its text encoding
+type transition[T comparable] struct {
+	FromState T                 `json:"from_state"`
+	ToState   T                 `json:"to_state"`
+	Timestamp time.Time         `json:"timestamp"`
+	EventTime time.Time         `json:"event_time"`
+	Actor     string            `json:"actor,omitempty"`
+	Reason    string            `json:"reason,omitempty"`
+	Metadata  map[string]string `json:"metadata"`
+	Version   uint64            `json:"version,omitempty"`
+}
+
+// snapshot mirrors statetrooper.Snapshot
+type snapshot[T comparable] struct {
+	FormatVersion int             `json:"format_version"`
+	State         T               `json:"state"`
+	Version       uint64          `json:"version"`
+	RulesetHash   string          `json:"ruleset_hash"`
+	Transitions   []transition[T] `json:"transitions"`
+}
+
+// MarshalTransition encodes the transition in the format
+func MarshalTransition[T comparable](f Format, t statetrooper.Transition[T]) ([]byte, error) {
+	return f.marshal(transition[T](t))
+}
+
+// UnmarshalTransition decodes a transition encoded by MarshalTransition
+func UnmarshalTransition[T comparable](f Format, data []byte) (statetrooper.Transition[T], error) {
+	var t transition[T]
+
+	err := f.unmarshal(data, &t)
+
+	return statetrooper.Transition[T](t), err
+}
+
+// MarshalFSM encodes a snapshot of the FSM in the format
+// As with statetrooper.Snapshot, metadata isn't redacted
+func MarshalFSM[T comparable](f Format, fsm *statetrooper.FSM[T]) ([]byte, error) {
+	snap, err := fsm.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	s := snapshot[T]{
+		FormatVersion: snap.FormatVersion,
+		State:         snap.State,
+		Version:       snap.Version,
+		RulesetHash:   snap.RulesetHash,
+		Transitions:   make([]transition[T], 0, len(snap.Transitions)),
+	}
+
+	for _, t := range snap.Transitions {
+		s.Transitions = append(s.Transitions, transition[T](t))
+	}
+
+	return f.marshal(s)
+}
+
+// UnmarshalFSM decodes a snapshot encoded by MarshalFSM and restores it into the FSM, which must have
+// the rules the snapshot was taken under, see statetrooper.FSM.Restore
+func UnmarshalFSM[T comparable](f Format, data []byte, fsm *statetrooper.FSM[T]) error {
+	var s snapshot[T]
+
+	err := f.unmarshal(data, &s)
+	if err != nil {
+		return err
+	}
+
+	snap := statetrooper.Snapshot[T]{
+		FormatVersion: s.FormatVersion,
+		State:         s.State,
+		Version:       s.Version,
+		RulesetHash:   s.RulesetHash,
+		Transitions:   make([]statetrooper.Transition[T], 0, len(s.Transitions)),
+	}
+
+	for _, t := range s.Transitions {
+		snap.Transitions = append(snap.Transitions, statetrooper.Transition[T](t))
+	}
+
+	return fsm.Restore(snap)
+}
@@ -0,0 +1,87 @@
+package codec
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hishamk/statetrooper"
+)
+
+type orderStatus string
+
+const (
+	statusCreated orderStatus = "created"
+	statusPicked  orderStatus = "picked"
+	statusPacked  orderStatus = "packed"
+)
+
+func newFSM(t *testing.T) *statetrooper.FSM[orderStatus] {
+	t.Helper()
+
+	fsm := statetrooper.NewFSM[orderStatus](statusCreated, 10)
+	fsm.AddRule(statusCreated, statusPicked)
+	fsm.AddRule(statusPicked, statusPacked)
+
+	return fsm
+}
+
+func equalTransitions(a, b statetrooper.Transition[orderStatus]) bool {
+	return a.FromState == b.FromState && a.ToState == b.ToState &&
+		a.Timestamp.Equal(b.Timestamp) && a.EventTime.Equal(b.EventTime) &&
+		a.Actor == b.Actor && a.Reason == b.Reason &&
+		len(a.Metadata) == len(b.Metadata) && a.Metadata["k"] == b.Metadata["k"]
+}
+
+func Test_transition(t *testing.T) {
+	transition := statetrooper.Transition[orderStatus]{
+		FromState: statusCreated,
+		ToState:   statusPicked,
+		Timestamp: time.Date(2023, 6, 18, 1, 2, 3, 456789, time.UTC),
+		EventTime: time.Date(2023, 6, 18, 1, 2, 0, 0, time.UTC),
+		Actor:     "picker:7",
+		Metadata:  map[string]string{"k": "v"},
+	}
+
+	for _, format := range []Format{MessagePack, CBOR} {
+		data, err := MarshalTransition(format, transition)
+		if err != nil {
+			t.Fatalf("%v: MarshalTransition() returned an error: %v", format, err)
+		}
+
+		decoded, err := UnmarshalTransition[orderStatus](format, data)
+		if err != nil {
+			t.Fatalf("%v: UnmarshalTransition() returned an error: %v", format, err)
+		}
+
+		if !equalTransitions(decoded, transition) {
+			t.Errorf("%v: decoded %v, expected %v", format, decoded, transition)
+		}
+	}
+}
+
+func Test_fsm(t *testing.T) {
+	fsm := newFSM(t)
+
+	if _, err := fsm.Transition(statusPicked, map[string]string{"k": "v"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, format := range []Format{MessagePack, CBOR} {
+		data, err := MarshalFSM(format, fsm)
+		if err != nil {
+			t.Fatalf("%v: MarshalFSM() returned an error: %v", format, err)
+		}
+
+		decoded := newFSM(t)
+
+		err = UnmarshalFSM(format, data, decoded)
+		if err != nil {
+			t.Fatalf("%v: UnmarshalFSM() returned an error: %v", format, err)
+		}
+
+		if decoded.CurrentState() != statusPicked || decoded.Version() != 1 || len(decoded.Transitions()) != 1 ||
+			!equalTransitions(decoded.Transitions()[0], fsm.Transitions()[0]) {
+			t.Errorf("%v: unexpected decoded FSM %v", format, decoded)
+		}
+	}
+}
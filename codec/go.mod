@@ -0,0 +1,16 @@
+module github.com/hishamk/statetrooper/codec
+
+go 1.20
+
+require (
+	github.com/fxamacker/cbor/v2 v2.7.0
+	github.com/hishamk/statetrooper v0.0.0
+	github.com/vmihailenco/msgpack/v5 v5.4.1
+)
+
+require (
+	github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
+	github.com/x448/float16 v0.8.4 // indirect
+)
+
+replace github.com/hishamk/statetrooper => ../
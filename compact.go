@@ -0,0 +1,207 @@
+package statetrooper
+
+import (
+	"strconv"
+	"time"
+)
+
+// MetadataCompacted is the metadata key holding the number of transitions summarized by a compacted record
+const MetadataCompacted = "compacted"
+
+// MetadataCompactedSince is the metadata key holding the RFC 3339 timestamp of the first transition
+// summarized by a compacted record, whose own timestamps are those of the last one
+const MetadataCompactedSince = "compacted_since"
+
+// WithHistoryCompaction compacts the history with CompactHistory whenever it is full, before
+// the oldest transitions are evicted, so that retry storms don't push the useful transitions out
+// of a bounded history
+// keepLast is the number of newest transitions left as they are
+// DEFAULT: the history is not compacted
+func WithHistoryCompaction[T comparable](keepLast int) FSMOption[T] {
+	return func(fsm *FSM[T]) {
+		fsm.compaction = true
+		fsm.compactKeepLast = keepLast
+
+		if keepLast < 0 {
+			fsm.compactKeepLast = 0
+		}
+	}
+}
+
+// CompactHistory collapses the runs of repeated self-transitions and of ping-pong transitions between
+// two states into a single record, except within the newest keepLast transitions
+// A compacted record goes from the first state of the run to the last one and keeps the last
+// transition's timestamps, actor, reason and metadata, to which MetadataCompacted and
+// MetadataCompactedSince are added. Compacted records are compacted again with their neighbors
+// Compacted records aren't passed to the eviction handler
+func (fsm *FSM[T]) CompactHistory(keepLast int) error {
+	fsm.lock()
+	defer fsm.unlock()
+
+	transitions, err := fsm.history().List()
+	if err != nil {
+		return err
+	}
+
+	compacted, ok := compactTransitions(transitions, keepLast)
+	if !ok {
+		return nil
+	}
+
+	return fsm.replaceHistory(compacted)
+}
+
+// autoCompact compacts the history if WithHistoryCompaction is set and the history is full
+// The in-memory history is only listed once full and if a run may have formed since it was last compacted
+// The caller must hold the lock
+func (fsm *FSM[T]) autoCompact() error {
+	if !fsm.compaction || fsm.maxHistory <= 0 {
+		return nil
+	}
+
+	if fsm.historyStore == nil && (fsm.compactClean || fsm.memoryHistory.Len() < fsm.maxHistory) {
+		return nil
+	}
+
+	transitions, err := fsm.history().List()
+	if err != nil {
+		return err
+	}
+
+	if len(transitions) < fsm.maxHistory {
+		return nil
+	}
+
+	compacted, ok := compactTransitions(transitions, fsm.compactKeepLast)
+	if ok {
+		err = fsm.replaceHistory(compacted)
+		if err != nil {
+			return err
+		}
+	}
+
+	fsm.compactClean = true
+
+	return nil
+}
+
+// trackRuns checks if the transition that entered the compacted part of the in-memory history, before
+// its newest keepLast transitions, starts a run with the previous one, which then needs to be compacted
+// The caller must hold the lock
+func (fsm *FSM[T]) trackRuns() {
+	if !fsm.compactClean || fsm.historyStore != nil {
+		return
+	}
+
+	n := fsm.memoryHistory.Len() - fsm.compactKeepLast
+	if n < 2 {
+		return
+	}
+
+	prev, next := fsm.memoryHistory.at(n-2), fsm.memoryHistory.at(n-1)
+	if sameRun(prev, prev, next) {
+		fsm.compactClean = false
+	}
+}
+
+// compactTransitions returns the transitions with the runs before the newest keepLast collapsed,
+// and whether any run was found
+// Collapsing is repeated until no run is left, since a collapsed ping-pong run is a self-transition
+// that may form a new run with its neighbors
+func compactTransitions[T comparable](transitions []Transition[T], keepLast int) ([]Transition[T], bool) {
+	if keepLast < 0 {
+		keepLast = 0
+	}
+
+	compacted := false
+
+	for {
+		next, ok := compactPass(transitions, keepLast)
+		if !ok {
+			return transitions, compacted
+		}
+
+		transitions, compacted = next, true
+	}
+}
+
+// compactPass collapses the runs before the newest keepLast transitions once
+func compactPass[T comparable](transitions []Transition[T], keepLast int) ([]Transition[T], bool) {
+	n := len(transitions) - keepLast
+	if n < 2 {
+		return transitions, false
+	}
+
+	compacted := make([]Transition[T], 0, len(transitions))
+
+	for i := 0; i < n; {
+		j := i + 1
+		for j < n && sameRun(transitions[i], transitions[j-1], transitions[j]) {
+			j++
+		}
+
+		if j-i > 1 {
+			compacted = append(compacted, summarize(transitions[i:j]))
+		} else {
+			compacted = append(compacted, transitions[i])
+		}
+
+		i = j
+	}
+
+	if len(compacted) == n {
+		return transitions, false
+	}
+
+	return append(compacted, transitions[n:]...), true
+}
+
+// sameRun checks if next continues the run started by first, whose latest transition is prev,
+// i.e. it follows prev and moves between the same two states
+func sameRun[T comparable](first, prev, next Transition[T]) bool {
+	if next.FromState != prev.ToState {
+		return false
+	}
+
+	return (next.FromState == first.FromState && next.ToState == first.ToState) ||
+		(next.FromState == first.ToState && next.ToState == first.FromState)
+}
+
+// summarize collapses a run of transitions into a single record
+func summarize[T comparable](run []Transition[T]) Transition[T] {
+	last := run[len(run)-1]
+
+	count := 0
+	for _, transition := range run {
+		count += compactedCount(transition)
+	}
+
+	since := run[0].Timestamp.Format(time.RFC3339Nano)
+	if value, ok := run[0].Metadata[MetadataCompactedSince]; ok {
+		since = value
+	}
+
+	metadata := make(map[string]string, len(last.Metadata)+2)
+	for key, value := range last.Metadata {
+		metadata[key] = value
+	}
+
+	metadata[MetadataCompacted] = strconv.Itoa(count)
+	metadata[MetadataCompactedSince] = since
+
+	summary := last
+	summary.FromState = run[0].FromState
+	summary.Metadata = metadata
+
+	return summary
+}
+
+// compactedCount returns the number of transitions a record stands for
+func compactedCount[T comparable](transition Transition[T]) int {
+	count, err := strconv.Atoi(transition.Metadata[MetadataCompacted])
+	if err != nil || count < 1 {
+		return 1
+	}
+
+	return count
+}
@@ -0,0 +1,141 @@
+package statetrooper
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_compactHistory(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 20)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumA, CustomStateEnumB, CustomStateEnumC)
+	fsm.AddRule(CustomStateEnumC, CustomStateEnumD)
+
+	path := []CustomStateEnum{
+		CustomStateEnumB, CustomStateEnumA, CustomStateEnumB, CustomStateEnumA, CustomStateEnumB,
+		CustomStateEnumB, CustomStateEnumB, CustomStateEnumC, CustomStateEnumD,
+	}
+
+	for _, state := range path {
+		if _, err := fsm.Transition(state, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	recorded := fsm.Transitions()
+
+	if err := fsm.CompactHistory(1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	transitions := fsm.Transitions()
+	if len(transitions) != 4 {
+		t.Fatalf("expected 4 transitions, got %d: %+v", len(transitions), transitions)
+	}
+
+	pingPong := transitions[0]
+	if pingPong.FromState != CustomStateEnumA || pingPong.ToState != CustomStateEnumB ||
+		pingPong.Metadata[MetadataCompacted] != "5" ||
+		pingPong.Metadata[MetadataCompactedSince] != recorded[0].Timestamp.Format(time.RFC3339Nano) ||
+		!pingPong.Timestamp.Equal(recorded[4].Timestamp) {
+		t.Errorf("unexpected ping-pong record: %+v", pingPong)
+	}
+
+	selfLoop := transitions[1]
+	if selfLoop.FromState != CustomStateEnumB || selfLoop.ToState != CustomStateEnumB ||
+		selfLoop.Metadata[MetadataCompacted] != "2" || !selfLoop.Timestamp.Equal(recorded[6].Timestamp) {
+		t.Errorf("unexpected self-transition record: %+v", selfLoop)
+	}
+
+	if transitions[2].ToState != CustomStateEnumC || transitions[3].ToState != CustomStateEnumD ||
+		transitions[2].Metadata != nil || transitions[3].Metadata != nil {
+		t.Errorf("expected the other transitions to be left as they are, got %+v", transitions[2:])
+	}
+}
+
+func Test_withHistoryCompaction(t *testing.T) {
+	var evicted []Transition[CustomStateEnum]
+
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 5,
+		WithHistoryCompaction[CustomStateEnum](1),
+		WithEvictionHandler[CustomStateEnum](func(transition Transition[CustomStateEnum]) {
+			evicted = append(evicted, transition)
+		}),
+	)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumC)
+	fsm.AddRule(CustomStateEnumC, CustomStateEnumD)
+	fsm.AddRule(CustomStateEnumD, CustomStateEnumC)
+
+	if _, err := fsm.Transition(CustomStateEnumC, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for i := 0; i < 10; i++ {
+		target := CustomStateEnumD
+		if i%2 == 1 {
+			target = CustomStateEnumC
+		}
+
+		if _, err := fsm.Transition(target, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if len(evicted) != 0 {
+		t.Errorf("expected no evictions, got %+v", evicted)
+	}
+
+	transitions := fsm.Transitions()
+	if transitions[0].ToState != CustomStateEnumC {
+		t.Errorf("expected the oldest transition to be kept, got %+v", transitions)
+	}
+
+	total := 0
+	for _, transition := range transitions {
+		total += compactedCount(transition)
+	}
+
+	if total != 11 {
+		t.Errorf("expected the history to account for 11 transitions, got %d: %+v", total, transitions)
+	}
+}
+
+func Test_withHistoryCompactionIncremental(t *testing.T) {
+	// the in-memory history is only compacted when a run may have formed, the custom store on every transition
+	now := func() time.Time {
+		return time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	memory := NewFSM[CustomStateEnum](CustomStateEnumA, 8,
+		WithHistoryCompaction[CustomStateEnum](2),
+		WithTimeProvider[CustomStateEnum](now),
+	)
+	custom := NewFSM[CustomStateEnum](CustomStateEnumA, 8,
+		WithHistoryCompaction[CustomStateEnum](2),
+		WithTimeProvider[CustomStateEnum](now),
+		WithHistoryStore[CustomStateEnum](NewMemoryHistoryStore[CustomStateEnum](8)),
+	)
+
+	states := []CustomStateEnum{CustomStateEnumA, CustomStateEnumB, CustomStateEnumC}
+
+	for _, fsm := range []*FSM[CustomStateEnum]{memory, custom} {
+		for _, state := range states {
+			fsm.AddRule(state, states...)
+		}
+	}
+
+	rng := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 500; i++ {
+		state := states[rng.Intn(len(states))]
+
+		_, _ = memory.Transition(state, nil)
+		_, _ = custom.Transition(state, nil)
+
+		if !reflect.DeepEqual(memory.Transitions(), custom.Transitions()) {
+			t.Fatalf("histories differ after %d transitions:\n%v\n%v", i+1, memory.Transitions(), custom.Transitions())
+		}
+	}
+}
@@ -0,0 +1,10 @@
+module github.com/hishamk/statetrooper/compat
+
+go 1.20
+
+require (
+	github.com/hishamk/statetrooper v0.0.0
+	github.com/looplab/fsm v1.0.3
+)
+
+replace github.com/hishamk/statetrooper => ../
@@ -0,0 +1,148 @@
+/*
+Package looplab builds statetrooper FSMs from github.com/looplab/fsm definitions, to migrate without
+rewriting the events and callbacks of existing state machines.
+
+	m := looplab.New("closed", 10, fsm.Events{
+		{Name: "open", Src: []string{"closed"}, Dst: "open"},
+		{Name: "close", Src: []string{"open"}, Dst: "closed"},
+	}, fsm.Callbacks{
+		"enter_open": func(ctx context.Context, e *fsm.Event) { ... },
+	})
+
+	err := m.Event(ctx, "open")
+
+Events are run by a looplab FSM, so callbacks keep their semantics, including cancelation, while the
+transitions are committed to the statetrooper FSM, which is the source of truth for the state. The
+statetrooper FSM's own guards, e.g. its authorizer and metadata validator, apply to every event and
+cancel it when they reject the transition.
+*/
+package looplab
+
+import (
+	"context"
+
+	"github.com/hishamk/statetrooper"
+	"github.com/looplab/fsm"
+)
+
+// MetadataEvent is the metadata key recording the name of the event that caused a transition
+const MetadataEvent = "event"
+
+// Ruleset returns the rules of the events, each source state being allowed to transition to the event's destination
+func Ruleset(events fsm.Events) statetrooper.Ruleset[string] {
+	rs := make(statetrooper.Ruleset[string])
+
+	for _, event := range events {
+		for _, src := range event.Src {
+			if !contains(rs[src], event.Dst) {
+				rs[src] = append(rs[src], event.Dst)
+			}
+		}
+	}
+
+	return rs
+}
+
+// Machine runs looplab/fsm events on a statetrooper FSM
+type Machine struct {
+	fsm     *statetrooper.FSM[string]
+	looplab *fsm.FSM
+}
+
+// New creates a statetrooper FSM in the initial state, with the rules of the events and the options, keeping
+// maxHistory transitions, and a Machine running the events and callbacks on it
+func New(
+	initial string,
+	maxHistory int,
+	events fsm.Events,
+	callbacks fsm.Callbacks,
+	opts ...statetrooper.FSMOption[string],
+) *Machine {
+	m := Machine{
+		fsm: statetrooper.NewFSMWithRuleset[string](initial, maxHistory, Ruleset(events), opts...),
+	}
+
+	wrapped := make(fsm.Callbacks, len(callbacks)+2)
+	for name, callback := range callbacks {
+		wrapped[name] = callback
+	}
+
+	leave, enter := callbacks["leave_state"], callbacks["enter_state"]
+
+	// the transition is committed once every leave callback allowed it
+	wrapped["leave_state"] = func(ctx context.Context, e *fsm.Event) {
+		if leave != nil {
+			leave(ctx, e)
+		}
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		_, err := m.fsm.Transition(e.Dst, map[string]string{MetadataEvent: e.Event})
+		if err != nil {
+			e.Cancel(err)
+		}
+	}
+
+	// asynchronous transitions, started by calling Async in a leave_<state> callback, are committed when completed
+	wrapped["enter_state"] = func(ctx context.Context, e *fsm.Event) {
+		if m.fsm.CurrentState() != e.Dst {
+			_, err := m.fsm.Transition(e.Dst, map[string]string{MetadataEvent: e.Event})
+			if err != nil {
+				e.Err = err
+			}
+		}
+
+		if enter != nil {
+			enter(ctx, e)
+		}
+	}
+
+	m.looplab = fsm.NewFSM(initial, events, wrapped)
+
+	return &m
+}
+
+// FSM returns the statetrooper FSM the events are run on
+func (m *Machine) FSM() *statetrooper.FSM[string] {
+	return m.fsm
+}
+
+// Current returns the current state of the statetrooper FSM
+func (m *Machine) Current() string {
+	return m.fsm.CurrentState()
+}
+
+// Can checks if the event can occur in the current state
+func (m *Machine) Can(event string) bool {
+	m.looplab.SetState(m.fsm.CurrentState())
+
+	return m.looplab.Can(event)
+}
+
+// Event runs the event with looplab/fsm's semantics and errors, e.g. fsm.InvalidEventError if it can't occur in
+// the current state, the transition being recorded by the statetrooper FSM with MetadataEvent set to its name
+// The transition is canceled with a fsm.CanceledError wrapping the statetrooper error if the FSM rejects it
+// Events shouldn't run concurrently with transitions made directly on the statetrooper FSM
+func (m *Machine) Event(ctx context.Context, event string, args ...interface{}) error {
+	m.looplab.SetState(m.fsm.CurrentState())
+
+	return m.looplab.Event(ctx, event, args...)
+}
+
+// Transition completes an asynchronous transition, see fsm.FSM.Transition
+func (m *Machine) Transition() error {
+	return m.looplab.Transition()
+}
+
+// contains checks if the state is one of the states
+func contains(states []string, state string) bool {
+	for _, s := range states {
+		if s == state {
+			return true
+		}
+	}
+
+	return false
+}
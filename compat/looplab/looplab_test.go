@@ -0,0 +1,114 @@
+package looplab
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/hishamk/statetrooper"
+	"github.com/looplab/fsm"
+)
+
+var doorEvents = fsm.Events{
+	{Name: "open", Src: []string{"closed"}, Dst: "open"},
+	{Name: "close", Src: []string{"open"}, Dst: "closed"},
+	{Name: "lock", Src: []string{"closed"}, Dst: "locked"},
+	{Name: "unlock", Src: []string{"locked"}, Dst: "closed"},
+}
+
+func TestRuleset(t *testing.T) {
+	expected := statetrooper.Ruleset[string]{
+		"closed": {"open", "locked"},
+		"open":   {"closed"},
+		"locked": {"closed"},
+	}
+
+	if rs := Ruleset(doorEvents); !reflect.DeepEqual(rs, expected) {
+		t.Errorf("Ruleset() = %v, expected %v", rs, expected)
+	}
+}
+
+func TestMachineEvent(t *testing.T) {
+	var calls []string
+
+	m := New("closed", 10, doorEvents, fsm.Callbacks{
+		"before_open": func(_ context.Context, e *fsm.Event) {
+			calls = append(calls, "before_open")
+		},
+		"leave_state": func(_ context.Context, e *fsm.Event) {
+			calls = append(calls, "leave_state")
+		},
+		"enter_open": func(_ context.Context, e *fsm.Event) {
+			calls = append(calls, "enter_open")
+		},
+		"before_lock": func(_ context.Context, e *fsm.Event) {
+			e.Cancel(errors.New("door is jammed"))
+		},
+	})
+
+	if err := m.Event(context.Background(), "open"); err != nil {
+		t.Fatalf("Event() returned an error: %v", err)
+	}
+
+	if m.Current() != "open" || m.FSM().CurrentState() != "open" {
+		t.Errorf("expected the FSM to be open, got %s", m.Current())
+	}
+
+	if expected := []string{"before_open", "leave_state", "enter_open"}; !reflect.DeepEqual(calls, expected) {
+		t.Errorf("expected the callbacks %v to be called, got %v", expected, calls)
+	}
+
+	transitions := m.FSM().Transitions()
+	if len(transitions) != 1 || transitions[0].Metadata[MetadataEvent] != "open" {
+		t.Errorf("expected the transition to be recorded with the event, got %+v", transitions)
+	}
+
+	var invalid fsm.InvalidEventError
+	if err := m.Event(context.Background(), "open"); !errors.As(err, &invalid) {
+		t.Errorf("Event() returned %v, expected an InvalidEventError", err)
+	}
+
+	if err := m.Event(context.Background(), "close"); err != nil {
+		t.Fatalf("Event() returned an error: %v", err)
+	}
+
+	var canceled fsm.CanceledError
+	if err := m.Event(context.Background(), "lock"); !errors.As(err, &canceled) || m.Current() != "closed" {
+		t.Errorf("Event() returned %v in %s, expected a CanceledError in closed", err, m.Current())
+	}
+}
+
+func TestMachineStatetrooperGuards(t *testing.T) {
+	m := New("closed", 10, doorEvents, nil, statetrooper.WithMetadataValidator(
+		func(from, to string, metadata map[string]string) error {
+			if to == "locked" {
+				return errors.New("locking is disabled")
+			}
+
+			return nil
+		},
+	))
+
+	var canceled fsm.CanceledError
+	if err := m.Event(context.Background(), "lock"); !errors.As(err, &canceled) {
+		t.Fatalf("Event() returned %v, expected a CanceledError", err)
+	}
+
+	var metadataErr statetrooper.MetadataError[string]
+	if !errors.As(canceled.Err, &metadataErr) || m.Current() != "closed" {
+		t.Errorf("expected the MetadataError to cancel the event, got %v in %s", canceled.Err, m.Current())
+	}
+
+	if !m.Can("open") || m.Can("unlock") {
+		t.Error("expected open to be possible and unlock not to be")
+	}
+
+	if err := m.FSM().Reset("locked"); err != nil {
+		t.Fatalf("Reset() returned an error: %v", err)
+	}
+
+	if err := m.Event(context.Background(), "unlock"); err != nil || m.Current() != "closed" {
+		t.Errorf("expected events to follow the statetrooper FSM's state, got %v in %s", err, m.Current())
+	}
+}
@@ -0,0 +1,305 @@
+/*
+Package stateless builds statetrooper FSMs with the configuration API of github.com/qmuntal/stateless, to migrate
+existing state machines by changing the import and adding the state and trigger types.
+
+	sm := stateless.NewStateMachine[State, Trigger](StateOffHook, 10)
+
+	sm.Configure(StateOffHook).
+		Permit(TriggerCallDialed, StateRinging)
+
+	sm.Configure(StateRinging).
+		OnEntryFrom(TriggerCallDialed, startRinging).
+		Permit(TriggerCallConnected, StateConnected, isAvailable).
+		Permit(TriggerCallConnected, StateVoicemail).
+		Ignore(TriggerCallDialed)
+
+	err := sm.Fire(TriggerCallDialed, "555-0100")
+
+Each permitted transition is a rule of the statetrooper FSM, which records the transitions with MetadataTrigger
+set to the trigger. Guards, entry and exit actions are run by the StateMachine around the FSM's transition,
+whose own guards, e.g. its authorizer and metadata validator, also apply.
+Substates, internal transitions, dynamic destinations and the firing modes aren't supported.
+*/
+package stateless
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/hishamk/statetrooper"
+)
+
+// MetadataTrigger is the metadata key recording the trigger that caused a transition
+const MetadataTrigger = "trigger"
+
+// GuardFunc is a guard of a transition, which is only taken if all its guards return true
+type GuardFunc = func(ctx context.Context, args ...any) bool
+
+// ActionFunc is an entry or exit action, given the arguments the trigger was fired with
+type ActionFunc = func(ctx context.Context, args ...any) error
+
+// UnhandledTriggerError is returned when a trigger is fired in a state that doesn't permit or ignore it,
+// or when the guards of all its transitions returned false
+type UnhandledTriggerError[S, T comparable] struct {
+	State   S
+	Trigger T
+	// GuardsUnmet is set if the state has transitions for the trigger but none of them has its guards met
+	GuardsUnmet bool
+}
+
+func (err UnhandledTriggerError[S, T]) Error() string {
+	if err.GuardsUnmet {
+		return fmt.Sprintf("stateless: trigger %v is valid in state %v but its guard conditions are not met", err.Trigger, err.State)
+	}
+
+	return fmt.Sprintf("stateless: no transitions are permitted from state %v for trigger %v", err.State, err.Trigger)
+}
+
+// StateMachine fires triggers on a statetrooper FSM
+// Triggers shouldn't be fired concurrently, nor with transitions made directly on the FSM
+type StateMachine[S, T comparable] struct {
+	fsm *statetrooper.FSM[S]
+
+	mu     sync.RWMutex
+	states map[S]*StateConfiguration[S, T]
+	rules  map[statetrooper.Rule[S]]bool
+}
+
+// StateConfiguration configures the transitions and actions of a state, see StateMachine.Configure
+type StateConfiguration[S, T comparable] struct {
+	sm          *StateMachine[S, T]
+	state       S
+	transitions []transition[S, T]
+	entry       []entryAction[T]
+	exit        []ActionFunc
+}
+
+// transition is a transition permitted or ignored for a trigger
+type transition[S, T comparable] struct {
+	trigger T
+	dst     S
+	ignore  bool
+	guards  []GuardFunc
+}
+
+// entryAction is an entry action, run on every entry or only when entering with the trigger
+type entryAction[T comparable] struct {
+	trigger *T
+	action  ActionFunc
+}
+
+// NewStateMachine creates a statetrooper FSM in the initial state with the options, keeping maxHistory transitions,
+// and a StateMachine configuring it
+func NewStateMachine[S, T comparable](initial S, maxHistory int, opts ...statetrooper.FSMOption[S]) *StateMachine[S, T] {
+	return &StateMachine[S, T]{
+		fsm:    statetrooper.NewFSM[S](initial, maxHistory, opts...),
+		states: make(map[S]*StateConfiguration[S, T]),
+		rules:  make(map[statetrooper.Rule[S]]bool),
+	}
+}
+
+// FSM returns the statetrooper FSM the triggers are fired on
+func (sm *StateMachine[S, T]) FSM() *statetrooper.FSM[S] {
+	return sm.fsm
+}
+
+// MustState returns the current state
+func (sm *StateMachine[S, T]) MustState() S {
+	return sm.fsm.CurrentState()
+}
+
+// Configure returns the configuration of the state, to permit transitions from it and set its actions
+func (sm *StateMachine[S, T]) Configure(state S) *StateConfiguration[S, T] {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	sc, ok := sm.states[state]
+	if !ok {
+		sc = &StateConfiguration[S, T]{sm: sm, state: state}
+		sm.states[state] = sc
+	}
+
+	return sc
+}
+
+// Permit allows the trigger to transition to the destination state when all the guards return true
+// Transitions for the same trigger are tried in the order they were permitted
+func (sc *StateConfiguration[S, T]) Permit(trigger T, dst S, guards ...GuardFunc) *StateConfiguration[S, T] {
+	sc.sm.mu.Lock()
+	defer sc.sm.mu.Unlock()
+
+	sc.transitions = append(sc.transitions, transition[S, T]{trigger: trigger, dst: dst, guards: guards})
+
+	rule := statetrooper.Rule[S]{From: sc.state, To: dst}
+	if !sc.sm.rules[rule] {
+		sc.sm.rules[rule] = true
+		sc.sm.fsm.AddRule(sc.state, dst)
+	}
+
+	return sc
+}
+
+// PermitReentry allows the trigger to transition from the state to itself, running its exit and entry actions
+func (sc *StateConfiguration[S, T]) PermitReentry(trigger T, guards ...GuardFunc) *StateConfiguration[S, T] {
+	return sc.Permit(trigger, sc.state, guards...)
+}
+
+// Ignore makes firing the trigger in the state a no-op when all the guards return true
+func (sc *StateConfiguration[S, T]) Ignore(trigger T, guards ...GuardFunc) *StateConfiguration[S, T] {
+	sc.sm.mu.Lock()
+	defer sc.sm.mu.Unlock()
+
+	sc.transitions = append(sc.transitions, transition[S, T]{trigger: trigger, ignore: true, guards: guards})
+
+	return sc
+}
+
+// OnEntry adds an action run after every transition into the state
+func (sc *StateConfiguration[S, T]) OnEntry(action ActionFunc) *StateConfiguration[S, T] {
+	sc.sm.mu.Lock()
+	defer sc.sm.mu.Unlock()
+
+	sc.entry = append(sc.entry, entryAction[T]{action: action})
+
+	return sc
+}
+
+// OnEntryFrom adds an action run after the transitions into the state caused by the trigger
+func (sc *StateConfiguration[S, T]) OnEntryFrom(trigger T, action ActionFunc) *StateConfiguration[S, T] {
+	sc.sm.mu.Lock()
+	defer sc.sm.mu.Unlock()
+
+	sc.entry = append(sc.entry, entryAction[T]{trigger: &trigger, action: action})
+
+	return sc
+}
+
+// OnExit adds an action run before every transition out of the state
+// An error returned by the action aborts the transition
+func (sc *StateConfiguration[S, T]) OnExit(action ActionFunc) *StateConfiguration[S, T] {
+	sc.sm.mu.Lock()
+	defer sc.sm.mu.Unlock()
+
+	sc.exit = append(sc.exit, action)
+
+	return sc
+}
+
+// Fire fires the trigger with a background context, see FireCtx
+func (sm *StateMachine[S, T]) Fire(trigger T, args ...any) error {
+	return sm.FireCtx(context.Background(), trigger, args...)
+}
+
+// FireCtx takes the first transition permitted for the trigger in the current state whose guards return true:
+// the exit actions of the current state run, the FSM transitions, then the entry actions of the destination run
+// An UnhandledTriggerError is returned if no transition can be taken, and the FSM's error if it rejects the transition
+func (sm *StateMachine[S, T]) FireCtx(ctx context.Context, trigger T, args ...any) error {
+	state := sm.fsm.CurrentState()
+
+	t, err := sm.find(ctx, state, trigger, args)
+	if err != nil || t.ignore {
+		return err
+	}
+
+	for _, action := range sm.exitActions(state) {
+		if err := action(ctx, args...); err != nil {
+			return err
+		}
+	}
+
+	_, err = sm.fsm.Transition(t.dst, map[string]string{MetadataTrigger: fmt.Sprint(trigger)},
+		statetrooper.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+
+	for _, action := range sm.entryActions(t.dst, trigger) {
+		if err := action(ctx, args...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CanFire checks if a transition can be taken, or the trigger ignored, in the current state
+func (sm *StateMachine[S, T]) CanFire(trigger T, args ...any) (bool, error) {
+	_, err := sm.find(context.Background(), sm.fsm.CurrentState(), trigger, args)
+
+	return err == nil, nil
+}
+
+// find returns the first transition of the state for the trigger whose guards return true
+func (sm *StateMachine[S, T]) find(ctx context.Context, state S, trigger T, args []any) (transition[S, T], error) {
+	sm.mu.RLock()
+
+	var candidates []transition[S, T]
+
+	if sc, ok := sm.states[state]; ok {
+		for _, t := range sc.transitions {
+			if t.trigger == trigger {
+				candidates = append(candidates, t)
+			}
+		}
+	}
+
+	sm.mu.RUnlock()
+
+	// guards run without the lock, so they can read the configuration
+	for _, t := range candidates {
+		if guardsMet(ctx, t.guards, args) {
+			return t, nil
+		}
+	}
+
+	return transition[S, T]{}, UnhandledTriggerError[S, T]{
+		State:       state,
+		Trigger:     trigger,
+		GuardsUnmet: len(candidates) > 0,
+	}
+}
+
+// exitActions returns the exit actions of the state
+func (sm *StateMachine[S, T]) exitActions(state S) []ActionFunc {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	if sc, ok := sm.states[state]; ok {
+		return append([]ActionFunc(nil), sc.exit...)
+	}
+
+	return nil
+}
+
+// entryActions returns the entry actions of the state run when entering it with the trigger
+func (sm *StateMachine[S, T]) entryActions(state S, trigger T) []ActionFunc {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	sc, ok := sm.states[state]
+	if !ok {
+		return nil
+	}
+
+	var actions []ActionFunc
+
+	for _, entry := range sc.entry {
+		if entry.trigger == nil || *entry.trigger == trigger {
+			actions = append(actions, entry.action)
+		}
+	}
+
+	return actions
+}
+
+// guardsMet checks if all the guards return true
+func guardsMet(ctx context.Context, guards []GuardFunc, args []any) bool {
+	for _, guard := range guards {
+		if !guard(ctx, args...) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,138 @@
+package stateless
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type state string
+
+type trigger string
+
+const (
+	stateOffHook   state = "off_hook"
+	stateRinging   state = "ringing"
+	stateConnected state = "connected"
+	stateVoicemail state = "voicemail"
+
+	triggerCallDialed    trigger = "call_dialed"
+	triggerCallConnected trigger = "call_connected"
+	triggerHungUp        trigger = "hung_up"
+)
+
+func newPhone(available *bool, calls *[]string) *StateMachine[state, trigger] {
+	sm := NewStateMachine[state, trigger](stateOffHook, 10)
+
+	sm.Configure(stateOffHook).
+		Permit(triggerCallDialed, stateRinging)
+
+	sm.Configure(stateRinging).
+		OnEntryFrom(triggerCallDialed, func(_ context.Context, args ...any) error {
+			*calls = append(*calls, "ringing "+args[0].(string))
+
+			return nil
+		}).
+		OnExit(func(_ context.Context, args ...any) error {
+			*calls = append(*calls, "stop ringing")
+
+			return nil
+		}).
+		Permit(triggerCallConnected, stateConnected, func(_ context.Context, args ...any) bool {
+			return *available
+		}).
+		Permit(triggerCallConnected, stateVoicemail).
+		PermitReentry(triggerCallDialed).
+		Ignore(triggerHungUp)
+
+	sm.Configure(stateConnected).
+		OnEntry(func(_ context.Context, args ...any) error {
+			*calls = append(*calls, "connected")
+
+			return nil
+		})
+
+	return sm
+}
+
+func TestStateMachineFire(t *testing.T) {
+	available := true
+
+	var calls []string
+
+	sm := newPhone(&available, &calls)
+
+	if err := sm.Fire(triggerCallDialed, "555-0100"); err != nil {
+		t.Fatalf("Fire() returned an error: %v", err)
+	}
+
+	if err := sm.Fire(triggerHungUp); err != nil || sm.MustState() != stateRinging {
+		t.Errorf("expected the ignored trigger to leave the phone ringing, got %v in %s", err, sm.MustState())
+	}
+
+	if err := sm.Fire(triggerCallConnected); err != nil {
+		t.Fatalf("Fire() returned an error: %v", err)
+	}
+
+	if sm.MustState() != stateConnected {
+		t.Errorf("expected the guarded transition to connected, got %s", sm.MustState())
+	}
+
+	if expected := []string{"ringing 555-0100", "stop ringing", "connected"}; !reflect.DeepEqual(calls, expected) {
+		t.Errorf("expected the actions %v, got %v", expected, calls)
+	}
+
+	transitions := sm.FSM().Transitions()
+	if len(transitions) != 2 || transitions[1].Metadata[MetadataTrigger] != "call_connected" {
+		t.Errorf("expected the transitions to be recorded with their trigger, got %+v", transitions)
+	}
+
+	var unhandled UnhandledTriggerError[state, trigger]
+	if err := sm.Fire(triggerCallDialed); !errors.As(err, &unhandled) || unhandled.GuardsUnmet {
+		t.Errorf("Fire() returned %v, expected an UnhandledTriggerError", err)
+	}
+}
+
+func TestStateMachineGuardFallback(t *testing.T) {
+	available := false
+
+	var calls []string
+
+	sm := newPhone(&available, &calls)
+
+	if ok, _ := sm.CanFire(triggerCallConnected); ok {
+		t.Error("CanFire() = true, expected false while off hook")
+	}
+
+	if err := sm.Fire(triggerCallDialed, "555-0100"); err != nil {
+		t.Fatalf("Fire() returned an error: %v", err)
+	}
+
+	if err := sm.Fire(triggerCallDialed, "555-0101"); err != nil || sm.MustState() != stateRinging {
+		t.Fatalf("expected the reentry to keep ringing, got %v in %s", err, sm.MustState())
+	}
+
+	if err := sm.Fire(triggerCallConnected); err != nil || sm.MustState() != stateVoicemail {
+		t.Errorf("expected the fallback to voicemail, got %v in %s", err, sm.MustState())
+	}
+
+	expected := []string{"ringing 555-0100", "stop ringing", "ringing 555-0101", "stop ringing"}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("expected the actions %v, got %v", expected, calls)
+	}
+}
+
+func TestStateMachineExitError(t *testing.T) {
+	sm := NewStateMachine[state, trigger](stateOffHook, 10)
+
+	sm.Configure(stateOffHook).
+		Permit(triggerCallDialed, stateRinging).
+		OnExit(func(_ context.Context, args ...any) error {
+			return errors.New("no dial tone")
+		})
+
+	if err := sm.Fire(triggerCallDialed); err == nil || sm.MustState() != stateOffHook {
+		t.Errorf("expected the exit error to abort the transition, got %v in %s", err, sm.MustState())
+	}
+}
@@ -0,0 +1,92 @@
+package statetrooper
+
+// compiledRuleset indexes a ruleset so that checking a transition is a constant time lookup
+// instead of a scan over the allowed target states
+type compiledRuleset[T comparable] struct {
+	index  map[T]int
+	states []T
+
+	// allowed is a row-major bitset of the transitions, bit from*n+to is set if the transition is allowed
+	allowed []uint64
+	n       int
+}
+
+// compile builds the index of the ruleset
+func (rs Ruleset[T]) compile() *compiledRuleset[T] {
+	c := compiledRuleset[T]{
+		index: make(map[T]int),
+	}
+
+	add := func(state T) int {
+		i, ok := c.index[state]
+		if !ok {
+			i = len(c.states)
+			c.index[state] = i
+			c.states = append(c.states, state)
+		}
+
+		return i
+	}
+
+	for fromState, toStates := range rs {
+		add(fromState)
+
+		for _, toState := range toStates {
+			add(toState)
+		}
+	}
+
+	c.n = len(c.states)
+	c.allowed = make([]uint64, (c.n*c.n+63)/64)
+
+	for fromState, toStates := range rs {
+		from := c.index[fromState]
+
+		for _, toState := range toStates {
+			bit := from*c.n + c.index[toState]
+			c.allowed[bit/64] |= 1 << (bit % 64)
+		}
+	}
+
+	return &c
+}
+
+// canTransition checks if a transition from one state to another state is allowed
+func (c *compiledRuleset[T]) canTransition(fromState, toState T) bool {
+	from, ok := c.index[fromState]
+	if !ok {
+		return false
+	}
+
+	to, ok := c.index[toState]
+	if !ok {
+		return false
+	}
+
+	return c.allowedIndex(from, to)
+}
+
+// allowedIndex checks if a transition between the states at the given indexes is allowed
+func (c *compiledRuleset[T]) allowedIndex(from, to int) bool {
+	bit := from*c.n + to
+
+	return c.allowed[bit/64]&(1<<(bit%64)) != 0
+}
+
+// Seal compiles the FSM's ruleset into an index so that checking a transition takes constant time
+// regardless of the number of target states, which benefits rulesets with a large fan-out
+// Once sealed, AddRule panics; ReplaceRules is still allowed and compiles the new ruleset
+func (fsm *FSM[T]) Seal() {
+	fsm.lock()
+	defer fsm.unlock()
+
+	fsm.compiled = fsm.ruleset.compile()
+}
+
+// Sealed reports whether the FSM's ruleset has been sealed with Seal
+func (fsm *FSM[T]) Sealed() bool {
+	fsm.rlock()
+	defer fsm.runlock()
+
+	return fsm.compiled != nil
+}
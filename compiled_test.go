@@ -0,0 +1,80 @@
+package statetrooper
+
+import (
+	"fmt"
+	"testing"
+)
+
+func Test_seal(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB, CustomStateEnumC)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumC)
+
+	fsm.Seal()
+
+	if !fsm.Sealed() {
+		t.Fatalf("expected the FSM to be sealed")
+	}
+
+	tests := []struct {
+		from     CustomStateEnum
+		to       CustomStateEnum
+		expected bool
+	}{
+		{CustomStateEnumA, CustomStateEnumB, true},
+		{CustomStateEnumA, CustomStateEnumC, true},
+		{CustomStateEnumB, CustomStateEnumC, true},
+		{CustomStateEnumB, CustomStateEnumA, false},
+		{CustomStateEnumC, CustomStateEnumA, false},
+		{CustomStateEnumD, CustomStateEnumA, false},
+		{CustomStateEnumA, CustomStateEnumD, false},
+	}
+
+	for _, test := range tests {
+		if result := fsm.canTransition(&test.from, &test.to); result != test.expected {
+			t.Errorf("canTransition(%v, %v) = %v, expected %v", test.from, test.to, result, test.expected)
+		}
+	}
+
+	if _, err := fsm.Transition(CustomStateEnumB, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err := fsm.ReplaceRules(Ruleset[CustomStateEnum]{CustomStateEnumB: {CustomStateEnumD}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !fsm.CanTransition(CustomStateEnumD) || fsm.CanTransition(CustomStateEnumC) {
+		t.Errorf("expected the replaced rules to be compiled")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected AddRule to panic on a sealed FSM")
+		}
+	}()
+
+	fsm.AddRule(CustomStateEnumD, CustomStateEnumA)
+}
+
+func Benchmark_canTransitionFanOut(b *testing.B) {
+	rules := Ruleset[string]{}
+	for i := 0; i < 50; i++ {
+		rules["hub"] = append(rules["hub"], fmt.Sprintf("target%d", i))
+	}
+
+	for _, sealed := range []bool{false, true} {
+		b.Run(fmt.Sprintf("sealed=%v", sealed), func(b *testing.B) {
+			fsm := NewFSMWithRuleset[string]("hub", 10, rules)
+			if sealed {
+				fsm.Seal()
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_ = fsm.CanTransition("target49")
+			}
+		})
+	}
+}
@@ -0,0 +1,264 @@
+package statetrooper
+
+import "sort"
+
+// HasCycles checks if a state can lead back to itself through the rules, including rules from a state to itself
+func (rs Ruleset[T]) HasCycles() bool {
+	return rs.cycle() != nil
+}
+
+// FindCycles returns the elementary cycles of the ruleset, each starting from its state sorted first by name
+// and listed without repeating it at the end, e.g. [picked packed] for picked -> packed -> picked
+// Cycles are ordered by their states. Their number can grow exponentially with densely connected rules
+func (rs Ruleset[T]) FindCycles() [][]T {
+	states := rs.sortedStates()
+
+	order := make(map[T]int, len(states))
+	for i, state := range states {
+		order[state] = i
+	}
+
+	var cycles [][]T
+
+	for i, start := range states {
+		var path []T
+
+		onPath := make(map[T]bool)
+
+		var visit func(state T)
+		visit = func(state T) {
+			path = append(path, state)
+			onPath[state] = true
+
+			for _, to := range rs.sortedTargets(state) {
+				switch {
+				case to == start:
+					cycles = append(cycles, append([]T(nil), path...))
+				case order[to] > i && !onPath[to]:
+					visit(to)
+				}
+			}
+
+			path = path[:len(path)-1]
+			onPath[state] = false
+		}
+
+		visit(start)
+	}
+
+	return cycles
+}
+
+// TopologicalOrder returns the states ordered so that every rule goes from a state to a later one,
+// states that are equally ready being sorted by name
+// A CycleError holding one of the cycles is returned if the ruleset isn't a directed acyclic graph
+func (rs Ruleset[T]) TopologicalOrder() ([]T, error) {
+	if cycle := rs.cycle(); cycle != nil {
+		return nil, CycleError[T]{Cycle: cycle}
+	}
+
+	states := rs.sortedStates()
+
+	inDegree := make(map[T]int, len(states))
+	for _, targets := range rs {
+		for _, to := range targets {
+			inDegree[to]++
+		}
+	}
+
+	var ready []T
+
+	for _, state := range states {
+		if inDegree[state] == 0 {
+			ready = append(ready, state)
+		}
+	}
+
+	ordered := make([]T, 0, len(states))
+
+	for len(ready) > 0 {
+		state := ready[0]
+		ready = ready[1:]
+		ordered = append(ordered, state)
+
+		for _, to := range rs[state] {
+			inDegree[to]--
+			if inDegree[to] == 0 {
+				ready = append(ready, to)
+			}
+		}
+
+		sort.Slice(ready, func(i, j int) bool {
+			return toString(ready[i]) < toString(ready[j])
+		})
+	}
+
+	return ordered, nil
+}
+
+// StronglyConnectedComponents returns the groups of states that can all reach each other through the rules,
+// each sorted by name and ordered by their first state. Every state belongs to one component, a state
+// that can't lead back to itself forming a component of its own
+// An entity can keep cycling within a component holding more than one state until a rule leads out of it
+func (rs Ruleset[T]) StronglyConnectedComponents() [][]T {
+	var (
+		components [][]T
+		stack      []T
+		next       int
+	)
+
+	index := make(map[T]int)
+	lowLink := make(map[T]int)
+	onStack := make(map[T]bool)
+
+	var visit func(state T)
+	visit = func(state T) {
+		index[state] = next
+		lowLink[state] = next
+		next++
+
+		stack = append(stack, state)
+		onStack[state] = true
+
+		for _, to := range rs[state] {
+			if _, ok := index[to]; !ok {
+				visit(to)
+
+				if lowLink[to] < lowLink[state] {
+					lowLink[state] = lowLink[to]
+				}
+			} else if onStack[to] && index[to] < lowLink[state] {
+				lowLink[state] = index[to]
+			}
+		}
+
+		if lowLink[state] != index[state] {
+			return
+		}
+
+		var component []T
+
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[top] = false
+			component = append(component, top)
+
+			if top == state {
+				break
+			}
+		}
+
+		sort.Slice(component, func(i, j int) bool {
+			return toString(component[i]) < toString(component[j])
+		})
+
+		components = append(components, component)
+	}
+
+	for _, state := range rs.sortedStates() {
+		if _, ok := index[state]; !ok {
+			visit(state)
+		}
+	}
+
+	sort.Slice(components, func(i, j int) bool {
+		return toString(components[i][0]) < toString(components[j][0])
+	})
+
+	return components
+}
+
+// cycle returns the first cycle found by a depth-first search of the states sorted by name, or nil
+func (rs Ruleset[T]) cycle() []T {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+
+	status := make(map[T]int)
+
+	var stack []T
+
+	var visit func(state T) []T
+	visit = func(state T) []T {
+		status[state] = visiting
+		stack = append(stack, state)
+
+		for _, to := range rs.sortedTargets(state) {
+			switch status[to] {
+			case visiting:
+				for i := range stack {
+					if stack[i] == to {
+						return append([]T(nil), stack[i:]...)
+					}
+				}
+			case unvisited:
+				if cycle := visit(to); cycle != nil {
+					return cycle
+				}
+			}
+		}
+
+		stack = stack[:len(stack)-1]
+		status[state] = done
+
+		return nil
+	}
+
+	for _, state := range rs.sortedStates() {
+		if status[state] == unvisited {
+			if cycle := visit(state); cycle != nil {
+				return cycle
+			}
+		}
+	}
+
+	return nil
+}
+
+// sortedTargets returns the allowed target states of the state sorted by name
+func (rs Ruleset[T]) sortedTargets(state T) []T {
+	targets := append([]T(nil), rs[state]...)
+
+	sort.Slice(targets, func(i, j int) bool {
+		return toString(targets[i]) < toString(targets[j])
+	})
+
+	return targets
+}
+
+// HasCycles checks if a state can lead back to itself through the FSM's rules
+func (fsm *FSM[T]) HasCycles() bool {
+	fsm.rlock()
+	defer fsm.runlock()
+
+	return fsm.ruleset.HasCycles()
+}
+
+// FindCycles returns the elementary cycles of the FSM's rules, see Ruleset.FindCycles
+func (fsm *FSM[T]) FindCycles() [][]T {
+	fsm.rlock()
+	defer fsm.runlock()
+
+	return fsm.ruleset.FindCycles()
+}
+
+// TopologicalOrder returns the states ordered so that every rule of the FSM goes from a state to a later one
+// A CycleError is returned if the rules aren't a directed acyclic graph, see Ruleset.TopologicalOrder
+func (fsm *FSM[T]) TopologicalOrder() ([]T, error) {
+	fsm.rlock()
+	defer fsm.runlock()
+
+	return fsm.ruleset.TopologicalOrder()
+}
+
+// StronglyConnectedComponents returns the groups of states that can all reach each other through the FSM's rules,
+// see Ruleset.StronglyConnectedComponents
+func (fsm *FSM[T]) StronglyConnectedComponents() [][]T {
+	fsm.rlock()
+	defer fsm.runlock()
+
+	return fsm.ruleset.StronglyConnectedComponents()
+}
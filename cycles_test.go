@@ -0,0 +1,118 @@
+package statetrooper
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func Test_rulesetCycles(t *testing.T) {
+	dag := Ruleset[string]{
+		"created": {"paid", "canceled"},
+		"paid":    {"shipped", "canceled"},
+		"shipped": {"delivered"},
+	}
+
+	if dag.HasCycles() {
+		t.Error("HasCycles() = true, expected false for a DAG")
+	}
+
+	if cycles := dag.FindCycles(); len(cycles) != 0 {
+		t.Errorf("FindCycles() = %v, expected no cycles", cycles)
+	}
+
+	order, err := dag.TopologicalOrder()
+	if err != nil {
+		t.Fatalf("TopologicalOrder() returned an error: %v", err)
+	}
+
+	expectedOrder := []string{"created", "paid", "canceled", "shipped", "delivered"}
+	if !reflect.DeepEqual(order, expectedOrder) {
+		t.Errorf("TopologicalOrder() = %v, expected %v", order, expectedOrder)
+	}
+
+	cyclic := Ruleset[string]{
+		"created":    {"picked", "canceled"},
+		"picked":     {"packed", "canceled"},
+		"packed":     {"picked", "shipped"},
+		"canceled":   {"reinstated"},
+		"reinstated": {"picked", "reinstated"},
+	}
+
+	if !cyclic.HasCycles() {
+		t.Error("HasCycles() = false, expected true")
+	}
+
+	expectedCycles := [][]string{
+		{"canceled", "reinstated", "picked"},
+		{"packed", "picked"},
+		{"reinstated"},
+	}
+	if cycles := cyclic.FindCycles(); !reflect.DeepEqual(cycles, expectedCycles) {
+		t.Errorf("FindCycles() = %v, expected %v", cycles, expectedCycles)
+	}
+
+	_, err = cyclic.TopologicalOrder()
+
+	var cycleErr CycleError[string]
+	if !errors.As(err, &cycleErr) || !errors.Is(err, ErrCycle) {
+		t.Fatalf("TopologicalOrder() returned %v, expected a CycleError", err)
+	}
+
+	expectedErr := "ruleset has a cycle: canceled -> reinstated -> picked -> canceled"
+	if err.Error() != expectedErr {
+		t.Errorf("TopologicalOrder() returned %q, expected %q", err.Error(), expectedErr)
+	}
+}
+
+func Test_fsmTopologicalOrder(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumC, CustomStateEnumB)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumC)
+
+	order, err := fsm.TopologicalOrder()
+	if err != nil {
+		t.Fatalf("TopologicalOrder() returned an error: %v", err)
+	}
+
+	expected := []CustomStateEnum{CustomStateEnumA, CustomStateEnumB, CustomStateEnumC}
+	if !reflect.DeepEqual(order, expected) {
+		t.Errorf("TopologicalOrder() = %v, expected %v", order, expected)
+	}
+
+	fsm.AddRule(CustomStateEnumC, CustomStateEnumA)
+
+	if !fsm.HasCycles() {
+		t.Error("HasCycles() = false, expected true")
+	}
+
+	if cycles := fsm.FindCycles(); len(cycles) != 2 {
+		t.Errorf("FindCycles() = %v, expected 2 cycles", cycles)
+	}
+}
+
+func Test_stronglyConnectedComponents(t *testing.T) {
+	rs := Ruleset[string]{
+		"created":    {"picked", "canceled"},
+		"picked":     {"packed", "canceled"},
+		"packed":     {"picked", "shipped"},
+		"shipped":    {"delivered"},
+		"canceled":   {"reinstated"},
+		"reinstated": {"picked"},
+	}
+
+	expected := [][]string{
+		{"canceled", "packed", "picked", "reinstated"},
+		{"created"},
+		{"delivered"},
+		{"shipped"},
+	}
+	if components := rs.StronglyConnectedComponents(); !reflect.DeepEqual(components, expected) {
+		t.Errorf("StronglyConnectedComponents() = %v, expected %v", components, expected)
+	}
+
+	fsm := NewFSMWithRuleset[string]("created", 10, rs)
+	if components := fsm.StronglyConnectedComponents(); !reflect.DeepEqual(components, expected) {
+		t.Errorf("FSM.StronglyConnectedComponents() = %v, expected %v", components, expected)
+	}
+}
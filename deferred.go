@@ -0,0 +1,124 @@
+package statetrooper
+
+import "time"
+
+// WithDeferredDroppedHandler sets a function called when a transition deferred with DeferUntilValid
+// is dropped, either because it expired, in which case err is ErrDeferredExpired, or because it failed
+// when applied, e.g. when rejected by the metadata validator
+// The handler is called while the FSM is locked and must not call the FSM's methods
+// DEFAULT: nil
+func WithDeferredDroppedHandler[T comparable](handler func(targetState T, err error)) FSMOption[T] {
+	return func(fsm *FSM[T]) {
+		fsm.deferredDroppedHandler = handler
+	}
+}
+
+// deferredTransition is a transition waiting for the FSM to reach a state from which it is allowed
+type deferredTransition[T comparable] struct {
+	id          uint64
+	targetState T
+	metadata    map[string]string
+	opts        []TransitionOption
+	expiresAt   time.Time
+}
+
+// DeferUntilValid transitions the entity to the target state as soon as the transition is allowed
+// If it is allowed now, the transition is made at once and its error returned. Otherwise the intent is
+// recorded and applied right after the transition that reaches a state from which it is allowed,
+// e.g. to cancel an order once it leaves a state that can't be cancelled
+// Deferred transitions are applied in the order they were deferred. They expire after ttl, or never
+// if ttl is 0, and are dropped when cancel is called
+func (fsm *FSM[T]) DeferUntilValid(
+	targetState T,
+	metadata map[string]string,
+	ttl time.Duration,
+	opts ...TransitionOption,
+) (cancel func(), err error) {
+	fsm.lock()
+	defer fsm.unlock()
+
+	if fsm.canTransition(&fsm.currentState, &targetState) {
+		_, err := fsm.transition(targetState, metadata, opts)
+
+		return func() {}, err
+	}
+
+	fsm.deferredID++
+
+	deferred := deferredTransition[T]{
+		id:          fsm.deferredID,
+		targetState: targetState,
+		metadata:    metadata,
+		opts:        opts,
+	}
+
+	if ttl > 0 {
+		deferred.expiresAt = fsm.timeProvider().Add(ttl)
+	}
+
+	fsm.deferred = append(fsm.deferred, deferred)
+
+	return func() {
+		fsm.lock()
+		defer fsm.unlock()
+
+		fsm.removeDeferred(deferred.id)
+	}, nil
+}
+
+// applyDeferred drops the expired deferred transitions and applies the first one allowed
+// from the current state, which in turn applies the next ones allowed from the state it reaches
+// The caller must hold the lock
+func (fsm *FSM[T]) applyDeferred() {
+	now := fsm.timeProvider()
+
+	pending := fsm.deferred[:0]
+
+	for _, deferred := range fsm.deferred {
+		if !deferred.expiresAt.IsZero() && !now.Before(deferred.expiresAt) {
+			fsm.dropDeferred(deferred.targetState, ErrDeferredExpired)
+
+			continue
+		}
+
+		pending = append(pending, deferred)
+	}
+
+	fsm.deferred = pending
+
+	for _, deferred := range fsm.deferred {
+		if !fsm.canTransition(&fsm.currentState, &deferred.targetState) {
+			continue
+		}
+
+		fsm.removeDeferred(deferred.id)
+
+		if _, err := fsm.transition(deferred.targetState, deferred.metadata, deferred.opts); err != nil {
+			fsm.dropDeferred(deferred.targetState, err)
+		}
+
+		return
+	}
+}
+
+// removeDeferred removes the deferred transition with the given id, if it is still pending
+func (fsm *FSM[T]) removeDeferred(id uint64) {
+	for i, deferred := range fsm.deferred {
+		if deferred.id == id {
+			fsm.deferred = append(fsm.deferred[:i], fsm.deferred[i+1:]...)
+
+			return
+		}
+	}
+}
+
+// dropDeferred reports a dropped deferred transition to the handler
+func (fsm *FSM[T]) dropDeferred(targetState T, err error) {
+	if fsm.deferredDroppedHandler == nil {
+		return
+	}
+
+	fsm.runHook(func() {
+		fsm.deferredDroppedHandler(targetState, err)
+	})
+}
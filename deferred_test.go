@@ -0,0 +1,85 @@
+package statetrooper
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func Test_deferUntilValid(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumC)
+	fsm.AddRule(CustomStateEnumC, CustomStateEnumD)
+
+	// allowed now, made at once
+	if _, err := fsm.DeferUntilValid(CustomStateEnumB, nil, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if fsm.CurrentState() != CustomStateEnumB {
+		t.Fatalf("expected the transition to be made at once, got %v", fsm.CurrentState())
+	}
+
+	// not allowed yet, applied once C is reached
+	if _, err := fsm.DeferUntilValid(CustomStateEnumD, map[string]string{"reason": "cancel"}, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if fsm.CurrentState() != CustomStateEnumB {
+		t.Fatalf("expected the transition to be deferred")
+	}
+
+	state, err := fsm.Transition(CustomStateEnumC, nil)
+	if err != nil || state != CustomStateEnumC {
+		t.Fatalf("expected the transition to return %v, got %v, %v", CustomStateEnumC, state, err)
+	}
+
+	if fsm.CurrentState() != CustomStateEnumD {
+		t.Errorf("expected the deferred transition to be applied, got %v", fsm.CurrentState())
+	}
+
+	transitions := fsm.Transitions()
+	if last := transitions[len(transitions)-1]; last.Metadata["reason"] != "cancel" {
+		t.Errorf("expected the deferred metadata to be recorded, got %v", last.Metadata)
+	}
+}
+
+func Test_deferUntilValidDropped(t *testing.T) {
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var dropped []error
+
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10,
+		WithTimeProvider[CustomStateEnum](func() time.Time { return now }),
+		WithDeferredDroppedHandler[CustomStateEnum](func(targetState CustomStateEnum, err error) {
+			dropped = append(dropped, err)
+		}),
+	)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumA)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumC)
+
+	if _, err := fsm.DeferUntilValid(CustomStateEnumC, nil, time.Minute); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cancel, err := fsm.DeferUntilValid(CustomStateEnumC, nil, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cancel()
+
+	now = now.Add(time.Minute)
+
+	fsm.Transition(CustomStateEnumB, nil)
+
+	if fsm.CurrentState() != CustomStateEnumB {
+		t.Errorf("expected the expired and cancelled transitions not to be applied, got %v", fsm.CurrentState())
+	}
+
+	if len(dropped) != 1 || !errors.Is(dropped[0], ErrDeferredExpired) {
+		t.Errorf("expected the expired transition to be reported, got %v", dropped)
+	}
+}
@@ -0,0 +1,223 @@
+package statetrooper
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Format decodes a serialized ruleset definition into v
+// FormatJSON is built in; YAML is supported by passing a YAML unmarshal function
+// e.g. statetrooper.Format(yaml.Unmarshal) using gopkg.in/yaml.v3
+type Format func(data []byte, v any) error
+
+// FormatJSON decodes JSON ruleset definitions
+var FormatJSON Format = json.Unmarshal
+
+// RuleDefinition defines the target states allowed from a state
+type RuleDefinition[T comparable] struct {
+	From T   `json:"from" yaml:"from"`
+	To   []T `json:"to" yaml:"to"`
+}
+
+// Definition is a declarative definition of a state machine
+// Every state referenced by the definition must be declared in States
+type Definition[T comparable] struct {
+	States   []T                 `json:"states" yaml:"states"`
+	Initial  T                   `json:"initial" yaml:"initial"`
+	Rules    []RuleDefinition[T] `json:"rules" yaml:"rules"`
+	Terminal []T                 `json:"terminal" yaml:"terminal"`
+	Groups   map[string][]T      `json:"groups" yaml:"groups"`
+}
+
+// LoadRuleset reads and validates a declarative state machine definition in the given format
+// Validation errors are returned joined together, each one being a DefinitionError
+// pointing at the offending entry
+func LoadRuleset[T comparable](r io.Reader, format Format) (*Definition[T], error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var def Definition[T]
+
+	err = format(data, &def)
+	if err != nil {
+		return nil, err
+	}
+
+	err = def.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return &def, nil
+}
+
+// Validate checks that every state referenced by the definition is declared, that no state or rule
+// is declared twice and that terminal states have no outgoing rules
+func (def *Definition[T]) Validate() error {
+	var errs []error
+
+	invalid := func(path string, format string, args ...any) {
+		errs = append(errs, DefinitionError{
+			Path:    path,
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	if len(def.States) == 0 {
+		invalid("states", "no states declared")
+	}
+
+	declared := make(map[T]bool, len(def.States))
+	for i, state := range def.States {
+		if declared[state] {
+			invalid(fmt.Sprintf("states[%d]", i), "state %v is declared more than once", state)
+		}
+
+		declared[state] = true
+	}
+
+	checkDeclared := func(path string, state T) {
+		if !declared[state] {
+			invalid(path, "state %v is not declared in states", state)
+		}
+	}
+
+	if len(def.States) > 0 {
+		checkDeclared("initial", def.Initial)
+	}
+
+	outgoing := make(map[T]bool, len(def.Rules))
+	seen := make(map[[2]T]bool)
+
+	for i, rule := range def.Rules {
+		checkDeclared(fmt.Sprintf("rules[%d].from", i), rule.From)
+
+		if len(rule.To) == 0 {
+			invalid(fmt.Sprintf("rules[%d].to", i), "no target states defined")
+		}
+
+		for j, toState := range rule.To {
+			path := fmt.Sprintf("rules[%d].to[%d]", i, j)
+
+			checkDeclared(path, toState)
+
+			if seen[[2]T{rule.From, toState}] {
+				invalid(path, "duplicate rule from %v to %v", rule.From, toState)
+			}
+
+			seen[[2]T{rule.From, toState}] = true
+		}
+
+		outgoing[rule.From] = true
+	}
+
+	for i, state := range def.Terminal {
+		path := fmt.Sprintf("terminal[%d]", i)
+
+		checkDeclared(path, state)
+
+		if outgoing[state] {
+			invalid(path, "terminal state %v has outgoing rules", state)
+		}
+	}
+
+	names := make([]string, 0, len(def.Groups))
+	for name := range def.Groups {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		for i, state := range def.Groups[name] {
+			checkDeclared(fmt.Sprintf("groups.%s[%d]", name, i), state)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Ruleset returns the ruleset built from the definition's rules
+func (def *Definition[T]) Ruleset() Ruleset[T] {
+	rs := make(Ruleset[T], len(def.Rules))
+
+	for _, rule := range def.Rules {
+		rs[rule.From] = append(rs[rule.From], rule.To...)
+	}
+
+	return rs
+}
+
+// NewFSM creates a new instance of FSM in the definition's initial state with the definition's rules
+func (def *Definition[T]) NewFSM(maxHistory int, opts ...FSMOption[T]) *FSM[T] {
+	return NewFSMWithRuleset[T](def.Initial, maxHistory, def.Ruleset(), opts...)
+}
+
+// IsTerminal checks if the state is declared as a terminal state
+func (def *Definition[T]) IsTerminal(state T) bool {
+	for _, terminal := range def.Terminal {
+		if terminal == state {
+			return true
+		}
+	}
+
+	return false
+}
+
+// InGroup checks if the state belongs to the named group
+func (def *Definition[T]) InGroup(name string, state T) bool {
+	for _, member := range def.Groups[name] {
+		if member == state {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Unreachable returns the declared states that can't be reached from the initial state
+func (def *Definition[T]) Unreachable() []T {
+	reachable := def.Ruleset().reachable(def.Initial)
+
+	var unreachable []T
+
+	for _, state := range def.States {
+		if !reachable[state] {
+			unreachable = append(unreachable, state)
+		}
+	}
+
+	return unreachable
+}
+
+// DeadEnds returns the declared states that have no outgoing rules to other states but are not
+// declared as terminal. A state whose only rule is a self-transition can't be left, so it is a dead end
+func (def *Definition[T]) DeadEnds() []T {
+	rs := def.Ruleset()
+
+	var deadEnds []T
+
+	for _, state := range def.States {
+		if !hasExit(rs[state], state) && !def.IsTerminal(state) {
+			deadEnds = append(deadEnds, state)
+		}
+	}
+
+	return deadEnds
+}
+
+// hasExit reports whether any of the target states differs from the state itself
+func hasExit[T comparable](targets []T, state T) bool {
+	for _, target := range targets {
+		if target != state {
+			return true
+		}
+	}
+
+	return false
+}
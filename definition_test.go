@@ -0,0 +1,123 @@
+package statetrooper
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const orderDefinitionJSON = `{
+	"states": ["created", "picked", "packed", "shipped", "canceled"],
+	"initial": "created",
+	"rules": [
+		{"from": "created", "to": ["picked", "canceled"]},
+		{"from": "picked", "to": ["packed", "canceled"]},
+		{"from": "packed", "to": ["shipped"]}
+	],
+	"terminal": ["shipped", "canceled"],
+	"groups": {
+		"warehouse": ["picked", "packed"]
+	}
+}`
+
+func Test_loadRuleset(t *testing.T) {
+	def, err := LoadRuleset[CustomStateEnum](strings.NewReader(orderDefinitionJSON), FormatJSON)
+	if err != nil {
+		t.Fatalf("LoadRuleset() returned an error: %v", err)
+	}
+
+	fsm := def.NewFSM(10)
+
+	if fsm.CurrentState() != "created" {
+		t.Errorf("NewFSM() created an FSM in state %v, expected created", fsm.CurrentState())
+	}
+
+	for _, state := range []CustomStateEnum{"picked", "packed", "shipped"} {
+		_, err = fsm.Transition(state, nil)
+		if err != nil {
+			t.Errorf("Transition(%v) returned an error: %v", state, err)
+		}
+	}
+
+	if !def.IsTerminal(fsm.CurrentState()) {
+		t.Errorf("IsTerminal(%v) = false, expected true", fsm.CurrentState())
+	}
+
+	if !def.InGroup("warehouse", "packed") || def.InGroup("warehouse", "created") {
+		t.Errorf("InGroup() returned unexpected group membership")
+	}
+}
+
+func Test_loadRulesetCustomFormat(t *testing.T) {
+	var called bool
+
+	// A custom format such as YAML is any function decoding the data into the definition
+	format := Format(func(data []byte, v any) error {
+		called = true
+
+		return FormatJSON(data, v)
+	})
+
+	_, err := LoadRuleset[CustomStateEnum](strings.NewReader(orderDefinitionJSON), format)
+	if err != nil {
+		t.Errorf("LoadRuleset() returned an error: %v", err)
+	}
+
+	if !called {
+		t.Errorf("LoadRuleset() did not use the custom format")
+	}
+}
+
+func Test_loadRulesetValidation(t *testing.T) {
+	tests := []struct {
+		definition string
+		paths      []string
+	}{
+		{`{"states": [], "rules": []}`, []string{"states"}},
+		{`{"states": ["a", "b"], "initial": "c", "rules": [{"from": "a", "to": ["b"]}]}`, []string{"initial"}},
+		{`{"states": ["a", "b"], "initial": "a", "rules": [{"from": "a", "to": ["b", "c", "b"]}]}`, []string{"rules[0].to[1]", "rules[0].to[2]"}},
+		{`{"states": ["a", "b", "a"], "initial": "a", "rules": [{"from": "x", "to": []}]}`, []string{"states[2]", "rules[0].from", "rules[0].to"}},
+		{`{"states": ["a", "b"], "initial": "a", "rules": [{"from": "a", "to": ["b"]}], "terminal": ["a"]}`, []string{"terminal[0]"}},
+		{`{"states": ["a", "b"], "initial": "a", "rules": [{"from": "a", "to": ["b"]}], "groups": {"g": ["b", "z"]}}`, []string{"groups.g[1]"}},
+	}
+
+	for _, test := range tests {
+		_, err := LoadRuleset[string](strings.NewReader(test.definition), FormatJSON)
+		if err == nil {
+			t.Errorf("LoadRuleset(%s) did not return an error", test.definition)
+
+			continue
+		}
+
+		var paths []string
+		for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+			var defErr DefinitionError
+			if errors.As(e, &defErr) {
+				paths = append(paths, defErr.Path)
+			}
+		}
+
+		if strings.Join(paths, ",") != strings.Join(test.paths, ",") {
+			t.Errorf("LoadRuleset(%s) returned errors for %v, expected %v: %v", test.definition, paths, test.paths, err)
+		}
+	}
+}
+
+func Test_definitionUnreachableAndDeadEnds(t *testing.T) {
+	def := Definition[string]{
+		States:   []string{"a", "b", "c", "d", "e"},
+		Initial:  "a",
+		Rules:    []RuleDefinition[string]{{From: "a", To: []string{"b", "c"}}, {From: "c", To: []string{"c"}}, {From: "d", To: []string{"a"}}},
+		Terminal: []string{"b"},
+	}
+
+	unreachable := def.Unreachable()
+	if strings.Join(unreachable, ",") != "d,e" {
+		t.Errorf("Unreachable() = %v, expected [d e]", unreachable)
+	}
+
+	deadEnds := def.DeadEnds()
+	if strings.Join(deadEnds, ",") != "c,e" {
+		t.Errorf("DeadEnds() = %v, expected [c e]", deadEnds)
+	}
+}
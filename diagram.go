@@ -0,0 +1,296 @@
+package statetrooper
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GenerateDOTRulesDiagram generates a Graphviz DOT diagram from the FSM's rules
+// In order to generate a diagram, T must be a string or have a String() method
+func (fsm *FSM[T]) GenerateDOTRulesDiagram() (string, error) {
+	fsm.rlock()
+	defer fsm.runlock()
+
+	nodes, edges, err := fsm.ruleEdges(func(state string) string {
+		return fmt.Sprintf("%q", state)
+	}, "\t%s -> %s;\n")
+	if err != nil {
+		return "", err
+	}
+
+	sb := strings.Builder{}
+
+	sb.WriteString("digraph {\n\trankdir=LR;\n")
+
+	for _, node := range nodes {
+		sb.WriteString(fmt.Sprintf("\t%s;\n", node))
+	}
+
+	sb.WriteString(strings.Join(edges, ""))
+	sb.WriteString("}\n")
+
+	return sb.String(), nil
+}
+
+// GeneratePlantUMLRulesDiagram generates a PlantUML state diagram from the FSM's rules
+// In order to generate a diagram, T must be a string or have a String() method
+func (fsm *FSM[T]) GeneratePlantUMLRulesDiagram() (string, error) {
+	fsm.rlock()
+	defer fsm.runlock()
+
+	_, edges, err := fsm.ruleEdges(diagramID, "%s --> %s\n")
+	if err != nil {
+		return "", err
+	}
+
+	sb := strings.Builder{}
+
+	sb.WriteString("@startuml\n")
+
+	// states that aren't valid identifiers are declared with a safe ID
+	for _, state := range fsm.sortedStates() {
+		_, declaration := plantUMLNode(toString(state))
+		sb.WriteString(declaration)
+	}
+
+	sb.WriteString(strings.Join(edges, ""))
+	sb.WriteString("@enduml\n")
+
+	return sb.String(), nil
+}
+
+// ruleEdges returns the sorted source states and the sorted edges of the FSM's rules
+// node formats each state and edgeFormat formats each pair of formatted states
+// The caller must hold the lock
+func (fsm *FSM[T]) ruleEdges(node func(string) string, edgeFormat string) ([]string, []string, error) {
+	if len(fsm.ruleset) == 0 {
+		return nil, nil, fmt.Errorf("no rules defined")
+	}
+
+	// Check if T as represented by currentState has a String() method
+	if !stringable(fsm.currentState) {
+		return nil, nil, fmt.Errorf("type T is not a string or does not have a String() method")
+	}
+
+	nodes := make([]string, 0, len(fsm.ruleset))
+
+	var edges []string
+
+	for fromState, toStates := range fsm.ruleset {
+		nodes = append(nodes, node(toString(fromState)))
+
+		for _, toState := range toStates {
+			edges = append(edges, fmt.Sprintf(edgeFormat, node(toString(fromState)), node(toString(toState))))
+		}
+	}
+
+	sort.Strings(nodes)
+	sort.Strings(edges)
+
+	return nodes, edges, nil
+}
+
+// DiagramOption is a function that sets an option on a diagram
+type DiagramOption func(*diagramOptions)
+
+// diagramOptions holds the options for a diagram
+type diagramOptions struct {
+	markCurrent    bool
+	timestamps     bool
+	durations      bool
+	metadataLabels []string
+}
+
+// historyLabels checks if history edges have labels besides the transition order numbers
+func (o diagramOptions) historyLabels() bool {
+	return o.timestamps || o.durations || len(o.metadataLabels) > 0
+}
+
+// WithCurrentStateMarked marks the FSM's current state in the diagram
+// DEFAULT: the current state is not marked
+func WithCurrentStateMarked() DiagramOption {
+	return func(o *diagramOptions) {
+		o.markCurrent = true
+	}
+}
+
+// WithTimestampLabels adds the transition timestamps, formatted as RFC 3339, to the labels of history edges
+// DEFAULT: edges are labeled with the transition order numbers only
+func WithTimestampLabels() DiagramOption {
+	return func(o *diagramOptions) {
+		o.timestamps = true
+	}
+}
+
+// WithDurationLabels adds the time elapsed since the previous transition to the labels of history edges
+// DEFAULT: edges are labeled with the transition order numbers only
+func WithDurationLabels() DiagramOption {
+	return func(o *diagramOptions) {
+		o.durations = true
+	}
+}
+
+// WithMetadataLabels adds the given metadata keys, as key=value, to the labels of history edges
+// Redacted metadata keys are redacted
+// DEFAULT: edges are labeled with the transition order numbers only
+func WithMetadataLabels(keys ...string) DiagramOption {
+	return func(o *diagramOptions) {
+		o.metadataLabels = keys
+	}
+}
+
+// parseDiagramOptions applies the diagram options
+func parseDiagramOptions(opts []DiagramOption) diagramOptions {
+	var o diagramOptions
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+// GenerateASCIIDiagram generates a plain text diagram of the FSM's rules for terminals and logs,
+// listing each state, sorted by name, followed by its allowed targets:
+//
+//	created (current)
+//	  |--> canceled
+//	  `--> paid
+//	canceled (final)
+//
+// In order to generate a diagram, T must be a string or have a String() method
+func (fsm *FSM[T]) GenerateASCIIDiagram(opts ...DiagramOption) (string, error) {
+	fsm.rlock()
+	defer fsm.runlock()
+
+	if len(fsm.ruleset) == 0 {
+		return "", fmt.Errorf("no rules defined")
+	}
+
+	if !stringable(fsm.currentState) {
+		return "", fmt.Errorf("type T is not a string or does not have a String() method")
+	}
+
+	o := parseDiagramOptions(opts)
+
+	sb := strings.Builder{}
+
+	for _, state := range fsm.sortedStates() {
+		sb.WriteString(toString(state))
+
+		targets := make([]string, 0, len(fsm.ruleset[state]))
+		for _, target := range fsm.ruleset[state] {
+			targets = append(targets, toString(target))
+		}
+
+		sort.Strings(targets)
+
+		if len(targets) == 0 {
+			sb.WriteString(" (final)")
+		}
+
+		if o.markCurrent && state == fsm.currentState {
+			sb.WriteString(" (current)")
+		}
+
+		sb.WriteString("\n")
+
+		for i, target := range targets {
+			branch := "|"
+			if i == len(targets)-1 {
+				branch = "`"
+			}
+
+			fmt.Fprintf(&sb, "  %s--> %s\n", branch, target)
+		}
+	}
+
+	return sb.String(), nil
+}
+
+// historyLabel returns the quoted label of the history edge of the transition at index i,
+// one line per label
+// The caller must hold the lock
+func (fsm *FSM[T]) historyLabel(o diagramOptions, transitions []Transition[T], i int) string {
+	transition := fsm.redact(transitions[i])
+
+	lines := []string{strconv.Itoa(i + 1)}
+
+	if o.timestamps {
+		lines = append(lines, transition.Timestamp.Format(time.RFC3339))
+	}
+
+	if o.durations && i > 0 {
+		lines = append(lines, "+"+transition.Timestamp.Sub(transitions[i-1].Timestamp).String())
+	}
+
+	for _, key := range o.metadataLabels {
+		if value, ok := transition.Metadata[key]; ok {
+			lines = append(lines, key+"="+value)
+		}
+	}
+
+	return `"` + strings.ReplaceAll(strings.Join(lines, "<br/>"), `"`, "#quot;") + `"`
+}
+
+// mermaidKeywords are the words Mermaid reserves, which can't be used as node IDs
+var mermaidKeywords = map[string]bool{
+	"end": true, "graph": true, "flowchart": true, "subgraph": true, "direction": true,
+	"style": true, "class": true, "classdef": true, "click": true, "linkstyle": true, "default": true,
+}
+
+// diagramID returns a node ID safe to use in Mermaid and PlantUML diagrams for the state
+// States made of ASCII letters, digits and underscores that aren't reserved keywords are their own ID.
+// Other states get their unsafe characters replaced with underscores and a hash of the state appended,
+// so that IDs are stable and distinct
+func diagramID(state string) string {
+	safe := state != "" && !mermaidKeywords[strings.ToLower(state)]
+
+	id := []byte(state)
+	for i := range id {
+		c := id[i]
+		if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (c < '0' || c > '9') && c != '_' {
+			id[i] = '_'
+			safe = false
+		}
+	}
+
+	if safe {
+		return state
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(state))
+
+	return fmt.Sprintf("%s_%08x", id, h.Sum32())
+}
+
+// mermaidNode returns the state as a Mermaid node, its ID followed by its label if they differ
+func mermaidNode(state string) string {
+	id := diagramID(state)
+	if id == state {
+		return state
+	}
+
+	return fmt.Sprintf(`%s["%s"]`, id, mermaidLabelReplacer.Replace(state))
+}
+
+// mermaidLabelReplacer escapes the characters of node labels that would break Mermaid's parsing
+var mermaidLabelReplacer = strings.NewReplacer(`"`, "#quot;")
+
+// mermaidLabelUnescaper reverses mermaidLabelReplacer
+var mermaidLabelUnescaper = strings.NewReplacer("#quot;", `"`)
+
+// plantUMLNode returns the PlantUML state declaration of the state if it needs a safe ID
+func plantUMLNode(state string) (id string, declaration string) {
+	id = diagramID(state)
+	if id == state {
+		return id, ""
+	}
+
+	return id, fmt.Sprintf("state \"%s\" as %s\n", strings.ReplaceAll(state, `"`, `'`), id)
+}
@@ -0,0 +1,212 @@
+package statetrooper
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// mermaidEdge matches a Mermaid arrow with an optional |label|
+var mermaidEdge = regexp.MustCompile(`\s*-->\s*(?:\|[^|]*\|\s*)?`)
+
+// mermaidNodeShape matches the shape and label following a Mermaid node ID e.g. A[label] or B(label)
+var mermaidNodeShape = regexp.MustCompile(`^([^\[\](){}>\s]+)\s*[\[({>].*$`)
+
+// ParseMermaidRules parses a Mermaid flowchart, such as the one produced by GenerateMermaidRulesDiagram,
+// into a ruleset. Each arrow between two nodes becomes a rule; node declarations, edge labels,
+// comments and directives are ignored
+func ParseMermaidRules[T ~string](diagram string) (Ruleset[T], error) {
+	rs := make(Ruleset[T])
+
+	lines := splitMermaidStatements(diagram)
+
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+
+		if line == "" || strings.HasPrefix(line, "%%") || isMermaidDirective(line) {
+			continue
+		}
+
+		nodes := mermaidEdge.Split(line, -1)
+		if len(nodes) < 2 {
+			// Node declaration
+			continue
+		}
+
+		states := make([]T, 0, len(nodes))
+
+		for _, node := range nodes {
+			id := mermaidNodeID(node)
+			if id == "" {
+				return nil, DefinitionError{
+					Path:    fmt.Sprintf("line %d (%q)", i+1, line),
+					Message: "missing node",
+				}
+			}
+
+			states = append(states, T(id))
+		}
+
+		for j := 0; j < len(states)-1; j++ {
+			rs.add(states[j], states[j+1])
+		}
+	}
+
+	if len(rs) == 0 {
+		return nil, fmt.Errorf("no rules defined")
+	}
+
+	return rs, nil
+}
+
+// splitMermaidStatements splits a Mermaid diagram on semicolons and newlines outside of quoted labels
+func splitMermaidStatements(diagram string) []string {
+	var (
+		statements []string
+		current    strings.Builder
+		quoted     bool
+	)
+
+	for _, r := range diagram {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case (r == ';' && !quoted) || r == '\n':
+			statements = append(statements, current.String())
+			current.Reset()
+
+			continue
+		}
+
+		current.WriteRune(r)
+	}
+
+	return append(statements, current.String())
+}
+
+// isMermaidDirective checks if the line declares the diagram type or a style
+func isMermaidDirective(line string) bool {
+	keyword := strings.Fields(line)[0]
+
+	switch keyword {
+	case "graph", "flowchart", "classDef", "class", "style", "linkStyle", "click":
+		return true
+	}
+
+	return false
+}
+
+// mermaidNodeID returns the ID of a Mermaid node stripping its shape and label
+// If the ID is the safe ID of the quoted label, as generated for states that aren't valid IDs,
+// the label is returned instead
+func mermaidNodeID(node string) string {
+	node = strings.TrimSpace(node)
+
+	m := mermaidNodeShape.FindStringSubmatch(node)
+	if m == nil {
+		return node
+	}
+
+	label := strings.TrimSpace(strings.TrimPrefix(node, m[1]))
+	if strings.HasPrefix(label, `["`) && strings.HasSuffix(label, `"]`) {
+		state := mermaidLabelUnescaper.Replace(label[2 : len(label)-2])
+		if diagramID(state) == m[1] {
+			return state
+		}
+	}
+
+	return m[1]
+}
+
+// ParseDOTRules parses a Graphviz DOT digraph into a ruleset
+// Each edge becomes a rule; node and graph attributes are ignored
+func ParseDOTRules[T ~string](diagram string) (Ruleset[T], error) {
+	start := strings.Index(diagram, "{")
+	end := strings.LastIndex(diagram, "}")
+
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("missing digraph body")
+	}
+
+	rs := make(Ruleset[T])
+
+	for i, statement := range splitDOTStatements(diagram[start+1 : end]) {
+		statement = strings.TrimSpace(stripDOTAttributes(statement))
+
+		if statement == "" || !strings.Contains(statement, "->") {
+			continue
+		}
+
+		nodes := strings.Split(statement, "->")
+		states := make([]T, 0, len(nodes))
+
+		for _, node := range nodes {
+			id := strings.Trim(strings.TrimSpace(node), `"`)
+			if id == "" {
+				return nil, DefinitionError{
+					Path:    fmt.Sprintf("statement %d (%q)", i+1, statement),
+					Message: "missing node",
+				}
+			}
+
+			states = append(states, T(id))
+		}
+
+		for j := 0; j < len(states)-1; j++ {
+			rs.add(states[j], states[j+1])
+		}
+	}
+
+	if len(rs) == 0 {
+		return nil, fmt.Errorf("no rules defined")
+	}
+
+	return rs, nil
+}
+
+// splitDOTStatements splits a DOT graph body on semicolons and newlines outside of quotes and brackets
+func splitDOTStatements(body string) []string {
+	var (
+		statements []string
+		current    strings.Builder
+		quoted     bool
+		depth      int
+	)
+
+	for _, r := range body {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case quoted:
+		case r == '[':
+			depth++
+		case r == ']':
+			depth--
+		case (r == ';' || r == '\n') && depth == 0:
+			statements = append(statements, current.String())
+			current.Reset()
+
+			continue
+		}
+
+		current.WriteRune(r)
+	}
+
+	return append(statements, current.String())
+}
+
+// stripDOTAttributes removes the attribute list following a DOT statement
+func stripDOTAttributes(statement string) string {
+	quoted := false
+
+	for i, r := range statement {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == '[' && !quoted:
+			return statement[:i]
+		}
+	}
+
+	return statement
+}
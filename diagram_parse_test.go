@@ -0,0 +1,97 @@
+package statetrooper
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_parseMermaidRules(t *testing.T) {
+	tests := []struct {
+		diagram  string
+		expected Ruleset[CustomStateEnum]
+	}{
+		{
+			"graph LR;\nA\nB\nA --> B;\nB --> C;\n",
+			Ruleset[CustomStateEnum]{"A": {"B"}, "B": {"C"}},
+		},
+		{
+			"flowchart TD\n%% comment\nA[Created] -->|1| B(Picked) --> C\nA --> C\nA --> B",
+			Ruleset[CustomStateEnum]{"A": {"B", "C"}, "B": {"C"}},
+		},
+	}
+
+	for _, test := range tests {
+		rs, err := ParseMermaidRules[CustomStateEnum](test.diagram)
+		if err != nil {
+			t.Errorf("ParseMermaidRules(%q) returned an error: %v", test.diagram, err)
+
+			continue
+		}
+
+		if !reflect.DeepEqual(rs, test.expected) {
+			t.Errorf("ParseMermaidRules(%q) = %v, expected %v", test.diagram, rs, test.expected)
+		}
+	}
+
+	for _, diagram := range []string{"graph LR;\nA\nB\n", "graph LR;\nA --> ;\n"} {
+		_, err := ParseMermaidRules[CustomStateEnum](diagram)
+		if err == nil {
+			t.Errorf("ParseMermaidRules(%q) did not return an error", diagram)
+		}
+	}
+}
+
+func Test_parseMermaidRulesRoundTrip(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB, CustomStateEnumD)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumC)
+
+	diagram, err := fsm.GenerateMermaidRulesDiagram()
+	if err != nil {
+		t.Fatalf("GenerateMermaidRulesDiagram() returned an error: %v", err)
+	}
+
+	rs, err := ParseMermaidRules[CustomStateEnum](diagram)
+	if err != nil {
+		t.Fatalf("ParseMermaidRules() returned an error: %v", err)
+	}
+
+	if !reflect.DeepEqual(rs, fsm.ruleset) {
+		t.Errorf("ParseMermaidRules() = %v, expected %v", rs, fsm.ruleset)
+	}
+}
+
+func Test_parseDOTRules(t *testing.T) {
+	diagram := `digraph order {
+		rankdir=LR;
+		node [shape=box];
+		created [label="Created"];
+		created -> picked [label="pick"];
+		picked -> packed -> shipped
+		"on hold" -> picked;
+		created -> "on hold"; created -> picked
+	}`
+
+	expected := Ruleset[string]{
+		"created": {"picked", "on hold"},
+		"picked":  {"packed"},
+		"packed":  {"shipped"},
+		"on hold": {"picked"},
+	}
+
+	rs, err := ParseDOTRules[string](diagram)
+	if err != nil {
+		t.Fatalf("ParseDOTRules() returned an error: %v", err)
+	}
+
+	if !reflect.DeepEqual(rs, expected) {
+		t.Errorf("ParseDOTRules() = %v, expected %v", rs, expected)
+	}
+
+	for _, diagram := range []string{"digraph {}", "A -> B", "digraph { A -> ; }"} {
+		_, err := ParseDOTRules[string](diagram)
+		if err == nil {
+			t.Errorf("ParseDOTRules(%q) did not return an error", diagram)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package statetrooper
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_generateDOTRulesDiagram(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumC)
+
+	d, err := fsm.GenerateDOTRulesDiagram()
+	if err != nil {
+		t.Errorf("GenerateDOTRulesDiagram() returned an error: %v", err)
+	}
+
+	expectedDiagram := "digraph {\n\trankdir=LR;\n\t\"A\";\n\t\"B\";\n\t\"A\" -> \"B\";\n\t\"B\" -> \"C\";\n}\n"
+
+	if d != expectedDiagram {
+		t.Errorf("GenerateDOTRulesDiagram() returned an unexpected diagram:\n%s\nexpected:\n%s", d, expectedDiagram)
+	}
+
+	// The generated diagram can be parsed back into the same rules
+	rs, err := ParseDOTRules[CustomStateEnum](d)
+	if err != nil || len(rs) != 2 || rs[CustomStateEnumA][0] != CustomStateEnumB || rs[CustomStateEnumB][0] != CustomStateEnumC {
+		t.Errorf("ParseDOTRules() = %v, %v, expected the FSM's rules", rs, err)
+	}
+}
+
+func Test_generatePlantUMLRulesDiagram(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumC)
+
+	d, err := fsm.GeneratePlantUMLRulesDiagram()
+	if err != nil {
+		t.Errorf("GeneratePlantUMLRulesDiagram() returned an error: %v", err)
+	}
+
+	expectedDiagram := "@startuml\nA --> B\nB --> C\n@enduml\n"
+
+	if d != expectedDiagram {
+		t.Errorf("GeneratePlantUMLRulesDiagram() returned an unexpected diagram:\n%s\nexpected:\n%s", d, expectedDiagram)
+	}
+
+	_, err = NewFSM[CustomStateEnum](CustomStateEnumA, 10).GeneratePlantUMLRulesDiagram()
+	if err == nil {
+		t.Errorf("GeneratePlantUMLRulesDiagram() did not return an error without rules")
+	}
+}
+
+func Test_generateASCIIDiagram(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumC, CustomStateEnumB)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumC)
+
+	d, err := fsm.GenerateASCIIDiagram(WithCurrentStateMarked())
+	if err != nil {
+		t.Errorf("GenerateASCIIDiagram() returned an error: %v", err)
+	}
+
+	expectedDiagram := "A (current)\n  |--> B\n  `--> C\nB\n  `--> C\nC (final)\n"
+
+	if d != expectedDiagram {
+		t.Errorf("GenerateASCIIDiagram() returned an unexpected diagram:\n%s\nexpected:\n%s", d, expectedDiagram)
+	}
+
+	d, err = fsm.GenerateASCIIDiagram()
+	if err != nil || d != "A\n  |--> B\n  `--> C\nB\n  `--> C\nC (final)\n" {
+		t.Errorf("GenerateASCIIDiagram() = %q, %v, expected the current state not to be marked", d, err)
+	}
+
+	_, err = NewFSM[CustomStateEnum](CustomStateEnumA, 10).GenerateASCIIDiagram()
+	if err == nil {
+		t.Errorf("GenerateASCIIDiagram() did not return an error without rules")
+	}
+}
+
+func Test_generateMermaidTransitionHistoryDiagramLabels(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10,
+		WithTimeProvider[CustomStateEnum](func() time.Time {
+			return now
+		}),
+		WithRedactedMetadataKeys[CustomStateEnum]("email"),
+	)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumC)
+
+	if _, err := fsm.Transition(CustomStateEnumB, map[string]string{"ticket": "OPS-1", "email": "a@b.c"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	now = now.Add(90 * time.Minute)
+
+	if _, err := fsm.Transition(CustomStateEnumC, map[string]string{"note": `say "hi"`}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	d, err := fsm.GenerateMermaidTransitionHistoryDiagram(WithTimestampLabels(), WithDurationLabels(), WithMetadataLabels("ticket", "email", "note"))
+	if err != nil {
+		t.Fatalf("GenerateMermaidTransitionHistoryDiagram() returned an error: %v", err)
+	}
+
+	expectedDiagram := "graph TD;\nA;\nB;\nC;\n\n" +
+		"A -->|\"1<br/>2024-01-01T12:00:00Z<br/>ticket=OPS-1<br/>email=" + RedactedValue + "\"| B;\n" +
+		"B -->|\"2<br/>2024-01-01T13:30:00Z<br/>+1h30m0s<br/>note=say #quot;hi#quot;\"| C;\n"
+
+	if d != expectedDiagram {
+		t.Errorf("GenerateMermaidTransitionHistoryDiagram() returned an unexpected diagram:\n%s\nexpected:\n%s", d, expectedDiagram)
+	}
+}
+
+func Test_diagramSpecialStates(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum]("on hold", 10)
+	fsm.AddRule("on hold", "end", "in-review")
+	fsm.AddRule("in-review", `say "yes"; ok`)
+
+	d, err := fsm.GenerateMermaidRulesDiagram()
+	if err != nil {
+		t.Fatalf("GenerateMermaidRulesDiagram() returned an error: %v", err)
+	}
+
+	for _, unsafe := range []string{"on hold -->", "--> end;", "in-review;"} {
+		if strings.Contains(d, unsafe) {
+			t.Errorf("expected %q to be replaced with a safe node ID, got:\n%s", unsafe, d)
+		}
+	}
+
+	// The generated diagram can be parsed back into the same rules
+	rs, err := ParseMermaidRules[CustomStateEnum](d)
+	if err != nil {
+		t.Fatalf("ParseMermaidRules() returned an error: %v", err)
+	}
+
+	if !reflect.DeepEqual(rs, fsm.ruleset) {
+		t.Errorf("ParseMermaidRules() = %v, expected %v", rs, fsm.ruleset)
+	}
+
+	if id := diagramID("on hold"); id != diagramID("on hold") || id == diagramID("on_hold") || !strings.HasPrefix(id, "on_hold_") {
+		t.Errorf("expected stable and distinct IDs, got %q and %q", id, diagramID("on_hold"))
+	}
+
+	d, err = fsm.GeneratePlantUMLRulesDiagram()
+	if err != nil {
+		t.Fatalf("GeneratePlantUMLRulesDiagram() returned an error: %v", err)
+	}
+
+	expected := "state \"on hold\" as " + diagramID("on hold") + "\n"
+	if !strings.Contains(d, expected) || !strings.Contains(d, diagramID("on hold")+" --> "+diagramID("end")+"\n") {
+		t.Errorf("expected the PlantUML diagram to declare and use safe IDs, got:\n%s", d)
+	}
+}
@@ -0,0 +1,190 @@
+package statetrooper
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Rule is a transition allowed by a ruleset
+type Rule[T comparable] struct {
+	From T
+	To   T
+}
+
+// Diff describes the differences between two FSMs, A being the FSM Diff is called on and B the other one
+type Diff[T comparable] struct {
+	StateA   T
+	StateB   T
+	VersionA uint64
+	VersionB uint64
+	// RulesOnlyInA and RulesOnlyInB are the rules allowed by a single FSM, ordered by state
+	RulesOnlyInA []Rule[T]
+	RulesOnlyInB []Rule[T]
+	// HistoryIndex is the index of the first differing transition, or -1 if the histories are equal
+	HistoryIndex int
+	// HistoryA and HistoryB are the histories of the FSMs
+	HistoryA []Transition[T]
+	HistoryB []Transition[T]
+}
+
+// Empty checks if the FSMs have the same current state, version, ruleset and history
+func (d Diff[T]) Empty() bool {
+	return d.StateA == d.StateB &&
+		d.VersionA == d.VersionB &&
+		len(d.RulesOnlyInA) == 0 &&
+		len(d.RulesOnlyInB) == 0 &&
+		d.HistoryIndex < 0
+}
+
+// String returns a human readable report of the differences, one per line
+func (d Diff[T]) String() string {
+	if d.Empty() {
+		return "no differences"
+	}
+
+	var lines []string
+
+	if d.StateA != d.StateB {
+		lines = append(lines, fmt.Sprintf("current state: %v != %v", d.StateA, d.StateB))
+	}
+
+	if d.VersionA != d.VersionB {
+		lines = append(lines, fmt.Sprintf("version: %d != %d", d.VersionA, d.VersionB))
+	}
+
+	for _, rule := range d.RulesOnlyInA {
+		lines = append(lines, fmt.Sprintf("rule %v -> %v: only in A", rule.From, rule.To))
+	}
+
+	for _, rule := range d.RulesOnlyInB {
+		lines = append(lines, fmt.Sprintf("rule %v -> %v: only in B", rule.From, rule.To))
+	}
+
+	if i := d.HistoryIndex; i >= 0 {
+		lines = append(lines, fmt.Sprintf("transition %d: %s != %s", i, describeAt(d.HistoryA, i), describeAt(d.HistoryB, i)))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// describeAt describes the transition at index i of the history, or its absence
+func describeAt[T comparable](history []Transition[T], i int) string {
+	if i >= len(history) {
+		return "none"
+	}
+
+	transition := history[i]
+
+	return fmt.Sprintf("%v -> %v at %v", transition.FromState, transition.ToState, transition.Timestamp)
+}
+
+// Diff compares the FSM to other, e.g. an in-memory FSM to its persisted copy to detect drift
+// The FSMs are read one after the other, so the result reflects the state of each at a slightly different time
+func (fsm *FSM[T]) Diff(other *FSM[T]) (Diff[T], error) {
+	a, err := fsm.Snapshot()
+	if err != nil {
+		return Diff[T]{}, err
+	}
+
+	b, err := other.Snapshot()
+	if err != nil {
+		return Diff[T]{}, err
+	}
+
+	d := Diff[T]{
+		StateA:       a.State,
+		StateB:       b.State,
+		VersionA:     a.Version,
+		VersionB:     b.Version,
+		HistoryIndex: -1,
+		HistoryA:     a.Transitions,
+		HistoryB:     b.Transitions,
+	}
+
+	rulesA, rulesB := fsm.rules(), other.rules()
+	d.RulesOnlyInA = rulesMissing(rulesA, rulesB)
+	d.RulesOnlyInB = rulesMissing(rulesB, rulesA)
+
+	for i := 0; i < len(a.Transitions) || i < len(b.Transitions); i++ {
+		if i >= len(a.Transitions) || i >= len(b.Transitions) || !transitionsEqual(a.Transitions[i], b.Transitions[i]) {
+			d.HistoryIndex = i
+
+			break
+		}
+	}
+
+	return d, nil
+}
+
+// Equal checks if the FSM has the same current state, version, ruleset and history as other
+// FSMs whose history can't be read are not equal
+func (fsm *FSM[T]) Equal(other *FSM[T]) bool {
+	d, err := fsm.Diff(other)
+
+	return err == nil && d.Empty()
+}
+
+// rules returns the rules of the ruleset
+func (fsm *FSM[T]) rules() []Rule[T] {
+	fsm.rlock()
+	defer fsm.runlock()
+
+	var rules []Rule[T]
+
+	for from, targets := range fsm.ruleset {
+		for _, to := range targets {
+			rules = append(rules, Rule[T]{From: from, To: to})
+		}
+	}
+
+	return rules
+}
+
+// rulesMissing returns the rules of a missing from b, ordered by state
+func rulesMissing[T comparable](a, b []Rule[T]) []Rule[T] {
+	set := make(map[Rule[T]]struct{}, len(b))
+	for _, rule := range b {
+		set[rule] = struct{}{}
+	}
+
+	var missing []Rule[T]
+
+	for _, rule := range a {
+		if _, ok := set[rule]; !ok {
+			missing = append(missing, rule)
+		}
+	}
+
+	sortRules(missing)
+
+	return missing
+}
+
+// sortRules orders the rules by source and target state
+func sortRules[T comparable](rules []Rule[T]) {
+	sort.Slice(rules, func(i, j int) bool {
+		if from, other := toString(rules[i].From), toString(rules[j].From); from != other {
+			return from < other
+		}
+
+		return toString(rules[i].To) < toString(rules[j].To)
+	})
+}
+
+// transitionsEqual checks if two transitions are equal, comparing timestamps as instants
+func transitionsEqual[T comparable](a, b Transition[T]) bool {
+	if a.FromState != b.FromState || a.ToState != b.ToState ||
+		!a.Timestamp.Equal(b.Timestamp) || !a.EventTime.Equal(b.EventTime) ||
+		a.Actor != b.Actor || a.Reason != b.Reason || len(a.Metadata) != len(b.Metadata) {
+		return false
+	}
+
+	for key, value := range a.Metadata {
+		if other, ok := b.Metadata[key]; !ok || other != value {
+			return false
+		}
+	}
+
+	return true
+}
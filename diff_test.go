@@ -0,0 +1,60 @@
+package statetrooper
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_diff(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumC)
+
+	if _, err := fsm.Transition(CustomStateEnumB, nil, WithActor("alice")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	clone, err := fsm.Clone()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !fsm.Equal(clone) {
+		d, _ := fsm.Diff(clone)
+		t.Fatalf("expected the clone to be equal, got:\n%v", d)
+	}
+
+	if _, err := clone.Transition(CustomStateEnumC, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	clone.AddRule(CustomStateEnumC, CustomStateEnumD)
+
+	d, err := fsm.Diff(clone)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if d.Empty() || fsm.Equal(clone) {
+		t.Fatalf("expected the FSMs to differ")
+	}
+
+	if d.StateA != CustomStateEnumB || d.StateB != CustomStateEnumC || d.VersionA != 1 || d.VersionB != 2 {
+		t.Errorf("unexpected states or versions: %+v", d)
+	}
+
+	if len(d.RulesOnlyInA) != 0 || len(d.RulesOnlyInB) != 1 || d.RulesOnlyInB[0] != (Rule[CustomStateEnum]{From: CustomStateEnumC, To: CustomStateEnumD}) {
+		t.Errorf("unexpected rule differences: %+v and %+v", d.RulesOnlyInA, d.RulesOnlyInB)
+	}
+
+	if d.HistoryIndex != 1 {
+		t.Errorf("expected the histories to differ at 1, got %d", d.HistoryIndex)
+	}
+
+	report := d.String()
+	for _, line := range []string{"current state: B != C", "version: 1 != 2", "rule C -> D: only in B", "transition 1: none != B -> C at "} {
+		if !strings.Contains(report, line) {
+			t.Errorf("expected the report to contain %q, got:\n%s", line, report)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package statetrooper
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseRules parses a compact rules definition into a ruleset
+//
+// Statements are separated by semicolons or newlines. Each statement is a chain of
+// states separated by arrows, allowing a transition from each state to the next one:
+//
+//	A -> B -> C; B -> D; * -> CANCELLED
+//
+// A step may list several comma separated states, allowing transitions from each of them
+// to each state of the next step, e.g. "A, B -> C, D"
+// The wildcard * as the first step stands for every state named in the definition, except the target itself
+func ParseRules[T ~string](def string) (Ruleset[T], error) {
+	type edge struct {
+		from, to T
+	}
+
+	var (
+		edges     []edge
+		wildcards []T
+		states    []T
+		known     = make(map[T]bool)
+	)
+
+	addState := func(state T) {
+		if !known[state] {
+			known[state] = true
+			states = append(states, state)
+		}
+	}
+
+	statements := strings.FieldsFunc(def, func(r rune) bool {
+		return r == ';' || r == '\n'
+	})
+
+	for i, statement := range statements {
+		statement = strings.TrimSpace(statement)
+		if statement == "" {
+			continue
+		}
+
+		invalid := func(format string, args ...any) error {
+			return DefinitionError{
+				Path:    fmt.Sprintf("statement %d (%q)", i+1, statement),
+				Message: fmt.Sprintf(format, args...),
+			}
+		}
+
+		parts := strings.Split(statement, "->")
+		if len(parts) < 2 {
+			return nil, invalid("expected at least one arrow")
+		}
+
+		steps := make([][]T, len(parts))
+
+		for j, part := range parts {
+			for _, name := range strings.Split(part, ",") {
+				name = strings.TrimSpace(name)
+
+				if name == "" {
+					return nil, invalid("empty state name")
+				}
+
+				if strings.ContainsAny(name, " \t\r") {
+					return nil, invalid("state name %q contains whitespace", name)
+				}
+
+				if name == "*" && (j > 0 || len(parts) > 2) {
+					return nil, invalid("the wildcard is only allowed as the source of a single arrow")
+				}
+
+				steps[j] = append(steps[j], T(name))
+			}
+		}
+
+		if steps[0][0] == "*" {
+			if len(steps[0]) > 1 {
+				return nil, invalid("the wildcard can't be combined with other states")
+			}
+
+			for _, toState := range steps[1] {
+				addState(toState)
+				wildcards = append(wildcards, toState)
+			}
+
+			continue
+		}
+
+		for j := 0; j < len(steps)-1; j++ {
+			for _, fromState := range steps[j] {
+				addState(fromState)
+
+				for _, toState := range steps[j+1] {
+					addState(toState)
+					edges = append(edges, edge{fromState, toState})
+				}
+			}
+		}
+	}
+
+	for _, toState := range wildcards {
+		for _, fromState := range states {
+			if fromState != toState {
+				edges = append(edges, edge{fromState, toState})
+			}
+		}
+	}
+
+	rs := make(Ruleset[T])
+	seen := make(map[edge]bool, len(edges))
+
+	for _, e := range edges {
+		if seen[e] {
+			continue
+		}
+
+		seen[e] = true
+		rs[e.from] = append(rs[e.from], e.to)
+	}
+
+	if len(rs) == 0 {
+		return nil, fmt.Errorf("no rules defined")
+	}
+
+	return rs, nil
+}
@@ -0,0 +1,66 @@
+package statetrooper
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_parseRules(t *testing.T) {
+	tests := []struct {
+		def      string
+		expected Ruleset[CustomStateEnum]
+	}{
+		{
+			"A -> B",
+			Ruleset[CustomStateEnum]{"A": {"B"}},
+		},
+		{
+			"A -> B -> C; B -> D",
+			Ruleset[CustomStateEnum]{"A": {"B"}, "B": {"C", "D"}},
+		},
+		{
+			"A, B -> C, D",
+			Ruleset[CustomStateEnum]{"A": {"C", "D"}, "B": {"C", "D"}},
+		},
+		{
+			"A -> B -> C\n* -> X",
+			Ruleset[CustomStateEnum]{"A": {"B", "X"}, "B": {"C", "X"}, "C": {"X"}},
+		},
+		{
+			"* -> X; A -> B; A -> B",
+			Ruleset[CustomStateEnum]{"A": {"B", "X"}, "B": {"X"}},
+		},
+	}
+
+	for _, test := range tests {
+		rs, err := ParseRules[CustomStateEnum](test.def)
+		if err != nil {
+			t.Errorf("ParseRules(%q) returned an error: %v", test.def, err)
+
+			continue
+		}
+
+		if !reflect.DeepEqual(rs, test.expected) {
+			t.Errorf("ParseRules(%q) = %v, expected %v", test.def, rs, test.expected)
+		}
+	}
+}
+
+func Test_parseRulesErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"A",
+		"A ->",
+		"A -> B C",
+		"A -> *",
+		"*, A -> B",
+		"* -> A -> B",
+	}
+
+	for _, def := range tests {
+		_, err := ParseRules[string](def)
+		if err == nil {
+			t.Errorf("ParseRules(%q) did not return an error", def)
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package statetrooper
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"sort"
+)
+
+// fsmEncoding is the self-contained representation of an FSM used by its text and gob encodings
+// Unlike the JSON export, it includes the rules and the maximum history size, so an FSM can be
+// decoded into a zero value
+type fsmEncoding[T comparable] struct {
+	Snapshot[T]
+	MaxHistory int               `json:"max_history"`
+	Rules      []ruleEncoding[T] `json:"rules"`
+}
+
+// ruleEncoding holds the allowed target states of a state
+// Rules are encoded as a list rather than a map so that any comparable state type is supported
+type ruleEncoding[T comparable] struct {
+	From T   `json:"from" yaml:"from"`
+	To   []T `json:"to" yaml:"to"`
+}
+
+// encoding captures the FSM in its self-contained representation, redacting the metadata keys
+// set with WithRedactedMetadataKeys
+// The caller must hold the lock
+func (fsm *FSM[T]) encoding() (fsmEncoding[T], error) {
+	transitions, err := fsm.history().List()
+	if err != nil {
+		return fsmEncoding[T]{}, err
+	}
+
+	return fsmEncoding[T]{
+		Snapshot: Snapshot[T]{
+			FormatVersion: SnapshotFormatVersion,
+			State:         fsm.currentState,
+			Version:       fsm.version,
+			RulesetHash:   fsm.ruleset.hash(),
+			Transitions:   fsm.redactAll(transitions),
+			Entries:       fsm.entryCounts(),
+		},
+		MaxHistory: fsm.maxHistory,
+		Rules:      fsm.ruleEncodings(),
+	}, nil
+}
+
+// ruleEncodings returns the rules ordered by source state
+// The caller must hold the lock
+func (fsm *FSM[T]) ruleEncodings() []ruleEncoding[T] {
+	rules := make([]ruleEncoding[T], 0, len(fsm.ruleset))
+	for fromState, toStates := range fsm.ruleset {
+		rules = append(rules, ruleEncoding[T]{From: fromState, To: toStates})
+	}
+
+	sort.Slice(rules, func(i, j int) bool {
+		return toString(rules[i].From) < toString(rules[j].From)
+	})
+
+	return rules
+}
+
+// setRuleEncodings replaces the FSM's ruleset with the encoded rules
+// The caller must hold the lock
+func (fsm *FSM[T]) setRuleEncodings(rules []ruleEncoding[T]) {
+	rs := make(Ruleset[T], len(rules))
+	for _, rule := range rules {
+		rs[rule.From] = append(rs[rule.From], rule.To...)
+	}
+
+	fsm.ruleset = rs
+	fsm.sharedRuleset = false
+
+	if fsm.compiled != nil {
+		fsm.compiled = rs.compile()
+	}
+}
+
+// decode replaces the FSM's rules, maximum history size, state, version and history with the encoded ones
+// The caller must hold the lock
+func (fsm *FSM[T]) decode(enc fsmEncoding[T]) error {
+	fsm.setDefaults()
+	fsm.setRuleEncodings(enc.Rules)
+	fsm.maxHistory = enc.MaxHistory
+
+	return fsm.restore(enc.Snapshot)
+}
+
+// MarshalText encodes the FSM including its rules, e.g. to store it in a cache
+// As with MarshalJSON, the metadata keys set with WithRedactedMetadataKeys are redacted, so the redacted values
+// don't survive a round trip. Use Snapshot to persist FSMs with redacted keys
+func (fsm *FSM[T]) MarshalText() ([]byte, error) {
+	fsm.rlock()
+	defer fsm.runlock()
+
+	enc, err := fsm.encoding()
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(enc)
+}
+
+// UnmarshalText decodes an FSM encoded by MarshalText, replacing its rules, maximum history size,
+// state, version and history. It can be used on a zero value FSM
+func (fsm *FSM[T]) UnmarshalText(text []byte) error {
+	var enc fsmEncoding[T]
+
+	err := json.Unmarshal(text, &enc)
+	if err != nil {
+		return err
+	}
+
+	fsm.lock()
+	defer fsm.unlock()
+
+	return fsm.decode(enc)
+}
+
+// GobEncode encodes the FSM including its rules with encoding/gob, e.g. for RPC layers that aren't JSON based
+// As with MarshalText, the metadata keys set with WithRedactedMetadataKeys are redacted
+func (fsm *FSM[T]) GobEncode() ([]byte, error) {
+	fsm.rlock()
+	defer fsm.runlock()
+
+	enc, err := fsm.encoding()
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+
+	err = gob.NewEncoder(&buf).Encode(enc)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// GobDecode decodes an FSM encoded by GobEncode, replacing its rules, maximum history size,
+// state, version and history. It can be used on a zero value FSM
+func (fsm *FSM[T]) GobDecode(data []byte) error {
+	var enc fsmEncoding[T]
+
+	err := gob.NewDecoder(bytes.NewReader(data)).Decode(&enc)
+	if err != nil {
+		return err
+	}
+
+	fsm.lock()
+	defer fsm.unlock()
+
+	return fsm.decode(enc)
+}
+
+// transitionFields has the fields of Transition without its methods, to marshal it with the default encoding
+type transitionFields[T comparable] Transition[T]
+
+// MarshalJSON encodes the transition as a JSON object
+func (t Transition[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(transitionFields[T](t))
+}
+
+// UnmarshalJSON decodes a transition from a JSON object
+func (t *Transition[T]) UnmarshalJSON(data []byte) error {
+	return json.Unmarshal(data, (*transitionFields[T])(t))
+}
+
+// MarshalText encodes the transition as JSON, e.g. to store it in a cache
+func (t Transition[T]) MarshalText() ([]byte, error) {
+	return t.MarshalJSON()
+}
+
+// UnmarshalText decodes a transition encoded by MarshalText
+func (t *Transition[T]) UnmarshalText(text []byte) error {
+	return t.UnmarshalJSON(text)
+}
+
+// fsmYAML is the YAML document produced by MarshalYAML
+type fsmYAML[T comparable] struct {
+	State       T                     `yaml:"state"`
+	Version     uint64                `yaml:"version"`
+	MaxHistory  int                   `yaml:"max_history"`
+	Rules       []ruleEncoding[T]     `yaml:"rules"`
+	RulesetHash string                `yaml:"ruleset_hash,omitempty"`
+	Transitions []transitionFields[T] `yaml:"transitions"`
+}
+
+// MarshalYAML implements the yaml.Marshaler interface of gopkg.in/yaml.v2 and gopkg.in/yaml.v3
+// Unlike the JSON form, the document includes the rules, so the FSM can be fully round-tripped through
+// a human-editable document. As with MarshalText, the metadata keys set with WithRedactedMetadataKeys are redacted
+func (fsm *FSM[T]) MarshalYAML() (interface{}, error) {
+	fsm.rlock()
+	defer fsm.runlock()
+
+	enc, err := fsm.encoding()
+	if err != nil {
+		return nil, err
+	}
+
+	doc := fsmYAML[T]{
+		State:       enc.State,
+		Version:     enc.Version,
+		MaxHistory:  enc.MaxHistory,
+		Rules:       enc.Rules,
+		RulesetHash: enc.RulesetHash,
+		Transitions: make([]transitionFields[T], len(enc.Transitions)),
+	}
+
+	for i, transition := range enc.Transitions {
+		doc.Transitions[i] = transitionFields[T](transition)
+	}
+
+	return doc, nil
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface of gopkg.in/yaml.v2, which gopkg.in/yaml.v3
+// also supports, replacing the FSM's rules, maximum history size, state, version and history
+// The ruleset_hash may be omitted from hand-edited documents; if present, it must match the rules
+func (fsm *FSM[T]) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var doc fsmYAML[T]
+
+	err := unmarshal(&doc)
+	if err != nil {
+		return err
+	}
+
+	enc := fsmEncoding[T]{
+		Snapshot: Snapshot[T]{
+			FormatVersion: SnapshotFormatVersion,
+			State:         doc.State,
+			Version:       doc.Version,
+			RulesetHash:   doc.RulesetHash,
+			Transitions:   make([]Transition[T], len(doc.Transitions)),
+		},
+		MaxHistory: doc.MaxHistory,
+		Rules:      doc.Rules,
+	}
+
+	for i, transition := range doc.Transitions {
+		enc.Transitions[i] = Transition[T](transition)
+	}
+
+	fsm.lock()
+	defer fsm.unlock()
+
+	return fsm.decode(enc)
+}
@@ -0,0 +1,145 @@
+package statetrooper
+
+import (
+	"bytes"
+	"encoding/gob"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func newEncodingTestFSM() *FSM[CustomStateEnum] {
+	staticTime := time.Date(2023, 6, 18, 0, 0, 0, 0, time.UTC)
+
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 5, WithTimeProvider[CustomStateEnum](func() time.Time {
+		return staticTime
+	}))
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumC)
+
+	_, _ = fsm.Transition(CustomStateEnumB, map[string]string{"k": "v"})
+
+	return fsm
+}
+
+func assertDecodedFSM(t *testing.T, original, decoded *FSM[CustomStateEnum]) {
+	t.Helper()
+
+	if decoded.CurrentState() != original.CurrentState() || decoded.Version() != original.Version() {
+		t.Errorf("decoded state %v at version %d, expected %v at version %d",
+			decoded.CurrentState(), decoded.Version(), original.CurrentState(), original.Version())
+	}
+
+	if !reflect.DeepEqual(decoded.Transitions(), original.Transitions()) {
+		t.Errorf("decoded transitions %v, expected %v", decoded.Transitions(), original.Transitions())
+	}
+
+	if decoded.RulesetHash() != original.RulesetHash() || decoded.maxHistory != original.maxHistory {
+		t.Errorf("expected the rules and maximum history size to be decoded")
+	}
+
+	if _, err := decoded.Transition(CustomStateEnumC, nil); err != nil {
+		t.Errorf("expected the decoded FSM to transition with its rules: %v", err)
+	}
+}
+
+func Test_textEncoding(t *testing.T) {
+	fsm := newEncodingTestFSM()
+
+	text, err := fsm.MarshalText()
+	if err != nil {
+		t.Fatalf("MarshalText() returned an error: %v", err)
+	}
+
+	var decoded FSM[CustomStateEnum]
+
+	err = decoded.UnmarshalText(text)
+	if err != nil {
+		t.Fatalf("UnmarshalText() returned an error: %v", err)
+	}
+
+	assertDecodedFSM(t, fsm, &decoded)
+}
+
+func Test_gobEncoding(t *testing.T) {
+	type cached struct {
+		ID  string
+		FSM *FSM[CustomStateEnum]
+	}
+
+	fsm := newEncodingTestFSM()
+
+	var buf bytes.Buffer
+
+	err := gob.NewEncoder(&buf).Encode(cached{ID: "order-1", FSM: fsm})
+	if err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+
+	var decoded cached
+
+	err = gob.NewDecoder(&buf).Decode(&decoded)
+	if err != nil {
+		t.Fatalf("failed to decode: %v", err)
+	}
+
+	assertDecodedFSM(t, fsm, decoded.FSM)
+}
+
+func Test_transitionTextEncoding(t *testing.T) {
+	transition := newEncodingTestFSM().Transitions()[0]
+
+	text, err := transition.MarshalText()
+	if err != nil {
+		t.Fatalf("MarshalText() returned an error: %v", err)
+	}
+
+	var decoded Transition[CustomStateEnum]
+
+	err = decoded.UnmarshalText(text)
+	if err != nil {
+		t.Fatalf("UnmarshalText() returned an error: %v", err)
+	}
+
+	if !reflect.DeepEqual(decoded, transition) {
+		t.Errorf("decoded %v, expected %v", decoded, transition)
+	}
+}
+
+func Test_yamlEncoding(t *testing.T) {
+	fsm := newEncodingTestFSM()
+
+	data, err := yaml.Marshal(fsm)
+	if err != nil {
+		t.Fatalf("yaml.Marshal() returned an error: %v", err)
+	}
+
+	if !strings.Contains(string(data), "from_state: A") || !strings.Contains(string(data), "rules:") {
+		t.Errorf("unexpected YAML document:\n%s", data)
+	}
+
+	var decoded FSM[CustomStateEnum]
+
+	err = yaml.Unmarshal(data, &decoded)
+	if err != nil {
+		t.Fatalf("yaml.Unmarshal() returned an error: %v", err)
+	}
+
+	assertDecodedFSM(t, fsm, &decoded)
+
+	// hand-edited documents may omit the ruleset hash
+	edited := strings.Replace(string(data), "ruleset_hash: "+fsm.RulesetHash(), "", 1)
+	edited = strings.Replace(edited, "to:\n        - C", "to:\n        - C\n        - D", 1)
+
+	err = yaml.Unmarshal([]byte(edited), &decoded)
+	if err != nil {
+		t.Fatalf("yaml.Unmarshal() of the edited document returned an error: %v", err)
+	}
+
+	if !decoded.CanTransition(CustomStateEnumD) {
+		t.Errorf("expected the edited rules to be loaded:\n%s", edited)
+	}
+}
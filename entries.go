@@ -0,0 +1,131 @@
+package statetrooper
+
+import "sort"
+
+// EntryCount is the number of times an FSM entered a state
+type EntryCount[T comparable] struct {
+	State T   `json:"state"`
+	Count int `json:"count"`
+}
+
+// TimesEntered returns the number of times the FSM entered the state, including the initial state
+// and self-transitions
+// Unlike the history, the counters aren't trimmed, so they tell reliably whether a state was visited
+// They are cleared by Reset and persisted by MarshalJSON and Snapshot. When restoring data without
+// counters, they are rebuilt from the restored history
+func (fsm *FSM[T]) TimesEntered(state T) int {
+	fsm.rlock()
+	defer fsm.runlock()
+
+	return fsm.entries[state]
+}
+
+// HasVisited checks if the FSM ever entered the state, including the initial state
+// Like TimesEntered, it doesn't depend on the history
+func (fsm *FSM[T]) HasVisited(state T) bool {
+	return fsm.TimesEntered(state) > 0
+}
+
+// DetectLoop checks if the FSM came back to a state within its last window transitions, including
+// the state they started from, e.g. to detect an entity bouncing between states and break the loop
+// A window of 0 or less covers the whole history. Compacted records count as the transitions they summarize
+func (fsm *FSM[T]) DetectLoop(window int) (bool, error) {
+	fsm.rlock()
+	defer fsm.runlock()
+
+	transitions, err := fsm.history().List()
+	if err != nil {
+		return false, err
+	}
+
+	if window > 0 && len(transitions) > window {
+		transitions = transitions[len(transitions)-window:]
+	}
+
+	if len(transitions) == 0 {
+		return false, nil
+	}
+
+	seen := make(map[T]struct{}, len(transitions)+1)
+	seen[transitions[0].FromState] = struct{}{}
+
+	for _, transition := range transitions {
+		if _, ok := seen[transition.ToState]; ok || compactedCount(transition) > 1 {
+			return true, nil
+		}
+
+		seen[transition.ToState] = struct{}{}
+	}
+
+	return false, nil
+}
+
+// entered counts an entry into the state
+// The caller must hold the lock
+func (fsm *FSM[T]) entered(state T) {
+	if fsm.entries == nil {
+		fsm.entries = make(map[T]int)
+	}
+
+	fsm.entries[state]++
+}
+
+// entryCounts returns the entry counters ordered by state
+// The caller must hold the lock
+func (fsm *FSM[T]) entryCounts() []EntryCount[T] {
+	if len(fsm.entries) == 0 {
+		return nil
+	}
+
+	counts := make([]EntryCount[T], 0, len(fsm.entries))
+	for state, count := range fsm.entries {
+		counts = append(counts, EntryCount[T]{State: state, Count: count})
+	}
+
+	sort.Slice(counts, func(i, j int) bool {
+		return toString(counts[i].State) < toString(counts[j].State)
+	})
+
+	return counts
+}
+
+// copyEntries returns a copy of the entry counters
+func copyEntries[T comparable](entries map[T]int) map[T]int {
+	if entries == nil {
+		return nil
+	}
+
+	copied := make(map[T]int, len(entries))
+	for state, count := range entries {
+		copied[state] = count
+	}
+
+	return copied
+}
+
+// restoreEntries replaces the entry counters with the restored ones, or rebuilds them from
+// the restored history and current state if there are none
+// The caller must hold the lock
+func (fsm *FSM[T]) restoreEntries(counts []EntryCount[T], transitions []Transition[T]) {
+	fsm.entries = make(map[T]int, len(counts))
+
+	for _, count := range counts {
+		fsm.entries[count.State] += count.Count
+	}
+
+	if len(counts) > 0 {
+		return
+	}
+
+	if len(transitions) == 0 {
+		fsm.entered(fsm.currentState)
+
+		return
+	}
+
+	fsm.entered(transitions[0].FromState)
+
+	for _, transition := range transitions {
+		fsm.entered(transition.ToState)
+	}
+}
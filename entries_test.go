@@ -0,0 +1,116 @@
+package statetrooper
+
+import "testing"
+
+func Test_timesEntered(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 1)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumA, CustomStateEnumC)
+
+	for _, state := range []CustomStateEnum{CustomStateEnumB, CustomStateEnumA, CustomStateEnumB, CustomStateEnumC} {
+		if _, err := fsm.Transition(state, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	expected := map[CustomStateEnum]int{CustomStateEnumA: 2, CustomStateEnumB: 2, CustomStateEnumC: 1, CustomStateEnumD: 0}
+
+	for state, count := range expected {
+		if entered := fsm.TimesEntered(state); entered != count {
+			t.Errorf("expected %v to be entered %d times, got %d", state, count, entered)
+		}
+	}
+
+	data, err := fsm.MarshalJSON()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	restored := NewFSM[CustomStateEnum](CustomStateEnumA, 1)
+	restored.AddRule(CustomStateEnumA, CustomStateEnumB)
+	restored.AddRule(CustomStateEnumB, CustomStateEnumA, CustomStateEnumC)
+
+	if err := restored.UnmarshalJSON(data); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for state, count := range expected {
+		if entered := restored.TimesEntered(state); entered != count {
+			t.Errorf("expected the restored %v to be entered %d times, got %d", state, count, entered)
+		}
+	}
+
+	if err := fsm.Reset(CustomStateEnumA); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if fsm.TimesEntered(CustomStateEnumA) != 1 || fsm.TimesEntered(CustomStateEnumB) != 0 {
+		t.Errorf("expected Reset to clear the counters")
+	}
+}
+
+func Test_timesEnteredRebuilt(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumA)
+
+	err := fsm.Restore(Snapshot[CustomStateEnum]{
+		State:   CustomStateEnumB,
+		Version: 3,
+		Transitions: []Transition[CustomStateEnum]{
+			{FromState: CustomStateEnumA, ToState: CustomStateEnumB},
+			{FromState: CustomStateEnumB, ToState: CustomStateEnumA},
+			{FromState: CustomStateEnumA, ToState: CustomStateEnumB},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if fsm.TimesEntered(CustomStateEnumA) != 2 || fsm.TimesEntered(CustomStateEnumB) != 2 {
+		t.Errorf("expected the counters to be rebuilt from the history, got %d and %d",
+			fsm.TimesEntered(CustomStateEnumA), fsm.TimesEntered(CustomStateEnumB))
+	}
+}
+
+func Test_detectLoop(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumA, CustomStateEnumC)
+	fsm.AddRule(CustomStateEnumC, CustomStateEnumD)
+
+	if !fsm.HasVisited(CustomStateEnumA) || fsm.HasVisited(CustomStateEnumB) {
+		t.Errorf("expected only the initial state to be visited")
+	}
+
+	for _, state := range []CustomStateEnum{CustomStateEnumB, CustomStateEnumA, CustomStateEnumB, CustomStateEnumC, CustomStateEnumD} {
+		if _, err := fsm.Transition(state, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if !fsm.HasVisited(CustomStateEnumC) {
+		t.Errorf("expected C to be visited")
+	}
+
+	tests := []struct {
+		window   int
+		expected bool
+	}{
+		{window: 2, expected: false},
+		{window: 3, expected: false},
+		{window: 4, expected: true},
+		{window: 0, expected: true},
+	}
+
+	for _, test := range tests {
+		loop, err := fsm.DetectLoop(test.window)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if loop != test.expected {
+			t.Errorf("DetectLoop(%d) = %v, expected %v", test.window, loop, test.expected)
+		}
+	}
+}
@@ -1,6 +1,41 @@
 package statetrooper
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrStaleSnapshot is matched by errors.Is when a loaded snapshot is older than the store's watermark
+var ErrStaleSnapshot = errors.New("stale snapshot")
+
+// ErrRulesetMismatch is matched by errors.Is when restored data was produced under a different ruleset
+var ErrRulesetMismatch = errors.New("ruleset mismatch")
+
+// ErrVersionMismatch is matched by errors.Is when a conditional transition expected a different version
+var ErrVersionMismatch = errors.New("version mismatch")
+
+// ErrRateLimited is matched by errors.Is when a transition is throttled by a rate limit
+var ErrRateLimited = errors.New("rate limited")
+
+// ErrQueueClosed is returned when enqueuing a transition on a closed TransitionQueue
+var ErrQueueClosed = errors.New("transition queue closed")
+
+// ErrDeferredExpired is passed to the handler set with WithDeferredDroppedHandler when a deferred transition expires
+var ErrDeferredExpired = errors.New("deferred transition expired")
+
+// ErrLinkCycle is matched by errors.Is when the links of an FSM lead back to it, see Link
+var ErrLinkCycle = errors.New("linked transition cycle")
+
+// ErrAutomaticCycle is returned along with the new state when automatic edges lead around a cycle, see SetAutomatic
+var ErrAutomaticCycle = errors.New("automatic transition cycle")
+
+// ErrNothingToRollback is returned by Rollback when the history doesn't hold the transition to reverse
+var ErrNothingToRollback = errors.New("nothing to roll back")
+
+// ErrCycle is matched by errors.Is when a ruleset expected to be a directed acyclic graph has a cycle
+var ErrCycle = errors.New("ruleset has a cycle")
 
 // TransitionError represents an error that occurs during a state transition
 type TransitionError[T comparable] struct {
@@ -11,3 +46,345 @@ type TransitionError[T comparable] struct {
 func (err TransitionError[T]) Error() string {
 	return fmt.Sprintf("invalid state transition from %v to %v", err.FromState, err.ToState)
 }
+
+// StaleSnapshotError represents an error that occurs when a snapshot being loaded
+// has a lower version than the watermark provided by the store
+type StaleSnapshotError struct {
+	Version   uint64
+	Watermark uint64
+}
+
+func (err StaleSnapshotError) Error() string {
+	return fmt.Sprintf("stale snapshot: version %d is behind watermark %d", err.Version, err.Watermark)
+}
+
+// Is reports whether the target is ErrStaleSnapshot
+func (err StaleSnapshotError) Is(target error) bool {
+	return target == ErrStaleSnapshot
+}
+
+// VersionMismatchError represents an error that occurs when a conditional transition
+// expected a different version than the FSM's current version
+type VersionMismatchError struct {
+	Expected uint64
+	Actual   uint64
+}
+
+func (err VersionMismatchError) Error() string {
+	return fmt.Sprintf("version mismatch: expected version %d, got %d", err.Expected, err.Actual)
+}
+
+// Is reports whether the target is ErrVersionMismatch
+func (err VersionMismatchError) Is(target error) bool {
+	return target == ErrVersionMismatch
+}
+
+// RulesetMismatchError represents an error that occurs when restored data was produced under a ruleset
+// whose fingerprint differs from the FSM's ruleset
+type RulesetMismatchError struct {
+	Expected string
+	Actual   string
+}
+
+func (err RulesetMismatchError) Error() string {
+	return fmt.Sprintf("ruleset mismatch: expected ruleset %s, got %s", err.Expected, err.Actual)
+}
+
+// Is reports whether the target is ErrRulesetMismatch
+func (err RulesetMismatchError) Is(target error) bool {
+	return target == ErrRulesetMismatch
+}
+
+// SnapshotFormatError represents an error that occurs when a snapshot was taken with a newer,
+// unsupported format
+type SnapshotFormatError struct {
+	FormatVersion int
+}
+
+func (err SnapshotFormatError) Error() string {
+	return fmt.Sprintf("unsupported snapshot format version %d, the latest supported is %d", err.FormatVersion, SnapshotFormatVersion)
+}
+
+// MetadataError represents an error that occurs when the metadata validator rejects a transition
+type MetadataError[T comparable] struct {
+	FromState T
+	ToState   T
+	Err       error
+}
+
+func (err MetadataError[T]) Error() string {
+	return fmt.Sprintf("invalid metadata for transition from %v to %v: %v", err.FromState, err.ToState, err.Err)
+}
+
+func (err MetadataError[T]) Unwrap() error {
+	return err.Err
+}
+
+// TimestampOrderError represents an error that occurs when a backdated transition would be recorded
+// before the newest transition in the history
+type TimestampOrderError struct {
+	Timestamp time.Time
+	Previous  time.Time
+}
+
+func (err TimestampOrderError) Error() string {
+	return fmt.Sprintf("timestamp %v is before the previous transition at %v", err.Timestamp, err.Previous)
+}
+
+// RateLimitError represents an error that occurs when a transition is throttled by a rate limit
+// RetryAfter is how long until the transition would be allowed
+type RateLimitError[T comparable] struct {
+	FromState  T
+	ToState    T
+	RetryAfter time.Duration
+}
+
+func (err RateLimitError[T]) Error() string {
+	return fmt.Sprintf("rate limited transition from %v to %v, retry after %v", err.FromState, err.ToState, err.RetryAfter)
+}
+
+// Is reports whether the target is ErrRateLimited
+func (err RateLimitError[T]) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+// AuthorizationError represents an error that occurs when the authorizer denies a transition
+type AuthorizationError[T comparable] struct {
+	Actor     string
+	FromState T
+	ToState   T
+	Err       error
+}
+
+func (err AuthorizationError[T]) Error() string {
+	return fmt.Sprintf("transition from %v to %v denied for actor %q: %v", err.FromState, err.ToState, err.Actor, err.Err)
+}
+
+func (err AuthorizationError[T]) Unwrap() error {
+	return err.Err
+}
+
+// PermanentError represents a permanent rejection by a metadata validator, see Permanent
+type PermanentError struct {
+	Err error
+}
+
+func (err PermanentError) Error() string {
+	return err.Err.Error()
+}
+
+func (err PermanentError) Unwrap() error {
+	return err.Err
+}
+
+// RetryError represents an error that occurs when TransitionWithRetry gives up
+// Err is the last rejection and Cause is the context's error, or nil if the maximum number of attempts
+// was reached
+type RetryError struct {
+	Attempts int
+	Err      error
+	Cause    error
+}
+
+func (err RetryError) Error() string {
+	if err.Cause != nil {
+		return fmt.Sprintf("gave up after %d attempts: %v: %v", err.Attempts, err.Cause, err.Err)
+	}
+
+	return fmt.Sprintf("gave up after %d attempts: %v", err.Attempts, err.Err)
+}
+
+// Unwrap returns the last rejection and the context's error
+func (err RetryError) Unwrap() []error {
+	if err.Cause == nil {
+		return []error{err.Err}
+	}
+
+	return []error{err.Err, err.Cause}
+}
+
+// PanicError represents a panic recovered from a user supplied callback such as a subscriber or a validator
+// Stack is the stack trace of the goroutine at the time of the panic
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (err PanicError) Error() string {
+	return fmt.Sprintf("callback panicked: %v", err.Value)
+}
+
+// HookError represents an error that occurs when hooks registered with OnTransition fail
+// The transition was committed regardless
+type HookError[T comparable] struct {
+	FromState T
+	ToState   T
+	Errs      []error
+}
+
+func (err HookError[T]) Error() string {
+	msgs := make([]string, len(err.Errs))
+	for i, e := range err.Errs {
+		msgs[i] = e.Error()
+	}
+
+	return fmt.Sprintf("hook of transition from %v to %v failed: %s", err.FromState, err.ToState, strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the errors of the hooks
+func (err HookError[T]) Unwrap() []error {
+	return err.Errs
+}
+
+// LinkError represents an error that occurs when transitions linked with Link fail
+// Committed is set if the triggering transition was made regardless
+type LinkError struct {
+	Committed bool
+	Errs      []error
+}
+
+func (err LinkError) Error() string {
+	msgs := make([]string, len(err.Errs))
+	for i, e := range err.Errs {
+		msgs[i] = e.Error()
+	}
+
+	return fmt.Sprintf("linked transition failed: %s", strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the errors of the linked transitions
+func (err LinkError) Unwrap() []error {
+	return err.Errs
+}
+
+// CompensationError represents an error that occurs when the action of a transition made with
+// TransitionWithAction fails
+// Compensated is set if the compensating transition was made, otherwise CompensationErr is the reason
+// it failed, or nil if the edge has no compensating transition
+type CompensationError[T comparable] struct {
+	FromState       T
+	ToState         T
+	Err             error
+	Compensated     bool
+	CompensationErr error
+}
+
+func (err CompensationError[T]) Error() string {
+	msg := fmt.Sprintf("action of transition from %v to %v failed: %v", err.FromState, err.ToState, err.Err)
+
+	switch {
+	case err.Compensated:
+		return msg + ", compensated"
+	case err.CompensationErr != nil:
+		return fmt.Sprintf("%s, compensation failed: %v", msg, err.CompensationErr)
+	default:
+		return msg
+	}
+}
+
+// Unwrap returns the action's error and the compensation's error
+func (err CompensationError[T]) Unwrap() []error {
+	if err.CompensationErr == nil {
+		return []error{err.Err}
+	}
+
+	return []error{err.Err, err.CompensationErr}
+}
+
+// AuditError represents an error that occurs when a transition can't be written to the audit writer
+type AuditError struct {
+	Err error
+}
+
+func (err AuditError) Error() string {
+	return fmt.Sprintf("failed to write the audit log: %v", err.Err)
+}
+
+func (err AuditError) Unwrap() error {
+	return err.Err
+}
+
+// ReplayError represents an error that occurs when a replayed or strictly imported history is inconsistent
+// with the ruleset
+// Index is the position of the offending transition in the history
+type ReplayError[T comparable] struct {
+	Index      int
+	Transition Transition[T]
+	Err        error
+}
+
+func (err ReplayError[T]) Error() string {
+	return fmt.Sprintf("invalid history at transition %d from %v to %v: %v",
+		err.Index, err.Transition.FromState, err.Transition.ToState, err.Err)
+}
+
+func (err ReplayError[T]) Unwrap() error {
+	return err.Err
+}
+
+// SimulationError represents an error that occurs when a simulated path contains a transition that would fail
+// Index is the position of the offending state in the path
+type SimulationError[T comparable] struct {
+	Index int
+	Err   error
+}
+
+func (err SimulationError[T]) Error() string {
+	return fmt.Sprintf("invalid path at step %d: %v", err.Index, err.Err)
+}
+
+func (err SimulationError[T]) Unwrap() error {
+	return err.Err
+}
+
+// UnknownStateError represents an error that occurs when a state is not defined in the ruleset
+type UnknownStateError[T comparable] struct {
+	State T
+}
+
+func (err UnknownStateError[T]) Error() string {
+	return fmt.Sprintf("state %v is not defined in the ruleset", err.State)
+}
+
+// DuplicateRuleError represents an error that occurs when a ruleset defines the same rule more than once
+type DuplicateRuleError[T comparable] struct {
+	FromState T
+	ToState   T
+}
+
+func (err DuplicateRuleError[T]) Error() string {
+	return fmt.Sprintf("duplicate rule from %v to %v", err.FromState, err.ToState)
+}
+
+// CycleError represents an error that occurs when a ruleset expected to be a directed acyclic graph has a cycle
+// Cycle lists the states of the cycle without repeating the first one at the end
+type CycleError[T comparable] struct {
+	Cycle []T
+}
+
+func (err CycleError[T]) Error() string {
+	states := make([]string, len(err.Cycle)+1)
+	for i, state := range err.Cycle {
+		states[i] = fmt.Sprint(state)
+	}
+
+	states[len(err.Cycle)] = states[0]
+
+	return fmt.Sprintf("ruleset has a cycle: %s", strings.Join(states, " -> "))
+}
+
+// Is reports whether the target is ErrCycle
+func (err CycleError[T]) Is(target error) bool {
+	return target == ErrCycle
+}
+
+// DefinitionError represents an error in a declarative state machine definition
+// Path points at the offending entry, e.g. rules[2].to[0]
+type DefinitionError struct {
+	Path    string
+	Message string
+}
+
+func (err DefinitionError) Error() string {
+	return fmt.Sprintf("%s: %s", err.Path, err.Message)
+}
@@ -0,0 +1,219 @@
+package statetrooper
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"sort"
+	"time"
+)
+
+// ExportOption is a function that sets an option on a history export
+type ExportOption func(*exportOptions)
+
+// exportOptions holds the options for a history export
+type exportOptions struct {
+	flatten      bool
+	metadataKeys []string
+}
+
+// WithFlattenedMetadata writes each metadata key as its own "metadata.<key>" column or field
+// instead of a single metadata JSON object
+// DEFAULT: metadata is written as a JSON object
+func WithFlattenedMetadata() ExportOption {
+	return func(o *exportOptions) {
+		o.flatten = true
+	}
+}
+
+// WithMetadataColumns flattens only the given metadata keys, in the given order, and drops the others
+// DEFAULT: every metadata key is written
+func WithMetadataColumns(keys ...string) ExportOption {
+	return func(o *exportOptions) {
+		o.flatten = true
+		o.metadataKeys = keys
+	}
+}
+
+// metadataPrefix prefixes the names of flattened metadata columns and fields
+const metadataPrefix = "metadata."
+
+// WriteHistoryCSV writes the transition history to w as CSV, from oldest to newest, with a header row
+// The columns are from_state, to_state, timestamp, event_time, actor, reason and metadata
+// Timestamps are formatted as RFC 3339 and left empty when zero. Redacted metadata keys are redacted
+func (fsm *FSM[T]) WriteHistoryCSV(w io.Writer, opts ...ExportOption) error {
+	transitions, err := fsm.exportHistory()
+	if err != nil {
+		return err
+	}
+
+	o := parseExportOptions(opts)
+	keys := metadataColumns(o, transitions)
+
+	header := []string{"from_state", "to_state", "timestamp", "event_time", "actor", "reason"}
+	if o.flatten {
+		for _, key := range keys {
+			header = append(header, metadataPrefix+key)
+		}
+	} else {
+		header = append(header, "metadata")
+	}
+
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	record := make([]string, len(header))
+
+	for _, transition := range transitions {
+		record = append(record[:0],
+			toString(transition.FromState),
+			toString(transition.ToState),
+			formatExportTime(transition.Timestamp),
+			formatExportTime(transition.EventTime),
+			transition.Actor,
+			transition.Reason,
+		)
+
+		if o.flatten {
+			for _, key := range keys {
+				record = append(record, transition.Metadata[key])
+			}
+		} else {
+			metadata := ""
+
+			if len(transition.Metadata) > 0 {
+				data, err := json.Marshal(transition.Metadata)
+				if err != nil {
+					return err
+				}
+
+				metadata = string(data)
+			}
+
+			record = append(record, metadata)
+		}
+
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+
+	return cw.Error()
+}
+
+// WriteHistoryJSONL writes the transition history to w as JSON Lines, one transition per line
+// from oldest to newest. Redacted metadata keys are redacted
+func (fsm *FSM[T]) WriteHistoryJSONL(w io.Writer, opts ...ExportOption) error {
+	transitions, err := fsm.exportHistory()
+	if err != nil {
+		return err
+	}
+
+	o := parseExportOptions(opts)
+	enc := json.NewEncoder(w)
+
+	for _, transition := range transitions {
+		if !o.flatten {
+			if err := enc.Encode(transition); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		fields := map[string]interface{}{
+			"from_state": transition.FromState,
+			"to_state":   transition.ToState,
+			"timestamp":  transition.Timestamp,
+			"event_time": transition.EventTime,
+		}
+
+		if transition.Actor != "" {
+			fields["actor"] = transition.Actor
+		}
+
+		if transition.Reason != "" {
+			fields["reason"] = transition.Reason
+		}
+
+		if o.metadataKeys != nil {
+			for _, key := range o.metadataKeys {
+				if value, ok := transition.Metadata[key]; ok {
+					fields[metadataPrefix+key] = value
+				}
+			}
+		} else {
+			for key, value := range transition.Metadata {
+				fields[metadataPrefix+key] = value
+			}
+		}
+
+		if err := enc.Encode(fields); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// exportHistory returns a redacted copy of the history, so that it is written without holding the lock
+func (fsm *FSM[T]) exportHistory() ([]Transition[T], error) {
+	fsm.rlock()
+	defer fsm.runlock()
+
+	transitions, err := fsm.history().List()
+	if err != nil {
+		return nil, err
+	}
+
+	return fsm.redactAll(fsm.localizeAll(transitions, true)), nil
+}
+
+// parseExportOptions applies the export options
+func parseExportOptions(opts []ExportOption) exportOptions {
+	var o exportOptions
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+// metadataColumns returns the flattened metadata keys, either the configured ones or
+// the sorted keys of all transitions
+func metadataColumns[T comparable](o exportOptions, transitions []Transition[T]) []string {
+	if o.metadataKeys != nil || !o.flatten {
+		return o.metadataKeys
+	}
+
+	seen := make(map[string]struct{})
+	for _, transition := range transitions {
+		for key := range transition.Metadata {
+			seen[key] = struct{}{}
+		}
+	}
+
+	keys := make([]string, 0, len(seen))
+	for key := range seen {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// formatExportTime formats the time as RFC 3339, or returns an empty string for the zero time
+func formatExportTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Format(time.RFC3339Nano)
+}
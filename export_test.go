@@ -0,0 +1,99 @@
+package statetrooper
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newExportFSM() *FSM[CustomStateEnum] {
+	ts := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10,
+		WithTimeProvider[CustomStateEnum](func() time.Time { return ts }),
+		WithRedactedMetadataKeys[CustomStateEnum]("token"),
+	)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumC)
+
+	fsm.Transition(CustomStateEnumB, map[string]string{"user": "alice", "token": "secret"}, WithActor("api"))
+	fsm.Transition(CustomStateEnumC, map[string]string{"note": "done, shipped"})
+
+	return fsm
+}
+
+func Test_writeHistoryCSV(t *testing.T) {
+	fsm := newExportFSM()
+
+	var buf bytes.Buffer
+	if err := fsm.WriteHistoryCSV(&buf); err != nil {
+		t.Fatalf("WriteHistoryCSV failed: %v", err)
+	}
+
+	expected := "from_state,to_state,timestamp,event_time,actor,reason,metadata\n" +
+		`A,B,2023-01-01T00:00:00Z,2023-01-01T00:00:00Z,api,,"{""token"":""[REDACTED]"",""user"":""alice""}"` + "\n" +
+		`B,C,2023-01-01T00:00:00Z,2023-01-01T00:00:00Z,,,"{""note"":""done, shipped""}"` + "\n"
+	if buf.String() != expected {
+		t.Errorf("unexpected CSV:\n%s\nexpected:\n%s", buf.String(), expected)
+	}
+
+	buf.Reset()
+	if err := fsm.WriteHistoryCSV(&buf, WithFlattenedMetadata()); err != nil {
+		t.Fatalf("WriteHistoryCSV failed: %v", err)
+	}
+
+	expected = "from_state,to_state,timestamp,event_time,actor,reason,metadata.note,metadata.token,metadata.user\n" +
+		"A,B,2023-01-01T00:00:00Z,2023-01-01T00:00:00Z,api,,,[REDACTED],alice\n" +
+		`B,C,2023-01-01T00:00:00Z,2023-01-01T00:00:00Z,,,"done, shipped",,` + "\n"
+	if buf.String() != expected {
+		t.Errorf("unexpected flattened CSV:\n%s\nexpected:\n%s", buf.String(), expected)
+	}
+
+	buf.Reset()
+	if err := fsm.WriteHistoryCSV(&buf, WithMetadataColumns("user")); err != nil {
+		t.Fatalf("WriteHistoryCSV failed: %v", err)
+	}
+
+	if header := strings.SplitN(buf.String(), "\n", 2)[0]; header != "from_state,to_state,timestamp,event_time,actor,reason,metadata.user" {
+		t.Errorf("unexpected header: %s", header)
+	}
+}
+
+func Test_writeHistoryJSONL(t *testing.T) {
+	fsm := newExportFSM()
+
+	var buf bytes.Buffer
+	if err := fsm.WriteHistoryJSONL(&buf); err != nil {
+		t.Fatalf("WriteHistoryJSONL failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d", len(lines))
+	}
+
+	var transition Transition[CustomStateEnum]
+	if err := json.Unmarshal([]byte(lines[0]), &transition); err != nil {
+		t.Fatalf("failed to decode line: %v", err)
+	}
+
+	if transition.ToState != CustomStateEnumB || transition.Metadata["token"] != RedactedValue {
+		t.Errorf("unexpected transition: %+v", transition)
+	}
+
+	buf.Reset()
+	if err := fsm.WriteHistoryJSONL(&buf, WithMetadataColumns("user")); err != nil {
+		t.Fatalf("WriteHistoryJSONL failed: %v", err)
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(strings.SplitN(buf.String(), "\n", 2)[0]), &fields); err != nil {
+		t.Fatalf("failed to decode line: %v", err)
+	}
+
+	if fields["metadata.user"] != "alice" || fields["actor"] != "api" || fields["metadata.token"] != nil {
+		t.Errorf("unexpected flattened fields: %v", fields)
+	}
+}
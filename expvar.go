@@ -0,0 +1,37 @@
+package statetrooper
+
+import (
+	"expvar"
+	"time"
+)
+
+// expvarState is the FSM's health published by PublishExpvar
+type expvarState[T comparable] struct {
+	CurrentState   T          `json:"current_state"`
+	Version        uint64     `json:"version"`
+	StateEnteredAt time.Time  `json:"state_entered_at"`
+	LastTransition *time.Time `json:"last_transition,omitempty"`
+}
+
+// PublishExpvar publishes the FSM's current state, version (its number of transitions), the time the
+// current state was entered and the time of the last transition under name with the expvar package,
+// so they are served by /debug/vars
+// As with expvar.Publish, PublishExpvar panics if name is already registered
+func (fsm *FSM[T]) PublishExpvar(name string) {
+	expvar.Publish(name, expvar.Func(func() any {
+		fsm.rlock()
+		defer fsm.runlock()
+
+		state := expvarState[T]{
+			CurrentState:   fsm.currentState,
+			Version:        fsm.version,
+			StateEnteredAt: fsm.enteredAt,
+		}
+
+		if last, ok, err := fsm.lastTransition(); err == nil && ok {
+			state.LastTransition = &last.Timestamp
+		}
+
+		return state
+	}))
+}
@@ -0,0 +1,44 @@
+package statetrooper
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"sync/atomic"
+	"testing"
+)
+
+// expvarRuns numbers the published names, as expvar names can't be published twice, e.g. with -count
+var expvarRuns atomic.Int64
+
+// expvarName returns a name that hasn't been published in this process
+func expvarName(t *testing.T) string {
+	return fmt.Sprintf("%s_%d", t.Name(), expvarRuns.Add(1))
+}
+
+func Test_publishExpvar(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+
+	name := expvarName(t)
+	fsm.PublishExpvar(name)
+
+	if _, err := fsm.Transition(CustomStateEnumB, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var published struct {
+		CurrentState   CustomStateEnum `json:"current_state"`
+		Version        uint64          `json:"version"`
+		LastTransition string          `json:"last_transition"`
+	}
+
+	err := json.Unmarshal([]byte(expvar.Get(name).String()), &published)
+	if err != nil {
+		t.Fatalf("failed to decode the published value: %v", err)
+	}
+
+	if published.CurrentState != CustomStateEnumB || published.Version != 1 || published.LastTransition == "" {
+		t.Errorf("unexpected published value %+v", published)
+	}
+}
@@ -0,0 +1,125 @@
+package fsmtest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hishamk/statetrooper"
+)
+
+// AssertState reports an error if the FSM isn't in the wanted state, returning whether it is
+func AssertState[T comparable](t testing.TB, fsm *statetrooper.FSM[T], want T) bool {
+	t.Helper()
+
+	if got := fsm.CurrentState(); got != want {
+		t.Errorf("unexpected state\n  expected: %v\n  actual:   %v\n  allowed from here: %v", want, got, fsm.AllowedTransitions())
+
+		return false
+	}
+
+	return true
+}
+
+// AssertTransitionOccurred reports an error if the history doesn't hold a transition from one state
+// to the other, returning whether it does
+func AssertTransitionOccurred[T comparable](t testing.TB, fsm *statetrooper.FSM[T], from, to T) bool {
+	t.Helper()
+
+	transitions := fsm.Transitions()
+
+	for _, transition := range transitions {
+		if transition.FromState == from && transition.ToState == to {
+			return true
+		}
+	}
+
+	t.Errorf("expected a transition from %v to %v, history:\n%s", from, to, formatTransitions(transitions))
+
+	return false
+}
+
+// AssertHistory reports an error if the history doesn't go through the expected states, starting with
+// the state the oldest transition starts from, returning whether it does
+// The error shows the expected and actual states side by side, marking the mismatches
+func AssertHistory[T comparable](t testing.TB, fsm *statetrooper.FSM[T], expected ...T) bool {
+	t.Helper()
+
+	transitions := fsm.Transitions()
+
+	var actual []T
+	if len(transitions) > 0 {
+		actual = append(actual, transitions[0].FromState)
+	}
+
+	for _, transition := range transitions {
+		actual = append(actual, transition.ToState)
+	}
+
+	mismatch := len(actual) != len(expected)
+	for i := 0; i < len(actual) && i < len(expected); i++ {
+		if actual[i] != expected[i] {
+			mismatch = true
+		}
+	}
+
+	if !mismatch {
+		return true
+	}
+
+	t.Errorf("unexpected history\n%s", sideBySide(expected, actual))
+
+	return false
+}
+
+// sideBySide formats the expected and actual states in two columns, marking the differing rows with a "!"
+func sideBySide[T comparable](expected, actual []T) string {
+	rows := len(expected)
+	if len(actual) > rows {
+		rows = len(actual)
+	}
+
+	cell := func(states []T, i int) string {
+		if i >= len(states) {
+			return "-"
+		}
+
+		return fmt.Sprint(states[i])
+	}
+
+	width := len("expected")
+	for i := 0; i < rows; i++ {
+		if w := len(cell(expected, i)); w > width {
+			width = w
+		}
+	}
+
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "     %-*s  %s\n", width, "expected", "actual")
+
+	for i := 0; i < rows; i++ {
+		marker := " "
+		if i >= len(expected) || i >= len(actual) || expected[i] != actual[i] {
+			marker = "!"
+		}
+
+		fmt.Fprintf(&sb, "%s%3d %-*s  %s\n", marker, i, width, cell(expected, i), cell(actual, i))
+	}
+
+	return sb.String()
+}
+
+// formatTransitions formats the transitions one per line
+func formatTransitions[T comparable](transitions []statetrooper.Transition[T]) string {
+	if len(transitions) == 0 {
+		return "  (empty)"
+	}
+
+	lines := make([]string, len(transitions))
+	for i, transition := range transitions {
+		lines[i] = fmt.Sprintf("  %d: %v -> %v", i, transition.FromState, transition.ToState)
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,55 @@
+package fsmtest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// recorder captures the errors reported by the assertions
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func Test_assertions(t *testing.T) {
+	fsm := newFSM()
+
+	for _, s := range []state{paid, packed, shipped} {
+		if _, err := fsm.Transition(s, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if !AssertState(t, fsm, shipped) || !AssertTransitionOccurred(t, fsm, paid, packed) ||
+		!AssertHistory(t, fsm, created, paid, packed, shipped) {
+		t.Fatalf("expected the assertions to pass")
+	}
+
+	r := &recorder{TB: t}
+
+	if AssertState(r, fsm, packed) || AssertTransitionOccurred(r, fsm, created, canceled) ||
+		AssertHistory(r, fsm, created, paid, shipped) {
+		t.Fatalf("expected the assertions to fail")
+	}
+
+	if len(r.errors) != 3 {
+		t.Fatalf("expected 3 errors, got %d", len(r.errors))
+	}
+
+	expected := "     expected  actual\n" +
+		"   0 created   created\n" +
+		"   1 paid      paid\n" +
+		"!  2 shipped   packed\n" +
+		"!  3 -         shipped\n"
+
+	if !strings.HasSuffix(r.errors[2], expected) {
+		t.Errorf("unexpected history diff:\n%s", r.errors[2])
+	}
+}
@@ -0,0 +1,214 @@
+/*
+Package fsmtest helps model-check state machine definitions with property-based tests and fuzzing.
+
+Random sequences of transitions allowed by the rules are applied to fresh state machines and
+invariants are checked after each transition. Failing sequences are shrunk to a minimal sequence
+that still fails, which is reported:
+
+	func TestOrderInvariants(t *testing.T) {
+		fsmtest.Check(t, newOrderFSM, fsmtest.Config{Iterations: 500, Steps: 30},
+			func(fsm *statetrooper.FSM[OrderStatusEnum]) error {
+				if fsm.CurrentState() == StatusShipped && !fsm.HasVisited(StatusPaid) {
+					return errors.New("shipped before being paid")
+				}
+
+				return nil
+			})
+	}
+
+The same checks can be driven by the fuzzing engine, the input bytes choosing the transitions:
+
+	func FuzzOrderInvariants(f *testing.F) {
+		fsmtest.Fuzz(f, newOrderFSM, shippedAfterPaid)
+	}
+
+Unit tests can assert the state and the history with AssertState, AssertTransitionOccurred and AssertHistory.
+*/
+package fsmtest
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/hishamk/statetrooper"
+)
+
+// Invariant checks a property that must hold after every transition
+type Invariant[T comparable] func(fsm *statetrooper.FSM[T]) error
+
+// Failure describes a sequence of transitions after which an invariant doesn't hold,
+// or a transition allowed by the rules fails
+type Failure[T comparable] struct {
+	// Sequence is the target states of the transitions made from the initial state
+	Sequence []T
+	// Step is the index in Sequence of the transition after which the check failed
+	Step int
+	Err  error
+}
+
+func (f *Failure[T]) Error() string {
+	return fmt.Sprintf("step %d of %v: %v", f.Step, f.Sequence, f.Err)
+}
+
+func (f *Failure[T]) Unwrap() error {
+	return f.Err
+}
+
+// Config configures Check
+type Config struct {
+	// Iterations is the number of random sequences checked DEFAULT: 100
+	Iterations int
+	// Steps is the maximum length of the sequences DEFAULT: 50
+	Steps int
+	// Seed seeds the random generator, so that failures are reproducible DEFAULT: 1
+	Seed int64
+}
+
+// Run applies the sequence of target states to a new FSM and checks the invariants after each transition
+// It returns nil if every check passes, or if a transition isn't allowed by the rules, the sequence
+// then not being a valid run of the state machine, which happens to shrunk sequences
+func Run[T comparable](newFSM func() *statetrooper.FSM[T], sequence []T, invariants ...Invariant[T]) *Failure[T] {
+	fsm := newFSM()
+
+	for i, state := range sequence {
+		_, err := fsm.Transition(state, nil)
+		if err != nil {
+			var transitionErr statetrooper.TransitionError[T]
+			if errors.As(err, &transitionErr) {
+				return nil
+			}
+
+			return &Failure[T]{Sequence: sequence, Step: i, Err: err}
+		}
+
+		for _, invariant := range invariants {
+			if err := invariant(fsm); err != nil {
+				return &Failure[T]{Sequence: sequence, Step: i, Err: err}
+			}
+		}
+	}
+
+	return nil
+}
+
+// RandomSequence walks a new FSM for up to steps transitions, each time choosing one of the allowed
+// target states at random
+// The walk stops early in a final state, or after a transition that fails, which is included
+func RandomSequence[T comparable](newFSM func() *statetrooper.FSM[T], steps int, rnd *rand.Rand) []T {
+	return walk(newFSM, steps, func(n int) int {
+		return rnd.Intn(n)
+	})
+}
+
+// SequenceFromBytes walks a new FSM like RandomSequence, each byte of data choosing one of the allowed
+// target states, so that the fuzzing engine explores the state machine
+func SequenceFromBytes[T comparable](newFSM func() *statetrooper.FSM[T], data []byte) []T {
+	i := 0
+
+	return walk(newFSM, len(data), func(n int) int {
+		choice := int(data[i]) % n
+		i++
+
+		return choice
+	})
+}
+
+// walk transitions a new FSM for up to steps transitions, choose picking the index of the next target state
+func walk[T comparable](newFSM func() *statetrooper.FSM[T], steps int, choose func(n int) int) []T {
+	fsm := newFSM()
+
+	var sequence []T
+
+	for len(sequence) < steps {
+		allowed := fsm.AllowedTransitions()
+		if len(allowed) == 0 {
+			break
+		}
+
+		state := allowed[choose(len(allowed))]
+		sequence = append(sequence, state)
+
+		if _, err := fsm.Transition(state, nil); err != nil {
+			break
+		}
+	}
+
+	return sequence
+}
+
+// Shrink returns a shortest subsequence of the failure's sequence it could find that still fails,
+// by removing chunks of transitions, from the largest down to single transitions
+func Shrink[T comparable](newFSM func() *statetrooper.FSM[T], failure *Failure[T], invariants ...Invariant[T]) *Failure[T] {
+	// the transitions after the failing step don't matter
+	sequence := failure.Sequence[:failure.Step+1]
+	failure = &Failure[T]{Sequence: sequence, Step: failure.Step, Err: failure.Err}
+
+	for size := len(sequence) - 1; size > 0; size-- {
+		for start := 0; start+size <= len(failure.Sequence); {
+			candidate := make([]T, 0, len(failure.Sequence)-size)
+			candidate = append(candidate, failure.Sequence[:start]...)
+			candidate = append(candidate, failure.Sequence[start+size:]...)
+
+			shrunk := Run(newFSM, candidate, invariants...)
+			if shrunk == nil {
+				start++
+
+				continue
+			}
+
+			shrunk.Sequence = shrunk.Sequence[:shrunk.Step+1]
+			failure = shrunk
+		}
+	}
+
+	return failure
+}
+
+// Check runs random sequences of transitions against new FSMs, failing the test with the shrunk
+// sequence if an invariant doesn't hold or a transition allowed by the rules fails
+func Check[T comparable](t testing.TB, newFSM func() *statetrooper.FSM[T], config Config, invariants ...Invariant[T]) {
+	t.Helper()
+
+	if config.Iterations <= 0 {
+		config.Iterations = 100
+	}
+
+	if config.Steps <= 0 {
+		config.Steps = 50
+	}
+
+	if config.Seed == 0 {
+		config.Seed = 1
+	}
+
+	rnd := rand.New(rand.NewSource(config.Seed))
+
+	for i := 0; i < config.Iterations; i++ {
+		sequence := RandomSequence(newFSM, config.Steps, rnd)
+
+		if failure := Run(newFSM, sequence, invariants...); failure != nil {
+			t.Fatalf("invariant violated (seed %d, iteration %d): %v", config.Seed, i, Shrink(newFSM, failure, invariants...))
+		}
+	}
+}
+
+// Fuzz registers a fuzz target running the sequences chosen by the fuzzing engine's inputs,
+// see SequenceFromBytes, failing with the shrunk sequence like Check
+// A few seed inputs are added to the corpus
+func Fuzz[T comparable](f *testing.F, newFSM func() *statetrooper.FSM[T], invariants ...Invariant[T]) {
+	f.Helper()
+
+	f.Add([]byte{})
+	f.Add([]byte{0, 0, 0, 0, 0, 0, 0, 0})
+	f.Add([]byte{0, 1, 2, 3, 4, 5, 6, 7})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		sequence := SequenceFromBytes(newFSM, data)
+
+		if failure := Run(newFSM, sequence, invariants...); failure != nil {
+			t.Fatalf("invariant violated: %v", Shrink(newFSM, failure, invariants...))
+		}
+	})
+}
@@ -0,0 +1,101 @@
+package fsmtest
+
+import (
+	"errors"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/hishamk/statetrooper"
+)
+
+type state string
+
+const (
+	created  state = "created"
+	paid     state = "paid"
+	packed   state = "packed"
+	shipped  state = "shipped"
+	canceled state = "canceled"
+)
+
+func newFSM() *statetrooper.FSM[state] {
+	fsm := statetrooper.NewFSM[state](created, 100)
+	fsm.AddRule(created, paid, canceled)
+	fsm.AddRule(paid, packed, created, canceled)
+	fsm.AddRule(packed, shipped, paid)
+
+	return fsm
+}
+
+func neverShipped(fsm *statetrooper.FSM[state]) error {
+	if fsm.CurrentState() == shipped {
+		return errors.New("shipped")
+	}
+
+	return nil
+}
+
+func Test_randomSequence(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 50; i++ {
+		sequence := RandomSequence(newFSM, 20, rnd)
+
+		if len(sequence) > 20 {
+			t.Fatalf("expected at most 20 steps, got %d", len(sequence))
+		}
+
+		fsm := newFSM()
+		for _, s := range sequence {
+			if _, err := fsm.Transition(s, nil); err != nil {
+				t.Fatalf("expected a valid sequence, got %v: %v", sequence, err)
+			}
+		}
+	}
+}
+
+func Test_runInvalidSequence(t *testing.T) {
+	if failure := Run(newFSM, []state{shipped}, neverShipped); failure != nil {
+		t.Errorf("expected an invalid sequence not to fail, got %v", failure)
+	}
+}
+
+func Test_shrink(t *testing.T) {
+	sequence := []state{paid, created, paid, packed, paid, packed, shipped}
+
+	failure := Run(newFSM, sequence, neverShipped)
+	if failure == nil || failure.Step != 6 {
+		t.Fatalf("expected a failure at step 6, got %v", failure)
+	}
+
+	shrunk := Shrink(newFSM, failure, neverShipped)
+
+	if expected := []state{paid, packed, shipped}; !reflect.DeepEqual(shrunk.Sequence, expected) {
+		t.Errorf("expected the sequence to shrink to %v, got %v", expected, shrunk.Sequence)
+	}
+
+	if shrunk.Step != 2 {
+		t.Errorf("expected the shrunk failure at step 2, got %d", shrunk.Step)
+	}
+}
+
+func Test_check(t *testing.T) {
+	Check(t, newFSM, Config{Iterations: 200, Steps: 20}, func(fsm *statetrooper.FSM[state]) error {
+		if fsm.CurrentState() == shipped && !fsm.HasVisited(packed) {
+			return errors.New("shipped without being packed")
+		}
+
+		return nil
+	})
+}
+
+func FuzzInvariants(f *testing.F) {
+	Fuzz(f, newFSM, func(fsm *statetrooper.FSM[state]) error {
+		if fsm.CurrentState() == canceled && fsm.HasVisited(shipped) {
+			return errors.New("canceled after shipping")
+		}
+
+		return nil
+	})
+}
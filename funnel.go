@@ -0,0 +1,180 @@
+package statetrooper
+
+import (
+	"sort"
+	"time"
+)
+
+// Funnel computes conversion analytics over the histories of many entities, e.g. orders
+// An entity's path starts with the state its oldest transition starts from, whose entry time is unknown,
+// followed by the target state of each transition, entered at the transition's timestamp
+type Funnel[T comparable] struct {
+	paths [][]visit[T]
+}
+
+// visit is the entry of an entity into a state, at a zero time if unknown
+type visit[T comparable] struct {
+	state T
+	at    time.Time
+}
+
+// FunnelStep holds the analytics of a step of a funnel, see Funnel.Steps
+type FunnelStep[T comparable] struct {
+	State T
+	// Reached is the number of entities that reached the state after the previous step
+	Reached int
+	// ConversionRate is the share of the entities that reached the previous step and then this one,
+	// 1 for the first step
+	ConversionRate float64
+	// DropOff is the number of entities whose history ends in the state
+	DropOff int
+	// MedianTime is the median time from the previous step to this one, 0 for the first step
+	// or if the entry times are unknown
+	MedianTime time.Duration
+}
+
+// NewFunnel creates a funnel over the histories, one per entity, each ordered from oldest to newest
+// as returned by Transitions. Empty histories are ignored
+func NewFunnel[T comparable](histories [][]Transition[T]) *Funnel[T] {
+	f := Funnel[T]{paths: make([][]visit[T], 0, len(histories))}
+
+	for _, history := range histories {
+		if len(history) == 0 {
+			continue
+		}
+
+		path := make([]visit[T], 0, len(history)+1)
+		path = append(path, visit[T]{state: history[0].FromState})
+
+		for _, transition := range history {
+			path = append(path, visit[T]{state: transition.ToState, at: transition.Timestamp})
+		}
+
+		f.paths = append(f.paths, path)
+	}
+
+	return &f
+}
+
+// Entities returns the number of entities in the funnel
+func (f *Funnel[T]) Entities() int {
+	return len(f.paths)
+}
+
+// Reached returns the number of entities that were ever in the state
+func (f *Funnel[T]) Reached(state T) int {
+	reached := 0
+
+	for _, path := range f.paths {
+		if firstVisit(path, state, 0) >= 0 {
+			reached++
+		}
+	}
+
+	return reached
+}
+
+// ConversionRate returns the share of the entities that reached from and then to, or 0 if none reached from
+func (f *Funnel[T]) ConversionRate(from, to T) float64 {
+	reached, converted := 0, 0
+
+	for _, path := range f.paths {
+		i := firstVisit(path, from, 0)
+		if i < 0 {
+			continue
+		}
+
+		reached++
+
+		if firstVisit(path, to, i+1) >= 0 {
+			converted++
+		}
+	}
+
+	if reached == 0 {
+		return 0
+	}
+
+	return float64(converted) / float64(reached)
+}
+
+// MedianTime returns the median time entities took from first entering from to then entering to
+// ok is false if no entity made it with known entry times
+func (f *Funnel[T]) MedianTime(from, to T) (median time.Duration, ok bool) {
+	var durations []time.Duration
+
+	for _, path := range f.paths {
+		i := firstVisit(path, from, 0)
+		if i < 0 || path[i].at.IsZero() {
+			continue
+		}
+
+		j := firstVisit(path, to, i+1)
+		if j < 0 {
+			continue
+		}
+
+		durations = append(durations, path[j].at.Sub(path[i].at))
+	}
+
+	if len(durations) == 0 {
+		return 0, false
+	}
+
+	sort.Slice(durations, func(i, j int) bool {
+		return durations[i] < durations[j]
+	})
+
+	return percentile(durations, 0.5), true
+}
+
+// DropOffs returns the number of entities whose history ends in each state, including final states
+func (f *Funnel[T]) DropOffs() map[T]int {
+	dropOffs := make(map[T]int)
+
+	for _, path := range f.paths {
+		dropOffs[path[len(path)-1].state]++
+	}
+
+	return dropOffs
+}
+
+// Steps returns the analytics of the funnel going through the states in order, e.g. created, paid, shipped
+func (f *Funnel[T]) Steps(states ...T) []FunnelStep[T] {
+	steps := make([]FunnelStep[T], len(states))
+	dropOffs := f.DropOffs()
+
+	for i, state := range states {
+		steps[i] = FunnelStep[T]{State: state, DropOff: dropOffs[state], ConversionRate: 1}
+
+		if i == 0 {
+			steps[i].Reached = f.Reached(state)
+
+			continue
+		}
+
+		prev := states[i-1]
+
+		for _, path := range f.paths {
+			if j := firstVisit(path, prev, 0); j >= 0 && firstVisit(path, state, j+1) >= 0 {
+				steps[i].Reached++
+			}
+		}
+
+		steps[i].ConversionRate = f.ConversionRate(prev, state)
+		steps[i].MedianTime, _ = f.MedianTime(prev, state)
+	}
+
+	return steps
+}
+
+// firstVisit returns the index of the first visit of the state in the path from start, or -1
+func firstVisit[T comparable](path []visit[T], state T, start int) int {
+	for i := start; i < len(path); i++ {
+		if path[i].state == state {
+			return i
+		}
+	}
+
+	return -1
+}
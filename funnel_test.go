@@ -0,0 +1,75 @@
+package statetrooper
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_funnel(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	history := func(steps ...interface{}) []Transition[CustomStateEnum] {
+		var transitions []Transition[CustomStateEnum]
+
+		from := CustomStateEnumA
+		for i := 0; i < len(steps); i += 2 {
+			to := steps[i].(CustomStateEnum)
+			transitions = append(transitions, Transition[CustomStateEnum]{
+				FromState: from,
+				ToState:   to,
+				Timestamp: start.Add(steps[i+1].(time.Duration)),
+			})
+			from = to
+		}
+
+		return transitions
+	}
+
+	f := NewFunnel([][]Transition[CustomStateEnum]{
+		history(CustomStateEnumB, time.Minute, CustomStateEnumC, 3*time.Minute),
+		history(CustomStateEnumB, time.Minute, CustomStateEnumC, 5*time.Minute),
+		history(CustomStateEnumB, 2*time.Minute, CustomStateEnumC, 12*time.Minute),
+		history(CustomStateEnumB, time.Minute),
+		history(CustomStateEnumD, time.Minute),
+		nil,
+	})
+
+	if f.Entities() != 5 {
+		t.Errorf("Entities() = %d, expected 5", f.Entities())
+	}
+
+	if reached := f.Reached(CustomStateEnumA); reached != 5 {
+		t.Errorf("Reached(A) = %d, expected 5", reached)
+	}
+
+	if rate := f.ConversionRate(CustomStateEnumB, CustomStateEnumC); rate != 0.75 {
+		t.Errorf("ConversionRate(B, C) = %v, expected 0.75", rate)
+	}
+
+	if rate := f.ConversionRate(CustomStateEnumC, CustomStateEnumB); rate != 0 {
+		t.Errorf("ConversionRate(C, B) = %v, expected 0", rate)
+	}
+
+	if median, ok := f.MedianTime(CustomStateEnumB, CustomStateEnumC); !ok || median != 4*time.Minute {
+		t.Errorf("MedianTime(B, C) = %v, %v, expected 4m, true", median, ok)
+	}
+
+	if _, ok := f.MedianTime(CustomStateEnumA, CustomStateEnumB); ok {
+		t.Error("MedianTime(A, B) is expected to be unknown, as the entry time of the initial state is unknown")
+	}
+
+	expectedDropOffs := map[CustomStateEnum]int{CustomStateEnumB: 1, CustomStateEnumC: 3, CustomStateEnumD: 1}
+	if dropOffs := f.DropOffs(); !reflect.DeepEqual(dropOffs, expectedDropOffs) {
+		t.Errorf("DropOffs() = %v, expected %v", dropOffs, expectedDropOffs)
+	}
+
+	expectedSteps := []FunnelStep[CustomStateEnum]{
+		{State: CustomStateEnumA, Reached: 5, ConversionRate: 1},
+		{State: CustomStateEnumB, Reached: 4, ConversionRate: 0.8, DropOff: 1},
+		{State: CustomStateEnumC, Reached: 3, ConversionRate: 0.75, DropOff: 3, MedianTime: 4 * time.Minute},
+	}
+
+	if steps := f.Steps(CustomStateEnumA, CustomStateEnumB, CustomStateEnumC); !reflect.DeepEqual(steps, expectedSteps) {
+		t.Errorf("Steps() = %+v, expected %+v", steps, expectedSteps)
+	}
+}
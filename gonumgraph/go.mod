@@ -0,0 +1,10 @@
+module github.com/hishamk/statetrooper/gonumgraph
+
+go 1.20
+
+require (
+	github.com/hishamk/statetrooper v0.0.0
+	gonum.org/v1/gonum v0.12.0
+)
+
+replace github.com/hishamk/statetrooper => ../
@@ -0,0 +1,88 @@
+/*
+Package gonumgraph adapts the rules of a statetrooper FSM to a gonum.org/v1/gonum/graph directed graph,
+so the graph algorithms of gonum, e.g. centrality and condensation, can be run on workflow definitions.
+
+	g := gonumgraph.New[OrderStatus](fsm)
+
+	ranks := network.PageRank(g, 0.85, 1e-6)
+	sccs := topo.TarjanSCC(g)
+
+Nodes are the states of the ruleset, sources and targets, with IDs following their order by name.
+
+The package lives in its own module, so the root module doesn't depend on gonum.
+*/
+package gonumgraph
+
+import (
+	"fmt"
+
+	"gonum.org/v1/gonum/graph"
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+// Ruleset is implemented by statetrooper.FSM and statetrooper.ReadOnlyFSM
+type Ruleset[T comparable] interface {
+	AdjacencyMatrix() (states []T, matrix [][]bool)
+}
+
+// Node is a state of the ruleset
+type Node[T comparable] struct {
+	id    int64
+	State T
+}
+
+// ID returns the ID of the node, the index of the state in the states sorted by name
+func (n Node[T]) ID() int64 {
+	return n.id
+}
+
+// DOTID returns the state as the ID of the node in the DOT encoding of gonum.org/v1/gonum/graph/encoding/dot
+func (n Node[T]) DOTID() string {
+	return fmt.Sprint(n.State)
+}
+
+// Graph is the directed graph of the rules of an FSM, each edge being an allowed transition
+type Graph[T comparable] struct {
+	*simple.DirectedGraph
+	nodes map[T]Node[T]
+}
+
+// New creates the directed graph of the rules of the FSM
+// The graph is a copy, rules added to the FSM later aren't part of it
+// Rules allowing a state to transition to itself are left out, as simple graphs have no self loops
+func New[T comparable](rs Ruleset[T]) *Graph[T] {
+	states, matrix := rs.AdjacencyMatrix()
+
+	g := Graph[T]{
+		DirectedGraph: simple.NewDirectedGraph(),
+		nodes:         make(map[T]Node[T], len(states)),
+	}
+
+	for i, state := range states {
+		node := Node[T]{id: int64(i), State: state}
+		g.nodes[state] = node
+		g.AddNode(node)
+	}
+
+	for i, row := range matrix {
+		for j, allowed := range row {
+			if allowed && i != j {
+				g.SetEdge(g.NewEdge(g.nodes[states[i]], g.nodes[states[j]]))
+			}
+		}
+	}
+
+	return &g
+}
+
+// NodeOf returns the node of the state, ok is false if the state isn't part of the ruleset
+func (g *Graph[T]) NodeOf(state T) (node Node[T], ok bool) {
+	node, ok = g.nodes[state]
+
+	return node, ok
+}
+
+// StateOf returns the state of a node of the graph, e.g. returned by a gonum algorithm
+func StateOf[T comparable](node graph.Node) T {
+	return node.(Node[T]).State
+}
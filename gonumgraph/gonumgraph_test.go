@@ -0,0 +1,75 @@
+package gonumgraph
+
+import (
+	"testing"
+
+	"github.com/hishamk/statetrooper"
+	"gonum.org/v1/gonum/graph/topo"
+)
+
+type orderStatus string
+
+const (
+	statusCreated  orderStatus = "created"
+	statusPicked   orderStatus = "picked"
+	statusPacked   orderStatus = "packed"
+	statusCanceled orderStatus = "canceled"
+)
+
+func newFSM() *statetrooper.FSM[orderStatus] {
+	fsm := statetrooper.NewFSM[orderStatus](statusCreated, 10)
+	fsm.AddRule(statusCreated, statusPicked, statusCanceled)
+	fsm.AddRule(statusPicked, statusPacked, statusCanceled, statusPicked)
+	fsm.AddRule(statusCanceled, statusCreated)
+
+	return fsm
+}
+
+func TestNew(t *testing.T) {
+	g := New[orderStatus](newFSM())
+
+	if n := g.Nodes().Len(); n != 4 {
+		t.Fatalf("expected 4 nodes, got %d", n)
+	}
+
+	if n := g.Edges().Len(); n != 5 {
+		t.Fatalf("expected 5 edges, self loops left out, got %d", n)
+	}
+
+	created, ok := g.NodeOf(statusCreated)
+	if !ok {
+		t.Fatal("expected created to be a node")
+	}
+
+	picked, _ := g.NodeOf(statusPicked)
+	if !g.HasEdgeFromTo(created.ID(), picked.ID()) {
+		t.Error("expected an edge from created to picked")
+	}
+
+	if g.HasEdgeFromTo(picked.ID(), created.ID()) {
+		t.Error("expected no edge from picked to created")
+	}
+
+	if _, ok := g.NodeOf("unknown"); ok {
+		t.Error("expected unknown not to be a node")
+	}
+
+	if state := StateOf[orderStatus](g.Node(created.ID())); state != statusCreated {
+		t.Errorf("expected the state of the node to be created, got %s", state)
+	}
+}
+
+func TestNewCondensation(t *testing.T) {
+	g := New[orderStatus](newFSM().ReadOnly())
+
+	sccs := topo.TarjanSCC(g)
+	if len(sccs) != 2 {
+		t.Fatalf("expected 2 strongly connected components, got %d", len(sccs))
+	}
+
+	for _, scc := range sccs {
+		if len(scc) == 1 && StateOf[orderStatus](scc[0]) != statusPacked {
+			t.Errorf("expected packed to be the only state outside the loop, got %s", StateOf[orderStatus](scc[0]))
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package statetrooper
+
+// Edges returns the rules of the ruleset as edges, ordered by source and target state
+func (fsm *FSM[T]) Edges() []Rule[T] {
+	edges := fsm.rules()
+	sortRules(edges)
+
+	return edges
+}
+
+// AdjacencyMatrix returns the states of the ruleset, sources and targets, sorted by name, and the matrix
+// of the rules, matrix[i][j] being true if states[j] is allowed from states[i]
+func (fsm *FSM[T]) AdjacencyMatrix() (states []T, matrix [][]bool) {
+	fsm.rlock()
+	defer fsm.runlock()
+
+	states = fsm.sortedStates()
+
+	indexes := make(map[T]int, len(states))
+	for i, state := range states {
+		indexes[state] = i
+	}
+
+	matrix = make([][]bool, len(states))
+	for i := range matrix {
+		matrix[i] = make([]bool, len(states))
+	}
+
+	for from, targets := range fsm.ruleset {
+		for _, to := range targets {
+			matrix[indexes[from]][indexes[to]] = true
+		}
+	}
+
+	return states, matrix
+}
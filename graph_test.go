@@ -0,0 +1,44 @@
+package statetrooper
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_edges(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumC, CustomStateEnumA)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+
+	expected := []Rule[CustomStateEnum]{
+		{From: CustomStateEnumA, To: CustomStateEnumB},
+		{From: CustomStateEnumB, To: CustomStateEnumA},
+		{From: CustomStateEnumB, To: CustomStateEnumC},
+	}
+
+	if edges := fsm.Edges(); !reflect.DeepEqual(edges, expected) {
+		t.Errorf("Edges() = %v, expected %v", edges, expected)
+	}
+}
+
+func Test_adjacencyMatrix(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB, CustomStateEnumC)
+	fsm.AddRule(CustomStateEnumC, CustomStateEnumC)
+
+	states, matrix := fsm.AdjacencyMatrix()
+
+	expectedStates := []CustomStateEnum{CustomStateEnumA, CustomStateEnumB, CustomStateEnumC}
+	if !reflect.DeepEqual(states, expectedStates) {
+		t.Errorf("AdjacencyMatrix() states = %v, expected %v", states, expectedStates)
+	}
+
+	expectedMatrix := [][]bool{
+		{false, true, true},
+		{false, false, false},
+		{false, false, true},
+	}
+	if !reflect.DeepEqual(matrix, expectedMatrix) {
+		t.Errorf("AdjacencyMatrix() matrix = %v, expected %v", matrix, expectedMatrix)
+	}
+}
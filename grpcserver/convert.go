@@ -0,0 +1,98 @@
+package grpcserver
+
+import (
+	"sort"
+
+	"github.com/hishamk/statetrooper"
+	"github.com/hishamk/statetrooper/grpcserver/statetrooperpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// TransitionToProto converts a transition to its protobuf representation
+func TransitionToProto[T ~string](transition statetrooper.Transition[T]) *statetrooperpb.TransitionRecord {
+	return &statetrooperpb.TransitionRecord{
+		FromState: string(transition.FromState),
+		ToState:   string(transition.ToState),
+		Timestamp: timestamppb.New(transition.Timestamp),
+		EventTime: timestamppb.New(transition.EventTime),
+		Metadata:  transition.Metadata,
+		Actor:     transition.Actor,
+		Reason:    transition.Reason,
+		Version:   transition.Version,
+	}
+}
+
+// TransitionFromProto converts a transition from its protobuf representation
+// Timestamps are returned in UTC
+func TransitionFromProto[T ~string](record *statetrooperpb.TransitionRecord) statetrooper.Transition[T] {
+	transition := statetrooper.Transition[T]{
+		FromState: T(record.GetFromState()),
+		ToState:   T(record.GetToState()),
+		Metadata:  record.GetMetadata(),
+		Actor:     record.GetActor(),
+		Reason:    record.GetReason(),
+		Version:   record.GetVersion(),
+	}
+
+	if record.GetTimestamp() != nil {
+		transition.Timestamp = record.GetTimestamp().AsTime()
+	}
+
+	if record.GetEventTime() != nil {
+		transition.EventTime = record.GetEventTime().AsTime()
+	}
+
+	return transition
+}
+
+// SnapshotToProto converts a snapshot to its protobuf representation
+func SnapshotToProto[T ~string](snapshot statetrooper.Snapshot[T]) *statetrooperpb.Snapshot {
+	transitions := make([]*statetrooperpb.TransitionRecord, len(snapshot.Transitions))
+	for i, transition := range snapshot.Transitions {
+		transitions[i] = TransitionToProto(transition)
+	}
+
+	var entries map[string]uint64
+	if len(snapshot.Entries) > 0 {
+		entries = make(map[string]uint64, len(snapshot.Entries))
+		for _, entry := range snapshot.Entries {
+			entries[string(entry.State)] = uint64(entry.Count)
+		}
+	}
+
+	return &statetrooperpb.Snapshot{
+		FormatVersion: int32(snapshot.FormatVersion),
+		State:         string(snapshot.State),
+		Version:       snapshot.Version,
+		RulesetHash:   snapshot.RulesetHash,
+		Transitions:   transitions,
+		Entries:       entries,
+	}
+}
+
+// SnapshotFromProto converts a snapshot from its protobuf representation, e.g. to restore it with
+// statetrooper.FSM.Restore
+func SnapshotFromProto[T ~string](snapshot *statetrooperpb.Snapshot) statetrooper.Snapshot[T] {
+	transitions := make([]statetrooper.Transition[T], len(snapshot.GetTransitions()))
+	for i, record := range snapshot.GetTransitions() {
+		transitions[i] = TransitionFromProto[T](record)
+	}
+
+	var entries []statetrooper.EntryCount[T]
+	for state, count := range snapshot.GetEntries() {
+		entries = append(entries, statetrooper.EntryCount[T]{State: T(state), Count: int(count)})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].State < entries[j].State
+	})
+
+	return statetrooper.Snapshot[T]{
+		FormatVersion: int(snapshot.GetFormatVersion()),
+		State:         T(snapshot.GetState()),
+		Version:       snapshot.GetVersion(),
+		RulesetHash:   snapshot.GetRulesetHash(),
+		Transitions:   transitions,
+		Entries:       entries,
+	}
+}
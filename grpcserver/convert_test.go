@@ -0,0 +1,43 @@
+package grpcserver
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hishamk/statetrooper"
+	"github.com/hishamk/statetrooper/grpcserver/statetrooperpb"
+	"google.golang.org/protobuf/proto"
+)
+
+func Test_snapshotProto(t *testing.T) {
+	fsm := statetrooper.NewFSM[orderStatus](statusCreated, 10, statetrooper.WithTimeProvider[orderStatus](func() time.Time {
+		return time.Date(2023, 6, 18, 0, 0, 0, 0, time.UTC)
+	}))
+	fsm.AddRule(statusCreated, statusPaid)
+
+	if _, err := fsm.Transition(statusPaid, map[string]string{"k": "v"}, statetrooper.WithActor("payments")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	snapshot, err := fsm.Snapshot()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := proto.Marshal(SnapshotToProto(snapshot))
+	if err != nil {
+		t.Fatalf("failed to marshal the snapshot: %v", err)
+	}
+
+	var decoded statetrooperpb.Snapshot
+
+	err = proto.Unmarshal(data, &decoded)
+	if err != nil {
+		t.Fatalf("failed to unmarshal the snapshot: %v", err)
+	}
+
+	if restored := SnapshotFromProto[orderStatus](&decoded); !reflect.DeepEqual(restored, snapshot) {
+		t.Errorf("SnapshotFromProto() = %+v, expected %+v", restored, snapshot)
+	}
+}
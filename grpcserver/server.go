@@ -0,0 +1,235 @@
+/*
+Package grpcserver exposes state machines over gRPC, so sidecar processes written in any
+language can drive the same machine.
+
+The service is defined in proto/statetrooper/v1/statetrooper.proto and the generated Go code
+lives in the statetrooperpb package. Regenerate it with:
+
+	buf generate
+
+Every request carries the ID of the entity whose state machine is addressed. A Resolver maps
+the ID to an FSM, e.g. by loading it from a store, and Single serves a single FSM whatever the ID:
+
+	srv := grpc.NewServer()
+	statetrooperpb.RegisterStateMachineServer(srv, grpcserver.New(grpcserver.Single(order.State)))
+
+States are sent as strings, so the state type must have an underlying string type.
+
+This package is a separate module so that the statetrooper module doesn't depend on gRPC.
+*/
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/hishamk/statetrooper"
+	"github.com/hishamk/statetrooper/grpcserver/statetrooperpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// streamBuffer is the number of transitions buffered for a slow stream client
+const streamBuffer = 64
+
+// ErrNotFound is returned by a Resolver when there is no FSM for the ID
+var ErrNotFound = errors.New("state machine not found")
+
+// Resolver returns the FSM of the entity with the given ID
+// It returns ErrNotFound if there is none
+type Resolver[T ~string] func(ctx context.Context, id string) (*statetrooper.FSM[T], error)
+
+// Single returns a Resolver serving fsm for every ID
+func Single[T ~string](fsm *statetrooper.FSM[T]) Resolver[T] {
+	return func(context.Context, string) (*statetrooper.FSM[T], error) {
+		return fsm, nil
+	}
+}
+
+// Server implements the StateMachine gRPC service
+type Server[T ~string] struct {
+	statetrooperpb.UnimplementedStateMachineServer
+
+	resolve Resolver[T]
+}
+
+// New creates a Server resolving the FSMs with resolve
+func New[T ~string](resolve Resolver[T]) *Server[T] {
+	return &Server[T]{resolve: resolve}
+}
+
+// GetState returns the current state
+func (s *Server[T]) GetState(ctx context.Context, req *statetrooperpb.GetStateRequest) (*statetrooperpb.GetStateResponse, error) {
+	fsm, err := s.fsm(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	return &statetrooperpb.GetStateResponse{State: string(fsm.CurrentState())}, nil
+}
+
+// ListAllowedTransitions returns the states reachable from the current state
+func (s *Server[T]) ListAllowedTransitions(ctx context.Context, req *statetrooperpb.ListAllowedTransitionsRequest) (*statetrooperpb.ListAllowedTransitionsResponse, error) {
+	fsm, err := s.fsm(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	return &statetrooperpb.ListAllowedTransitionsResponse{
+		State:         string(fsm.CurrentState()),
+		AllowedStates: stateStrings(fsm.AllowedTransitions()),
+	}, nil
+}
+
+// Transition transitions to the target state
+// A transition committed despite a failing subscriber or hook returns the new state, the errors of callbacks
+// run after the commit not undoing it. Rejected transitions are mapped to FAILED_PRECONDITION for transitions
+// the rules or links don't allow, PERMISSION_DENIED, RESOURCE_EXHAUSTED when rate limited and
+// INVALID_ARGUMENT for rejected metadata
+func (s *Server[T]) Transition(ctx context.Context, req *statetrooperpb.TransitionRequest) (*statetrooperpb.TransitionResponse, error) {
+	fsm, err := s.fsm(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	committed := false
+	opts := []statetrooper.TransitionOption{statetrooper.WithCommitted(&committed)}
+
+	if req.GetActor() != "" {
+		opts = append(opts, statetrooper.WithActor(req.GetActor()))
+	}
+
+	if req.GetReason() != "" {
+		opts = append(opts, statetrooper.WithReason(req.GetReason()))
+	}
+
+	state, err := fsm.Transition(T(req.GetTargetState()), req.GetMetadata(), opts...)
+
+	var (
+		transitionErr statetrooper.TransitionError[T]
+		authErr       statetrooper.AuthorizationError[T]
+		rateLimitErr  statetrooper.RateLimitError[T]
+		metadataErr   statetrooper.MetadataError[T]
+		linkErr       statetrooper.LinkError
+	)
+
+	switch {
+	case err == nil || committed:
+		return &statetrooperpb.TransitionResponse{State: string(state)}, nil
+	case errors.As(err, &transitionErr):
+		return nil, status.Errorf(codes.FailedPrecondition, "%v, allowed states: %v", err, fsm.AllowedTransitions())
+	case errors.As(err, &authErr):
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	case errors.As(err, &rateLimitErr):
+		return nil, status.Error(codes.ResourceExhausted, err.Error())
+	case errors.As(err, &metadataErr):
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	case errors.As(err, &linkErr):
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	default:
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+}
+
+// StreamTransitions streams every committed transition until the client cancels
+// The response headers are sent once the stream is subscribed to the FSM, so transitions
+// committed after the client receives them are streamed
+// A client falling more than a buffer's worth of transitions behind gets a RESOURCE_EXHAUSTED error
+// rather than silently missing transitions
+func (s *Server[T]) StreamTransitions(req *statetrooperpb.StreamTransitionsRequest, stream statetrooperpb.StateMachine_StreamTransitionsServer) error {
+	fsm, err := s.fsm(stream.Context(), req.GetId())
+	if err != nil {
+		return err
+	}
+
+	events := make(chan statetrooper.Transition[T], streamBuffer)
+	lagged := make(chan struct{})
+
+	var once sync.Once
+
+	unsubscribe := fsm.Subscribe(func(transition statetrooper.Transition[T]) {
+		select {
+		case events <- transition:
+		default:
+			once.Do(func() {
+				close(lagged)
+			})
+		}
+	})
+	defer unsubscribe()
+
+	// send the headers once subscribed so clients can wait for the stream to be live
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-lagged:
+			return status.Error(codes.ResourceExhausted, "stream fell behind the committed transitions")
+		case transition := <-events:
+			if err := stream.Send(TransitionToProto(transition)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// RenderDiagram renders the rules or the transition history as a diagram
+// As with the FSM's diagram methods, the state type must implement fmt.Stringer
+func (s *Server[T]) RenderDiagram(ctx context.Context, req *statetrooperpb.RenderDiagramRequest) (*statetrooperpb.RenderDiagramResponse, error) {
+	fsm, err := s.fsm(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	var diagram string
+
+	switch req.GetFormat() {
+	case statetrooperpb.DiagramFormat_DIAGRAM_FORMAT_UNSPECIFIED, statetrooperpb.DiagramFormat_DIAGRAM_FORMAT_MERMAID:
+		diagram, err = fsm.GenerateMermaidRulesDiagram()
+	case statetrooperpb.DiagramFormat_DIAGRAM_FORMAT_DOT:
+		diagram, err = fsm.GenerateDOTRulesDiagram()
+	case statetrooperpb.DiagramFormat_DIAGRAM_FORMAT_PLANTUML:
+		diagram, err = fsm.GeneratePlantUMLRulesDiagram()
+	case statetrooperpb.DiagramFormat_DIAGRAM_FORMAT_MERMAID_HISTORY:
+		diagram, err = fsm.GenerateMermaidTransitionHistoryDiagram()
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unknown diagram format %v", req.GetFormat())
+	}
+
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &statetrooperpb.RenderDiagramResponse{Diagram: diagram}, nil
+}
+
+// fsm resolves the FSM of the entity, mapping resolver errors to gRPC errors
+func (s *Server[T]) fsm(ctx context.Context, id string) (*statetrooper.FSM[T], error) {
+	fsm, err := s.resolve(ctx, id)
+
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return nil, status.Errorf(codes.NotFound, "no state machine for %q", id)
+	case err != nil:
+		return nil, status.Error(codes.Internal, fmt.Sprintf("failed to resolve state machine %q: %v", id, err))
+	}
+
+	return fsm, nil
+}
+
+// stateStrings converts states to strings
+func stateStrings[T ~string](states []T) []string {
+	out := make([]string, len(states))
+	for i, state := range states {
+		out[i] = string(state)
+	}
+
+	return out
+}
@@ -0,0 +1,248 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/hishamk/statetrooper"
+	"github.com/hishamk/statetrooper/grpcserver/statetrooperpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type orderStatus string
+
+const (
+	statusCreated orderStatus = "created"
+	statusPaid    orderStatus = "paid"
+	statusShipped orderStatus = "shipped"
+)
+
+func (s orderStatus) String() string {
+	return string(s)
+}
+
+// newClient serves the resolver over an in-memory connection
+func newClient(t *testing.T, resolve Resolver[orderStatus]) statetrooperpb.StateMachineClient {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+
+	srv := grpc.NewServer()
+	statetrooperpb.RegisterStateMachineServer(srv, New(resolve))
+
+	go func() {
+		_ = srv.Serve(lis)
+	}()
+
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+
+	t.Cleanup(func() {
+		conn.Close()
+	})
+
+	return statetrooperpb.NewStateMachineClient(conn)
+}
+
+func newOrderFSM() *statetrooper.FSM[orderStatus] {
+	fsm := statetrooper.NewFSM[orderStatus](statusCreated, 10)
+	fsm.AddRule(statusCreated, statusPaid)
+	fsm.AddRule(statusPaid, statusShipped)
+
+	return fsm
+}
+
+func Test_server(t *testing.T) {
+	ctx := context.Background()
+	fsm := newOrderFSM()
+	client := newClient(t, Single(fsm))
+
+	state, err := client.GetState(ctx, &statetrooperpb.GetStateRequest{Id: "order-1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if state.GetState() != string(statusCreated) {
+		t.Errorf("expected state %v, got %v", statusCreated, state.GetState())
+	}
+
+	allowed, err := client.ListAllowedTransitions(ctx, &statetrooperpb.ListAllowedTransitionsRequest{Id: "order-1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(allowed.GetAllowedStates()) != 1 || allowed.GetAllowedStates()[0] != string(statusPaid) {
+		t.Errorf("unexpected allowed states: %v", allowed.GetAllowedStates())
+	}
+
+	resp, err := client.Transition(ctx, &statetrooperpb.TransitionRequest{
+		Id:          "order-1",
+		TargetState: string(statusPaid),
+		Metadata:    map[string]string{"by": "sidecar"},
+		Actor:       "sidecar",
+		Reason:      "payment received",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if resp.GetState() != string(statusPaid) || fsm.CurrentState() != statusPaid {
+		t.Errorf("expected state %v, got %v", statusPaid, resp.GetState())
+	}
+
+	if tr := fsm.Transitions()[0]; tr.Actor != "sidecar" || tr.Reason != "payment received" {
+		t.Errorf("expected the actor and reason to be recorded, got %q and %q", tr.Actor, tr.Reason)
+	}
+
+	_, err = client.Transition(ctx, &statetrooperpb.TransitionRequest{Id: "order-1", TargetState: string(statusCreated)})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("expected FailedPrecondition, got %v", err)
+	}
+
+	diagram, err := client.RenderDiagram(ctx, &statetrooperpb.RenderDiagramRequest{
+		Id:     "order-1",
+		Format: statetrooperpb.DiagramFormat_DIAGRAM_FORMAT_DOT,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if expected, _ := fsm.GenerateDOTRulesDiagram(); diagram.GetDiagram() != expected {
+		t.Errorf("expected diagram %q, got %q", expected, diagram.GetDiagram())
+	}
+}
+
+func Test_serverTransitionErrors(t *testing.T) {
+	ctx := context.Background()
+
+	fsm := statetrooper.NewFSM[orderStatus](statusCreated, 10,
+		statetrooper.WithMetadataValidator[orderStatus](func(from, to orderStatus, metadata map[string]string) error {
+			if metadata["ticket"] == "" {
+				return errors.New("ticket required")
+			}
+
+			return nil
+		}),
+		statetrooper.WithAuthorizer[orderStatus](func(ctx context.Context, actor string, from, to orderStatus) error {
+			if actor != "sidecar" {
+				return errors.New("unknown actor")
+			}
+
+			return nil
+		}),
+		statetrooper.WithRateLimit[orderStatus](1, time.Hour),
+	)
+	fsm.AddRule(statusCreated, statusPaid)
+	fsm.AddRule(statusPaid, statusShipped)
+
+	fsm.Subscribe(func(statetrooper.Transition[orderStatus]) {
+		panic("boom")
+	})
+
+	client := newClient(t, Single(fsm))
+
+	tests := []struct {
+		req   *statetrooperpb.TransitionRequest
+		code  codes.Code
+		state orderStatus
+	}{
+		// unauthorized
+		{&statetrooperpb.TransitionRequest{TargetState: string(statusPaid), Metadata: map[string]string{"ticket": "42"}}, codes.PermissionDenied, statusCreated},
+		// rejected metadata
+		{&statetrooperpb.TransitionRequest{TargetState: string(statusPaid), Actor: "sidecar"}, codes.InvalidArgument, statusCreated},
+		// committed although the subscriber panicked
+		{&statetrooperpb.TransitionRequest{TargetState: string(statusPaid), Actor: "sidecar", Metadata: map[string]string{"ticket": "42"}}, codes.OK, statusPaid},
+		// throttled
+		{&statetrooperpb.TransitionRequest{TargetState: string(statusShipped), Actor: "sidecar", Metadata: map[string]string{"ticket": "43"}}, codes.ResourceExhausted, statusPaid},
+	}
+
+	for _, test := range tests {
+		test.req.Id = "order-1"
+
+		resp, err := client.Transition(ctx, test.req)
+		if status.Code(err) != test.code {
+			t.Errorf("%v: expected %v, got %v", test.req, test.code, err)
+		}
+
+		if test.code == codes.OK && resp.GetState() != string(test.state) {
+			t.Errorf("%v: expected state %v in the response, got %v", test.req, test.state, resp.GetState())
+		}
+
+		if fsm.CurrentState() != test.state {
+			t.Errorf("%v: expected state %v, got %v", test.req, test.state, fsm.CurrentState())
+		}
+	}
+}
+
+func Test_serverStreamTransitions(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	fsm := newOrderFSM()
+	client := newClient(t, Single(fsm))
+
+	stream, err := client.StreamTransitions(ctx, &statetrooperpb.StreamTransitionsRequest{Id: "order-1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := stream.Header(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, state := range []orderStatus{statusPaid, statusShipped} {
+		if _, err := fsm.Transition(state, map[string]string{"state": string(state)}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	for _, state := range []orderStatus{statusPaid, statusShipped} {
+		record, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if record.GetToState() != string(state) || record.GetMetadata()["state"] != string(state) {
+			t.Errorf("expected a transition to %v, got %v", state, record)
+		}
+
+		if record.GetTimestamp().AsTime().IsZero() {
+			t.Errorf("expected a timestamp, got %v", record.GetTimestamp())
+		}
+	}
+}
+
+func Test_serverNotFound(t *testing.T) {
+	client := newClient(t, func(context.Context, string) (*statetrooper.FSM[orderStatus], error) {
+		return nil, ErrNotFound
+	})
+
+	_, err := client.GetState(context.Background(), &statetrooperpb.GetStateRequest{Id: "missing"})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound, got %v", err)
+	}
+
+	client = newClient(t, func(context.Context, string) (*statetrooper.FSM[orderStatus], error) {
+		return nil, errors.New("store unavailable")
+	})
+
+	_, err = client.GetState(context.Background(), &statetrooperpb.GetStateRequest{Id: "order-1"})
+	if status.Code(err) != codes.Internal {
+		t.Errorf("expected Internal, got %v", err)
+	}
+}
@@ -0,0 +1,870 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        (unknown)
+// source: statetrooper/v1/statetrooper.proto
+
+package statetrooperpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type DiagramFormat int32
+
+const (
+	// Defaults to a Mermaid diagram of the rules
+	DiagramFormat_DIAGRAM_FORMAT_UNSPECIFIED DiagramFormat = 0
+	DiagramFormat_DIAGRAM_FORMAT_MERMAID     DiagramFormat = 1
+	DiagramFormat_DIAGRAM_FORMAT_DOT         DiagramFormat = 2
+	DiagramFormat_DIAGRAM_FORMAT_PLANTUML    DiagramFormat = 3
+	// A Mermaid diagram of the transition history
+	DiagramFormat_DIAGRAM_FORMAT_MERMAID_HISTORY DiagramFormat = 4
+)
+
+// Enum value maps for DiagramFormat.
+var (
+	DiagramFormat_name = map[int32]string{
+		0: "DIAGRAM_FORMAT_UNSPECIFIED",
+		1: "DIAGRAM_FORMAT_MERMAID",
+		2: "DIAGRAM_FORMAT_DOT",
+		3: "DIAGRAM_FORMAT_PLANTUML",
+		4: "DIAGRAM_FORMAT_MERMAID_HISTORY",
+	}
+	DiagramFormat_value = map[string]int32{
+		"DIAGRAM_FORMAT_UNSPECIFIED":     0,
+		"DIAGRAM_FORMAT_MERMAID":         1,
+		"DIAGRAM_FORMAT_DOT":             2,
+		"DIAGRAM_FORMAT_PLANTUML":        3,
+		"DIAGRAM_FORMAT_MERMAID_HISTORY": 4,
+	}
+)
+
+func (x DiagramFormat) Enum() *DiagramFormat {
+	p := new(DiagramFormat)
+	*p = x
+	return p
+}
+
+func (x DiagramFormat) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (DiagramFormat) Descriptor() protoreflect.EnumDescriptor {
+	return file_statetrooper_v1_statetrooper_proto_enumTypes[0].Descriptor()
+}
+
+func (DiagramFormat) Type() protoreflect.EnumType {
+	return &file_statetrooper_v1_statetrooper_proto_enumTypes[0]
+}
+
+func (x DiagramFormat) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use DiagramFormat.Descriptor instead.
+func (DiagramFormat) EnumDescriptor() ([]byte, []int) {
+	return file_statetrooper_v1_statetrooper_proto_rawDescGZIP(), []int{0}
+}
+
+type GetStateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStateRequest) Reset() {
+	*x = GetStateRequest{}
+	mi := &file_statetrooper_v1_statetrooper_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStateRequest) ProtoMessage() {}
+
+func (x *GetStateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_statetrooper_v1_statetrooper_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStateRequest.ProtoReflect.Descriptor instead.
+func (*GetStateRequest) Descriptor() ([]byte, []int) {
+	return file_statetrooper_v1_statetrooper_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *GetStateRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type GetStateResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	State         string                 `protobuf:"bytes,1,opt,name=state,proto3" json:"state,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStateResponse) Reset() {
+	*x = GetStateResponse{}
+	mi := &file_statetrooper_v1_statetrooper_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStateResponse) ProtoMessage() {}
+
+func (x *GetStateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_statetrooper_v1_statetrooper_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStateResponse.ProtoReflect.Descriptor instead.
+func (*GetStateResponse) Descriptor() ([]byte, []int) {
+	return file_statetrooper_v1_statetrooper_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *GetStateResponse) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+type ListAllowedTransitionsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAllowedTransitionsRequest) Reset() {
+	*x = ListAllowedTransitionsRequest{}
+	mi := &file_statetrooper_v1_statetrooper_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAllowedTransitionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAllowedTransitionsRequest) ProtoMessage() {}
+
+func (x *ListAllowedTransitionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_statetrooper_v1_statetrooper_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAllowedTransitionsRequest.ProtoReflect.Descriptor instead.
+func (*ListAllowedTransitionsRequest) Descriptor() ([]byte, []int) {
+	return file_statetrooper_v1_statetrooper_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListAllowedTransitionsRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type ListAllowedTransitionsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	State         string                 `protobuf:"bytes,1,opt,name=state,proto3" json:"state,omitempty"`
+	AllowedStates []string               `protobuf:"bytes,2,rep,name=allowed_states,json=allowedStates,proto3" json:"allowed_states,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAllowedTransitionsResponse) Reset() {
+	*x = ListAllowedTransitionsResponse{}
+	mi := &file_statetrooper_v1_statetrooper_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAllowedTransitionsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAllowedTransitionsResponse) ProtoMessage() {}
+
+func (x *ListAllowedTransitionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_statetrooper_v1_statetrooper_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAllowedTransitionsResponse.ProtoReflect.Descriptor instead.
+func (*ListAllowedTransitionsResponse) Descriptor() ([]byte, []int) {
+	return file_statetrooper_v1_statetrooper_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ListAllowedTransitionsResponse) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *ListAllowedTransitionsResponse) GetAllowedStates() []string {
+	if x != nil {
+		return x.AllowedStates
+	}
+	return nil
+}
+
+type TransitionRequest struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Id          string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	TargetState string                 `protobuf:"bytes,2,opt,name=target_state,json=targetState,proto3" json:"target_state,omitempty"`
+	Metadata    map[string]string      `protobuf:"bytes,3,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// actor records who or what made the transition
+	Actor string `protobuf:"bytes,4,opt,name=actor,proto3" json:"actor,omitempty"`
+	// reason records why the transition was made
+	Reason        string `protobuf:"bytes,5,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TransitionRequest) Reset() {
+	*x = TransitionRequest{}
+	mi := &file_statetrooper_v1_statetrooper_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TransitionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TransitionRequest) ProtoMessage() {}
+
+func (x *TransitionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_statetrooper_v1_statetrooper_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TransitionRequest.ProtoReflect.Descriptor instead.
+func (*TransitionRequest) Descriptor() ([]byte, []int) {
+	return file_statetrooper_v1_statetrooper_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *TransitionRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *TransitionRequest) GetTargetState() string {
+	if x != nil {
+		return x.TargetState
+	}
+	return ""
+}
+
+func (x *TransitionRequest) GetMetadata() map[string]string {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *TransitionRequest) GetActor() string {
+	if x != nil {
+		return x.Actor
+	}
+	return ""
+}
+
+func (x *TransitionRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type TransitionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	State         string                 `protobuf:"bytes,1,opt,name=state,proto3" json:"state,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TransitionResponse) Reset() {
+	*x = TransitionResponse{}
+	mi := &file_statetrooper_v1_statetrooper_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TransitionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TransitionResponse) ProtoMessage() {}
+
+func (x *TransitionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_statetrooper_v1_statetrooper_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TransitionResponse.ProtoReflect.Descriptor instead.
+func (*TransitionResponse) Descriptor() ([]byte, []int) {
+	return file_statetrooper_v1_statetrooper_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *TransitionResponse) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+type StreamTransitionsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamTransitionsRequest) Reset() {
+	*x = StreamTransitionsRequest{}
+	mi := &file_statetrooper_v1_statetrooper_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamTransitionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamTransitionsRequest) ProtoMessage() {}
+
+func (x *StreamTransitionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_statetrooper_v1_statetrooper_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamTransitionsRequest.ProtoReflect.Descriptor instead.
+func (*StreamTransitionsRequest) Descriptor() ([]byte, []int) {
+	return file_statetrooper_v1_statetrooper_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *StreamTransitionsRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type TransitionRecord struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	FromState string                 `protobuf:"bytes,1,opt,name=from_state,json=fromState,proto3" json:"from_state,omitempty"`
+	ToState   string                 `protobuf:"bytes,2,opt,name=to_state,json=toState,proto3" json:"to_state,omitempty"`
+	Timestamp *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	EventTime *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=event_time,json=eventTime,proto3" json:"event_time,omitempty"`
+	Metadata  map[string]string      `protobuf:"bytes,5,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Actor     string                 `protobuf:"bytes,6,opt,name=actor,proto3" json:"actor,omitempty"`
+	Reason    string                 `protobuf:"bytes,7,opt,name=reason,proto3" json:"reason,omitempty"`
+	// version is the state machine's version once the transition was committed, 0 if unknown
+	Version       uint64 `protobuf:"varint,8,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TransitionRecord) Reset() {
+	*x = TransitionRecord{}
+	mi := &file_statetrooper_v1_statetrooper_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TransitionRecord) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TransitionRecord) ProtoMessage() {}
+
+func (x *TransitionRecord) ProtoReflect() protoreflect.Message {
+	mi := &file_statetrooper_v1_statetrooper_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TransitionRecord.ProtoReflect.Descriptor instead.
+func (*TransitionRecord) Descriptor() ([]byte, []int) {
+	return file_statetrooper_v1_statetrooper_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *TransitionRecord) GetFromState() string {
+	if x != nil {
+		return x.FromState
+	}
+	return ""
+}
+
+func (x *TransitionRecord) GetToState() string {
+	if x != nil {
+		return x.ToState
+	}
+	return ""
+}
+
+func (x *TransitionRecord) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *TransitionRecord) GetEventTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.EventTime
+	}
+	return nil
+}
+
+func (x *TransitionRecord) GetMetadata() map[string]string {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *TransitionRecord) GetActor() string {
+	if x != nil {
+		return x.Actor
+	}
+	return ""
+}
+
+func (x *TransitionRecord) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *TransitionRecord) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+// Snapshot captures the state of a state machine for persistence or for consumers in other languages
+// It mirrors the Snapshot type of the Go package
+type Snapshot struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// format_version is the snapshot format version the snapshot was taken with
+	FormatVersion int32  `protobuf:"varint,1,opt,name=format_version,json=formatVersion,proto3" json:"format_version,omitempty"`
+	State         string `protobuf:"bytes,2,opt,name=state,proto3" json:"state,omitempty"`
+	// version is the number of successful transitions
+	Version uint64 `protobuf:"varint,3,opt,name=version,proto3" json:"version,omitempty"`
+	// ruleset_hash is the fingerprint of the ruleset the snapshot was taken under
+	RulesetHash string `protobuf:"bytes,4,opt,name=ruleset_hash,json=rulesetHash,proto3" json:"ruleset_hash,omitempty"`
+	// transitions is the transition history from oldest to newest
+	Transitions []*TransitionRecord `protobuf:"bytes,5,rep,name=transitions,proto3" json:"transitions,omitempty"`
+	// entries counts the entries into each state, which unlike the history are never trimmed
+	Entries       map[string]uint64 `protobuf:"bytes,6,rep,name=entries,proto3" json:"entries,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Snapshot) Reset() {
+	*x = Snapshot{}
+	mi := &file_statetrooper_v1_statetrooper_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Snapshot) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Snapshot) ProtoMessage() {}
+
+func (x *Snapshot) ProtoReflect() protoreflect.Message {
+	mi := &file_statetrooper_v1_statetrooper_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Snapshot.ProtoReflect.Descriptor instead.
+func (*Snapshot) Descriptor() ([]byte, []int) {
+	return file_statetrooper_v1_statetrooper_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Snapshot) GetFormatVersion() int32 {
+	if x != nil {
+		return x.FormatVersion
+	}
+	return 0
+}
+
+func (x *Snapshot) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *Snapshot) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *Snapshot) GetRulesetHash() string {
+	if x != nil {
+		return x.RulesetHash
+	}
+	return ""
+}
+
+func (x *Snapshot) GetTransitions() []*TransitionRecord {
+	if x != nil {
+		return x.Transitions
+	}
+	return nil
+}
+
+func (x *Snapshot) GetEntries() map[string]uint64 {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+type RenderDiagramRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Format        DiagramFormat          `protobuf:"varint,2,opt,name=format,proto3,enum=statetrooper.v1.DiagramFormat" json:"format,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RenderDiagramRequest) Reset() {
+	*x = RenderDiagramRequest{}
+	mi := &file_statetrooper_v1_statetrooper_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RenderDiagramRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RenderDiagramRequest) ProtoMessage() {}
+
+func (x *RenderDiagramRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_statetrooper_v1_statetrooper_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RenderDiagramRequest.ProtoReflect.Descriptor instead.
+func (*RenderDiagramRequest) Descriptor() ([]byte, []int) {
+	return file_statetrooper_v1_statetrooper_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *RenderDiagramRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *RenderDiagramRequest) GetFormat() DiagramFormat {
+	if x != nil {
+		return x.Format
+	}
+	return DiagramFormat_DIAGRAM_FORMAT_UNSPECIFIED
+}
+
+type RenderDiagramResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Diagram       string                 `protobuf:"bytes,1,opt,name=diagram,proto3" json:"diagram,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RenderDiagramResponse) Reset() {
+	*x = RenderDiagramResponse{}
+	mi := &file_statetrooper_v1_statetrooper_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RenderDiagramResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RenderDiagramResponse) ProtoMessage() {}
+
+func (x *RenderDiagramResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_statetrooper_v1_statetrooper_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RenderDiagramResponse.ProtoReflect.Descriptor instead.
+func (*RenderDiagramResponse) Descriptor() ([]byte, []int) {
+	return file_statetrooper_v1_statetrooper_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *RenderDiagramResponse) GetDiagram() string {
+	if x != nil {
+		return x.Diagram
+	}
+	return ""
+}
+
+var File_statetrooper_v1_statetrooper_proto protoreflect.FileDescriptor
+
+const file_statetrooper_v1_statetrooper_proto_rawDesc = "" +
+	"\n" +
+	"\"statetrooper/v1/statetrooper.proto\x12\x0fstatetrooper.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"!\n" +
+	"\x0fGetStateRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"(\n" +
+	"\x10GetStateResponse\x12\x14\n" +
+	"\x05state\x18\x01 \x01(\tR\x05state\"/\n" +
+	"\x1dListAllowedTransitionsRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"]\n" +
+	"\x1eListAllowedTransitionsResponse\x12\x14\n" +
+	"\x05state\x18\x01 \x01(\tR\x05state\x12%\n" +
+	"\x0eallowed_states\x18\x02 \x03(\tR\rallowedStates\"\xff\x01\n" +
+	"\x11TransitionRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12!\n" +
+	"\ftarget_state\x18\x02 \x01(\tR\vtargetState\x12L\n" +
+	"\bmetadata\x18\x03 \x03(\v20.statetrooper.v1.TransitionRequest.MetadataEntryR\bmetadata\x12\x14\n" +
+	"\x05actor\x18\x04 \x01(\tR\x05actor\x12\x16\n" +
+	"\x06reason\x18\x05 \x01(\tR\x06reason\x1a;\n" +
+	"\rMetadataEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"*\n" +
+	"\x12TransitionResponse\x12\x14\n" +
+	"\x05state\x18\x01 \x01(\tR\x05state\"*\n" +
+	"\x18StreamTransitionsRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\x93\x03\n" +
+	"\x10TransitionRecord\x12\x1d\n" +
+	"\n" +
+	"from_state\x18\x01 \x01(\tR\tfromState\x12\x19\n" +
+	"\bto_state\x18\x02 \x01(\tR\atoState\x128\n" +
+	"\ttimestamp\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x129\n" +
+	"\n" +
+	"event_time\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\teventTime\x12K\n" +
+	"\bmetadata\x18\x05 \x03(\v2/.statetrooper.v1.TransitionRecord.MetadataEntryR\bmetadata\x12\x14\n" +
+	"\x05actor\x18\x06 \x01(\tR\x05actor\x12\x16\n" +
+	"\x06reason\x18\a \x01(\tR\x06reason\x12\x18\n" +
+	"\aversion\x18\b \x01(\x04R\aversion\x1a;\n" +
+	"\rMetadataEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xc7\x02\n" +
+	"\bSnapshot\x12%\n" +
+	"\x0eformat_version\x18\x01 \x01(\x05R\rformatVersion\x12\x14\n" +
+	"\x05state\x18\x02 \x01(\tR\x05state\x12\x18\n" +
+	"\aversion\x18\x03 \x01(\x04R\aversion\x12!\n" +
+	"\fruleset_hash\x18\x04 \x01(\tR\vrulesetHash\x12C\n" +
+	"\vtransitions\x18\x05 \x03(\v2!.statetrooper.v1.TransitionRecordR\vtransitions\x12@\n" +
+	"\aentries\x18\x06 \x03(\v2&.statetrooper.v1.Snapshot.EntriesEntryR\aentries\x1a:\n" +
+	"\fEntriesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x04R\x05value:\x028\x01\"^\n" +
+	"\x14RenderDiagramRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x126\n" +
+	"\x06format\x18\x02 \x01(\x0e2\x1e.statetrooper.v1.DiagramFormatR\x06format\"1\n" +
+	"\x15RenderDiagramResponse\x12\x18\n" +
+	"\adiagram\x18\x01 \x01(\tR\adiagram*\xa4\x01\n" +
+	"\rDiagramFormat\x12\x1e\n" +
+	"\x1aDIAGRAM_FORMAT_UNSPECIFIED\x10\x00\x12\x1a\n" +
+	"\x16DIAGRAM_FORMAT_MERMAID\x10\x01\x12\x16\n" +
+	"\x12DIAGRAM_FORMAT_DOT\x10\x02\x12\x1b\n" +
+	"\x17DIAGRAM_FORMAT_PLANTUML\x10\x03\x12\"\n" +
+	"\x1eDIAGRAM_FORMAT_MERMAID_HISTORY\x10\x042\xf6\x03\n" +
+	"\fStateMachine\x12O\n" +
+	"\bGetState\x12 .statetrooper.v1.GetStateRequest\x1a!.statetrooper.v1.GetStateResponse\x12y\n" +
+	"\x16ListAllowedTransitions\x12..statetrooper.v1.ListAllowedTransitionsRequest\x1a/.statetrooper.v1.ListAllowedTransitionsResponse\x12U\n" +
+	"\n" +
+	"Transition\x12\".statetrooper.v1.TransitionRequest\x1a#.statetrooper.v1.TransitionResponse\x12c\n" +
+	"\x11StreamTransitions\x12).statetrooper.v1.StreamTransitionsRequest\x1a!.statetrooper.v1.TransitionRecord0\x01\x12^\n" +
+	"\rRenderDiagram\x12%.statetrooper.v1.RenderDiagramRequest\x1a&.statetrooper.v1.RenderDiagramResponseB;Z9github.com/hishamk/statetrooper/grpcserver/statetrooperpbb\x06proto3"
+
+var (
+	file_statetrooper_v1_statetrooper_proto_rawDescOnce sync.Once
+	file_statetrooper_v1_statetrooper_proto_rawDescData []byte
+)
+
+func file_statetrooper_v1_statetrooper_proto_rawDescGZIP() []byte {
+	file_statetrooper_v1_statetrooper_proto_rawDescOnce.Do(func() {
+		file_statetrooper_v1_statetrooper_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_statetrooper_v1_statetrooper_proto_rawDesc), len(file_statetrooper_v1_statetrooper_proto_rawDesc)))
+	})
+	return file_statetrooper_v1_statetrooper_proto_rawDescData
+}
+
+var file_statetrooper_v1_statetrooper_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_statetrooper_v1_statetrooper_proto_msgTypes = make([]protoimpl.MessageInfo, 14)
+var file_statetrooper_v1_statetrooper_proto_goTypes = []any{
+	(DiagramFormat)(0),                     // 0: statetrooper.v1.DiagramFormat
+	(*GetStateRequest)(nil),                // 1: statetrooper.v1.GetStateRequest
+	(*GetStateResponse)(nil),               // 2: statetrooper.v1.GetStateResponse
+	(*ListAllowedTransitionsRequest)(nil),  // 3: statetrooper.v1.ListAllowedTransitionsRequest
+	(*ListAllowedTransitionsResponse)(nil), // 4: statetrooper.v1.ListAllowedTransitionsResponse
+	(*TransitionRequest)(nil),              // 5: statetrooper.v1.TransitionRequest
+	(*TransitionResponse)(nil),             // 6: statetrooper.v1.TransitionResponse
+	(*StreamTransitionsRequest)(nil),       // 7: statetrooper.v1.StreamTransitionsRequest
+	(*TransitionRecord)(nil),               // 8: statetrooper.v1.TransitionRecord
+	(*Snapshot)(nil),                       // 9: statetrooper.v1.Snapshot
+	(*RenderDiagramRequest)(nil),           // 10: statetrooper.v1.RenderDiagramRequest
+	(*RenderDiagramResponse)(nil),          // 11: statetrooper.v1.RenderDiagramResponse
+	nil,                                    // 12: statetrooper.v1.TransitionRequest.MetadataEntry
+	nil,                                    // 13: statetrooper.v1.TransitionRecord.MetadataEntry
+	nil,                                    // 14: statetrooper.v1.Snapshot.EntriesEntry
+	(*timestamppb.Timestamp)(nil),          // 15: google.protobuf.Timestamp
+}
+var file_statetrooper_v1_statetrooper_proto_depIdxs = []int32{
+	12, // 0: statetrooper.v1.TransitionRequest.metadata:type_name -> statetrooper.v1.TransitionRequest.MetadataEntry
+	15, // 1: statetrooper.v1.TransitionRecord.timestamp:type_name -> google.protobuf.Timestamp
+	15, // 2: statetrooper.v1.TransitionRecord.event_time:type_name -> google.protobuf.Timestamp
+	13, // 3: statetrooper.v1.TransitionRecord.metadata:type_name -> statetrooper.v1.TransitionRecord.MetadataEntry
+	8,  // 4: statetrooper.v1.Snapshot.transitions:type_name -> statetrooper.v1.TransitionRecord
+	14, // 5: statetrooper.v1.Snapshot.entries:type_name -> statetrooper.v1.Snapshot.EntriesEntry
+	0,  // 6: statetrooper.v1.RenderDiagramRequest.format:type_name -> statetrooper.v1.DiagramFormat
+	1,  // 7: statetrooper.v1.StateMachine.GetState:input_type -> statetrooper.v1.GetStateRequest
+	3,  // 8: statetrooper.v1.StateMachine.ListAllowedTransitions:input_type -> statetrooper.v1.ListAllowedTransitionsRequest
+	5,  // 9: statetrooper.v1.StateMachine.Transition:input_type -> statetrooper.v1.TransitionRequest
+	7,  // 10: statetrooper.v1.StateMachine.StreamTransitions:input_type -> statetrooper.v1.StreamTransitionsRequest
+	10, // 11: statetrooper.v1.StateMachine.RenderDiagram:input_type -> statetrooper.v1.RenderDiagramRequest
+	2,  // 12: statetrooper.v1.StateMachine.GetState:output_type -> statetrooper.v1.GetStateResponse
+	4,  // 13: statetrooper.v1.StateMachine.ListAllowedTransitions:output_type -> statetrooper.v1.ListAllowedTransitionsResponse
+	6,  // 14: statetrooper.v1.StateMachine.Transition:output_type -> statetrooper.v1.TransitionResponse
+	8,  // 15: statetrooper.v1.StateMachine.StreamTransitions:output_type -> statetrooper.v1.TransitionRecord
+	11, // 16: statetrooper.v1.StateMachine.RenderDiagram:output_type -> statetrooper.v1.RenderDiagramResponse
+	12, // [12:17] is the sub-list for method output_type
+	7,  // [7:12] is the sub-list for method input_type
+	7,  // [7:7] is the sub-list for extension type_name
+	7,  // [7:7] is the sub-list for extension extendee
+	0,  // [0:7] is the sub-list for field type_name
+}
+
+func init() { file_statetrooper_v1_statetrooper_proto_init() }
+func file_statetrooper_v1_statetrooper_proto_init() {
+	if File_statetrooper_v1_statetrooper_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_statetrooper_v1_statetrooper_proto_rawDesc), len(file_statetrooper_v1_statetrooper_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   14,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_statetrooper_v1_statetrooper_proto_goTypes,
+		DependencyIndexes: file_statetrooper_v1_statetrooper_proto_depIdxs,
+		EnumInfos:         file_statetrooper_v1_statetrooper_proto_enumTypes,
+		MessageInfos:      file_statetrooper_v1_statetrooper_proto_msgTypes,
+	}.Build()
+	File_statetrooper_v1_statetrooper_proto = out.File
+	file_statetrooper_v1_statetrooper_proto_goTypes = nil
+	file_statetrooper_v1_statetrooper_proto_depIdxs = nil
+}
@@ -0,0 +1,295 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             (unknown)
+// source: statetrooper/v1/statetrooper.proto
+
+package statetrooperpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	StateMachine_GetState_FullMethodName               = "/statetrooper.v1.StateMachine/GetState"
+	StateMachine_ListAllowedTransitions_FullMethodName = "/statetrooper.v1.StateMachine/ListAllowedTransitions"
+	StateMachine_Transition_FullMethodName             = "/statetrooper.v1.StateMachine/Transition"
+	StateMachine_StreamTransitions_FullMethodName      = "/statetrooper.v1.StateMachine/StreamTransitions"
+	StateMachine_RenderDiagram_FullMethodName          = "/statetrooper.v1.StateMachine/RenderDiagram"
+)
+
+// StateMachineClient is the client API for StateMachine service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// StateMachine drives state machines hosted by another process
+// Every request carries the ID of the entity whose state machine is addressed
+type StateMachineClient interface {
+	// GetState returns the current state
+	GetState(ctx context.Context, in *GetStateRequest, opts ...grpc.CallOption) (*GetStateResponse, error)
+	// ListAllowedTransitions returns the states reachable from the current state
+	ListAllowedTransitions(ctx context.Context, in *ListAllowedTransitionsRequest, opts ...grpc.CallOption) (*ListAllowedTransitionsResponse, error)
+	// Transition transitions to the target state
+	// Invalid transitions fail with FAILED_PRECONDITION
+	Transition(ctx context.Context, in *TransitionRequest, opts ...grpc.CallOption) (*TransitionResponse, error)
+	// StreamTransitions streams every committed transition until the client cancels
+	StreamTransitions(ctx context.Context, in *StreamTransitionsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[TransitionRecord], error)
+	// RenderDiagram renders the rules or the transition history as a diagram
+	RenderDiagram(ctx context.Context, in *RenderDiagramRequest, opts ...grpc.CallOption) (*RenderDiagramResponse, error)
+}
+
+type stateMachineClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewStateMachineClient(cc grpc.ClientConnInterface) StateMachineClient {
+	return &stateMachineClient{cc}
+}
+
+func (c *stateMachineClient) GetState(ctx context.Context, in *GetStateRequest, opts ...grpc.CallOption) (*GetStateResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetStateResponse)
+	err := c.cc.Invoke(ctx, StateMachine_GetState_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stateMachineClient) ListAllowedTransitions(ctx context.Context, in *ListAllowedTransitionsRequest, opts ...grpc.CallOption) (*ListAllowedTransitionsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListAllowedTransitionsResponse)
+	err := c.cc.Invoke(ctx, StateMachine_ListAllowedTransitions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stateMachineClient) Transition(ctx context.Context, in *TransitionRequest, opts ...grpc.CallOption) (*TransitionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TransitionResponse)
+	err := c.cc.Invoke(ctx, StateMachine_Transition_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stateMachineClient) StreamTransitions(ctx context.Context, in *StreamTransitionsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[TransitionRecord], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &StateMachine_ServiceDesc.Streams[0], StateMachine_StreamTransitions_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamTransitionsRequest, TransitionRecord]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type StateMachine_StreamTransitionsClient = grpc.ServerStreamingClient[TransitionRecord]
+
+func (c *stateMachineClient) RenderDiagram(ctx context.Context, in *RenderDiagramRequest, opts ...grpc.CallOption) (*RenderDiagramResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RenderDiagramResponse)
+	err := c.cc.Invoke(ctx, StateMachine_RenderDiagram_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// StateMachineServer is the server API for StateMachine service.
+// All implementations must embed UnimplementedStateMachineServer
+// for forward compatibility.
+//
+// StateMachine drives state machines hosted by another process
+// Every request carries the ID of the entity whose state machine is addressed
+type StateMachineServer interface {
+	// GetState returns the current state
+	GetState(context.Context, *GetStateRequest) (*GetStateResponse, error)
+	// ListAllowedTransitions returns the states reachable from the current state
+	ListAllowedTransitions(context.Context, *ListAllowedTransitionsRequest) (*ListAllowedTransitionsResponse, error)
+	// Transition transitions to the target state
+	// Invalid transitions fail with FAILED_PRECONDITION
+	Transition(context.Context, *TransitionRequest) (*TransitionResponse, error)
+	// StreamTransitions streams every committed transition until the client cancels
+	StreamTransitions(*StreamTransitionsRequest, grpc.ServerStreamingServer[TransitionRecord]) error
+	// RenderDiagram renders the rules or the transition history as a diagram
+	RenderDiagram(context.Context, *RenderDiagramRequest) (*RenderDiagramResponse, error)
+	mustEmbedUnimplementedStateMachineServer()
+}
+
+// UnimplementedStateMachineServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedStateMachineServer struct{}
+
+func (UnimplementedStateMachineServer) GetState(context.Context, *GetStateRequest) (*GetStateResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetState not implemented")
+}
+func (UnimplementedStateMachineServer) ListAllowedTransitions(context.Context, *ListAllowedTransitionsRequest) (*ListAllowedTransitionsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListAllowedTransitions not implemented")
+}
+func (UnimplementedStateMachineServer) Transition(context.Context, *TransitionRequest) (*TransitionResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Transition not implemented")
+}
+func (UnimplementedStateMachineServer) StreamTransitions(*StreamTransitionsRequest, grpc.ServerStreamingServer[TransitionRecord]) error {
+	return status.Error(codes.Unimplemented, "method StreamTransitions not implemented")
+}
+func (UnimplementedStateMachineServer) RenderDiagram(context.Context, *RenderDiagramRequest) (*RenderDiagramResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method RenderDiagram not implemented")
+}
+func (UnimplementedStateMachineServer) mustEmbedUnimplementedStateMachineServer() {}
+func (UnimplementedStateMachineServer) testEmbeddedByValue()                      {}
+
+// UnsafeStateMachineServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to StateMachineServer will
+// result in compilation errors.
+type UnsafeStateMachineServer interface {
+	mustEmbedUnimplementedStateMachineServer()
+}
+
+func RegisterStateMachineServer(s grpc.ServiceRegistrar, srv StateMachineServer) {
+	// If the following call panics, it indicates UnimplementedStateMachineServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&StateMachine_ServiceDesc, srv)
+}
+
+func _StateMachine_GetState_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StateMachineServer).GetState(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StateMachine_GetState_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StateMachineServer).GetState(ctx, req.(*GetStateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StateMachine_ListAllowedTransitions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAllowedTransitionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StateMachineServer).ListAllowedTransitions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StateMachine_ListAllowedTransitions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StateMachineServer).ListAllowedTransitions(ctx, req.(*ListAllowedTransitionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StateMachine_Transition_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TransitionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StateMachineServer).Transition(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StateMachine_Transition_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StateMachineServer).Transition(ctx, req.(*TransitionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StateMachine_StreamTransitions_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamTransitionsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(StateMachineServer).StreamTransitions(m, &grpc.GenericServerStream[StreamTransitionsRequest, TransitionRecord]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type StateMachine_StreamTransitionsServer = grpc.ServerStreamingServer[TransitionRecord]
+
+func _StateMachine_RenderDiagram_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RenderDiagramRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StateMachineServer).RenderDiagram(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StateMachine_RenderDiagram_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StateMachineServer).RenderDiagram(ctx, req.(*RenderDiagramRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// StateMachine_ServiceDesc is the grpc.ServiceDesc for StateMachine service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var StateMachine_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "statetrooper.v1.StateMachine",
+	HandlerType: (*StateMachineServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetState",
+			Handler:    _StateMachine_GetState_Handler,
+		},
+		{
+			MethodName: "ListAllowedTransitions",
+			Handler:    _StateMachine_ListAllowedTransitions_Handler,
+		},
+		{
+			MethodName: "Transition",
+			Handler:    _StateMachine_Transition_Handler,
+		},
+		{
+			MethodName: "RenderDiagram",
+			Handler:    _StateMachine_RenderDiagram_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamTransitions",
+			Handler:       _StateMachine_StreamTransitions_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "statetrooper/v1/statetrooper.proto",
+}
@@ -0,0 +1,179 @@
+package statetrooper
+
+// HistoryStore stores the transition history of an FSM
+// The FSM calls Append and Trim while it is exclusively locked, but List may be called concurrently
+// by readers, so List must be safe to call concurrently with other List calls
+type HistoryStore[T comparable] interface {
+	// Append records a transition as the newest entry in the history
+	Append(transition Transition[T]) error
+
+	// List returns the transitions ordered from oldest to newest
+	List() ([]Transition[T], error)
+
+	// Trim removes the oldest transitions until at most max transitions remain
+	// evicted, if not nil, is called with each removed transition from oldest to newest
+	Trim(max int, evicted func(Transition[T])) error
+}
+
+// WithHistoryStore sets the store used for the transition history
+// DEFAULT: an in-memory MemoryHistoryStore
+// This is useful for backing the transition history with a database
+func WithHistoryStore[T comparable](store HistoryStore[T]) FSMOption[T] {
+	return func(fsm *FSM[T]) {
+		fsm.historyStore = store
+	}
+}
+
+// MemoryHistoryStore is the default in-memory HistoryStore
+// Transitions are stored in a ring buffer so that once the history is full,
+// trimming the oldest transition and appending a new one doesn't reallocate
+// The zero value is an empty store ready to use
+type MemoryHistoryStore[T comparable] struct {
+	buf   []Transition[T]
+	start int
+	size  int
+}
+
+// NewMemoryHistoryStore creates a new in-memory history store with room for capacity transitions
+// The store grows beyond its capacity if needed
+func NewMemoryHistoryStore[T comparable](capacity int) *MemoryHistoryStore[T] {
+	return &MemoryHistoryStore[T]{
+		buf: make([]Transition[T], capacity),
+	}
+}
+
+// Append records a transition as the newest entry in the history
+func (s *MemoryHistoryStore[T]) Append(transition Transition[T]) error {
+	if s.size == len(s.buf) {
+		s.grow()
+	}
+
+	s.buf[(s.start+s.size)%len(s.buf)] = transition
+	s.size++
+
+	return nil
+}
+
+// List returns a copy of the transitions ordered from oldest to newest
+func (s *MemoryHistoryStore[T]) List() ([]Transition[T], error) {
+	transitions := make([]Transition[T], s.size)
+
+	s.copyTo(transitions)
+
+	return transitions, nil
+}
+
+// Trim removes the oldest transitions until at most max transitions remain
+func (s *MemoryHistoryStore[T]) Trim(max int, evicted func(Transition[T])) error {
+	for s.size > max && s.size > 0 {
+		transition := s.buf[s.start]
+
+		// Release the evicted transition's metadata
+		s.buf[s.start] = Transition[T]{}
+		s.start = (s.start + 1) % len(s.buf)
+		s.size--
+
+		if evicted != nil {
+			evicted(transition)
+		}
+	}
+
+	return nil
+}
+
+// Len returns the number of transitions in the history
+func (s *MemoryHistoryStore[T]) Len() int {
+	return s.size
+}
+
+// last returns the newest transition, if any
+func (s *MemoryHistoryStore[T]) last() (Transition[T], bool) {
+	if s.size == 0 {
+		return Transition[T]{}, false
+	}
+
+	return s.buf[(s.start+s.size-1)%len(s.buf)], true
+}
+
+// at returns the i-th transition ordered from oldest to newest
+func (s *MemoryHistoryStore[T]) at(i int) Transition[T] {
+	return s.buf[(s.start+i)%len(s.buf)]
+}
+
+// each calls fn for each transition ordered from oldest to newest until fn returns false
+func (s *MemoryHistoryStore[T]) each(fn func(Transition[T]) bool) {
+	for i := 0; i < s.size; i++ {
+		if !fn(s.buf[(s.start+i)%len(s.buf)]) {
+			return
+		}
+	}
+}
+
+// copyTo copies the transitions ordered from oldest to newest into dst
+func (s *MemoryHistoryStore[T]) copyTo(dst []Transition[T]) {
+	if s.start+s.size <= len(s.buf) {
+		copy(dst, s.buf[s.start:s.start+s.size])
+
+		return
+	}
+
+	n := copy(dst, s.buf[s.start:])
+	copy(dst[n:], s.buf[:s.size-n])
+}
+
+// grow doubles the capacity of the ring buffer, moving the oldest transition to the start
+func (s *MemoryHistoryStore[T]) grow() {
+	capacity := 2 * len(s.buf)
+	if capacity == 0 {
+		capacity = 4
+	}
+
+	buf := make([]Transition[T], capacity)
+	s.copyTo(buf)
+
+	s.buf = buf
+	s.start = 0
+}
+
+// lastTransition returns the newest transition in the FSM's history, if any
+// The caller must hold the lock
+func (fsm *FSM[T]) lastTransition() (Transition[T], bool, error) {
+	if fsm.historyStore == nil {
+		last, ok := fsm.memoryHistory.last()
+
+		return last, ok, nil
+	}
+
+	transitions, err := fsm.historyStore.List()
+	if err != nil || len(transitions) == 0 {
+		return Transition[T]{}, false, err
+	}
+
+	return transitions[len(transitions)-1], true, nil
+}
+
+// IterTransitions calls fn for each transition ordered from oldest to newest until fn returns false
+// Unlike Transitions, the in-memory history is not copied. fn is called while the FSM is locked for
+// reading, so it must not transition or modify the FSM
+// If the history store fails to list the transitions, fn is not called
+func (fsm *FSM[T]) IterTransitions(fn func(Transition[T]) bool) {
+	fsm.rlock()
+	defer fsm.runlock()
+
+	if fsm.historyStore == nil {
+		fsm.memoryHistory.each(fn)
+
+		return
+	}
+
+	transitions, err := fsm.historyStore.List()
+	if err != nil {
+		return
+	}
+
+	for _, transition := range transitions {
+		if !fn(transition) {
+			return
+		}
+	}
+}
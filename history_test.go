@@ -0,0 +1,178 @@
+package statetrooper
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func Test_memoryHistoryStore(t *testing.T) {
+	tests := []struct {
+		capacity int
+		max      int
+		appends  int
+		expected []CustomStateEnum
+		evicted  []CustomStateEnum
+	}{
+		{0, -1, 3, []CustomStateEnum{CustomStateEnumA, CustomStateEnumB, CustomStateEnumC}, nil},                                // Zero value, no trimming
+		{3, 3, 2, []CustomStateEnum{CustomStateEnumA, CustomStateEnumB}, nil},                                                   // Not full
+		{2, 2, 3, []CustomStateEnum{CustomStateEnumB, CustomStateEnumC}, []CustomStateEnum{CustomStateEnumA}},                   // Wrapped once
+		{2, 2, 4, []CustomStateEnum{CustomStateEnumC, CustomStateEnumD}, []CustomStateEnum{CustomStateEnumA, CustomStateEnumB}}, // Wrapped twice
+		{1, 3, 4, []CustomStateEnum{CustomStateEnumB, CustomStateEnumC, CustomStateEnumD}, []CustomStateEnum{CustomStateEnumA}}, // Grown beyond capacity
+	}
+
+	states := []CustomStateEnum{CustomStateEnumA, CustomStateEnumB, CustomStateEnumC, CustomStateEnumD}
+
+	for _, test := range tests {
+		var evicted []CustomStateEnum
+
+		store := NewMemoryHistoryStore[CustomStateEnum](test.capacity)
+
+		for i := 0; i < test.appends; i++ {
+			// Trim before appending the same way the FSM does
+			if test.max > 0 {
+				store.Trim(test.max-1, func(tr Transition[CustomStateEnum]) {
+					evicted = append(evicted, tr.ToState)
+				})
+			}
+
+			store.Append(Transition[CustomStateEnum]{ToState: states[i]})
+		}
+
+		transitions, err := store.List()
+		if err != nil {
+			t.Fatalf("List() returned an error: %v", err)
+		}
+
+		got := make([]CustomStateEnum, 0, store.Len())
+		for _, tr := range transitions {
+			got = append(got, tr.ToState)
+		}
+
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("store with capacity %d and max %d after %d appends = %v, expected %v", test.capacity, test.max, test.appends, got, test.expected)
+		}
+
+		if !reflect.DeepEqual(evicted, test.evicted) {
+			t.Errorf("store with capacity %d and max %d after %d appends evicted %v, expected %v", test.capacity, test.max, test.appends, evicted, test.evicted)
+		}
+	}
+}
+
+// failingHistoryStore is a HistoryStore that fails to append transitions
+type failingHistoryStore[T comparable] struct {
+	MemoryHistoryStore[T]
+}
+
+func (s *failingHistoryStore[T]) Append(Transition[T]) error {
+	return errors.New("store unavailable")
+}
+
+func Test_withHistoryStore(t *testing.T) {
+	store := NewMemoryHistoryStore[CustomStateEnum](0)
+
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10, WithHistoryStore[CustomStateEnum](store))
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+
+	fsm.Transition(CustomStateEnumB, nil)
+
+	if store.Len() != 1 {
+		t.Errorf("history store contains %d transitions, expected 1", store.Len())
+	}
+
+	failing := NewFSM[CustomStateEnum](CustomStateEnumA, 10, WithHistoryStore[CustomStateEnum](&failingHistoryStore[CustomStateEnum]{}))
+	failing.AddRule(CustomStateEnumA, CustomStateEnumB)
+
+	_, err := failing.Transition(CustomStateEnumB, nil)
+	if err == nil {
+		t.Errorf("Transition() did not return the history store error")
+	}
+
+	if failing.CurrentState() != CustomStateEnumA {
+		t.Errorf("Transition() changed the current state to %v although the history store failed", failing.CurrentState())
+	}
+}
+
+func Test_iterTransitions(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 3)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumA)
+
+	for i, target := range []CustomStateEnum{CustomStateEnumB, CustomStateEnumA, CustomStateEnumB, CustomStateEnumA} {
+		if _, err := fsm.Transition(target, map[string]string{"i": strconv.Itoa(i)}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	var seen []string
+	fsm.IterTransitions(func(transition Transition[CustomStateEnum]) bool {
+		seen = append(seen, transition.Metadata["i"])
+
+		return true
+	})
+
+	if !reflect.DeepEqual(seen, []string{"1", "2", "3"}) {
+		t.Errorf("IterTransitions() visited %v, expected [1 2 3]", seen)
+	}
+
+	seen = nil
+	fsm.IterTransitions(func(transition Transition[CustomStateEnum]) bool {
+		seen = append(seen, transition.Metadata["i"])
+
+		return false
+	})
+
+	if !reflect.DeepEqual(seen, []string{"1"}) {
+		t.Errorf("IterTransitions() visited %v after stopping, expected [1]", seen)
+	}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		fsm.IterTransitions(func(Transition[CustomStateEnum]) bool {
+			return true
+		})
+	})
+
+	if allocs != 0 {
+		t.Errorf("IterTransitions allocated %v times, expected 0", allocs)
+	}
+}
+
+func Test_transitionAllocations(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumA)
+
+	if got := cap(fsm.memoryHistory.buf); got != 10 {
+		t.Errorf("history capacity = %d, expected 10", got)
+	}
+
+	targets := []CustomStateEnum{CustomStateEnumB, CustomStateEnumA}
+	i := 0
+
+	allocs := testing.AllocsPerRun(100, func() {
+		_, _ = fsm.Transition(targets[i%2], nil)
+		i++
+	})
+
+	if allocs != 0 {
+		t.Errorf("Transition allocated %v times, expected 0", allocs)
+	}
+}
+
+func Benchmark_memoryHistoryStoreFull(b *testing.B) {
+	limit := 1000
+	store := NewMemoryHistoryStore[CustomStateEnum](limit)
+	tr := Transition[CustomStateEnum]{FromState: CustomStateEnumA, ToState: CustomStateEnumB}
+
+	for i := 0; i < limit; i++ {
+		store.Append(tr)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		store.Trim(limit-1, nil)
+		store.Append(tr)
+	}
+}
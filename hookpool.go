@@ -0,0 +1,204 @@
+package statetrooper
+
+import (
+	"context"
+	"sync"
+)
+
+// AsyncHookConfig configures the worker pool running subscribers asynchronously, see WithAsyncSubscribers
+type AsyncHookConfig[T comparable] struct {
+	// Workers is the number of goroutines running subscribers DEFAULT: 1, which keeps the commit order
+	Workers int
+	// QueueSize is the number of subscriber calls that can be pending DEFAULT: 64
+	QueueSize int
+	// DropWhenFull drops subscriber calls when the queue is full instead of blocking the transition
+	// until there is room DEFAULT: false
+	DropWhenFull bool
+	// OnDrop is called with each dropped transition DEFAULT: nil
+	OnDrop func(Transition[T])
+	// OnPanic is called with the value recovered from a panicking subscriber DEFAULT: nil
+	OnPanic func(recovered interface{})
+}
+
+// WithAsyncSubscribers runs the subscribers on a bounded worker pool instead of synchronously
+// so that slow side effects such as emails or webhooks don't hold the FSM's lock or block the caller
+// Calls are queued once the FSM's lock is released, so a subscriber may read the FSM. When the queue is
+// full and DropWhenFull isn't set, the transition returns once there is room
+// Panics in subscribers are recovered. With several workers, subscribers may see transitions out of order
+// The pool is stopped with CloseAsyncSubscribers
+// DEFAULT: subscribers run synchronously while the FSM is locked
+func WithAsyncSubscribers[T comparable](config AsyncHookConfig[T]) FSMOption[T] {
+	return func(fsm *FSM[T]) {
+		fsm.hookPool = newHookPool(config)
+	}
+}
+
+// CloseAsyncSubscribers stops accepting subscriber calls and waits for the pending ones to run
+// ctx's error is returned if ctx is done first. It does nothing unless WithAsyncSubscribers is set
+func (fsm *FSM[T]) CloseAsyncSubscribers(ctx context.Context) error {
+	if fsm.hookPool == nil {
+		return nil
+	}
+
+	return fsm.hookPool.close(ctx)
+}
+
+// hookPool runs subscriber calls on a bounded set of workers
+type hookPool[T comparable] struct {
+	config AsyncHookConfig[T]
+	jobs   chan hookJob[T]
+	done   chan struct{}
+
+	// mu guards closed against concurrent sends on jobs
+	mu     sync.RWMutex
+	closed bool
+
+	// batches are queued in the order of their tickets, taken while the FSM is locked, to keep the commit order
+	turnMu     sync.Mutex
+	turnCond   *sync.Cond
+	nextTicket uint64
+	turn       uint64
+}
+
+// hookJob is a pending subscriber call with the transition it was made for
+type hookJob[T comparable] struct {
+	transition Transition[T]
+	fn         func()
+}
+
+// newHookPool starts the workers of a pool
+func newHookPool[T comparable](config AsyncHookConfig[T]) *hookPool[T] {
+	if config.Workers < 1 {
+		config.Workers = 1
+	}
+
+	if config.QueueSize < 1 {
+		config.QueueSize = 64
+	}
+
+	p := &hookPool[T]{
+		config: config,
+		jobs:   make(chan hookJob[T], config.QueueSize),
+		done:   make(chan struct{}),
+	}
+
+	p.turnCond = sync.NewCond(&p.turnMu)
+
+	var wg sync.WaitGroup
+
+	wg.Add(config.Workers)
+
+	for i := 0; i < config.Workers; i++ {
+		go func() {
+			defer wg.Done()
+
+			for job := range p.jobs {
+				p.run(job)
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(p.done)
+	}()
+
+	return p
+}
+
+// newSubscriberJob returns a call of the subscriber with the transition
+// Capturing the transition here keeps it from escaping to the heap when subscribers run synchronously
+func newSubscriberJob[T comparable](fn func(Transition[T]), transition Transition[T]) hookJob[T] {
+	return hookJob[T]{transition: transition, fn: func() { fn(transition) }}
+}
+
+// ticket returns the position of the next batch of subscriber calls
+// The caller must hold the FSM's lock
+func (p *hookPool[T]) ticket() uint64 {
+	p.turnMu.Lock()
+	defer p.turnMu.Unlock()
+
+	ticket := p.nextTicket
+	p.nextTicket++
+
+	return ticket
+}
+
+// submitBatch queues the subscriber calls once the batches with earlier tickets are queued
+// The caller must not hold the FSM's lock, as a full queue blocks until a worker takes a call
+func (p *hookPool[T]) submitBatch(ticket uint64, jobs []hookJob[T]) {
+	p.turnMu.Lock()
+	for p.turn != ticket {
+		p.turnCond.Wait()
+	}
+	p.turnMu.Unlock()
+
+	for _, job := range jobs {
+		p.submit(job)
+	}
+
+	p.turnMu.Lock()
+	p.turn++
+	p.turnCond.Broadcast()
+	p.turnMu.Unlock()
+}
+
+// submit queues a subscriber call, blocking or dropping it when the queue is full
+// Calls submitted after the pool is closed are dropped
+func (p *hookPool[T]) submit(job hookJob[T]) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		p.drop(job)
+
+		return
+	}
+
+	if !p.config.DropWhenFull {
+		p.jobs <- job
+
+		return
+	}
+
+	select {
+	case p.jobs <- job:
+	default:
+		p.drop(job)
+	}
+}
+
+// run runs a subscriber call, recovering from panics
+func (p *hookPool[T]) run(job hookJob[T]) {
+	defer func() {
+		if r := recover(); r != nil && p.config.OnPanic != nil {
+			p.config.OnPanic(r)
+		}
+	}()
+
+	job.fn()
+}
+
+// drop reports a dropped subscriber call
+func (p *hookPool[T]) drop(job hookJob[T]) {
+	if p.config.OnDrop != nil {
+		p.config.OnDrop(job.transition)
+	}
+}
+
+// close stops accepting subscriber calls and waits for the pending ones to run
+func (p *hookPool[T]) close(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.jobs)
+	}
+	p.mu.Unlock()
+
+	select {
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,143 @@
+package statetrooper
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_withAsyncSubscribers(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		received  []CustomStateEnum
+		recovered []interface{}
+	)
+
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10,
+		WithAsyncSubscribers[CustomStateEnum](AsyncHookConfig[CustomStateEnum]{
+			OnPanic: func(r interface{}) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				recovered = append(recovered, r)
+			},
+		}),
+	)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumC)
+
+	fsm.Subscribe(func(transition Transition[CustomStateEnum]) {
+		// the subscriber can call back into the FSM as it doesn't run under the lock
+		_ = fsm.CurrentState()
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		received = append(received, transition.ToState)
+	})
+
+	fsm.Subscribe(func(Transition[CustomStateEnum]) {
+		panic("boom")
+	})
+
+	fsm.Transition(CustomStateEnumB, nil)
+	fsm.Transition(CustomStateEnumC, nil)
+
+	if err := fsm.CloseAsyncSubscribers(context.Background()); err != nil {
+		t.Fatalf("CloseAsyncSubscribers failed: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(received) != 2 || received[0] != CustomStateEnumB || received[1] != CustomStateEnumC {
+		t.Errorf("expected the transitions in commit order, got %v", received)
+	}
+
+	if len(recovered) != 2 {
+		t.Errorf("expected 2 recovered panics, got %d", len(recovered))
+	}
+}
+
+func Test_withAsyncSubscribersDropWhenFull(t *testing.T) {
+	release := make(chan struct{})
+
+	var dropped []Transition[CustomStateEnum]
+
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10,
+		WithAsyncSubscribers[CustomStateEnum](AsyncHookConfig[CustomStateEnum]{
+			QueueSize:    1,
+			DropWhenFull: true,
+			OnDrop: func(transition Transition[CustomStateEnum]) {
+				dropped = append(dropped, transition)
+			},
+		}),
+	)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumA)
+
+	started := make(chan struct{}, 1)
+
+	fsm.Subscribe(func(Transition[CustomStateEnum]) {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-release
+	})
+
+	// the first call blocks the worker, the second fills the queue and the third is dropped
+	fsm.Transition(CustomStateEnumA, nil)
+	<-started
+	fsm.Transition(CustomStateEnumA, nil)
+	fsm.Transition(CustomStateEnumA, nil)
+
+	close(release)
+
+	if err := fsm.CloseAsyncSubscribers(context.Background()); err != nil {
+		t.Fatalf("CloseAsyncSubscribers failed: %v", err)
+	}
+
+	if len(dropped) != 1 {
+		t.Errorf("expected 1 dropped call, got %d", len(dropped))
+	}
+}
+
+func Test_withAsyncSubscribersFullQueue(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10,
+		WithAsyncSubscribers[CustomStateEnum](AsyncHookConfig[CustomStateEnum]{Workers: 1, QueueSize: 1}),
+	)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+
+	var calls sync.WaitGroup
+
+	calls.Add(3)
+
+	// the subscribers read the FSM while the transition waits for room in the queue
+	for i := 0; i < 3; i++ {
+		fsm.Subscribe(func(Transition[CustomStateEnum]) {
+			defer calls.Done()
+
+			_ = fsm.CurrentState()
+		})
+	}
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		fsm.Transition(CustomStateEnumB, nil)
+		calls.Wait()
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the transition deadlocked with the subscribers")
+	}
+
+	if err := fsm.CloseAsyncSubscribers(context.Background()); err != nil {
+		t.Fatalf("CloseAsyncSubscribers failed: %v", err)
+	}
+}
@@ -0,0 +1,327 @@
+package statetrooper
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// streamBuffer is the number of transitions buffered for a slow stream client
+const streamBuffer = 64
+
+// HandlerOption configures the handler returned by Handler
+type HandlerOption func(*handlerOptions)
+
+// handlerOptions holds the handler configuration
+type handlerOptions struct {
+	transitions bool
+	middleware  func(http.Handler) http.Handler
+}
+
+// WithTransitionEndpoint enables the POST /transition endpoint. The endpoint
+// is wrapped with the given middleware e.g. to authenticate callers before
+// they can change the state. A nil middleware leaves the endpoint unprotected.
+// Transitions are made with the request's context, so the authorizer set with
+// WithAuthorizer sees the actor the middleware set with ContextWithActor.
+func WithTransitionEndpoint(middleware func(http.Handler) http.Handler) HandlerOption {
+	return func(o *handlerOptions) {
+		o.transitions = true
+		o.middleware = middleware
+	}
+}
+
+// Handler returns an http.Handler exposing the FSM for debugging
+//
+//	GET /             the current state, allowed transitions and transition history as JSON
+//	GET /diagram      an HTML page rendering the rules and transition history as Mermaid diagrams
+//	GET /stream       a Server-Sent Events stream of committed transitions, see StreamTransitions
+//	POST /transition  transitions the FSM, only served when WithTransitionEndpoint is set
+//
+// Use http.StripPrefix to mount the handler under a path e.g.
+//
+//	mux.Handle("/debug/order/", http.StripPrefix("/debug/order", order.State.Handler()))
+func (fsm *FSM[T]) Handler(opts ...HandlerOption) http.Handler {
+	var options handlerOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	h := &fsmHandler[T]{fsm: fsm}
+
+	if options.transitions {
+		h.transition = http.HandlerFunc(h.serveTransition)
+		if options.middleware != nil {
+			h.transition = options.middleware(h.transition)
+		}
+	}
+
+	return h
+}
+
+// fsmHandler serves the FSM's state and diagrams over HTTP
+type fsmHandler[T comparable] struct {
+	fsm        *FSM[T]
+	transition http.Handler
+}
+
+// stateResponse is the JSON representation of the FSM served by the handler
+type stateResponse[T comparable] struct {
+	CurrentState       T               `json:"current_state"`
+	Version            uint64          `json:"version"`
+	AllowedTransitions []T             `json:"allowed_transitions"`
+	Transitions        []Transition[T] `json:"transitions"`
+}
+
+func (h *fsmHandler[T]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimSuffix(r.URL.Path, "/")
+
+	switch path {
+	case "":
+		h.serveState(w, r)
+	case "/diagram":
+		h.serveDiagram(w, r)
+	case "/stream":
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", "GET")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+
+			return
+		}
+
+		h.fsm.StreamTransitions(w, r)
+	case "/transition":
+		if h.transition == nil {
+			http.NotFound(w, r)
+
+			return
+		}
+
+		h.transition.ServeHTTP(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// serveState serves the current state, allowed transitions and history as JSON
+func (h *fsmHandler[T]) serveState(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	writeJSON(w, http.StatusOK, h.fsm.stateResponse())
+}
+
+// stateResponse captures the FSM's state for the handler
+func (fsm *FSM[T]) stateResponse() stateResponse[T] {
+	fsm.rlock()
+	defer fsm.runlock()
+
+	transitions, _ := fsm.history().List()
+
+	return stateResponse[T]{
+		CurrentState:       fsm.currentState,
+		Version:            fsm.version,
+		AllowedTransitions: fsm.allowedTransitions(),
+		Transitions:        fsm.redactAll(transitions),
+	}
+}
+
+var diagramPage = template.Must(template.New("diagram").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>State machine: {{.CurrentState}}</title>
+<script src="https://cdn.jsdelivr.net/npm/mermaid/dist/mermaid.min.js"></script>
+<script>mermaid.initialize({startOnLoad: true});</script>
+</head>
+<body>
+<h1>Current state: {{.CurrentState}}</h1>
+<h2>Rules</h2>
+{{if .RulesError}}<p>{{.RulesError}}</p>{{else}}<pre class="mermaid">{{.Rules}}</pre>{{end}}
+<h2>Transition history</h2>
+{{if .HistoryError}}<p>{{.HistoryError}}</p>{{else}}<pre class="mermaid">{{.History}}</pre>{{end}}
+</body>
+</html>
+`))
+
+// serveDiagram serves an HTML page rendering the rules and history diagrams
+func (h *fsmHandler[T]) serveDiagram(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	data := struct {
+		CurrentState string
+		Rules        string
+		RulesError   error
+		History      string
+		HistoryError error
+	}{
+		CurrentState: toString(h.fsm.CurrentState()),
+	}
+
+	data.Rules, data.RulesError = h.fsm.GenerateMermaidRulesDiagram()
+	data.History, data.HistoryError = h.fsm.GenerateMermaidTransitionHistoryDiagram()
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+	_ = diagramPage.Execute(w, data)
+}
+
+// transitionRequest is the JSON body accepted by the transition endpoint
+// The actor is only recorded if the request's context has no actor and no authorizer is set,
+// as a client could otherwise claim to be anyone
+type transitionRequest[T comparable] struct {
+	TargetState T                 `json:"target_state"`
+	Metadata    map[string]string `json:"metadata"`
+	Actor       string            `json:"actor"`
+	Reason      string            `json:"reason"`
+}
+
+// transitionErrorResponse is the JSON body returned when a transition is rejected
+type transitionErrorResponse[T comparable] struct {
+	Error              string `json:"error"`
+	FromState          T      `json:"from_state"`
+	ToState            T      `json:"to_state"`
+	AllowedTransitions []T    `json:"allowed_transitions"`
+}
+
+// serveTransition transitions the FSM to the requested target state
+func (h *fsmHandler[T]) serveTransition(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	var req transitionRequest[T]
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body: "+err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	// the context carries the actor authenticated by the middleware, which the body can't override
+	opts := []TransitionOption{WithContext(r.Context())}
+	if ActorFromContext(r.Context()) == "" && req.Actor != "" && !h.fsm.hasAuthorizer() {
+		opts = append(opts, WithActor(req.Actor))
+	}
+
+	if req.Reason != "" {
+		opts = append(opts, WithReason(req.Reason))
+	}
+
+	committed := false
+	opts = append(opts, WithCommitted(&committed))
+
+	_, err := h.fsm.Transition(req.TargetState, req.Metadata, opts...)
+
+	var (
+		transitionErr TransitionError[T]
+		authErr       AuthorizationError[T]
+		rateLimitErr  RateLimitError[T]
+		metadataErr   MetadataError[T]
+		linkErr       LinkError
+	)
+
+	switch {
+	case err == nil || committed:
+		// errors of callbacks run after the commit don't undo the transition, so they aren't the client's concern
+		writeJSON(w, http.StatusOK, h.fsm.stateResponse())
+	case errors.As(err, &transitionErr):
+		writeJSON(w, http.StatusConflict, transitionErrorResponse[T]{
+			Error:              err.Error(),
+			FromState:          transitionErr.FromState,
+			ToState:            transitionErr.ToState,
+			AllowedTransitions: h.fsm.AllowedTransitions(),
+		})
+	case errors.As(err, &authErr):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.As(err, &rateLimitErr):
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(rateLimitErr.RetryAfter.Seconds()))))
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+	case errors.As(err, &metadataErr):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	case errors.As(err, &linkErr):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// StreamTransitions streams each committed transition to the client as a Server-Sent Event
+// until the request's context is done. Each event is named "transition" and carries the
+// transition record as JSON. A client falling more than a buffer's worth of transitions
+// behind is disconnected rather than silently missing transitions, and should reconnect
+// and reload the state
+func (fsm *FSM[T]) StreamTransitions(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+
+		return
+	}
+
+	events := make(chan Transition[T], streamBuffer)
+	lagged := make(chan struct{})
+
+	var once sync.Once
+
+	unsubscribe := fsm.Subscribe(func(transition Transition[T]) {
+		select {
+		case events <- transition:
+		default:
+			once.Do(func() {
+				close(lagged)
+			})
+		}
+	})
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-lagged:
+			return
+		case transition := <-events:
+			data, err := json.Marshal(transition)
+			if err != nil {
+				return
+			}
+
+			if _, err := fmt.Fprintf(w, "event: transition\ndata: %s\n\n", data); err != nil {
+				return
+			}
+
+			flusher.Flush()
+		}
+	}
+}
+
+// writeJSON writes v as a JSON response with the given status code
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,343 @@
+package statetrooper
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_handlerState(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumC, CustomStateEnumD)
+
+	if _, err := fsm.Transition(CustomStateEnumB, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	fsm.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("expected JSON content type, got %q", ct)
+	}
+
+	var got stateResponse[CustomStateEnum]
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if got.CurrentState != CustomStateEnumB {
+		t.Errorf("expected current state %v, got %v", CustomStateEnumB, got.CurrentState)
+	}
+
+	if got.Version != 1 {
+		t.Errorf("expected version 1, got %d", got.Version)
+	}
+
+	expected := []CustomStateEnum{CustomStateEnumC, CustomStateEnumD}
+	if !reflect.DeepEqual(got.AllowedTransitions, expected) {
+		t.Errorf("expected allowed transitions %v, got %v", expected, got.AllowedTransitions)
+	}
+
+	if len(got.Transitions) != 1 || got.Transitions[0].ToState != CustomStateEnumB {
+		t.Errorf("unexpected transitions: %v", got.Transitions)
+	}
+}
+
+func Test_handlerDiagram(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+
+	if _, err := fsm.Transition(CustomStateEnumB, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	fsm.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/diagram", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+
+	body := rec.Body.String()
+	for _, want := range []string{"Current state: B", `<pre class="mermaid">`, "A --&gt; B"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected body to contain %q, got:\n%s", want, body)
+		}
+	}
+}
+
+func Test_handlerRouting(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	handler := fsm.Handler()
+
+	tests := []struct {
+		method string
+		path   string
+		status int
+	}{
+		{http.MethodGet, "/unknown", http.StatusNotFound},
+		{http.MethodDelete, "/", http.StatusMethodNotAllowed},
+		{http.MethodPost, "/diagram", http.StatusMethodNotAllowed},
+		{http.MethodPost, "/transition", http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(test.method, test.path, nil))
+
+		if rec.Code != test.status {
+			t.Errorf("%s %s: expected status %d, got %d", test.method, test.path, test.status, rec.Code)
+		}
+	}
+}
+
+func Test_handlerTransition(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumC)
+
+	handler := fsm.Handler(WithTransitionEndpoint(nil))
+
+	rec := httptest.NewRecorder()
+	body := strings.NewReader(`{"target_state": "B", "metadata": {"by": "ops"}, "actor": "user:42", "reason": "stuck"}`)
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/transition", body))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+
+	if fsm.CurrentState() != CustomStateEnumB {
+		t.Errorf("expected current state %v, got %v", CustomStateEnumB, fsm.CurrentState())
+	}
+
+	if tr := fsm.Transitions()[0]; tr.Metadata["by"] != "ops" || tr.Actor != "user:42" || tr.Reason != "stuck" {
+		t.Errorf("expected metadata, actor and reason to be recorded, got %v", tr)
+	}
+
+	rec = httptest.NewRecorder()
+	body = strings.NewReader(`{"target_state": "D"}`)
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/transition", body))
+
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected status %d, got %d", http.StatusConflict, rec.Code)
+	}
+
+	var got transitionErrorResponse[CustomStateEnum]
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	expected := transitionErrorResponse[CustomStateEnum]{
+		Error:              "invalid state transition from B to D",
+		FromState:          CustomStateEnumB,
+		ToState:            CustomStateEnumD,
+		AllowedTransitions: []CustomStateEnum{CustomStateEnumC},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %+v, got %+v", expected, got)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/transition", strings.NewReader("{")))
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, rec.Code)
+	}
+}
+
+func Test_handlerTransitionMiddleware(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+
+	auth := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer secret" {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+
+	handler := fsm.Handler(WithTransitionEndpoint(auth))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/transition", strings.NewReader(`{"target_state": "B"}`)))
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected status %d, got %d", http.StatusUnauthorized, rec.Code)
+	}
+
+	if fsm.CurrentState() != CustomStateEnumA {
+		t.Fatalf("expected state to be unchanged, got %v", fsm.CurrentState())
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/transition", strings.NewReader(`{"target_state": "B"}`))
+	req.Header.Set("Authorization", "Bearer secret")
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+
+	if fsm.CurrentState() != CustomStateEnumB {
+		t.Errorf("expected current state %v, got %v", CustomStateEnumB, fsm.CurrentState())
+	}
+}
+
+func Test_handlerTransitionAuthorizer(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10,
+		WithAuthorizer[CustomStateEnum](func(ctx context.Context, actor string, from, to CustomStateEnum) error {
+			if actor != "admin" {
+				return errors.New("admin only")
+			}
+
+			return nil
+		}),
+	)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+
+	auth := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(ContextWithActor(r.Context(), r.Header.Get("X-User"))))
+		})
+	}
+
+	handler := fsm.Handler(WithTransitionEndpoint(auth))
+
+	// the actor in the body doesn't override the authenticated one
+	req := httptest.NewRequest(http.MethodPost, "/transition", strings.NewReader(`{"target_state": "B", "actor": "admin"}`))
+	req.Header.Set("X-User", "bob")
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusForbidden || fsm.CurrentState() != CustomStateEnumA {
+		t.Fatalf("expected the transition to be denied, got status %d and state %v", rec.Code, fsm.CurrentState())
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/transition", strings.NewReader(`{"target_state": "B"}`))
+	req.Header.Set("X-User", "admin")
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+
+	if actor := fsm.Transitions()[0].Actor; actor != "admin" {
+		t.Errorf("expected the authenticated actor to be recorded, got %q", actor)
+	}
+}
+
+func Test_handlerTransitionErrors(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10,
+		WithMetadataValidator[CustomStateEnum](func(from, to CustomStateEnum, metadata map[string]string) error {
+			if metadata["ticket"] == "" {
+				return errors.New("ticket required")
+			}
+
+			return nil
+		}),
+		WithRateLimit[CustomStateEnum](1, time.Hour),
+	)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumC)
+
+	fsm.Subscribe(func(Transition[CustomStateEnum]) {
+		panic("boom")
+	})
+
+	handler := fsm.Handler(WithTransitionEndpoint(nil))
+
+	tests := []struct {
+		body   string
+		status int
+		state  CustomStateEnum
+	}{
+		// rejected metadata
+		{`{"target_state": "B"}`, http.StatusUnprocessableEntity, CustomStateEnumA},
+		// committed although the subscriber panicked
+		{`{"target_state": "B", "metadata": {"ticket": "42"}}`, http.StatusOK, CustomStateEnumB},
+		// throttled
+		{`{"target_state": "C", "metadata": {"ticket": "43"}}`, http.StatusTooManyRequests, CustomStateEnumB},
+	}
+
+	for _, test := range tests {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/transition", strings.NewReader(test.body)))
+
+		if rec.Code != test.status {
+			t.Errorf("%s: expected status %d, got %d: %s", test.body, test.status, rec.Code, rec.Body.String())
+		}
+
+		if fsm.CurrentState() != test.state {
+			t.Errorf("%s: expected state %v, got %v", test.body, test.state, fsm.CurrentState())
+		}
+	}
+}
+
+func Test_streamTransitions(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumC)
+
+	server := httptest.NewServer(fsm.Handler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/stream")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("expected event stream content type, got %q", ct)
+	}
+
+	for _, state := range []CustomStateEnum{CustomStateEnumB, CustomStateEnumC} {
+		if _, err := fsm.Transition(state, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+
+	var got []CustomStateEnum
+
+	for len(got) < 2 && scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+
+		var tr Transition[CustomStateEnum]
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &tr); err != nil {
+			t.Fatalf("failed to decode event: %v", err)
+		}
+
+		got = append(got, tr.ToState)
+	}
+
+	if expected := []CustomStateEnum{CustomStateEnumB, CustomStateEnumC}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected streamed transitions %v, got %v", expected, got)
+	}
+}
@@ -0,0 +1,97 @@
+package statetrooper
+
+import "time"
+
+// MetadataIdempotencyKey is the metadata key recording the idempotency key of a transition
+const MetadataIdempotencyKey = "idempotency_key"
+
+// DefaultIdempotencyWindow is how long idempotency keys are remembered unless set with WithIdempotencyWindow
+const DefaultIdempotencyWindow = 5 * time.Minute
+
+// WithIdempotencyKey deduplicates the transition by key, e.g. the ID of a message that may be redelivered
+// If a transition with the same key succeeded within the idempotency window, the transition isn't
+// made again and the state it led to is returned without error, so redeliveries don't produce duplicate
+// history entries. The key is recorded in the metadata under MetadataIdempotencyKey
+// Repeated transitions are authorized before the key is looked up, so the result isn't returned to callers
+// the authorizer denies
+func WithIdempotencyKey(key string) TransitionOption {
+	return func(opts *transitionOptions) {
+		opts.idempotencyKey = key
+	}
+}
+
+// WithIdempotencyWindow sets how long the keys of successful transitions are remembered
+// DEFAULT: DefaultIdempotencyWindow
+func WithIdempotencyWindow[T comparable](window time.Duration) FSMOption[T] {
+	return func(fsm *FSM[T]) {
+		fsm.idempotencyWindow = window
+	}
+}
+
+// idempotentResult is the result of a transition made with an idempotency key
+type idempotentResult[T comparable] struct {
+	state T
+	at    time.Time
+}
+
+// rememberedKey is an idempotency key in the order keys were remembered, see expireKeys
+type rememberedKey struct {
+	key string
+	at  time.Time
+}
+
+// deduplicate returns the result of the transition made with the key within the idempotency window, if any
+// Expired keys are forgotten
+// The caller must hold the lock
+func (fsm *FSM[T]) deduplicate(key string, now time.Time) (T, bool) {
+	window := fsm.idempotencyWindow
+	if window == 0 {
+		window = DefaultIdempotencyWindow
+	}
+
+	fsm.expireKeys(now, window)
+
+	result, ok := fsm.idempotencyKeys[key]
+	if ok && now.Sub(result.at) >= window {
+		// remembered out of order, e.g. after the time provider went back
+		delete(fsm.idempotencyKeys, key)
+
+		return result.state, false
+	}
+
+	return result.state, ok
+}
+
+// expireKeys forgets the oldest keys until one is within the window, so each key is expired once
+// The caller must hold the lock
+func (fsm *FSM[T]) expireKeys(now time.Time, window time.Duration) {
+	for len(fsm.idempotencyOrder) > 0 {
+		oldest := fsm.idempotencyOrder[0]
+		if now.Sub(oldest.at) < window {
+			break
+		}
+
+		// the key may have been remembered again since
+		if result, ok := fsm.idempotencyKeys[oldest.key]; ok && result.at.Equal(oldest.at) {
+			delete(fsm.idempotencyKeys, oldest.key)
+		}
+
+		fsm.idempotencyOrder[0] = rememberedKey{}
+		fsm.idempotencyOrder = fsm.idempotencyOrder[1:]
+	}
+
+	if len(fsm.idempotencyOrder) == 0 {
+		fsm.idempotencyOrder = nil
+	}
+}
+
+// rememberKey remembers the result of a successful transition made with the key
+// The caller must hold the lock
+func (fsm *FSM[T]) rememberKey(key string, state T, now time.Time) {
+	if fsm.idempotencyKeys == nil {
+		fsm.idempotencyKeys = make(map[string]idempotentResult[T])
+	}
+
+	fsm.idempotencyKeys[key] = idempotentResult[T]{state: state, at: now}
+	fsm.idempotencyOrder = append(fsm.idempotencyOrder, rememberedKey{key: key, at: now})
+}
@@ -0,0 +1,113 @@
+package statetrooper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func Test_withIdempotencyKey(t *testing.T) {
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10,
+		WithTimeProvider[CustomStateEnum](func() time.Time { return now }),
+		WithIdempotencyWindow[CustomStateEnum](time.Minute),
+	)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumA)
+
+	if _, err := fsm.Transition(CustomStateEnumB, nil, WithIdempotencyKey("msg-1")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// a redelivery returns the original result without being recorded
+	state, err := fsm.Transition(CustomStateEnumB, nil, WithIdempotencyKey("msg-1"))
+	if err != nil || state != CustomStateEnumB {
+		t.Fatalf("expected the redelivery to return %v, got %v, %v", CustomStateEnumB, state, err)
+	}
+
+	transitions := fsm.Transitions()
+	if len(transitions) != 1 || fsm.Version() != 1 {
+		t.Fatalf("expected a single transition, got %d", len(transitions))
+	}
+
+	if transitions[0].Metadata[MetadataIdempotencyKey] != "msg-1" {
+		t.Errorf("expected the key to be recorded in the metadata, got %v", transitions[0].Metadata)
+	}
+
+	// a failed transition isn't remembered
+	if _, err := fsm.Transition(CustomStateEnumC, nil, WithIdempotencyKey("msg-2")); err == nil {
+		t.Fatalf("expected an error")
+	}
+
+	if _, err := fsm.Transition(CustomStateEnumA, nil, WithIdempotencyKey("msg-2")); err != nil {
+		t.Fatalf("expected the key of a failed transition to be reusable: %v", err)
+	}
+
+	// keys expire after the window
+	now = now.Add(time.Minute)
+
+	if _, err := fsm.Transition(CustomStateEnumB, nil, WithIdempotencyKey("msg-1")); err != nil {
+		t.Errorf("expected the expired key to be forgotten: %v", err)
+	}
+
+	if fsm.Version() != 3 {
+		t.Errorf("expected version 3, got %d", fsm.Version())
+	}
+}
+
+func Test_idempotencyKeysExpireInOrder(t *testing.T) {
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10,
+		WithTimeProvider[CustomStateEnum](func() time.Time { return now }),
+		WithIdempotencyWindow[CustomStateEnum](time.Minute),
+	)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumA)
+
+	for i := 0; i < 5; i++ {
+		if _, err := fsm.Transition(CustomStateEnumA, nil, WithIdempotencyKey(fmt.Sprint("msg-", i))); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		now = now.Add(20 * time.Second)
+	}
+
+	// the keys remembered more than a minute ago are forgotten, the others kept
+	if _, ok := fsm.deduplicate("msg-4", now); !ok {
+		t.Errorf("expected msg-4 to be remembered")
+	}
+
+	if len(fsm.idempotencyKeys) != 2 || len(fsm.idempotencyOrder) != 2 {
+		t.Errorf("expected 2 remembered keys, got %d keys and %d queued", len(fsm.idempotencyKeys), len(fsm.idempotencyOrder))
+	}
+}
+
+func Test_idempotencyKeyAuthorized(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10,
+		WithAuthorizer[CustomStateEnum](func(ctx context.Context, actor string, from, to CustomStateEnum) error {
+			if actor != "alice" {
+				return errors.New("not allowed")
+			}
+
+			return nil
+		}),
+	)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+
+	if _, err := fsm.Transition(CustomStateEnumB, nil, WithIdempotencyKey("k"), WithActor("alice")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// another actor reusing the key is still authorized
+	_, err := fsm.Transition(CustomStateEnumB, nil, WithIdempotencyKey("k"), WithActor("mallory"))
+	if !errors.As(err, &AuthorizationError[CustomStateEnum]{}) {
+		t.Errorf("expected an AuthorizationError, got %v", err)
+	}
+
+	if state, err := fsm.Transition(CustomStateEnumB, nil, WithIdempotencyKey("k"), WithActor("alice")); err != nil || state != CustomStateEnumB {
+		t.Errorf("expected the authorized retry to be deduplicated, got %v and %v", state, err)
+	}
+}
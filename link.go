@@ -0,0 +1,189 @@
+package statetrooper
+
+import "sync"
+
+// MetadataLinked is the metadata key marking the transitions triggered by a link, see Link
+const MetadataLinked = "linked"
+
+// link is a transition of another FSM triggered when the FSM enters a state
+type link[T comparable] struct {
+	id      uint64
+	trigger T
+	// check returns an error if the linked transition isn't allowed
+	check func() error
+	// fire makes the linked transition
+	fire func() error
+}
+
+// Link keeps two FSMs in lock-step: whenever source enters the trigger state, target transitions
+// to the target state with the given metadata and MetadataLinked set to "true"
+// The linked transitions are checked before the source transition is made, and if one isn't allowed
+// the source transition fails with a LinkError and neither FSM is changed. A linked transition can still
+// fail when made, e.g. when rejected by the metadata validator, in which case the source transition stays
+// made and a LinkError with Committed set is returned
+// Links can be chained, the target's own links being triggered in turn. The linked transitions are checked
+// and made while source is locked, so links must not lead back to their source, e.g. two FSMs linked in both
+// directions, as FSMs transitioned concurrently would wait for each other. The source transition fails with
+// ErrLinkCycle instead, before either FSM is changed. Calling unlink removes the link
+func Link[T, U comparable](
+	source *FSM[T],
+	trigger T,
+	target *FSM[U],
+	targetState U,
+	metadata map[string]string,
+) (unlink func()) {
+	metadata = withMetadata(metadata, MetadataLinked, "true")
+
+	source.lock()
+	defer source.unlock()
+
+	source.linkID++
+
+	l := link[T]{
+		id:      source.linkID,
+		trigger: trigger,
+		check: func() error {
+			if linksTo(target, source) {
+				return ErrLinkCycle
+			}
+
+			target.rlock()
+			defer target.runlock()
+
+			if !target.canTransition(&target.currentState, &targetState) {
+				return TransitionError[U]{FromState: target.currentState, ToState: targetState}
+			}
+
+			return nil
+		},
+		fire: func() error {
+			_, err := target.Transition(targetState, metadata)
+
+			return err
+		},
+	}
+
+	source.links = append(source.links, l)
+	source.linkGraph.add(target)
+
+	return func() {
+		source.lock()
+		defer source.unlock()
+
+		for i, existing := range source.links {
+			if existing.id == l.id {
+				source.links = append(source.links[:i], source.links[i+1:]...)
+				source.linkGraph.remove(target)
+
+				return
+			}
+		}
+	}
+}
+
+// linkNode is an FSM of any state type in the graph of links
+type linkNode interface {
+	linkTargets() []linkNode
+}
+
+// linkGraph holds the FSMs an FSM links to, one entry per link
+// It has its own lock, so cycles can be detected without locking the FSMs
+type linkGraph struct {
+	mu      sync.Mutex
+	targets []linkNode
+}
+
+func (g *linkGraph) add(target linkNode) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.targets = append(g.targets, target)
+}
+
+func (g *linkGraph) remove(target linkNode) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for i, existing := range g.targets {
+		if existing == target {
+			g.targets = append(g.targets[:i], g.targets[i+1:]...)
+
+			return
+		}
+	}
+}
+
+func (fsm *FSM[T]) linkTargets() []linkNode {
+	fsm.linkGraph.mu.Lock()
+	defer fsm.linkGraph.mu.Unlock()
+
+	return append([]linkNode(nil), fsm.linkGraph.targets...)
+}
+
+// linksTo reports whether the links of from lead to to, directly or through other FSMs
+func linksTo(from, to linkNode) bool {
+	visited := map[linkNode]bool{}
+	queue := []linkNode{from}
+
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+
+		if node == to {
+			return true
+		}
+
+		if visited[node] {
+			continue
+		}
+
+		visited[node] = true
+		queue = append(queue, node.linkTargets()...)
+	}
+
+	return false
+}
+
+// checkLinks checks that the transitions linked to the target state are allowed
+// The caller must hold the lock
+func (fsm *FSM[T]) checkLinks(targetState T) error {
+	var errs []error
+
+	for _, l := range fsm.links {
+		if l.trigger != targetState {
+			continue
+		}
+
+		if err := l.check(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return LinkError{Errs: errs}
+	}
+
+	return nil
+}
+
+// fireLinks makes the transitions linked to the state the FSM entered
+// The caller must hold the lock
+func (fsm *FSM[T]) fireLinks(state T) error {
+	var errs []error
+
+	for _, l := range fsm.links {
+		if l.trigger != state {
+			continue
+		}
+
+		if err := l.fire(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return LinkError{Committed: true, Errs: errs}
+	}
+
+	return nil
+}
@@ -0,0 +1,142 @@
+package statetrooper
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type shipmentState string
+
+const (
+	shipmentPending   shipmentState = "pending"
+	shipmentScheduled shipmentState = "scheduled"
+	shipmentCanceled  shipmentState = "canceled"
+)
+
+func newLinkedFSMs() (*FSM[CustomStateEnum], *FSM[shipmentState]) {
+	order := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	order.AddRule(CustomStateEnumA, CustomStateEnumB)
+	order.AddRule(CustomStateEnumB, CustomStateEnumC)
+
+	shipment := NewFSM[shipmentState](shipmentPending, 10)
+	shipment.AddRule(shipmentPending, shipmentScheduled)
+
+	return order, shipment
+}
+
+func Test_link(t *testing.T) {
+	order, shipment := newLinkedFSMs()
+
+	Link(order, CustomStateEnumB, shipment, shipmentScheduled, map[string]string{"by": "order"})
+
+	if _, err := order.Transition(CustomStateEnumB, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if shipment.CurrentState() != shipmentScheduled {
+		t.Fatalf("expected the linked transition to be made, got %v", shipment.CurrentState())
+	}
+
+	md := shipment.Transitions()[0].Metadata
+	if md["by"] != "order" || md[MetadataLinked] != "true" {
+		t.Errorf("unexpected linked metadata: %v", md)
+	}
+
+	// a linked transition that isn't allowed fails the source transition
+	unlink := Link(order, CustomStateEnumC, shipment, shipmentCanceled, nil)
+
+	_, err := order.Transition(CustomStateEnumC, nil)
+
+	var linkErr LinkError
+	if !errors.As(err, &linkErr) || linkErr.Committed {
+		t.Fatalf("expected an uncommitted LinkError, got %v", err)
+	}
+
+	var transitionErr TransitionError[shipmentState]
+	if !errors.As(err, &transitionErr) || order.CurrentState() != CustomStateEnumB {
+		t.Errorf("expected the order to be unchanged, got %v", order.CurrentState())
+	}
+
+	unlink()
+
+	if _, err := order.Transition(CustomStateEnumC, nil); err != nil {
+		t.Errorf("expected the unlinked transition to succeed: %v", err)
+	}
+}
+
+func Test_linkCycle(t *testing.T) {
+	a := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	a.AddRule(CustomStateEnumA, CustomStateEnumB)
+	a.AddRule(CustomStateEnumB, CustomStateEnumC)
+
+	b := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	b.AddRule(CustomStateEnumA, CustomStateEnumB)
+
+	Link(a, CustomStateEnumB, b, CustomStateEnumB, nil)
+	Link(b, CustomStateEnumB, a, CustomStateEnumC, nil)
+
+	_, err := a.Transition(CustomStateEnumB, nil)
+	if !errors.Is(err, ErrLinkCycle) {
+		t.Fatalf("expected ErrLinkCycle, got %v", err)
+	}
+
+	var linkErr LinkError
+	if !errors.As(err, &linkErr) || linkErr.Committed || a.CurrentState() != CustomStateEnumA || b.CurrentState() != CustomStateEnumA {
+		t.Errorf("expected neither FSM to change, got %v with %v and %v", err, a.CurrentState(), b.CurrentState())
+	}
+}
+
+func Test_linkMutualConcurrent(t *testing.T) {
+	a := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	a.AddRule(CustomStateEnumA, CustomStateEnumB)
+
+	b := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	b.AddRule(CustomStateEnumA, CustomStateEnumB)
+
+	Link(a, CustomStateEnumB, b, CustomStateEnumB, nil)
+	Link(b, CustomStateEnumB, a, CustomStateEnumB, nil)
+
+	errs := make(chan error, 200)
+
+	var wg sync.WaitGroup
+
+	for _, fsm := range []*FSM[CustomStateEnum]{a, b} {
+		wg.Add(1)
+
+		go func(fsm *FSM[CustomStateEnum]) {
+			defer wg.Done()
+
+			for i := 0; i < 100; i++ {
+				_, err := fsm.Transition(CustomStateEnumB, nil)
+				errs <- err
+			}
+		}(fsm)
+	}
+
+	done := make(chan struct{})
+
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the mutually linked FSMs not to deadlock")
+	}
+
+	close(errs)
+
+	for err := range errs {
+		if !errors.Is(err, ErrLinkCycle) {
+			t.Fatalf("expected ErrLinkCycle, got %v", err)
+		}
+	}
+
+	if a.CurrentState() != CustomStateEnumA || b.CurrentState() != CustomStateEnumA {
+		t.Errorf("expected neither FSM to change, got %v and %v", a.CurrentState(), b.CurrentState())
+	}
+}
@@ -0,0 +1,47 @@
+package statetrooper
+
+import "time"
+
+// WithTimestampLocation normalizes the recorded and marshaled timestamps to the given location,
+// e.g. time.UTC, so that histories produced by processes in different time zones sort and compare
+// consistently
+// Timestamps passed with WithTimestamp and WithEventTime and imported histories are normalized too
+// DEFAULT: timestamps keep the location of the time provider
+func WithTimestampLocation[T comparable](loc *time.Location) FSMOption[T] {
+	return func(fsm *FSM[T]) {
+		fsm.location = loc
+	}
+}
+
+// now returns the current time of the time provider in the timestamp location
+func (fsm *FSM[T]) now() time.Time {
+	return fsm.localize(fsm.timeProvider())
+}
+
+// localize returns t in the timestamp location, leaving the zero time untouched
+func (fsm *FSM[T]) localize(t time.Time) time.Time {
+	if fsm.location == nil || t.IsZero() {
+		return t
+	}
+
+	return t.In(fsm.location)
+}
+
+// localizeAll returns the transitions with their timestamps in the timestamp location
+// The transitions are copied unless the slice is owned by the caller
+func (fsm *FSM[T]) localizeAll(transitions []Transition[T], owned bool) []Transition[T] {
+	if fsm.location == nil {
+		return transitions
+	}
+
+	if !owned {
+		transitions = append([]Transition[T](nil), transitions...)
+	}
+
+	for i := range transitions {
+		transitions[i].Timestamp = fsm.localize(transitions[i].Timestamp)
+		transitions[i].EventTime = fsm.localize(transitions[i].EventTime)
+	}
+
+	return transitions
+}
@@ -0,0 +1,64 @@
+package statetrooper
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_withTimestampLocation(t *testing.T) {
+	zone := time.FixedZone("UTC+2", 2*60*60)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, zone)
+
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10,
+		WithTimeProvider[CustomStateEnum](func() time.Time {
+			return now
+		}),
+		WithTimestampLocation[CustomStateEnum](time.UTC),
+	)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumC)
+
+	if _, err := fsm.Transition(CustomStateEnumB, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	eventTime := time.Date(2024, 1, 1, 11, 0, 0, 0, zone)
+
+	if _, err := fsm.Transition(CustomStateEnumC, nil, WithEventTime(eventTime)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	transitions := fsm.Transitions()
+
+	for i, transition := range transitions {
+		if transition.Timestamp.Location() != time.UTC || transition.EventTime.Location() != time.UTC {
+			t.Errorf("expected transition %d to be recorded in UTC, got %v and %v",
+				i, transition.Timestamp.Location(), transition.EventTime.Location())
+		}
+	}
+
+	if !transitions[1].Timestamp.Equal(now) || !transitions[1].EventTime.Equal(eventTime) {
+		t.Errorf("expected the timestamps to denote the same instants, got %+v", transitions[1])
+	}
+
+	snapshot := Snapshot[CustomStateEnum]{
+		FormatVersion: SnapshotFormatVersion,
+		State:         CustomStateEnumB,
+		Version:       1,
+		Transitions: []Transition[CustomStateEnum]{
+			{FromState: CustomStateEnumA, ToState: CustomStateEnumB, Timestamp: now, EventTime: now},
+		},
+	}
+
+	if err := fsm.Restore(snapshot); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if loc := fsm.Transitions()[0].Timestamp.Location(); loc != time.UTC {
+		t.Errorf("expected the restored timestamp to be in UTC, got %v", loc)
+	}
+
+	if loc := snapshot.Transitions[0].Timestamp.Location(); loc != zone {
+		t.Errorf("expected the snapshot not to be modified, got %v", loc)
+	}
+}
@@ -0,0 +1,56 @@
+package statetrooper
+
+// WithNoLocking disables the FSM's locking for callers that guarantee the FSM is only ever accessed
+// from a single goroutine, e.g. in actor-per-entity architectures, removing the mutex overhead
+// The FSM must then not be used concurrently, including by SetStateTimeout, SetStateSLA, ScheduleTransition
+// and Handler, whose callbacks run on other goroutines
+// DEFAULT: locking enabled
+func WithNoLocking[T comparable]() FSMOption[T] {
+	return func(fsm *FSM[T]) {
+		fsm.noLocking = true
+	}
+}
+
+// lock acquires the lock for writing unless locking is disabled
+func (fsm *FSM[T]) lock() {
+	if !fsm.noLocking {
+		fsm.mu.Lock()
+	}
+}
+
+// unlock releases the lock for writing unless locking is disabled
+// Subscriber calls made while the lock was held are then queued on the hook pool, so a full queue
+// doesn't block the workers waiting for the lock
+func (fsm *FSM[T]) unlock() {
+	if len(fsm.pendingHooks) == 0 {
+		if !fsm.noLocking {
+			fsm.mu.Unlock()
+		}
+
+		return
+	}
+
+	jobs := fsm.pendingHooks
+	fsm.pendingHooks = nil
+	ticket := fsm.hookPool.ticket()
+
+	if !fsm.noLocking {
+		fsm.mu.Unlock()
+	}
+
+	fsm.hookPool.submitBatch(ticket, jobs)
+}
+
+// rlock acquires the lock for reading unless locking is disabled
+func (fsm *FSM[T]) rlock() {
+	if !fsm.noLocking {
+		fsm.mu.RLock()
+	}
+}
+
+// runlock releases the lock for reading unless locking is disabled
+func (fsm *FSM[T]) runlock() {
+	if !fsm.noLocking {
+		fsm.mu.RUnlock()
+	}
+}
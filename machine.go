@@ -0,0 +1,20 @@
+package statetrooper
+
+// Machine is the interface implemented by FSM for checking and making transitions
+// Depend on Machine instead of *FSM to mock the state machine in tests or wrap it with decorators,
+// e.g. for logging or metrics, without changing call sites
+type Machine[T comparable] interface {
+	// CanTransition checks if a transition from the current state to the target state is valid
+	CanTransition(targetState T) bool
+
+	// Transition transitions the entity from the current state to the target state
+	Transition(targetState T, metadata map[string]string, opts ...TransitionOption) (T, error)
+
+	// CurrentState returns the current state
+	CurrentState() T
+
+	// Transitions returns the transition history ordered from oldest to newest
+	Transitions() []Transition[T]
+}
+
+var _ Machine[string] = (*FSM[string])(nil)
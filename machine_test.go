@@ -0,0 +1,34 @@
+package statetrooper
+
+import "testing"
+
+// countingMachine decorates a Machine by counting the transitions made through it
+type countingMachine[T comparable] struct {
+	Machine[T]
+	count int
+}
+
+func (m *countingMachine[T]) Transition(targetState T, metadata map[string]string, opts ...TransitionOption) (T, error) {
+	m.count++
+
+	return m.Machine.Transition(targetState, metadata, opts...)
+}
+
+func Test_machineDecorator(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+
+	var machine Machine[CustomStateEnum] = &countingMachine[CustomStateEnum]{Machine: fsm}
+
+	if _, err := machine.Transition(CustomStateEnumB, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if machine.CurrentState() != CustomStateEnumB || len(machine.Transitions()) != 1 {
+		t.Errorf("expected the decorated FSM to transition, got %v", machine.CurrentState())
+	}
+
+	if count := machine.(*countingMachine[CustomStateEnum]).count; count != 1 {
+		t.Errorf("expected the decorator to count 1 transition, got %d", count)
+	}
+}
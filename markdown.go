@@ -0,0 +1,157 @@
+package statetrooper
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// GenerateMarkdownDoc generates a Markdown document describing the FSM's rules, e.g. for runbooks
+// It lists the states with their timeouts and SLAs, the allowed targets of each state with the
+// guards of its edges, the guards applying to every transition, and embeds a Mermaid diagram of the rules
+// Guards are named after the functions implementing them
+// In order to generate a document, T must be a string or have a String() method
+func (fsm *FSM[T]) GenerateMarkdownDoc() (string, error) {
+	fsm.rlock()
+	defer fsm.runlock()
+
+	diagram, err := fsm.mermaidRulesDiagram()
+	if err != nil {
+		return "", err
+	}
+
+	states := fsm.sortedStates()
+
+	sb := strings.Builder{}
+
+	sb.WriteString("# State machine\n\n## States\n\n")
+	sb.WriteString("| State | Terminal | Timeout | SLA |\n| --- | --- | --- | --- |\n")
+
+	for _, state := range states {
+		timeout := ""
+		if t, ok := fsm.timeouts[state]; ok {
+			timeout = fmt.Sprintf("%v, then %s", t.d, toString(t.fallback))
+		}
+
+		sla := ""
+		if d, ok := fsm.slas[state]; ok {
+			sla = d.String()
+		}
+
+		fmt.Fprintf(&sb, "| %s | %s | %s | %s |\n",
+			markdownCell(toString(state)), yesNo(len(fsm.ruleset[state]) == 0), markdownCell(timeout), sla)
+	}
+
+	sb.WriteString("\n## Rules\n\n")
+	sb.WriteString("| From | Allowed targets | Terminal | Guards |\n| --- | --- | --- | --- |\n")
+
+	for _, state := range states {
+		targets := make([]string, 0, len(fsm.ruleset[state]))
+		guards := []string{}
+
+		for _, target := range fsm.ruleset[state] {
+			targets = append(targets, toString(target))
+			guards = append(guards, fsm.edgeGuards(state, target)...)
+		}
+
+		sort.Strings(targets)
+
+		fmt.Fprintf(&sb, "| %s | %s | %s | %s |\n",
+			markdownCell(toString(state)),
+			markdownCell(strings.Join(targets, ", ")),
+			yesNo(len(targets) == 0),
+			markdownCell(strings.Join(guards, "; ")))
+	}
+
+	if guards := fsm.globalGuards(); len(guards) > 0 {
+		sb.WriteString("\n## Guards\n\nEvery transition is subject to:\n\n")
+
+		for _, guard := range guards {
+			fmt.Fprintf(&sb, "- %s\n", guard)
+		}
+	}
+
+	sb.WriteString("\n## Diagram\n\n```mermaid\n")
+	sb.WriteString(diagram)
+	sb.WriteString("```\n")
+
+	return sb.String(), nil
+}
+
+// sortedStates returns the states of the ruleset, sources and targets, sorted by name
+// The caller must hold the lock
+func (fsm *FSM[T]) sortedStates() []T {
+	return fsm.ruleset.sortedStates()
+}
+
+// edgeGuards describes the rate limit, compensation and automatic marking of an edge
+// The caller must hold the lock
+func (fsm *FSM[T]) edgeGuards(from, to T) []string {
+	var guards []string
+
+	e := edge[T]{from: from, to: to}
+
+	if bucket, ok := fsm.edgeRateLimits[e]; ok {
+		guards = append(guards, fmt.Sprintf("to %s: %s", toString(to), bucket))
+	}
+
+	if compensation, ok := fsm.compensations[e]; ok {
+		guards = append(guards, fmt.Sprintf("to %s: compensated by %s", toString(to), toString(compensation)))
+	}
+
+	for _, automatic := range fsm.automatic[from] {
+		if automatic.to == to {
+			guards = append(guards, fmt.Sprintf("to %s: automatic, priority %d", toString(to), automatic.priority))
+		}
+	}
+
+	return guards
+}
+
+// globalGuards describes the guards applying to every transition
+// The caller must hold the lock
+func (fsm *FSM[T]) globalGuards() []string {
+	var guards []string
+
+	if fsm.authorizer != nil {
+		guards = append(guards, fmt.Sprintf("authorizer `%s`", funcName(fsm.authorizer)))
+	}
+
+	if fsm.metadataValidator != nil {
+		guards = append(guards, fmt.Sprintf("metadata validator `%s`", funcName(fsm.metadataValidator)))
+	}
+
+	if fsm.rateLimit != nil {
+		guards = append(guards, fsm.rateLimit.String())
+	}
+
+	return guards
+}
+
+// funcName returns the name of a function without its package path, e.g. orders.validateRefund
+func funcName(fn interface{}) string {
+	f := runtime.FuncForPC(reflect.ValueOf(fn).Pointer())
+	if f == nil {
+		return "unknown"
+	}
+
+	name := f.Name()
+
+	return name[strings.LastIndex(name, "/")+1:]
+}
+
+// markdownCell escapes the pipes of a table cell
+func markdownCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+// yesNo formats a flag for a table cell
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+
+	return "no"
+}
@@ -0,0 +1,44 @@
+package statetrooper
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func validateTicket(from, to CustomStateEnum, metadata map[string]string) error {
+	if metadata["ticket"] == "" {
+		return errors.New("missing ticket")
+	}
+
+	return nil
+}
+
+func Test_generateMarkdownDoc(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10,
+		WithMetadataValidator[CustomStateEnum](validateTicket),
+		WithEdgeRateLimit[CustomStateEnum](CustomStateEnumA, CustomStateEnumB, 1, time.Minute),
+	)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB, CustomStateEnumC)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumC)
+	fsm.SetStateSLA(CustomStateEnumB, time.Hour)
+
+	doc, err := fsm.GenerateMarkdownDoc()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, expected := range []string{
+		"| B | no |  | 1h0m0s |\n",
+		"| C | yes |  |  |\n",
+		"| A | B, C | no | to B: rate limit of 1 per 1m0s |\n",
+		"| C |  | yes |  |\n",
+		"- metadata validator `statetrooper.validateTicket`\n",
+		"```mermaid\ngraph LR;\n",
+	} {
+		if !strings.Contains(doc, expected) {
+			t.Errorf("expected the document to contain %q, got:\n%s", expected, doc)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package statetrooper
+
+import (
+	"runtime/debug"
+	"time"
+)
+
+// Metrics receives internal timings from the FSM so that time spent waiting for the FSM's lock
+// and time spent in user supplied callbacks can be told apart from the total transition latency
+// Implementations are called synchronously from Transition and must be safe for concurrent use
+// Durations are measured using the monotonic clock, independently of the FSM's time provider
+type Metrics interface {
+	// ObserveLockWait is called with the time Transition spent waiting to acquire the FSM's lock
+	ObserveLockWait(d time.Duration)
+
+	// ObserveHook is called with the time spent in a user supplied callback such as the eviction handler
+	// It is called while the FSM is locked
+	ObserveHook(d time.Duration)
+
+	// ObserveTransition is called with the total latency of a Transition call, including
+	// the lock wait and callbacks, and the error it returned if any
+	ObserveTransition(d time.Duration, err error)
+}
+
+// WithMetrics sets the metrics receiver for the FSM
+// DEFAULT: nil, in which case no timings are measured
+func WithMetrics[T comparable](metrics Metrics) FSMOption[T] {
+	return func(fsm *FSM[T]) {
+		fsm.metrics = metrics
+	}
+}
+
+// runHook runs a user supplied callback, reporting the time spent in it if metrics are enabled
+// A panic in the callback is recovered and returned as a PanicError, so that a misbehaving callback
+// can't crash the goroutine holding the FSM's lock
+func (fsm *FSM[T]) runHook(hook func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+
+	if fsm.metrics == nil {
+		hook()
+
+		return nil
+	}
+
+	start := time.Now()
+	defer func() {
+		fsm.metrics.ObserveHook(time.Since(start))
+	}()
+
+	hook()
+
+	return nil
+}
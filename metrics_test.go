@@ -0,0 +1,78 @@
+package statetrooper
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingMetrics records the observations it receives for testing
+type recordingMetrics struct {
+	mu          sync.Mutex
+	lockWaits   []time.Duration
+	hooks       []time.Duration
+	transitions []time.Duration
+	errs        []error
+}
+
+func (m *recordingMetrics) ObserveLockWait(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.lockWaits = append(m.lockWaits, d)
+}
+
+func (m *recordingMetrics) ObserveHook(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.hooks = append(m.hooks, d)
+}
+
+func (m *recordingMetrics) ObserveTransition(d time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.transitions = append(m.transitions, d)
+	m.errs = append(m.errs, err)
+}
+
+func Test_withMetrics(t *testing.T) {
+	metrics := &recordingMetrics{}
+
+	fsm := NewFSM[CustomStateEnum](
+		CustomStateEnumA,
+		1,
+		WithMetrics[CustomStateEnum](metrics),
+		WithEvictionHandler[CustomStateEnum](func(Transition[CustomStateEnum]) {
+			time.Sleep(2 * time.Millisecond)
+		}),
+	)
+
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumC)
+
+	fsm.Transition(CustomStateEnumB, nil)
+	fsm.Transition(CustomStateEnumD, nil) // Invalid transition
+	fsm.Transition(CustomStateEnumC, nil) // Evicts the first transition
+
+	if len(metrics.lockWaits) != 3 || len(metrics.transitions) != 3 {
+		t.Fatalf("unexpected number of observations: %d lock waits, %d transitions, expected 3", len(metrics.lockWaits), len(metrics.transitions))
+	}
+
+	if metrics.errs[0] != nil || metrics.errs[1] == nil || metrics.errs[2] != nil {
+		t.Errorf("ObserveTransition received unexpected errors: %v", metrics.errs)
+	}
+
+	if len(metrics.hooks) != 1 {
+		t.Fatalf("ObserveHook called %d times, expected 1", len(metrics.hooks))
+	}
+
+	if metrics.hooks[0] < 2*time.Millisecond {
+		t.Errorf("ObserveHook received %v, expected at least the time spent in the eviction handler", metrics.hooks[0])
+	}
+
+	if metrics.transitions[2] < metrics.hooks[0] {
+		t.Errorf("ObserveTransition received %v, expected it to include the hook time %v", metrics.transitions[2], metrics.hooks[0])
+	}
+}
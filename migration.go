@@ -0,0 +1,65 @@
+package statetrooper
+
+// MetadataMigrated is the metadata key marking the synthetic transitions recorded by state migrations
+const MetadataMigrated = "migrated"
+
+// WithStateMigrations maps states removed or renamed in the ruleset to their replacements
+// When UnmarshalJSON or Restore loads an entity in a migrated state, the FSM moves to the replacement
+// and records the change as a transition whose metadata has MetadataMigrated set to "true"
+// Migrations can be chained, e.g. a state renamed twice is mapped to its latest name
+// As migrations are meant for ruleset changes, the ruleset fingerprint of the loaded data isn't checked
+// DEFAULT: no migrations
+func WithStateMigrations[T comparable](migrations map[T]T) FSMOption[T] {
+	return func(fsm *FSM[T]) {
+		fsm.migrations = make(map[T]T, len(migrations))
+		for from, to := range migrations {
+			fsm.migrations[from] = to
+		}
+	}
+}
+
+// migrate maps the current state to its replacement if it has been migrated
+// The caller must hold the lock
+func (fsm *FSM[T]) migrate() error {
+	target, ok := fsm.migrations[fsm.currentState]
+	if !ok {
+		return nil
+	}
+
+	// follow chained migrations, bounded in case the migrations form a cycle
+	for i := 0; i < len(fsm.migrations); i++ {
+		next, ok := fsm.migrations[target]
+		if !ok || next == target {
+			break
+		}
+
+		target = next
+	}
+
+	if target == fsm.currentState {
+		return nil
+	}
+
+	tn := fsm.now()
+
+	if fsm.maxHistory != 0 {
+		err := fsm.recordTransition(Transition[T]{
+			FromState: fsm.currentState,
+			ToState:   target,
+			Timestamp: tn,
+			EventTime: tn,
+			Metadata:  map[string]string{MetadataMigrated: "true"},
+			Version:   fsm.version + 1,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	fsm.setState(target)
+	fsm.version++
+	fsm.enter(tn, true)
+	fsm.entered(target)
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package statetrooper
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func Test_withStateMigrations(t *testing.T) {
+	// the data was exported when D existed and was later renamed to C
+	old := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	old.AddRule(CustomStateEnumA, CustomStateEnumD)
+
+	if _, err := old.Transition(CustomStateEnumD, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := json.Marshal(old)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10,
+		WithRulesetHashCheck[CustomStateEnum](),
+		WithStateMigrations(map[CustomStateEnum]CustomStateEnum{
+			CustomStateEnumD: CustomStateEnumB,
+			CustomStateEnumB: CustomStateEnumC,
+		}))
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumC)
+
+	if err := json.Unmarshal(data, fsm); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if fsm.CurrentState() != CustomStateEnumC {
+		t.Errorf("expected current state %v, got %v", CustomStateEnumC, fsm.CurrentState())
+	}
+
+	if fsm.Version() != 2 {
+		t.Errorf("expected the migration to increment the version, got %d", fsm.Version())
+	}
+
+	transitions := fsm.Transitions()
+	if len(transitions) != 2 {
+		t.Fatalf("expected 2 transitions, got %d", len(transitions))
+	}
+
+	migration := transitions[1]
+	if migration.FromState != CustomStateEnumD || migration.ToState != CustomStateEnumC || migration.Metadata[MetadataMigrated] != "true" {
+		t.Errorf("unexpected migration transition: %v", migration)
+	}
+
+	// restoring a snapshot applies the migrations as well
+	snapshot, err := old.Snapshot()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := fsm.Restore(snapshot); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if fsm.CurrentState() != CustomStateEnumC || fsm.Version() != 2 {
+		t.Errorf("expected state %v at version 2, got %v at version %d", CustomStateEnumC, fsm.CurrentState(), fsm.Version())
+	}
+}
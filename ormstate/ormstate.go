@@ -0,0 +1,296 @@
+/*
+Package ormstate embeds an FSM-backed state field in GORM and sqlx models.
+
+A State field holds the FSM of its entity. It is stored in its column like statetrooper.StateColumn and
+scanning it restores the FSM in the loaded state. The transitions made since are kept until saved, so
+they can be validated before the model is saved and appended to an audit table afterwards, in the same
+transaction. They are only dropped once the transaction commits and MarkSaved is called, so a rolled back
+save appends them again when retried:
+
+	type orderWorkflow struct{}
+
+	func (orderWorkflow) NewFSM() *statetrooper.FSM[OrderStatus] {
+		return statetrooper.NewFSMWithRuleset[OrderStatus](StatusCreated, 10, orderRules)
+	}
+
+	type Order struct {
+		ID     string
+		Status ormstate.State[OrderStatus, orderWorkflow]
+	}
+
+	func (o *Order) BeforeSave(tx *gorm.DB) error {
+		return o.Status.Validate()
+	}
+
+	func (o *Order) AfterSave(tx *gorm.DB) error {
+		return o.Status.AppendTransitions(tx.Statement.Context, tx.Statement.ConnPool, o.ID)
+	}
+
+	if err := db.Save(&order).Error; err == nil {
+		order.Status.MarkSaved()
+	}
+
+With sqlx, which has no hooks, call Validate before and AppendTransitions after the statement saving the model,
+passing the *sqlx.Tx, and MarkSaved once the transaction commits. The package only depends on database/sql.
+
+The audit table has the columns entity_id, from_state, to_state, timestamp, event_time, actor, reason and
+metadata, the metadata being stored as a JSON object. Metadata keys set with WithRedactedMetadataKeys are redacted.
+*/
+package ormstate
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/hishamk/statetrooper"
+)
+
+// ErrUntracked is returned by Validate when the state changed without a transition being committed,
+// e.g. after a Reset that wasn't recorded
+var ErrUntracked = errors.New("ormstate: state changed without a transition")
+
+// Definition creates the FSMs of a State field, in their initial state
+// It is usually implemented by an empty struct type
+type Definition[T comparable] interface {
+	NewFSM() *statetrooper.FSM[T]
+}
+
+// Execer is implemented by *sql.DB, *sql.Tx, *sqlx.DB, *sqlx.Tx and GORM's connection pools
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// Option is a function that sets an option of AppendTransitions
+type Option func(*config)
+
+// config holds the options of AppendTransitions
+type config struct {
+	auditTable  string
+	placeholder func(n int) string
+}
+
+// WithAuditTable sets the name of the table the transitions are appended to
+// DEFAULT: fsm_transitions
+func WithAuditTable(name string) Option {
+	return func(c *config) {
+		c.auditTable = name
+	}
+}
+
+// WithQuestionPlaceholders uses ? as the query placeholders, e.g. for MySQL and SQLite
+// DEFAULT: $1, $2... as used by Postgres
+func WithQuestionPlaceholders() Option {
+	return func(c *config) {
+		c.placeholder = func(int) string {
+			return "?"
+		}
+	}
+}
+
+// State is a model field holding the FSM of its entity, see the package documentation
+// The zero value holds an FSM created by D in its initial state, created on first use
+type State[T comparable, D Definition[T]] struct {
+	entity *entity[T]
+}
+
+// entity is shared by the copies of a State
+type entity[T comparable] struct {
+	mu      sync.Mutex
+	fsm     *statetrooper.FSM[T]
+	saved   T
+	pending []statetrooper.Transition[T]
+}
+
+// New returns a State holding the FSM, e.g. one restored from a snapshot
+// The FSM's current state is considered saved
+func New[T comparable, D Definition[T]](fsm *statetrooper.FSM[T]) State[T, D] {
+	return State[T, D]{entity: newEntity(fsm)}
+}
+
+// newEntity tracks the transitions of the FSM
+func newEntity[T comparable](fsm *statetrooper.FSM[T]) *entity[T] {
+	e := entity[T]{fsm: fsm, saved: fsm.CurrentState()}
+
+	fsm.Subscribe(func(transition statetrooper.Transition[T]) {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+
+		e.pending = append(e.pending, transition)
+	})
+
+	return &e
+}
+
+// init creates the FSM of a zero value State
+func (s *State[T, D]) init() *entity[T] {
+	if s.entity == nil {
+		var d D
+
+		s.entity = newEntity(d.NewFSM())
+	}
+
+	return s.entity
+}
+
+// FSM returns the FSM held by the field
+func (s *State[T, D]) FSM() *statetrooper.FSM[T] {
+	return s.init().fsm
+}
+
+// Current returns the current state
+func (s *State[T, D]) Current() T {
+	return s.init().fsm.CurrentState()
+}
+
+// Transition transitions the entity to the target state, see statetrooper.FSM.Transition
+func (s *State[T, D]) Transition(targetState T, metadata map[string]string, opts ...statetrooper.TransitionOption) (T, error) {
+	return s.init().fsm.Transition(targetState, metadata, opts...)
+}
+
+// Pending returns the transitions committed since the entity was loaded or last saved
+func (s *State[T, D]) Pending() []statetrooper.Transition[T] {
+	e := s.init()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return append([]statetrooper.Transition[T](nil), e.pending...)
+}
+
+// Validate checks that the pending transitions lead from the saved state to the current state and are allowed
+// by the ruleset as with statetrooper.FSM.ValidateHistory, e.g. in a BeforeSave hook
+// ErrUntracked is returned if the state changed without a transition being committed
+func (s *State[T, D]) Validate() error {
+	e := s.init()
+	current := e.fsm.CurrentState()
+
+	e.mu.Lock()
+	saved, pending := e.saved, append([]statetrooper.Transition[T](nil), e.pending...)
+	e.mu.Unlock()
+
+	if len(pending) == 0 {
+		if current != saved {
+			return ErrUntracked
+		}
+
+		return nil
+	}
+
+	if pending[0].FromState != saved || pending[len(pending)-1].ToState != current {
+		return ErrUntracked
+	}
+
+	return e.fsm.ValidateHistory(current, pending)
+}
+
+// MarkSaved considers the current state saved and drops the pending transitions, e.g. once the transaction
+// they were appended in with AppendTransitions commits, or without appending them
+func (s *State[T, D]) MarkSaved() {
+	e := s.init()
+	current := e.fsm.CurrentState()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.saved = current
+	e.pending = nil
+}
+
+// AppendTransitions appends the pending transitions of the entity to the audit table, e.g. in an AfterSave
+// hook running in the transaction saving the model
+// States are stored as their column value, see statetrooper.StateColumn, and metadata as a JSON object or NULL
+// The transitions stay pending until MarkSaved is called once the transaction commits, so they are appended
+// again if the transaction is rolled back and the save retried
+func (s *State[T, D]) AppendTransitions(ctx context.Context, exec Execer, entityID any, opts ...Option) error {
+	c := config{
+		auditTable: "fsm_transitions",
+		placeholder: func(n int) string {
+			return fmt.Sprintf("$%d", n)
+		},
+	}
+
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	placeholders := make([]string, 8)
+	for i := range placeholders {
+		placeholders[i] = c.placeholder(i + 1)
+	}
+
+	query := fmt.Sprintf(
+		"INSERT INTO %s (entity_id, from_state, to_state, timestamp, event_time, actor, reason, metadata) VALUES (%s)",
+		c.auditTable, strings.Join(placeholders, ", "),
+	)
+
+	for _, transition := range s.Pending() {
+		fromState, err := statetrooper.StateColumn[T]{State: transition.FromState}.Value()
+		if err != nil {
+			return err
+		}
+
+		toState, err := statetrooper.StateColumn[T]{State: transition.ToState}.Value()
+		if err != nil {
+			return err
+		}
+
+		var metadata any
+
+		if transition.Metadata != nil {
+			data, err := json.Marshal(transition.Metadata)
+			if err != nil {
+				return err
+			}
+
+			metadata = string(data)
+		}
+
+		_, err = exec.ExecContext(ctx, query, entityID, fromState, toState, transition.Timestamp,
+			transition.EventTime, transition.Actor, transition.Reason, metadata)
+		if err != nil {
+			return fmt.Errorf("ormstate: failed to append transition: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Value implements driver.Valuer, storing the current state
+func (s State[T, D]) Value() (driver.Value, error) {
+	return statetrooper.StateColumn[T]{State: s.Current()}.Value()
+}
+
+// Scan implements sql.Scanner, restoring the FSM in the scanned state with an empty history
+// Scanning a state not defined in the ruleset returns an UnknownStateError
+func (s *State[T, D]) Scan(src any) error {
+	e := s.init()
+
+	column := e.fsm.StateColumn()
+
+	err := column.Scan(src)
+	if err != nil {
+		return err
+	}
+
+	err = e.fsm.Restore(statetrooper.Snapshot[T]{
+		FormatVersion: statetrooper.SnapshotFormatVersion,
+		State:         column.State,
+	})
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.saved = column.State
+	e.pending = nil
+
+	return nil
+}
@@ -0,0 +1,197 @@
+package ormstate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hishamk/statetrooper"
+)
+
+type orderStatus string
+
+const (
+	statusCreated orderStatus = "created"
+	statusPicked  orderStatus = "picked"
+	statusPacked  orderStatus = "packed"
+)
+
+type orderWorkflow struct{}
+
+func (orderWorkflow) NewFSM() *statetrooper.FSM[orderStatus] {
+	fsm := statetrooper.NewFSM[orderStatus](statusCreated, 10)
+	fsm.AddRule(statusCreated, statusPicked)
+	fsm.AddRule(statusPicked, statusPacked)
+
+	return fsm
+}
+
+type order struct {
+	ID     string
+	Status State[orderStatus, orderWorkflow]
+}
+
+// execRecorder records the statements it is given
+type execRecorder struct {
+	queries []string
+	args    [][]any
+	err     error
+}
+
+func (r *execRecorder) ExecContext(_ context.Context, query string, args ...any) (sql.Result, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	r.queries = append(r.queries, query)
+	r.args = append(r.args, args)
+
+	return nil, nil
+}
+
+func TestStateScanValue(t *testing.T) {
+	var o order
+
+	if value, err := o.Status.Value(); err != nil || value != "created" {
+		t.Errorf("Value() = %v, %v, expected the initial state", value, err)
+	}
+
+	if err := o.Status.Scan("picked"); err != nil {
+		t.Fatalf("Scan() returned an error: %v", err)
+	}
+
+	if o.Status.Current() != statusPicked {
+		t.Errorf("expected the FSM to be restored in picked, got %v", o.Status.Current())
+	}
+
+	if _, err := o.Status.Transition(statusPacked, nil); err != nil {
+		t.Fatalf("Transition() returned an error: %v", err)
+	}
+
+	if value, err := o.Status.Value(); err != nil || value != "packed" {
+		t.Errorf("Value() = %v, %v, expected packed", value, err)
+	}
+
+	var unknown statetrooper.UnknownStateError[orderStatus]
+	if err := o.Status.Scan("lost"); !errors.As(err, &unknown) {
+		t.Errorf("Scan() returned %v, expected an UnknownStateError", err)
+	}
+}
+
+func TestStateValidate(t *testing.T) {
+	var o order
+
+	if err := o.Status.Scan("created"); err != nil {
+		t.Fatalf("Scan() returned an error: %v", err)
+	}
+
+	if err := o.Status.Validate(); err != nil {
+		t.Errorf("Validate() returned an error for an unchanged state: %v", err)
+	}
+
+	if _, err := o.Status.Transition(statusPicked, nil); err != nil {
+		t.Fatalf("Transition() returned an error: %v", err)
+	}
+
+	if err := o.Status.Validate(); err != nil {
+		t.Errorf("Validate() returned an error: %v", err)
+	}
+
+	if err := o.Status.FSM().Reset(statusPacked); err != nil {
+		t.Fatalf("Reset() returned an error: %v", err)
+	}
+
+	if err := o.Status.Validate(); !errors.Is(err, ErrUntracked) {
+		t.Errorf("Validate() returned %v, expected ErrUntracked", err)
+	}
+}
+
+func TestStateAppendTransitions(t *testing.T) {
+	o := order{ID: "42", Status: New[orderStatus, orderWorkflow](orderWorkflow{}.NewFSM())}
+
+	if _, err := o.Status.Transition(statusPicked, map[string]string{"by": "alice"}, statetrooper.WithActor("alice")); err != nil {
+		t.Fatalf("Transition() returned an error: %v", err)
+	}
+
+	if _, err := o.Status.Transition(statusPacked, nil); err != nil {
+		t.Fatalf("Transition() returned an error: %v", err)
+	}
+
+	failing := &execRecorder{err: errors.New("connection reset")}
+	if err := o.Status.AppendTransitions(context.Background(), failing, o.ID); err == nil {
+		t.Fatal("AppendTransitions() is expected to fail")
+	}
+
+	if n := len(o.Status.Pending()); n != 2 {
+		t.Fatalf("expected the transitions to stay pending after a failure, got %d", n)
+	}
+
+	rec := &execRecorder{}
+	if err := o.Status.AppendTransitions(context.Background(), rec, o.ID, WithAuditTable("order_audit"), WithQuestionPlaceholders()); err != nil {
+		t.Fatalf("AppendTransitions() returned an error: %v", err)
+	}
+
+	if len(rec.queries) != 2 {
+		t.Fatalf("expected 2 rows to be appended, got %d", len(rec.queries))
+	}
+
+	expectedQuery := "INSERT INTO order_audit (entity_id, from_state, to_state, timestamp, event_time, actor, reason, metadata) " +
+		"VALUES (?, ?, ?, ?, ?, ?, ?, ?)"
+	if rec.queries[0] != expectedQuery {
+		t.Errorf("unexpected query:\n%s\nexpected:\n%s", rec.queries[0], expectedQuery)
+	}
+
+	first := rec.args[0]
+	if !reflect.DeepEqual([]any{first[0], first[1], first[2], first[5], first[7]}, []any{"42", "created", "picked", "alice", `{"by":"alice"}`}) {
+		t.Errorf("unexpected arguments: %v", first)
+	}
+
+	if rec.args[1][7] != nil {
+		t.Errorf("expected NULL metadata, got %v", rec.args[1][7])
+	}
+
+	// the transaction may still be rolled back, so the transitions stay pending until it commits
+	if n := len(o.Status.Pending()); n != 2 {
+		t.Fatalf("expected the transitions to stay pending until saved, got %d", n)
+	}
+
+	rec = &execRecorder{}
+	if err := o.Status.AppendTransitions(context.Background(), rec, o.ID); err != nil || len(rec.queries) != 2 {
+		t.Fatalf("expected the transitions to be appended again after a rollback, got %v, %v", rec.queries, err)
+	}
+
+	o.Status.MarkSaved()
+
+	if n := len(o.Status.Pending()); n != 0 {
+		t.Errorf("expected no pending transitions once saved, got %d", n)
+	}
+
+	if err := o.Status.Validate(); err != nil {
+		t.Errorf("Validate() returned an error once saved: %v", err)
+	}
+
+	rec = &execRecorder{}
+	if err := o.Status.AppendTransitions(context.Background(), rec, o.ID); err != nil || len(rec.queries) != 0 {
+		t.Errorf("expected nothing to be appended, got %v, %v", rec.queries, err)
+	}
+}
+
+func TestStateDefaultQuery(t *testing.T) {
+	var o order
+
+	if _, err := o.Status.Transition(statusPicked, nil); err != nil {
+		t.Fatalf("Transition() returned an error: %v", err)
+	}
+
+	rec := &execRecorder{}
+	if err := o.Status.AppendTransitions(context.Background(), rec, 7); err != nil {
+		t.Fatalf("AppendTransitions() returned an error: %v", err)
+	}
+
+	if !strings.HasPrefix(rec.queries[0], "INSERT INTO fsm_transitions ") || !strings.HasSuffix(rec.queries[0], "($1, $2, $3, $4, $5, $6, $7, $8)") {
+		t.Errorf("unexpected query: %s", rec.queries[0])
+	}
+}
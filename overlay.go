@@ -0,0 +1,230 @@
+package statetrooper
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// overlayColor is the color of the edges taken in overlay diagrams
+const overlayColor = "#e4572e"
+
+// GenerateMermaidOverlayDiagram generates a Mermaid.js diagram of the FSM's rules overlaid with its history,
+// to spot the unused and hot paths
+// Edges taken are drawn thick and colored, labeled with the number of times they were taken. Transitions
+// the rules don't allow, e.g. forced ones, are drawn dotted. The current state is outlined
+// In order to generate a diagram, T must be a string or have a String() method
+func (fsm *FSM[T]) GenerateMermaidOverlayDiagram() (string, error) {
+	fsm.rlock()
+	defer fsm.runlock()
+
+	if len(fsm.ruleset) == 0 {
+		return "", fmt.Errorf("no rules defined")
+	}
+
+	if !stringable(fsm.currentState) {
+		return "", fmt.Errorf("type T is not a string or does not have a String() method")
+	}
+
+	transitions, err := fsm.history().List()
+	if err != nil {
+		return "", err
+	}
+
+	counts := make(map[edge[T]]int)
+	for _, transition := range transitions {
+		counts[edge[T]{from: transition.FromState, to: transition.ToState}] += compactedCount(transition)
+	}
+
+	return overlayDiagram(fsm.ruleset, counts, map[T]int{fsm.currentState: 1}, false), nil
+}
+
+// overlayDiagram generates a Mermaid.js diagram of the rules overlaid with the number of times each edge
+// was taken and the number of entities in each state
+// If nodeCounts is set, nodes are labeled with their number of entities, otherwise the states
+// with entities are only outlined
+func overlayDiagram[T comparable](rs Ruleset[T], counts map[edge[T]]int, current map[T]int, nodeCounts bool) string {
+	type link struct {
+		from, to string
+		count    int
+		allowed  bool
+	}
+
+	states := make(map[T]struct{})
+	links := make([]link, 0, len(counts))
+	seen := make(map[edge[T]]bool, len(counts))
+
+	for from, targets := range rs {
+		states[from] = struct{}{}
+
+		for _, to := range targets {
+			states[to] = struct{}{}
+
+			e := edge[T]{from: from, to: to}
+			seen[e] = true
+			links = append(links, link{from: toString(from), to: toString(to), count: counts[e], allowed: true})
+		}
+	}
+
+	for e, count := range counts {
+		states[e.from] = struct{}{}
+		states[e.to] = struct{}{}
+
+		if !seen[e] {
+			links = append(links, link{from: toString(e.from), to: toString(e.to), count: count})
+		}
+	}
+
+	for state := range current {
+		states[state] = struct{}{}
+	}
+
+	sort.Slice(links, func(i, j int) bool {
+		if links[i].from != links[j].from {
+			return links[i].from < links[j].from
+		}
+
+		return links[i].to < links[j].to
+	})
+
+	names := make([]string, 0, len(states))
+	byName := make(map[string]T, len(states))
+
+	for state := range states {
+		name := toString(state)
+		names = append(names, name)
+		byName[name] = state
+	}
+
+	sort.Strings(names)
+
+	sb := strings.Builder{}
+
+	sb.WriteString("graph LR;\n")
+
+	for _, name := range names {
+		n, ok := current[byName[name]]
+
+		switch {
+		case nodeCounts:
+			fmt.Fprintf(&sb, "%s[\"%s (%d)\"];\n", diagramID(name), mermaidLabelReplacer.Replace(name), n)
+		default:
+			fmt.Fprintf(&sb, "%s;\n", mermaidNode(name))
+		}
+
+		if ok && n > 0 {
+			fmt.Fprintf(&sb, "style %s stroke-width:3px;\n", diagramID(name))
+		}
+	}
+
+	var taken []string
+
+	for i, l := range links {
+		from, to := diagramID(l.from), diagramID(l.to)
+
+		switch {
+		case !l.allowed:
+			fmt.Fprintf(&sb, "%s -.->|\"%d\"| %s;\n", from, l.count, to)
+		case l.count > 0:
+			fmt.Fprintf(&sb, "%s ==>|\"%d\"| %s;\n", from, l.count, to)
+		default:
+			fmt.Fprintf(&sb, "%s --> %s;\n", from, to)
+		}
+
+		if l.count > 0 {
+			taken = append(taken, strconv.Itoa(i))
+		}
+	}
+
+	if len(taken) > 0 {
+		fmt.Fprintf(&sb, "linkStyle %s stroke:%s,stroke-width:3px;\n", strings.Join(taken, ","), overlayColor)
+	}
+
+	return sb.String()
+}
+
+// GenerateMermaidAggregateDiagram generates a Mermaid.js funnel view of many FSMs, e.g. all the orders
+// It draws the union of their rules with every edge labeled with the number of transitions the FSMs made
+// through it, as recorded in their histories, and every node with the number of FSMs currently in the state
+// In order to generate a diagram, T must be a string or have a String() method
+func GenerateMermaidAggregateDiagram[T comparable](fsms []*FSM[T]) (string, error) {
+	rs := make(Ruleset[T])
+	counts := make(map[edge[T]]int)
+	current := make(map[T]int)
+
+	for _, fsm := range fsms {
+		err := fsm.aggregate(rs, counts, current)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if len(rs) == 0 {
+		return "", fmt.Errorf("no rules defined")
+	}
+
+	return overlayDiagram(rs, counts, current, true), nil
+}
+
+// aggregate adds the FSM's rules, edge counts and current state to the aggregated ones
+func (fsm *FSM[T]) aggregate(rs Ruleset[T], counts map[edge[T]]int, current map[T]int) error {
+	fsm.rlock()
+	defer fsm.runlock()
+
+	if !stringable(fsm.currentState) {
+		return fmt.Errorf("type T is not a string or does not have a String() method")
+	}
+
+	for from, targets := range fsm.ruleset {
+		for _, to := range targets {
+			rs.add(from, to)
+		}
+	}
+
+	transitions, err := fsm.history().List()
+	if err != nil {
+		return err
+	}
+
+	for _, transition := range transitions {
+		counts[edge[T]{from: transition.FromState, to: transition.ToState}] += compactedCount(transition)
+	}
+
+	current[fsm.currentState]++
+
+	return nil
+}
+
+// GenerateMermaidAggregateDiagram generates a Mermaid.js funnel view of the pool's entities
+// Edges are labeled with the number of transitions made through them since the pool was created,
+// and nodes with the number of entities currently in the state
+// In order to generate a diagram, T must be a string or have a String() method
+func (pool *FSMPool[T]) GenerateMermaidAggregateDiagram() (string, error) {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	if pool.compiled.n == 0 {
+		return "", fmt.Errorf("no rules defined")
+	}
+
+	if !stringable(pool.compiled.states[0]) {
+		return "", fmt.Errorf("type T is not a string or does not have a String() method")
+	}
+
+	counts := make(map[edge[T]]int)
+
+	for i := range pool.edgeCounts {
+		if count := pool.edgeCounts[i].Load(); count > 0 {
+			from, to := pool.compiled.states[i/pool.compiled.n], pool.compiled.states[i%pool.compiled.n]
+			counts[edge[T]{from: from, to: to}] = int(count)
+		}
+	}
+
+	current := make(map[T]int)
+	for i := range pool.entities {
+		current[pool.compiled.states[pool.entities[i].Load()]]++
+	}
+
+	return overlayDiagram(pool.ruleset(), counts, current, true), nil
+}
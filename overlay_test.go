@@ -0,0 +1,104 @@
+package statetrooper
+
+import "testing"
+
+func Test_generateMermaidOverlayDiagram(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB, CustomStateEnumC)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumA)
+
+	for _, state := range []CustomStateEnum{CustomStateEnumB, CustomStateEnumA, CustomStateEnumB} {
+		if _, err := fsm.Transition(state, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if _, err := fsm.ForceTransition(CustomStateEnumD, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	d, err := fsm.GenerateMermaidOverlayDiagram()
+	if err != nil {
+		t.Fatalf("GenerateMermaidOverlayDiagram() returned an error: %v", err)
+	}
+
+	expectedDiagram := "graph LR;\nA;\nB;\nC;\nD;\nstyle D stroke-width:3px;\n" +
+		"A ==>|\"2\"| B;\n" +
+		"A --> C;\n" +
+		"B ==>|\"1\"| A;\n" +
+		"B -.->|\"1\"| D;\n" +
+		"linkStyle 0,2,3 stroke:#e4572e,stroke-width:3px;\n"
+
+	if d != expectedDiagram {
+		t.Errorf("GenerateMermaidOverlayDiagram() returned an unexpected diagram:\n%s\nexpected:\n%s", d, expectedDiagram)
+	}
+}
+
+func Test_generateMermaidAggregateDiagram(t *testing.T) {
+	var fsms []*FSM[CustomStateEnum]
+
+	for _, path := range [][]CustomStateEnum{
+		{CustomStateEnumB, CustomStateEnumC},
+		{CustomStateEnumB},
+		{},
+	} {
+		fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+		fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+		fsm.AddRule(CustomStateEnumB, CustomStateEnumC)
+
+		for _, state := range path {
+			if _, err := fsm.Transition(state, nil); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+
+		fsms = append(fsms, fsm)
+	}
+
+	d, err := GenerateMermaidAggregateDiagram(fsms)
+	if err != nil {
+		t.Fatalf("GenerateMermaidAggregateDiagram() returned an error: %v", err)
+	}
+
+	expectedDiagram := "graph LR;\n" +
+		"A[\"A (1)\"];\nstyle A stroke-width:3px;\n" +
+		"B[\"B (1)\"];\nstyle B stroke-width:3px;\n" +
+		"C[\"C (1)\"];\nstyle C stroke-width:3px;\n" +
+		"A ==>|\"2\"| B;\n" +
+		"B ==>|\"1\"| C;\n" +
+		"linkStyle 0,1 stroke:#e4572e,stroke-width:3px;\n"
+
+	if d != expectedDiagram {
+		t.Errorf("GenerateMermaidAggregateDiagram() returned an unexpected diagram:\n%s\nexpected:\n%s", d, expectedDiagram)
+	}
+
+	pool, err := NewFSMPool[CustomStateEnum](Ruleset[CustomStateEnum]{
+		CustomStateEnumA: {CustomStateEnumB},
+		CustomStateEnumB: {CustomStateEnumC},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, path := range [][]CustomStateEnum{{CustomStateEnumB, CustomStateEnumC}, {CustomStateEnumB}, {}} {
+		id, err := pool.Add(CustomStateEnumA)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		for _, state := range path {
+			if _, err := pool.Transition(id, state, nil); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+	}
+
+	d, err = pool.GenerateMermaidAggregateDiagram()
+	if err != nil {
+		t.Fatalf("GenerateMermaidAggregateDiagram() returned an error: %v", err)
+	}
+
+	if d != expectedDiagram {
+		t.Errorf("FSMPool.GenerateMermaidAggregateDiagram() returned an unexpected diagram:\n%s\nexpected:\n%s", d, expectedDiagram)
+	}
+}
@@ -0,0 +1,418 @@
+/*
+Package pgstore persists statetrooper FSMs to Postgres.
+
+The current state and version of each entity are stored in a states table and
+every transition is appended to a transitions table. Writes use optimistic
+locking on the version column so concurrent writers of the same entity are
+detected instead of silently overwriting each other.
+
+All helpers run inside a caller-supplied transaction, so persisting the FSM can
+be committed or rolled back together with the caller's own changes.
+
+States and metadata are stored as JSONB, so any state type that can be
+marshaled to JSON is supported. The package only depends on database/sql; any
+Postgres driver can be used.
+*/
+package pgstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hishamk/statetrooper"
+)
+
+// ErrNotFound is returned when no state is stored for an entity
+var ErrNotFound = errors.New("pgstore: entity not found")
+
+// ErrVersionConflict is returned when the stored version of an entity doesn't match the expected version
+// because another writer has changed it in the meantime
+var ErrVersionConflict = errors.New("pgstore: version conflict")
+
+// Querier is implemented by *sql.Tx and *sql.DB
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// Option is a function that sets an option on the Store
+type Option func(*config)
+
+// config holds the options for the Store
+type config struct {
+	statesTable      string
+	transitionsTable string
+	historyLimit     int
+}
+
+// WithStatesTable sets the name of the table holding the current state of each entity
+// DEFAULT: fsm_states
+func WithStatesTable(name string) Option {
+	return func(c *config) {
+		c.statesTable = name
+	}
+}
+
+// WithTransitionsTable sets the name of the table holding the transition history of each entity
+// DEFAULT: fsm_transitions
+func WithTransitionsTable(name string) Option {
+	return func(c *config) {
+		c.transitionsTable = name
+	}
+}
+
+// WithHistoryLimit sets the number of most recent transitions loaded into the FSM's history
+// DEFAULT: 0, which loads every stored transition
+func WithHistoryLimit(limit int) Option {
+	return func(c *config) {
+		c.historyLimit = limit
+	}
+}
+
+// Store persists FSMs with states of type T to Postgres
+type Store[T comparable] struct {
+	config
+}
+
+// New creates a new Store
+func New[T comparable](opts ...Option) *Store[T] {
+	s := Store[T]{
+		config: config{
+			statesTable:      "fsm_states",
+			transitionsTable: "fsm_transitions",
+		},
+	}
+
+	for _, opt := range opts {
+		opt(&s.config)
+	}
+
+	return &s
+}
+
+// Schema returns the DDL statements creating the tables used by the Store
+// The statements are idempotent
+func (s *Store[T]) Schema() string {
+	return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %[1]s (
+	entity_id TEXT PRIMARY KEY,
+	state JSONB NOT NULL,
+	version BIGINT NOT NULL,
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS %[2]s (
+	entity_id TEXT NOT NULL,
+	seq BIGINT NOT NULL,
+	from_state JSONB NOT NULL,
+	to_state JSONB NOT NULL,
+	timestamp TIMESTAMPTZ NOT NULL,
+	event_time TIMESTAMPTZ NOT NULL,
+	metadata JSONB,
+	actor TEXT NOT NULL DEFAULT '',
+	reason TEXT NOT NULL DEFAULT '',
+	PRIMARY KEY (entity_id, seq)
+);
+`, s.statesTable, s.transitionsTable)
+}
+
+// Load loads the current state, version and transition history of the entity into the FSM
+// If no state is stored for the entity, ErrNotFound is returned and the FSM is not changed
+func (s *Store[T]) Load(ctx context.Context, tx Querier, entityID string, fsm *statetrooper.FSM[T]) error {
+	var snap struct {
+		CurrentState json.RawMessage   `json:"current_state"`
+		Version      uint64            `json:"version"`
+		Transitions  []json.RawMessage `json:"transitions"`
+	}
+
+	err := tx.QueryRowContext(
+		ctx,
+		fmt.Sprintf("SELECT state, version FROM %s WHERE entity_id = $1", s.statesTable),
+		entityID,
+	).Scan(&snap.CurrentState, &snap.Version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+
+	if err != nil {
+		return fmt.Errorf("pgstore: failed to load state: %w", err)
+	}
+
+	query := fmt.Sprintf(
+		"SELECT seq, from_state, to_state, timestamp, event_time, actor, reason, metadata FROM %s WHERE entity_id = $1 ORDER BY seq DESC",
+		s.transitionsTable,
+	)
+
+	args := []any{entityID}
+	if s.historyLimit > 0 {
+		query += " LIMIT $2"
+		args = append(args, s.historyLimit)
+	}
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("pgstore: failed to load transitions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row struct {
+			FromState json.RawMessage `json:"from_state"`
+			ToState   json.RawMessage `json:"to_state"`
+			Timestamp time.Time       `json:"timestamp"`
+			EventTime time.Time       `json:"event_time"`
+			Actor     string          `json:"actor"`
+			Reason    string          `json:"reason"`
+			Metadata  json.RawMessage `json:"metadata"`
+			Version   uint64          `json:"version"`
+		}
+
+		// Metadata is nullable so it is scanned into a byte slice first
+		var metadata []byte
+
+		// The sequence number is the FSM's version once the transition was committed
+		var seq int64
+
+		err = rows.Scan(&seq, &row.FromState, &row.ToState, &row.Timestamp, &row.EventTime, &row.Actor, &row.Reason, &metadata)
+		if err != nil {
+			return fmt.Errorf("pgstore: failed to load transitions: %w", err)
+		}
+
+		row.Version = uint64(seq)
+		row.Metadata = json.RawMessage("null")
+		if len(metadata) > 0 {
+			row.Metadata = metadata
+		}
+
+		transition, err := json.Marshal(row)
+		if err != nil {
+			return err
+		}
+
+		snap.Transitions = append(snap.Transitions, transition)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return fmt.Errorf("pgstore: failed to load transitions: %w", err)
+	}
+
+	// Transitions are queried newest first so the limit keeps the most recent ones
+	for i, j := 0, len(snap.Transitions)-1; i < j; i, j = i+1, j-1 {
+		snap.Transitions[i], snap.Transitions[j] = snap.Transitions[j], snap.Transitions[i]
+	}
+
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+
+	return fsm.UnmarshalJSON(data)
+}
+
+// Save stores the current state and version of the FSM along with the transitions in its history
+// that haven't been stored yet
+// expectedVersion is the version of the entity as it was loaded, 0 for an entity that hasn't been stored yet
+// If the stored version doesn't match, ErrVersionConflict is returned
+func (s *Store[T]) Save(ctx context.Context, tx Querier, entityID string, fsm *statetrooper.FSM[T], expectedVersion uint64) error {
+	// Snapshots keep the metadata values redacted from the FSM's JSON
+	snap, err := fsm.Snapshot()
+	if err != nil {
+		return err
+	}
+
+	err = s.saveState(ctx, tx, entityID, snap.State, snap.Version, expectedVersion)
+	if err != nil {
+		return err
+	}
+
+	for i, transition := range snap.Transitions {
+		seq := sequence(snap, i)
+		if seq <= int64(expectedVersion) {
+			continue
+		}
+
+		err = s.insertTransition(ctx, tx, entityID, seq, transition)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// TransitionTx transitions the FSM to the target state and persists the change within the transaction
+// The stored version of the entity must match the version of the FSM, otherwise ErrVersionConflict is returned
+// and the FSM is not changed
+// The state and version the FSM ends in are stored, e.g. after following automatic edges, along with every
+// transition it recorded. A transition committed despite a failing subscriber or hook is persisted and
+// its error returned
+// If persisting the transition fails after the FSM has transitioned, the caller should roll back the
+// transaction and reload the FSM
+func (s *Store[T]) TransitionTx(
+	ctx context.Context,
+	tx Querier,
+	entityID string,
+	fsm *statetrooper.FSM[T],
+	targetState T,
+	metadata map[string]string,
+	opts ...statetrooper.TransitionOption,
+) (T, error) {
+	version := fsm.Version()
+	currentState := fsm.CurrentState()
+
+	if !fsm.CanTransition(targetState) {
+		return currentState, statetrooper.TransitionError[T]{
+			FromState: currentState,
+			ToState:   targetState,
+		}
+	}
+
+	// The row is locked so its version can't change between this check and the update
+	var stored int64
+
+	err := tx.QueryRowContext(
+		ctx,
+		fmt.Sprintf("SELECT version FROM %s WHERE entity_id = $1 FOR UPDATE", s.statesTable),
+		entityID,
+	).Scan(&stored)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return currentState, fmt.Errorf("pgstore: failed to load state: %w", err)
+	}
+
+	if uint64(stored) != version {
+		return currentState, ErrVersionConflict
+	}
+
+	committed := false
+
+	// The FSM must not have moved on since its version was read
+	newState, transitionErr := fsm.TransitionIfVersion(version, targetState, metadata, append(opts, statetrooper.WithCommitted(&committed))...)
+	if !committed {
+		return newState, transitionErr
+	}
+
+	snap, err := fsm.Snapshot()
+	if err != nil {
+		return newState, err
+	}
+
+	err = s.saveState(ctx, tx, entityID, snap.State, snap.Version, version)
+	if err != nil {
+		return newState, err
+	}
+
+	// Automatic edges and deferred transitions may have recorded more than one transition
+	for i := range snap.Transitions {
+		seq := sequence(snap, i)
+		if seq <= int64(version) {
+			continue
+		}
+
+		err = s.insertTransition(ctx, tx, entityID, seq, snap.Transitions[i])
+		if err != nil {
+			return newState, err
+		}
+	}
+
+	return newState, transitionErr
+}
+
+// sequence returns the sequence number of the i-th transition of the snapshot, the FSM's version once it
+// was committed, which trimming, compaction and resets don't change
+// Transitions recorded without a version, e.g. replayed ones, are numbered back from the snapshot's version
+func sequence[T comparable](snap statetrooper.Snapshot[T], i int) int64 {
+	if version := snap.Transitions[i].Version; version != 0 {
+		return int64(version)
+	}
+
+	return int64(snap.Version) - int64(len(snap.Transitions)-1-i)
+}
+
+// saveState writes the state and version of the entity if its stored version matches the expected version
+func (s *Store[T]) saveState(ctx context.Context, tx Querier, entityID string, state T, version, expectedVersion uint64) error {
+	stateJSON, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	var result sql.Result
+
+	// An entity at version 0 may or may not have been stored yet
+	if expectedVersion == 0 {
+		result, err = tx.ExecContext(
+			ctx,
+			fmt.Sprintf(
+				"INSERT INTO %[1]s (entity_id, state, version, updated_at) VALUES ($1, $2, $3, now()) "+
+					"ON CONFLICT (entity_id) DO UPDATE SET state = EXCLUDED.state, version = EXCLUDED.version, updated_at = now() "+
+					"WHERE %[1]s.version = 0",
+				s.statesTable,
+			),
+			entityID, stateJSON, int64(version),
+		)
+	} else {
+		result, err = tx.ExecContext(
+			ctx,
+			fmt.Sprintf(
+				"UPDATE %s SET state = $2, version = $3, updated_at = now() WHERE entity_id = $1 AND version = $4",
+				s.statesTable,
+			),
+			entityID, stateJSON, int64(version), int64(expectedVersion),
+		)
+	}
+
+	if err != nil {
+		return fmt.Errorf("pgstore: failed to save state: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("pgstore: failed to save state: %w", err)
+	}
+
+	if affected == 0 {
+		return ErrVersionConflict
+	}
+
+	return nil
+}
+
+// insertTransition appends a transition to the history of the entity
+func (s *Store[T]) insertTransition(ctx context.Context, tx Querier, entityID string, seq int64, transition statetrooper.Transition[T]) error {
+	fromState, err := json.Marshal(transition.FromState)
+	if err != nil {
+		return err
+	}
+
+	toState, err := json.Marshal(transition.ToState)
+	if err != nil {
+		return err
+	}
+
+	var metadata []byte
+	if transition.Metadata != nil {
+		metadata, err = json.Marshal(transition.Metadata)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		fmt.Sprintf(
+			"INSERT INTO %s (entity_id, seq, from_state, to_state, timestamp, event_time, metadata, actor, reason) "+
+				"VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) ON CONFLICT (entity_id, seq) DO NOTHING",
+			s.transitionsTable,
+		),
+		entityID, seq, fromState, toState, transition.Timestamp, transition.EventTime, metadata, transition.Actor, transition.Reason,
+	)
+	if err != nil {
+		return fmt.Errorf("pgstore: failed to save transition: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,455 @@
+package pgstore
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hishamk/statetrooper"
+)
+
+// fakeDB is an in-memory stand-in for Postgres that understands the queries issued by the Store
+type fakeDB struct {
+	mu          sync.Mutex
+	states      map[string]fakeState
+	transitions map[string][]fakeTransition
+}
+
+type fakeState struct {
+	state   []byte
+	version int64
+}
+
+type fakeTransition struct {
+	seq       int64
+	fromState []byte
+	toState   []byte
+	timestamp time.Time
+	eventTime time.Time
+	actor     string
+	reason    string
+	metadata  []byte
+}
+
+var (
+	fakeDBs   = map[string]*fakeDB{}
+	fakeDBsMu sync.Mutex
+)
+
+func init() {
+	sql.Register("pgstore-fake", fakeDriver{})
+}
+
+// openFakeDB opens a new, empty fake database
+func openFakeDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	fakeDBsMu.Lock()
+	fakeDBs[t.Name()] = &fakeDB{
+		states:      map[string]fakeState{},
+		transitions: map[string][]fakeTransition{},
+	}
+	fakeDBsMu.Unlock()
+
+	db, err := sql.Open("pgstore-fake", t.Name())
+	if err != nil {
+		t.Fatalf("failed to open fake database: %v", err)
+	}
+
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	return db
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	fakeDBsMu.Lock()
+	defer fakeDBsMu.Unlock()
+
+	return &fakeConn{db: fakeDBs[name]}, nil
+}
+
+type fakeConn struct {
+	db *fakeDB
+}
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("prepared statements are not supported")
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return fakeTx{}, nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error {
+	return nil
+}
+
+func (fakeTx) Rollback() error {
+	return nil
+}
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+
+	switch {
+	case strings.HasPrefix(query, "INSERT INTO fsm_states"):
+		id := args[0].Value.(string)
+		if st, ok := c.db.states[id]; ok && st.version != 0 {
+			return driver.RowsAffected(0), nil
+		}
+
+		c.db.states[id] = fakeState{state: args[1].Value.([]byte), version: args[2].Value.(int64)}
+
+		return driver.RowsAffected(1), nil
+	case strings.HasPrefix(query, "UPDATE fsm_states"):
+		id := args[0].Value.(string)
+		if st, ok := c.db.states[id]; !ok || st.version != args[3].Value.(int64) {
+			return driver.RowsAffected(0), nil
+		}
+
+		c.db.states[id] = fakeState{state: args[1].Value.([]byte), version: args[2].Value.(int64)}
+
+		return driver.RowsAffected(1), nil
+	case strings.HasPrefix(query, "INSERT INTO fsm_transitions"):
+		id := args[0].Value.(string)
+		seq := args[1].Value.(int64)
+
+		for _, tr := range c.db.transitions[id] {
+			if tr.seq == seq {
+				return driver.RowsAffected(0), nil
+			}
+		}
+
+		var metadata []byte
+		if args[6].Value != nil {
+			metadata = args[6].Value.([]byte)
+		}
+
+		c.db.transitions[id] = append(c.db.transitions[id], fakeTransition{
+			seq:       seq,
+			fromState: args[2].Value.([]byte),
+			toState:   args[3].Value.([]byte),
+			timestamp: args[4].Value.(time.Time),
+			eventTime: args[5].Value.(time.Time),
+			actor:     args[7].Value.(string),
+			reason:    args[8].Value.(string),
+			metadata:  metadata,
+		})
+
+		return driver.RowsAffected(1), nil
+	}
+
+	return nil, errors.New("unexpected query: " + query)
+}
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+
+	id := args[0].Value.(string)
+
+	switch {
+	case strings.HasPrefix(query, "SELECT state, version"):
+		rows := &fakeRows{columns: []string{"state", "version"}}
+		if st, ok := c.db.states[id]; ok {
+			rows.values = append(rows.values, []driver.Value{st.state, st.version})
+		}
+
+		return rows, nil
+	case strings.HasPrefix(query, "SELECT version"):
+		rows := &fakeRows{columns: []string{"version"}}
+		if st, ok := c.db.states[id]; ok {
+			rows.values = append(rows.values, []driver.Value{st.version})
+		}
+
+		return rows, nil
+	case strings.HasPrefix(query, "SELECT seq"):
+		transitions := append([]fakeTransition(nil), c.db.transitions[id]...)
+		sort.Slice(transitions, func(i, j int) bool {
+			return transitions[i].seq > transitions[j].seq
+		})
+
+		if len(args) > 1 && int(args[1].Value.(int64)) < len(transitions) {
+			transitions = transitions[:args[1].Value.(int64)]
+		}
+
+		rows := &fakeRows{columns: []string{"seq", "from_state", "to_state", "timestamp", "event_time", "actor", "reason", "metadata"}}
+		for _, tr := range transitions {
+			var metadata driver.Value
+			if tr.metadata != nil {
+				metadata = tr.metadata
+			}
+
+			rows.values = append(rows.values, []driver.Value{tr.seq, tr.fromState, tr.toState, tr.timestamp, tr.eventTime, tr.actor, tr.reason, metadata})
+		}
+
+		return rows, nil
+	}
+
+	return nil, errors.New("unexpected query: " + query)
+}
+
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string {
+	return r.columns
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+
+	return nil
+}
+
+type orderStatus string
+
+const (
+	statusCreated orderStatus = "created"
+	statusPicked  orderStatus = "picked"
+	statusPacked  orderStatus = "packed"
+	statusShipped orderStatus = "shipped"
+)
+
+func newOrderFSM(initial orderStatus) *statetrooper.FSM[orderStatus] {
+	fsm := statetrooper.NewFSM[orderStatus](initial, 10)
+	fsm.AddRule(statusCreated, statusPicked)
+	fsm.AddRule(statusPicked, statusPacked)
+	fsm.AddRule(statusPacked, statusShipped)
+
+	return fsm
+}
+
+func Test_saveAndLoad(t *testing.T) {
+	ctx := context.Background()
+	db := openFakeDB(t)
+	store := New[orderStatus]()
+
+	fsm := newOrderFSM(statusCreated)
+	fsm.Transition(statusPicked, map[string]string{"picker": "Nadia"})
+	fsm.Transition(statusPacked, nil)
+
+	err := store.Save(ctx, db, "order-1", fsm, 0)
+	if err != nil {
+		t.Fatalf("Save() returned an error: %v", err)
+	}
+
+	// Saving again with the initial version conflicts with the stored version
+	err = store.Save(ctx, db, "order-1", fsm, 0)
+	if !errors.Is(err, ErrVersionConflict) {
+		t.Errorf("Save() returned %v, expected ErrVersionConflict", err)
+	}
+
+	loaded := newOrderFSM(statusCreated)
+
+	err = store.Load(ctx, db, "order-1", loaded)
+	if err != nil {
+		t.Fatalf("Load() returned an error: %v", err)
+	}
+
+	if loaded.CurrentState() != statusPacked {
+		t.Errorf("Load() loaded state %v, expected %v", loaded.CurrentState(), statusPacked)
+	}
+
+	transitions := loaded.Transitions()
+	if len(transitions) != 2 {
+		t.Fatalf("Load() loaded %d transitions, expected 2", len(transitions))
+	}
+
+	if transitions[0].ToState != statusPicked || transitions[0].Metadata["picker"] != "Nadia" {
+		t.Errorf("Load() loaded an unexpected first transition: %v", transitions[0])
+	}
+
+	if transitions[1].ToState != statusPacked || transitions[1].Metadata != nil {
+		t.Errorf("Load() loaded an unexpected second transition: %v", transitions[1])
+	}
+
+	err = store.Load(ctx, db, "order-2", loaded)
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Load() returned %v for an unknown entity, expected ErrNotFound", err)
+	}
+}
+
+func Test_transitionTx(t *testing.T) {
+	ctx := context.Background()
+	db := openFakeDB(t)
+	store := New[orderStatus](WithHistoryLimit(1))
+
+	fsm := newOrderFSM(statusCreated)
+
+	err := store.Save(ctx, db, "order-1", fsm, 0)
+	if err != nil {
+		t.Fatalf("Save() returned an error: %v", err)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("BeginTx() returned an error: %v", err)
+	}
+
+	_, err = store.TransitionTx(ctx, tx, "order-1", fsm, statusPicked, nil)
+	if err != nil {
+		t.Fatalf("TransitionTx() returned an error: %v", err)
+	}
+
+	_, err = store.TransitionTx(ctx, tx, "order-1", fsm, statusShipped, nil)
+	var transitionErr statetrooper.TransitionError[orderStatus]
+	if !errors.As(err, &transitionErr) {
+		t.Errorf("TransitionTx() returned %v for an invalid transition, expected a TransitionError", err)
+	}
+
+	tx.Commit()
+
+	// A second FSM loaded before the first one transitions again is stale
+	stale := newOrderFSM(statusCreated)
+	store.Load(ctx, db, "order-1", stale)
+
+	_, err = store.TransitionTx(ctx, db, "order-1", fsm, statusPacked, nil,
+		statetrooper.WithActor("user:42"), statetrooper.WithReason("restock"))
+	if err != nil {
+		t.Fatalf("TransitionTx() returned an error: %v", err)
+	}
+
+	_, err = store.TransitionTx(ctx, db, "order-1", stale, statusPacked, nil)
+	if !errors.Is(err, ErrVersionConflict) {
+		t.Errorf("TransitionTx() returned %v for a stale FSM, expected ErrVersionConflict", err)
+	}
+
+	if stale.CurrentState() != statusPicked {
+		t.Errorf("TransitionTx() changed the stale FSM to %v on a version conflict", stale.CurrentState())
+	}
+
+	loaded := newOrderFSM(statusCreated)
+	store.Load(ctx, db, "order-1", loaded)
+
+	if loaded.CurrentState() != statusPacked {
+		t.Errorf("Load() loaded state %v, expected %v", loaded.CurrentState(), statusPacked)
+	}
+
+	transitions := loaded.Transitions()
+	if len(transitions) != 1 || transitions[0].FromState != statusPicked || transitions[0].ToState != statusPacked {
+		t.Errorf("Load() with a history limit loaded unexpected transitions: %v", transitions)
+	}
+
+	if len(transitions) == 1 && (transitions[0].Actor != "user:42" || transitions[0].Reason != "restock") {
+		t.Errorf("Load() loaded actor %q and reason %q, expected user:42 and restock", transitions[0].Actor, transitions[0].Reason)
+	}
+}
+
+func Test_saveCompactedHistory(t *testing.T) {
+	ctx := context.Background()
+	db := openFakeDB(t)
+	store := New[orderStatus]()
+
+	fsm := newOrderFSM(statusCreated)
+	fsm.AddRule(statusPicked, statusPicked)
+
+	for _, state := range []orderStatus{statusPicked, statusPicked, statusPicked} {
+		fsm.Transition(state, nil)
+	}
+
+	if err := store.Save(ctx, db, "order-1", fsm, 0); err != nil {
+		t.Fatalf("Save() returned an error: %v", err)
+	}
+
+	for _, state := range []orderStatus{statusPicked, statusPicked, statusPacked} {
+		fsm.Transition(state, nil)
+	}
+
+	// the retries are compacted into a single record, so the history no longer has a record per version
+	if err := fsm.CompactHistory(1); err != nil {
+		t.Fatalf("CompactHistory() returned an error: %v", err)
+	}
+
+	if err := store.Save(ctx, db, "order-1", fsm, 3); err != nil {
+		t.Fatalf("Save() returned an error: %v", err)
+	}
+
+	loaded := newOrderFSM(statusCreated)
+
+	if err := store.Load(ctx, db, "order-1", loaded); err != nil {
+		t.Fatalf("Load() returned an error: %v", err)
+	}
+
+	var versions []uint64
+	for _, transition := range loaded.Transitions() {
+		versions = append(versions, transition.Version)
+	}
+
+	if fmt.Sprint(versions) != "[1 2 3 5 6]" {
+		t.Errorf("Load() loaded transitions with versions %v, expected [1 2 3 5 6]", versions)
+	}
+
+	if transitions := loaded.Transitions(); transitions[3].Metadata[statetrooper.MetadataCompacted] != "4" {
+		t.Errorf("Load() loaded %v, expected the compacted record at version 5", transitions[3])
+	}
+}
+
+func Test_transitionTxFollowsAutomaticEdges(t *testing.T) {
+	ctx := context.Background()
+	db := openFakeDB(t)
+	store := New[orderStatus]()
+
+	fsm := newOrderFSM(statusCreated)
+	fsm.SetAutomatic(statusPicked, statusPacked, 0, nil)
+	fsm.Subscribe(func(transition statetrooper.Transition[orderStatus]) {
+		if transition.ToState == statusPacked {
+			panic("subscriber failed")
+		}
+	})
+
+	// the subscriber fails after the automatic hop is committed
+	newState, err := store.TransitionTx(ctx, db, "order-1", fsm, statusPicked, nil)
+	if !errors.As(err, &statetrooper.PanicError{}) {
+		t.Errorf("TransitionTx() returned %v, expected the subscriber's PanicError", err)
+	}
+
+	if newState != statusPacked {
+		t.Errorf("TransitionTx() returned state %v, expected %v", newState, statusPacked)
+	}
+
+	loaded := newOrderFSM(statusCreated)
+
+	if err := store.Load(ctx, db, "order-1", loaded); err != nil {
+		t.Fatalf("Load() returned an error: %v", err)
+	}
+
+	if loaded.CurrentState() != statusPacked || loaded.Version() != 2 || len(loaded.Transitions()) != 2 {
+		t.Errorf("Load() loaded %v at version %d with %d transitions, expected %v at version 2 with 2 transitions",
+			loaded.CurrentState(), loaded.Version(), len(loaded.Transitions()), statusPacked)
+	}
+
+	if _, err := store.TransitionTx(ctx, db, "order-1", fsm, statusShipped, nil); err != nil {
+		t.Errorf("TransitionTx() returned %v after the automatic hop, expected no error", err)
+	}
+}
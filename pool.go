@@ -0,0 +1,192 @@
+package statetrooper
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// FSMPool holds the states of many entities sharing a ruleset in a compact form, for fleets too
+// large to keep an FSM per entity in memory
+// Each entity only takes the index of its state in the compiled ruleset. The pool doesn't keep a
+// transition history; transitions can be off-loaded with WithPoolTransitionHandler instead
+// Entities are identified by the id returned by Add
+type FSMPool[T comparable] struct {
+	// mu guards the growth of entities, transitions only need the read lock and are applied atomically
+	mu       sync.RWMutex
+	entities []atomic.Uint32
+	compiled *compiledRuleset[T]
+
+	// edgeCounts counts the transitions of every edge, indexed like the compiled ruleset's bitset
+	edgeCounts []atomic.Uint64
+
+	// timeProvider is used to timestamp the transitions passed to the transition handler DEFAULT: time.Now
+	timeProvider func() time.Time
+
+	// transitionHandler is called with every transition DEFAULT: nil
+	transitionHandler func(id int, transition Transition[T])
+}
+
+// PoolOption is a function that sets an option on the FSMPool
+type PoolOption[T comparable] func(*FSMPool[T])
+
+// WithPoolTimeProvider sets the time provider used to timestamp transitions
+// DEFAULT: time.Now
+func WithPoolTimeProvider[T comparable](provider func() time.Time) PoolOption[T] {
+	return func(pool *FSMPool[T]) {
+		pool.timeProvider = provider
+	}
+}
+
+// WithPoolTransitionHandler sets a function called with every transition of the pool's entities,
+// e.g. to off-load the history to a database
+// It is called after the transition has been applied and may be called concurrently
+// DEFAULT: nil
+func WithPoolTransitionHandler[T comparable](handler func(id int, transition Transition[T])) PoolOption[T] {
+	return func(pool *FSMPool[T]) {
+		pool.transitionHandler = handler
+	}
+}
+
+// NewFSMPool creates a new pool of entities sharing the ruleset
+// The ruleset is validated and compiled, so later changes to it do not affect the pool
+func NewFSMPool[T comparable](rs Ruleset[T], opts ...PoolOption[T]) (*FSMPool[T], error) {
+	err := rs.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	compiled := rs.compile()
+
+	pool := FSMPool[T]{
+		compiled:     compiled,
+		edgeCounts:   make([]atomic.Uint64, compiled.n*compiled.n),
+		timeProvider: time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(&pool)
+	}
+
+	return &pool, nil
+}
+
+// Add adds an entity in the initial state to the pool and returns its id
+// The initial state must be defined in the ruleset, otherwise an UnknownStateError is returned
+func (pool *FSMPool[T]) Add(initialState T) (int, error) {
+	index, ok := pool.compiled.index[initialState]
+	if !ok {
+		return 0, UnknownStateError[T]{State: initialState}
+	}
+
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	if len(pool.entities) == cap(pool.entities) {
+		entities := make([]atomic.Uint32, len(pool.entities), 2*len(pool.entities)+16)
+		for i := range pool.entities {
+			entities[i].Store(pool.entities[i].Load())
+		}
+
+		pool.entities = entities
+	}
+
+	pool.entities = pool.entities[:len(pool.entities)+1]
+	pool.entities[len(pool.entities)-1].Store(uint32(index))
+
+	return len(pool.entities) - 1, nil
+}
+
+// Len returns the number of entities in the pool
+func (pool *FSMPool[T]) Len() int {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	return len(pool.entities)
+}
+
+// CurrentState returns the current state of the entity
+// The id must have been returned by Add, otherwise CurrentState panics
+func (pool *FSMPool[T]) CurrentState(id int) T {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	return pool.compiled.states[pool.entities[id].Load()]
+}
+
+// CanTransition checks if a transition from the current state of the entity to the target state is valid
+// The id must have been returned by Add, otherwise CanTransition panics
+func (pool *FSMPool[T]) CanTransition(id int, targetState T) bool {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	to, ok := pool.compiled.index[targetState]
+	if !ok {
+		return false
+	}
+
+	return pool.compiled.allowedIndex(int(pool.entities[id].Load()), to)
+}
+
+// Transition transitions the entity from its current state to the target state
+// If the transition is invalid, a TransitionError is returned and the state is not changed
+// The id must have been returned by Add, otherwise Transition panics
+func (pool *FSMPool[T]) Transition(id int, targetState T, metadata map[string]string) (T, error) {
+	pool.mu.RLock()
+
+	entity := &pool.entities[id]
+	to, known := pool.compiled.index[targetState]
+
+	for {
+		from := entity.Load()
+
+		if !known || !pool.compiled.allowedIndex(int(from), to) {
+			pool.mu.RUnlock()
+
+			return pool.compiled.states[from], TransitionError[T]{
+				FromState: pool.compiled.states[from],
+				ToState:   targetState,
+			}
+		}
+
+		if entity.CompareAndSwap(from, uint32(to)) {
+			pool.mu.RUnlock()
+
+			pool.edgeCounts[int(from)*pool.compiled.n+to].Add(1)
+
+			if pool.transitionHandler != nil {
+				tn := pool.timeProvider()
+
+				pool.transitionHandler(id, Transition[T]{
+					FromState: pool.compiled.states[from],
+					ToState:   targetState,
+					Timestamp: tn,
+					EventTime: tn,
+					Metadata:  metadata,
+				})
+			}
+
+			return targetState, nil
+		}
+	}
+}
+
+// FSM creates a standalone FSM for the entity in its current state, sharing the pool's ruleset
+// e.g. to use features the pool doesn't provide for a single entity
+func (pool *FSMPool[T]) FSM(id int, maxHistory int, opts ...FSMOption[T]) *FSM[T] {
+	return NewFSMWithRuleset[T](pool.CurrentState(id), maxHistory, pool.ruleset(), opts...)
+}
+
+// ruleset rebuilds the ruleset from the compiled ruleset
+func (pool *FSMPool[T]) ruleset() Ruleset[T] {
+	rs := make(Ruleset[T], len(pool.compiled.states))
+	for from, fromState := range pool.compiled.states {
+		for to, toState := range pool.compiled.states {
+			if pool.compiled.allowedIndex(from, to) {
+				rs[fromState] = append(rs[fromState], toState)
+			}
+		}
+	}
+
+	return rs
+}
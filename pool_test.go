@@ -0,0 +1,121 @@
+package statetrooper
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+func Test_fsmPool(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		recorded []Transition[CustomStateEnum]
+	)
+
+	pool, err := NewFSMPool(Ruleset[CustomStateEnum]{
+		CustomStateEnumA: {CustomStateEnumB},
+		CustomStateEnumB: {CustomStateEnumC},
+	}, WithPoolTransitionHandler(func(id int, transition Transition[CustomStateEnum]) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		recorded = append(recorded, transition)
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := pool.Add(CustomStateEnumD); !errors.As(err, &UnknownStateError[CustomStateEnum]{}) {
+		t.Errorf("expected an UnknownStateError, got %v", err)
+	}
+
+	first, _ := pool.Add(CustomStateEnumA)
+	second, _ := pool.Add(CustomStateEnumB)
+
+	if pool.Len() != 2 {
+		t.Errorf("Len() = %d, expected 2", pool.Len())
+	}
+
+	if !pool.CanTransition(first, CustomStateEnumB) || pool.CanTransition(first, CustomStateEnumC) {
+		t.Errorf("unexpected CanTransition result for %v", pool.CurrentState(first))
+	}
+
+	newState, err := pool.Transition(first, CustomStateEnumB, map[string]string{"k": "v"})
+	if err != nil || newState != CustomStateEnumB {
+		t.Fatalf("Transition() = %v, %v, expected %v", newState, err, CustomStateEnumB)
+	}
+
+	newState, err = pool.Transition(second, CustomStateEnumA, nil)
+	if !errors.As(err, &TransitionError[CustomStateEnum]{}) || newState != CustomStateEnumB {
+		t.Errorf("Transition() = %v, %v, expected a TransitionError", newState, err)
+	}
+
+	if pool.CurrentState(first) != CustomStateEnumB || pool.CurrentState(second) != CustomStateEnumB {
+		t.Errorf("unexpected states %v and %v", pool.CurrentState(first), pool.CurrentState(second))
+	}
+
+	if len(recorded) != 1 || recorded[0].FromState != CustomStateEnumA || recorded[0].Metadata["k"] != "v" {
+		t.Errorf("unexpected transitions passed to the handler: %v", recorded)
+	}
+
+	fsm := pool.FSM(first, 10)
+	if fsm.CurrentState() != CustomStateEnumB || !fsm.CanTransition(CustomStateEnumC) {
+		t.Errorf("unexpected standalone FSM %v", fsm)
+	}
+}
+
+func Test_fsmPoolConcurrentTransitions(t *testing.T) {
+	pool, _ := NewFSMPool(Ruleset[CustomStateEnum]{
+		CustomStateEnumA: {CustomStateEnumB},
+	})
+
+	id, _ := pool.Add(CustomStateEnumA)
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		succeeded int
+	)
+
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			// grow the pool concurrently with the transitions
+			_, _ = pool.Add(CustomStateEnumA)
+
+			if _, err := pool.Transition(id, CustomStateEnumB, nil); err == nil {
+				mu.Lock()
+				succeeded++
+				mu.Unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if succeeded != 1 {
+		t.Errorf("%d concurrent transitions succeeded, expected 1", succeeded)
+	}
+}
+
+func Benchmark_fsmPoolTransition(b *testing.B) {
+	pool, _ := NewFSMPool(Ruleset[CustomStateEnum]{
+		CustomStateEnumA: {CustomStateEnumB},
+		CustomStateEnumB: {CustomStateEnumA},
+	})
+
+	id, _ := pool.Add(CustomStateEnumA)
+	targets := []CustomStateEnum{CustomStateEnumB, CustomStateEnumA}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := pool.Transition(id, targets[i%2], nil)
+		if err != nil {
+			b.Errorf("Transition returned an error: %v", err)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package publish
+
+import "context"
+
+// KafkaProducer produces a record to a Kafka topic
+// e.g. with segmentio/kafka-go:
+//
+//	producer := publish.KafkaProducerFunc(func(ctx context.Context, topic string, key, value []byte) error {
+//		return writer.WriteMessages(ctx, kafka.Message{Topic: topic, Key: key, Value: value})
+//	})
+type KafkaProducer interface {
+	Produce(ctx context.Context, topic string, key, value []byte) error
+}
+
+// KafkaProducerFunc is an adapter to allow the use of ordinary functions as a KafkaProducer
+type KafkaProducerFunc func(ctx context.Context, topic string, key, value []byte) error
+
+// Produce calls f(ctx, topic, key, value)
+func (f KafkaProducerFunc) Produce(ctx context.Context, topic string, key, value []byte) error {
+	return f(ctx, topic, key, value)
+}
+
+// Kafka returns a Publisher producing each message to the Kafka topic of the message
+// The message key is used as the record key, so the transitions of an entity keep their order
+func Kafka(producer KafkaProducer) Publisher {
+	return PublisherFunc(func(ctx context.Context, msg Message) error {
+		return producer.Produce(ctx, msg.Topic, msg.Key, msg.Value)
+	})
+}
@@ -0,0 +1,20 @@
+package publish
+
+import "context"
+
+// NATSConn publishes data to a NATS subject, it is implemented by *nats.Conn
+type NATSConn interface {
+	Publish(subject string, data []byte) error
+}
+
+// NATS returns a Publisher publishing each message to the NATS subject of the message
+// NATS messages have no key, so the message key is not sent
+func NATS(conn NATSConn) Publisher {
+	return PublisherFunc(func(ctx context.Context, msg Message) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		return conn.Publish(msg.Topic, msg.Value)
+	})
+}
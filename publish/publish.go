@@ -0,0 +1,137 @@
+/*
+Package publish emits an event for every committed transition of a state machine to a
+message broker, so other services can react to state changes.
+
+A Publisher sends a Message to a topic. Adapters are provided for Kafka and NATS, neither
+of which depends on a client library:
+
+	conn, _ := nats.Connect(nats.DefaultURL)
+	detach := publish.Attach(order.State, publish.NATS(conn), publish.WithTopic("orders.state"), publish.WithKey(orderID))
+	defer detach()
+
+Each message carries the transition record serialized as JSON.
+*/
+package publish
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hishamk/statetrooper"
+)
+
+// DefaultTopic is the topic transitions are published to unless WithTopic is used
+const DefaultTopic = "statetrooper.transitions"
+
+// Message is an event sent to a message broker
+type Message struct {
+	// Topic is the Kafka topic or NATS subject
+	Topic string
+	// Key identifies the entity whose state changed, it is used as the Kafka partition key
+	Key []byte
+	// Value is the transition record serialized as JSON
+	Value []byte
+}
+
+// Publisher sends messages to a message broker
+// Implementations must be safe for concurrent use
+type Publisher interface {
+	Publish(ctx context.Context, msg Message) error
+}
+
+// PublisherFunc is an adapter to allow the use of ordinary functions as a Publisher
+type PublisherFunc func(ctx context.Context, msg Message) error
+
+// Publish calls f(ctx, msg)
+func (f PublisherFunc) Publish(ctx context.Context, msg Message) error {
+	return f(ctx, msg)
+}
+
+// Option is a function that sets an option on Attach
+type Option func(*config)
+
+// config holds the options for Attach
+type config struct {
+	topic        string
+	key          []byte
+	timeout      time.Duration
+	errorHandler func(error)
+}
+
+// WithTopic sets the topic or subject the transitions are published to
+// DEFAULT: DefaultTopic
+func WithTopic(topic string) Option {
+	return func(c *config) {
+		c.topic = topic
+	}
+}
+
+// WithKey sets the key identifying the entity, e.g. its ID
+// DEFAULT: no key
+func WithKey(key string) Option {
+	return func(c *config) {
+		c.key = []byte(key)
+	}
+}
+
+// WithTimeout sets the maximum time spent publishing a single transition
+// DEFAULT: 5 seconds
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *config) {
+		c.timeout = timeout
+	}
+}
+
+// WithErrorHandler sets a function called when a transition can't be published
+// The transition itself has already been committed when the handler is called
+// DEFAULT: errors are ignored
+func WithErrorHandler(handler func(error)) Option {
+	return func(c *config) {
+		c.errorHandler = handler
+	}
+}
+
+// Attach publishes every transition committed by the FSM until the returned function is called
+// Transitions are published synchronously while the FSM is locked, so the publisher's latency
+// adds to the latency of Transition but events are published in commit order
+func Attach[T comparable](fsm *statetrooper.FSM[T], publisher Publisher, opts ...Option) (detach func()) {
+	cfg := config{
+		topic:   DefaultTopic,
+		timeout: 5 * time.Second,
+	}
+
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	return fsm.Subscribe(func(transition statetrooper.Transition[T]) {
+		err := publish(publisher, &cfg, transition)
+		if err != nil && cfg.errorHandler != nil {
+			cfg.errorHandler(err)
+		}
+	})
+}
+
+// publish serializes and publishes a single transition
+func publish[T comparable](publisher Publisher, cfg *config, transition statetrooper.Transition[T]) error {
+	value, err := json.Marshal(transition)
+	if err != nil {
+		return fmt.Errorf("failed to marshal transition: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.timeout)
+	defer cancel()
+
+	err = publisher.Publish(ctx, Message{
+		Topic: cfg.topic,
+		Key:   cfg.key,
+		Value: value,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to publish transition to %s: %w", cfg.topic, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,122 @@
+package publish
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/hishamk/statetrooper"
+)
+
+type orderStatus string
+
+const (
+	statusCreated orderStatus = "created"
+	statusPaid    orderStatus = "paid"
+	statusShipped orderStatus = "shipped"
+)
+
+func newOrderFSM() *statetrooper.FSM[orderStatus] {
+	fsm := statetrooper.NewFSM[orderStatus](statusCreated, 10)
+	fsm.AddRule(statusCreated, statusPaid)
+	fsm.AddRule(statusPaid, statusShipped)
+
+	return fsm
+}
+
+// fakeNATS records the messages published to each subject
+type fakeNATS struct {
+	subjects []string
+	data     [][]byte
+}
+
+func (c *fakeNATS) Publish(subject string, data []byte) error {
+	c.subjects = append(c.subjects, subject)
+	c.data = append(c.data, data)
+
+	return nil
+}
+
+func Test_attachKafka(t *testing.T) {
+	fsm := newOrderFSM()
+
+	var messages []Message
+
+	producer := KafkaProducerFunc(func(_ context.Context, topic string, key, value []byte) error {
+		messages = append(messages, Message{Topic: topic, Key: key, Value: value})
+
+		return nil
+	})
+
+	detach := Attach(fsm, Kafka(producer), WithTopic("orders"), WithKey("order-1"))
+
+	if _, err := fsm.Transition(statusPaid, map[string]string{"by": "alice"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	detach()
+
+	if _, err := fsm.Transition(statusShipped, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(messages) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(messages))
+	}
+
+	if messages[0].Topic != "orders" || string(messages[0].Key) != "order-1" {
+		t.Errorf("unexpected topic or key: %q %q", messages[0].Topic, messages[0].Key)
+	}
+
+	var transition statetrooper.Transition[orderStatus]
+	if err := json.Unmarshal(messages[0].Value, &transition); err != nil {
+		t.Fatalf("failed to decode message: %v", err)
+	}
+
+	if transition.FromState != statusCreated || transition.ToState != statusPaid || transition.Metadata["by"] != "alice" {
+		t.Errorf("unexpected transition: %+v", transition)
+	}
+}
+
+func Test_attachNATS(t *testing.T) {
+	fsm := newOrderFSM()
+	conn := &fakeNATS{}
+
+	Attach(fsm, NATS(conn))
+
+	if _, err := fsm.Transition(statusPaid, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(conn.subjects) != 1 || conn.subjects[0] != DefaultTopic {
+		t.Fatalf("expected a message on %s, got %v", DefaultTopic, conn.subjects)
+	}
+}
+
+func Test_attachErrorHandler(t *testing.T) {
+	fsm := newOrderFSM()
+	errBroker := errors.New("broker unavailable")
+
+	var handled error
+
+	publisher := PublisherFunc(func(context.Context, Message) error {
+		return errBroker
+	})
+
+	Attach(fsm, publisher, WithErrorHandler(func(err error) {
+		handled = err
+	}))
+
+	if _, err := fsm.Transition(statusPaid, nil); err != nil {
+		t.Fatalf("expected the transition to succeed, got %v", err)
+	}
+
+	if !errors.Is(handled, errBroker) {
+		t.Errorf("expected the broker error to be handled, got %v", handled)
+	}
+
+	if fsm.CurrentState() != statusPaid {
+		t.Errorf("expected current state %v, got %v", statusPaid, fsm.CurrentState())
+	}
+}
@@ -0,0 +1,135 @@
+package statetrooper
+
+import (
+	"context"
+	"sync"
+)
+
+// QueueMetrics can be implemented by a Metrics receiver to observe the depth of transition queues
+type QueueMetrics interface {
+	// ObserveQueueDepth is called with the number of pending transitions whenever a transition
+	// is enqueued or dequeued
+	ObserveQueueDepth(depth int)
+}
+
+// TransitionResult is the outcome of a queued transition
+type TransitionResult[T comparable] struct {
+	State T
+	Err   error
+}
+
+// TransitionQueue applies the transitions of an FSM serially from a single worker goroutine,
+// so that callers of a high-contention entity wait on a result channel rather than on the FSM's lock
+type TransitionQueue[T comparable] struct {
+	fsm      *FSM[T]
+	requests chan queuedTransition[T]
+	done     chan struct{}
+	metrics  QueueMetrics
+
+	// mu guards closed against concurrent sends on requests
+	mu     sync.RWMutex
+	closed bool
+}
+
+// queuedTransition is a transition waiting in a TransitionQueue
+type queuedTransition[T comparable] struct {
+	targetState T
+	metadata    map[string]string
+	opts        []TransitionOption
+	result      chan TransitionResult[T]
+}
+
+// NewTransitionQueue starts a worker applying the transitions enqueued on the returned queue
+// size is the number of transitions that can be pending before Enqueue blocks
+// If the FSM's Metrics receiver implements QueueMetrics, the depth of the queue is reported to it
+// The queue must be closed with Close to stop the worker
+func (fsm *FSM[T]) NewTransitionQueue(size int) *TransitionQueue[T] {
+	q := &TransitionQueue[T]{
+		fsm:      fsm,
+		requests: make(chan queuedTransition[T], size),
+		done:     make(chan struct{}),
+	}
+
+	q.metrics, _ = fsm.metrics.(QueueMetrics)
+
+	go q.run()
+
+	return q
+}
+
+// Enqueue enqueues a transition to the target state and returns a channel receiving its result
+// It blocks while the queue is full, returning ctx's error if ctx is done first
+// ErrQueueClosed is returned once the queue has been closed
+func (q *TransitionQueue[T]) Enqueue(
+	ctx context.Context,
+	targetState T,
+	metadata map[string]string,
+	opts ...TransitionOption,
+) (<-chan TransitionResult[T], error) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	if q.closed {
+		return nil, ErrQueueClosed
+	}
+
+	req := queuedTransition[T]{
+		targetState: targetState,
+		metadata:    metadata,
+		opts:        opts,
+		result:      make(chan TransitionResult[T], 1),
+	}
+
+	select {
+	case q.requests <- req:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	q.observeDepth()
+
+	return req.result, nil
+}
+
+// Depth returns the number of pending transitions
+func (q *TransitionQueue[T]) Depth() int {
+	return len(q.requests)
+}
+
+// Close stops accepting transitions and waits for the pending ones to be applied
+// ctx's error is returned if ctx is done before the queue is drained, in which case
+// the worker keeps draining the queue in the background
+func (q *TransitionQueue[T]) Close(ctx context.Context) error {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.requests)
+	}
+	q.mu.Unlock()
+
+	select {
+	case <-q.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run applies the queued transitions until the queue is closed and drained
+func (q *TransitionQueue[T]) run() {
+	defer close(q.done)
+
+	for req := range q.requests {
+		q.observeDepth()
+
+		state, err := q.fsm.Transition(req.targetState, req.metadata, req.opts...)
+		req.result <- TransitionResult[T]{State: state, Err: err}
+	}
+}
+
+// observeDepth reports the depth of the queue if queue metrics are enabled
+func (q *TransitionQueue[T]) observeDepth() {
+	if q.metrics != nil {
+		q.metrics.ObserveQueueDepth(len(q.requests))
+	}
+}
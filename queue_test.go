@@ -0,0 +1,99 @@
+package statetrooper
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+// queueMetrics records the queue depths it receives for testing
+type queueMetrics struct {
+	recordingMetrics
+	depths []int
+}
+
+func (m *queueMetrics) ObserveQueueDepth(depth int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.depths = append(m.depths, depth)
+}
+
+func Test_transitionQueue(t *testing.T) {
+	metrics := &queueMetrics{}
+
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, UnlimitedHistory,
+		WithMetrics[CustomStateEnum](metrics))
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumA)
+
+	q := fsm.NewTransitionQueue(16)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			result, err := q.Enqueue(context.Background(), CustomStateEnumA, nil)
+			if err != nil {
+				t.Errorf("Enqueue failed: %v", err)
+
+				return
+			}
+
+			if res := <-result; res.Err != nil || res.State != CustomStateEnumA {
+				t.Errorf("unexpected result: %+v", res)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if err := q.Close(context.Background()); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	if fsm.Version() != 100 {
+		t.Errorf("expected 100 transitions, got %d", fsm.Version())
+	}
+
+	metrics.mu.Lock()
+	if len(metrics.depths) != 200 {
+		t.Errorf("expected the depth to be observed on every enqueue and dequeue, got %d", len(metrics.depths))
+	}
+	metrics.mu.Unlock()
+
+	if _, err := q.Enqueue(context.Background(), CustomStateEnumA, nil); !errors.Is(err, ErrQueueClosed) {
+		t.Errorf("expected ErrQueueClosed, got %v", err)
+	}
+}
+
+func Test_transitionQueueCloseDrains(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+
+	q := fsm.NewTransitionQueue(2)
+
+	ok, _ := q.Enqueue(context.Background(), CustomStateEnumB, nil)
+	invalid, _ := q.Enqueue(context.Background(), CustomStateEnumC, nil)
+
+	if err := q.Close(context.Background()); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	if res := <-ok; res.Err != nil || res.State != CustomStateEnumB {
+		t.Errorf("unexpected result: %+v", res)
+	}
+
+	var transitionErr TransitionError[CustomStateEnum]
+	if res := <-invalid; !errors.As(res.Err, &transitionErr) {
+		t.Errorf("expected a TransitionError, got %v", res.Err)
+	}
+
+	if q.Depth() != 0 {
+		t.Errorf("expected an empty queue, got %d", q.Depth())
+	}
+}
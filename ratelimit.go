@@ -0,0 +1,119 @@
+package statetrooper
+
+import (
+	"fmt"
+	"time"
+)
+
+// WithRateLimit caps the transitions of the FSM to n per interval, with bursts of up to n transitions
+// Throttled transitions fail with a RateLimitError matching ErrRateLimited and leave the FSM unchanged
+// Only committed transitions take a token, so transitions failing for other reasons don't use up the limit
+// This keeps a runaway retry loop from flipping an entity between states thousands of times per second
+// DEFAULT: transitions are not rate limited
+func WithRateLimit[T comparable](n int, interval time.Duration) FSMOption[T] {
+	return func(fsm *FSM[T]) {
+		fsm.rateLimit = newTokenBucket(n, interval)
+	}
+}
+
+// WithEdgeRateLimit caps the transitions from one state to another to n per interval,
+// with bursts of up to n transitions. It can be combined with WithRateLimit and set for several edges
+// DEFAULT: transitions are not rate limited
+func WithEdgeRateLimit[T comparable](fromState, toState T, n int, interval time.Duration) FSMOption[T] {
+	return func(fsm *FSM[T]) {
+		if fsm.edgeRateLimits == nil {
+			fsm.edgeRateLimits = make(map[edge[T]]*tokenBucket)
+		}
+
+		fsm.edgeRateLimits[edge[T]{from: fromState, to: toState}] = newTokenBucket(n, interval)
+	}
+}
+
+// edge identifies a transition from one state to another
+type edge[T comparable] struct {
+	from T
+	to   T
+}
+
+// tokenBucket is a token bucket holding up to capacity tokens, refilled with one token every refill
+type tokenBucket struct {
+	capacity float64
+	refill   time.Duration
+	tokens   float64
+	last     time.Time
+}
+
+// newTokenBucket creates a full token bucket allowing n tokens per interval
+func newTokenBucket(n int, interval time.Duration) *tokenBucket {
+	if n < 1 {
+		n = 1
+	}
+
+	return &tokenBucket{
+		capacity: float64(n),
+		refill:   interval / time.Duration(n),
+		tokens:   float64(n),
+	}
+}
+
+// available reports whether a token is available at now without taking it
+func (b *tokenBucket) available(now time.Time) (bool, time.Duration) {
+	b.fill(now)
+
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) * float64(b.refill))
+	}
+
+	return true, 0
+}
+
+// fill adds the tokens refilled since the last call
+func (b *tokenBucket) fill(now time.Time) {
+	if !b.last.IsZero() && now.After(b.last) && b.refill > 0 {
+		b.tokens += float64(now.Sub(b.last)) / float64(b.refill)
+		if b.tokens > b.capacity {
+			b.tokens = b.capacity
+		}
+	}
+
+	if b.last.IsZero() || now.After(b.last) {
+		b.last = now
+	}
+}
+
+// throttle checks that the FSM's and the edge's buckets have a token, or returns a RateLimitError if either
+// is empty. The buckets are returned so their tokens are only taken with takeTokens once the transition
+// commits, and a transition failing later doesn't use up the rate limit
+// The caller must hold the lock
+func (fsm *FSM[T]) throttle(fromState, toState T, now time.Time) ([2]*tokenBucket, error) {
+	buckets := [2]*tokenBucket{fsm.rateLimit}
+	if fsm.edgeRateLimits != nil {
+		buckets[1] = fsm.edgeRateLimits[edge[T]{from: fromState, to: toState}]
+	}
+
+	for _, b := range buckets {
+		if b == nil {
+			continue
+		}
+
+		if ok, retryAfter := b.available(now); !ok {
+			return buckets, RateLimitError[T]{FromState: fromState, ToState: toState, RetryAfter: retryAfter}
+		}
+	}
+
+	return buckets, nil
+}
+
+// takeTokens takes a token from each of the buckets returned by throttle
+func takeTokens(buckets [2]*tokenBucket) {
+	for _, b := range buckets {
+		if b != nil {
+			b.tokens--
+		}
+	}
+}
+
+// String describes the rate limit, e.g. "rate limit of 10 per 1s"
+func (b *tokenBucket) String() string {
+	return fmt.Sprintf("rate limit of %v per %v", b.capacity, b.refill*time.Duration(b.capacity))
+}
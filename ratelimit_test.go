@@ -0,0 +1,101 @@
+package statetrooper
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func Test_withRateLimit(t *testing.T) {
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10,
+		WithTimeProvider[CustomStateEnum](func() time.Time { return now }),
+		WithRateLimit[CustomStateEnum](2, time.Second),
+	)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumA)
+
+	// a burst of 2 transitions is allowed
+	for _, target := range []CustomStateEnum{CustomStateEnumB, CustomStateEnumA} {
+		if _, err := fsm.Transition(target, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	_, err := fsm.Transition(CustomStateEnumB, nil)
+	if !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("expected ErrRateLimited, got %v", err)
+	}
+
+	var rateErr RateLimitError[CustomStateEnum]
+	if !errors.As(err, &rateErr) || rateErr.RetryAfter != 500*time.Millisecond {
+		t.Errorf("expected a retry after 500ms, got %v", err)
+	}
+
+	if fsm.CurrentState() != CustomStateEnumA || fsm.Version() != 2 {
+		t.Errorf("expected a throttled transition to leave the FSM unchanged")
+	}
+
+	// a token is refilled every 500ms
+	now = now.Add(500 * time.Millisecond)
+
+	if _, err := fsm.Transition(CustomStateEnumB, nil); err != nil {
+		t.Errorf("expected the transition to be allowed after the refill: %v", err)
+	}
+}
+
+func Test_withEdgeRateLimit(t *testing.T) {
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10,
+		WithTimeProvider[CustomStateEnum](func() time.Time { return now }),
+		WithEdgeRateLimit[CustomStateEnum](CustomStateEnumA, CustomStateEnumB, 1, time.Minute),
+	)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumA)
+
+	if _, err := fsm.Transition(CustomStateEnumB, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the other edge is not limited
+	if _, err := fsm.Transition(CustomStateEnumA, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := fsm.Transition(CustomStateEnumB, nil); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("expected ErrRateLimited, got %v", err)
+	}
+
+	now = now.Add(time.Minute)
+
+	if _, err := fsm.Transition(CustomStateEnumB, nil); err != nil {
+		t.Errorf("expected the transition to be allowed after the refill: %v", err)
+	}
+}
+
+func Test_withRateLimitFailedTransition(t *testing.T) {
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10,
+		WithTimeProvider[CustomStateEnum](func() time.Time { return now }),
+		WithRateLimit[CustomStateEnum](1, time.Hour),
+		WithEdgeRateLimit(CustomStateEnumA, CustomStateEnumB, 1, time.Hour),
+	)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+
+	// the linked transition isn't allowed, so the transition fails after the rate limit is checked
+	target := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	unlink := Link(fsm, CustomStateEnumB, target, CustomStateEnumC, nil)
+
+	if _, err := fsm.Transition(CustomStateEnumB, nil); !errors.As(err, &LinkError{}) {
+		t.Fatalf("expected a LinkError, got %v", err)
+	}
+
+	unlink()
+
+	if _, err := fsm.Transition(CustomStateEnumB, nil); err != nil {
+		t.Errorf("expected a failed transition not to use up the rate limit: %v", err)
+	}
+}
@@ -0,0 +1,129 @@
+package statetrooper
+
+import "math/rand"
+
+// ReadOnlyFSM is a view of an FSM that can't transition or modify it, e.g. for reporting code
+// It reflects the FSM's current state and history
+type ReadOnlyFSM[T comparable] struct {
+	fsm *FSM[T]
+}
+
+// ReadOnly returns a read-only view of the FSM
+func (fsm *FSM[T]) ReadOnly() ReadOnlyFSM[T] {
+	return ReadOnlyFSM[T]{fsm: fsm}
+}
+
+// CurrentState returns the current state of the FSM
+func (v ReadOnlyFSM[T]) CurrentState() T {
+	return v.fsm.CurrentState()
+}
+
+// Version returns the number of successful transitions of the FSM
+func (v ReadOnlyFSM[T]) Version() uint64 {
+	return v.fsm.Version()
+}
+
+// CanTransition checks if a transition from the current state to the target state is valid
+func (v ReadOnlyFSM[T]) CanTransition(targetState T) bool {
+	return v.fsm.CanTransition(targetState)
+}
+
+// AllowedTransitions returns the states the FSM can transition to from the current state
+func (v ReadOnlyFSM[T]) AllowedTransitions() []T {
+	return v.fsm.AllowedTransitions()
+}
+
+// Transitions returns a copy of the transition history ordered from oldest to newest
+func (v ReadOnlyFSM[T]) Transitions() []Transition[T] {
+	return v.fsm.Transitions()
+}
+
+// IterTransitions calls fn for each transition ordered from oldest to newest until fn returns false
+func (v ReadOnlyFSM[T]) IterTransitions(fn func(Transition[T]) bool) {
+	v.fsm.IterTransitions(fn)
+}
+
+// GenerateMermaidRulesDiagram generates a Mermaid.js diagram from the FSM's rules
+func (v ReadOnlyFSM[T]) GenerateMermaidRulesDiagram() (string, error) {
+	return v.fsm.GenerateMermaidRulesDiagram()
+}
+
+// GenerateMermaidTransitionHistoryDiagram generates a Mermaid.js diagram from the FSM's transition history
+func (v ReadOnlyFSM[T]) GenerateMermaidTransitionHistoryDiagram(opts ...DiagramOption) (string, error) {
+	return v.fsm.GenerateMermaidTransitionHistoryDiagram(opts...)
+}
+
+// GenerateMermaidOverlayDiagram generates a Mermaid.js diagram of the FSM's rules overlaid with its history
+func (v ReadOnlyFSM[T]) GenerateMermaidOverlayDiagram() (string, error) {
+	return v.fsm.GenerateMermaidOverlayDiagram()
+}
+
+// GenerateDOTRulesDiagram generates a Graphviz DOT diagram from the FSM's rules
+func (v ReadOnlyFSM[T]) GenerateDOTRulesDiagram() (string, error) {
+	return v.fsm.GenerateDOTRulesDiagram()
+}
+
+// GeneratePlantUMLRulesDiagram generates a PlantUML state diagram from the FSM's rules
+func (v ReadOnlyFSM[T]) GeneratePlantUMLRulesDiagram() (string, error) {
+	return v.fsm.GeneratePlantUMLRulesDiagram()
+}
+
+// GenerateMarkdownDoc generates a Markdown document describing the FSM's rules
+func (v ReadOnlyFSM[T]) GenerateMarkdownDoc() (string, error) {
+	return v.fsm.GenerateMarkdownDoc()
+}
+
+// GenerateASCIIDiagram generates a plain text diagram of the FSM's rules
+func (v ReadOnlyFSM[T]) GenerateASCIIDiagram(opts ...DiagramOption) (string, error) {
+	return v.fsm.GenerateASCIIDiagram(opts...)
+}
+
+// String returns a string representation of the FSM
+func (v ReadOnlyFSM[T]) String() string {
+	return v.fsm.String()
+}
+
+// Edges returns the rules of the FSM's ruleset as edges, ordered by source and target state
+func (v ReadOnlyFSM[T]) Edges() []Rule[T] {
+	return v.fsm.Edges()
+}
+
+// AdjacencyMatrix returns the states of the FSM's ruleset sorted by name and the matrix of its rules
+func (v ReadOnlyFSM[T]) AdjacencyMatrix() ([]T, [][]bool) {
+	return v.fsm.AdjacencyMatrix()
+}
+
+// HasCycles checks if a state can lead back to itself through the FSM's rules
+func (v ReadOnlyFSM[T]) HasCycles() bool {
+	return v.fsm.HasCycles()
+}
+
+// FindCycles returns the elementary cycles of the FSM's rules
+func (v ReadOnlyFSM[T]) FindCycles() [][]T {
+	return v.fsm.FindCycles()
+}
+
+// TopologicalOrder returns the states ordered so that every rule of the FSM goes from a state to a later one
+func (v ReadOnlyFSM[T]) TopologicalOrder() ([]T, error) {
+	return v.fsm.TopologicalOrder()
+}
+
+// StronglyConnectedComponents returns the groups of states that can all reach each other through the FSM's rules
+func (v ReadOnlyFSM[T]) StronglyConnectedComponents() [][]T {
+	return v.fsm.StronglyConnectedComponents()
+}
+
+// RuleWeight returns the weight of the rule from fromState to toState, 1 if it wasn't set
+func (v ReadOnlyFSM[T]) RuleWeight(fromState, toState T) float64 {
+	return v.fsm.RuleWeight(fromState, toState)
+}
+
+// NextStateWeightedRandom picks an allowed target state of the current state at random, weighted by the rules
+func (v ReadOnlyFSM[T]) NextStateWeightedRandom(rng *rand.Rand) (T, bool) {
+	return v.fsm.NextStateWeightedRandom(rng)
+}
+
+// SimulateRandomWalk walks the FSM's rules as a Markov chain from the current state without changing the FSM
+func (v ReadOnlyFSM[T]) SimulateRandomWalk(steps int, rng *rand.Rand) []T {
+	return v.fsm.SimulateRandomWalk(steps, rng)
+}
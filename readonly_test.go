@@ -0,0 +1,27 @@
+package statetrooper
+
+import "testing"
+
+func Test_readOnly(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+
+	view := fsm.ReadOnly()
+
+	if _, err := fsm.Transition(CustomStateEnumB, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if view.CurrentState() != CustomStateEnumB || view.Version() != 1 || len(view.Transitions()) != 1 {
+		t.Errorf("expected the view to reflect the transition, got %v", view.CurrentState())
+	}
+
+	if view.CanTransition(CustomStateEnumA) {
+		t.Errorf("expected the transition back to %v to be invalid", CustomStateEnumA)
+	}
+
+	diagram, err := view.GenerateMermaidRulesDiagram()
+	if err != nil || diagram == "" {
+		t.Errorf("GenerateMermaidRulesDiagram() = %q, %v", diagram, err)
+	}
+}
@@ -0,0 +1,66 @@
+package statetrooper
+
+// RedactedValue replaces the values of redacted metadata keys in exports
+const RedactedValue = "[REDACTED]"
+
+// WithRedactedMetadataKeys redacts the values of the given metadata keys wherever transitions leave the FSM
+// as exports: MarshalJSON, MarshalText, GobEncode, MarshalYAML, String, the HTTP handler, the CSV and JSONL exports,
+// the audit writer and the transitions passed to subscribers
+// The history itself keeps the values, so Transitions and Snapshot return them unredacted
+// As the exported JSON is redacted, use Snapshot rather than MarshalJSON to persist FSMs with redacted keys
+// DEFAULT: no keys are redacted
+func WithRedactedMetadataKeys[T comparable](keys ...string) FSMOption[T] {
+	return func(fsm *FSM[T]) {
+		if fsm.redactedKeys == nil {
+			fsm.redactedKeys = make(map[string]struct{}, len(keys))
+		}
+
+		for _, key := range keys {
+			fsm.redactedKeys[key] = struct{}{}
+		}
+	}
+}
+
+// redact returns the transition with the values of redacted metadata keys replaced
+// The metadata is copied if any value is redacted, so the history is left untouched
+func (fsm *FSM[T]) redact(transition Transition[T]) Transition[T] {
+	if len(fsm.redactedKeys) == 0 {
+		return transition
+	}
+
+	var metadata map[string]string
+
+	for key := range transition.Metadata {
+		if _, ok := fsm.redactedKeys[key]; !ok {
+			continue
+		}
+
+		if metadata == nil {
+			metadata = make(map[string]string, len(transition.Metadata))
+			for k, v := range transition.Metadata {
+				metadata[k] = v
+			}
+		}
+
+		metadata[key] = RedactedValue
+	}
+
+	if metadata != nil {
+		transition.Metadata = metadata
+	}
+
+	return transition
+}
+
+// redactAll redacts the transitions in place
+func (fsm *FSM[T]) redactAll(transitions []Transition[T]) []Transition[T] {
+	if len(fsm.redactedKeys) == 0 {
+		return transitions
+	}
+
+	for i := range transitions {
+		transitions[i] = fsm.redact(transitions[i])
+	}
+
+	return transitions
+}
@@ -0,0 +1,81 @@
+package statetrooper
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func Test_withRedactedMetadataKeys(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10, WithRedactedMetadataKeys[CustomStateEnum]("ssn", "token"))
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+
+	var published Transition[CustomStateEnum]
+
+	fsm.Subscribe(func(tr Transition[CustomStateEnum]) {
+		published = tr
+	})
+
+	_, err := fsm.Transition(CustomStateEnumB, map[string]string{"ssn": "123-45-6789", "requested_by": "Mahmoud"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := json.Marshal(fsm)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	text, err := fsm.MarshalText()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	gobData, err := fsm.GobEncode()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	yamlData, err := yaml.Marshal(fsm)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	exports := map[string]string{
+		"MarshalJSON": string(data),
+		"MarshalText": string(text),
+		"GobEncode":   string(gobData),
+		"MarshalYAML": string(yamlData),
+		"String":      fsm.String(),
+	}
+
+	for name, export := range exports {
+		if strings.Contains(export, "123-45-6789") {
+			t.Errorf("%s leaked a redacted value:\n%s", name, export)
+		}
+
+		if !strings.Contains(export, RedactedValue) || !strings.Contains(export, "Mahmoud") {
+			t.Errorf("%s didn't redact only the redacted keys:\n%s", name, export)
+		}
+	}
+
+	if published.Metadata["ssn"] != RedactedValue || published.Metadata["requested_by"] != "Mahmoud" {
+		t.Errorf("expected subscribers to receive redacted metadata, got %v", published.Metadata)
+	}
+
+	// the history keeps the values
+	if md := fsm.Transitions()[0].Metadata; md["ssn"] != "123-45-6789" {
+		t.Errorf("expected the history to be unredacted, got %v", md)
+	}
+
+	snapshot, err := fsm.Snapshot()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if md := snapshot.Transitions[0].Metadata; md["ssn"] != "123-45-6789" {
+		t.Errorf("expected the snapshot to be unredacted, got %v", md)
+	}
+}
@@ -0,0 +1,352 @@
+/*
+Package redistore provides a state machine whose state is stored in Redis so that
+multiple processes can safely advance the same entity's state machine.
+
+Each transition is validated and applied atomically by a Lua script running inside
+Redis, so concurrent transitions from horizontally scaled workers can't interleave.
+
+The package doesn't depend on a Redis client. Any client able to evaluate Lua scripts
+can be used by implementing Scripter, e.g. with go-redis:
+
+	scripter := redistore.ScripterFunc(func(ctx context.Context, script string, keys []string, args ...any) (any, error) {
+		return rdb.Eval(ctx, script, keys, args...).Result()
+	})
+
+States are stored as JSON, so any state type that can be marshaled to JSON is supported.
+*/
+package redistore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/hishamk/statetrooper"
+)
+
+// Scripter evaluates a Lua script in Redis and returns its result
+// Lua tables are expected to be returned as []any, integers as int64 and strings as string
+type Scripter interface {
+	Eval(ctx context.Context, script string, keys []string, args ...any) (any, error)
+}
+
+// ScripterFunc is an adapter to allow the use of ordinary functions as a Scripter
+type ScripterFunc func(ctx context.Context, script string, keys []string, args ...any) (any, error)
+
+// Eval calls f(ctx, script, keys, args...)
+func (f ScripterFunc) Eval(ctx context.Context, script string, keys []string, args ...any) (any, error) {
+	return f(ctx, script, keys, args...)
+}
+
+// transitionScript validates and applies a transition atomically
+// KEYS[1] is the state hash, KEYS[2] the history list
+// ARGV[1] is the initial state, ARGV[2] the target state, ARGV[3] the timestamp, ARGV[4] the metadata,
+// ARGV[5] the maximum history size and ARGV[6...] the states from which the target state can be reached
+const transitionScript = `
+local current = redis.call('HGET', KEYS[1], 'state')
+if not current then
+	current = ARGV[1]
+end
+
+local allowed = false
+for i = 6, #ARGV do
+	if ARGV[i] == current then
+		allowed = true
+		break
+	end
+end
+
+if not allowed then
+	return {0, current, tonumber(redis.call('HGET', KEYS[1], 'version') or '0')}
+end
+
+local version = redis.call('HINCRBY', KEYS[1], 'version', 1)
+redis.call('HSET', KEYS[1], 'state', ARGV[2])
+
+local maxHistory = tonumber(ARGV[5])
+if maxHistory ~= 0 then
+	redis.call('RPUSH', KEYS[2], '{"from_state":' .. current .. ',"to_state":' .. ARGV[2] ..
+		',"timestamp":' .. ARGV[3] .. ',"event_time":' .. ARGV[3] .. ',"metadata":' .. ARGV[4] .. '}')
+
+	if maxHistory > 0 then
+		redis.call('LTRIM', KEYS[2], -maxHistory, -1)
+	end
+end
+
+return {1, ARGV[2], version}
+`
+
+// stateScript returns the current state and version
+// KEYS[1] is the state hash and ARGV[1] the initial state
+const stateScript = `
+local current = redis.call('HGET', KEYS[1], 'state')
+if not current then
+	current = ARGV[1]
+end
+
+return {current, tonumber(redis.call('HGET', KEYS[1], 'version') or '0')}
+`
+
+// historyScript returns the transition history from oldest to newest
+// KEYS[2] is the history list
+const historyScript = `
+return redis.call('LRANGE', KEYS[2], 0, -1)
+`
+
+// Option is a function that sets an option on the FSM
+type Option func(*config)
+
+// config holds the options for the FSM
+type config struct {
+	maxHistory   int
+	timeProvider func() time.Time
+}
+
+// WithMaxHistory sets the number of transitions kept in the history list
+// 0 disables the history and statetrooper.UnlimitedHistory keeps every transition
+// DEFAULT: 10
+func WithMaxHistory(maxHistory int) Option {
+	return func(c *config) {
+		c.maxHistory = maxHistory
+	}
+}
+
+// WithTimeProvider sets the time provider used to timestamp transitions
+// DEFAULT: time.Now
+func WithTimeProvider(provider func() time.Time) Option {
+	return func(c *config) {
+		c.timeProvider = provider
+	}
+}
+
+// FSM is a state machine whose state and transition history are stored in Redis
+// The state is kept in a hash at key and the history in a list at key + ":history"
+// When using Redis Cluster, key should contain a hash tag (e.g. "{order:42}") so both keys share a slot
+type FSM[T comparable] struct {
+	client  Scripter
+	keys    []string
+	initial []byte
+	config
+
+	// sources maps each target state to the JSON encoded states from which it can be reached
+	sources map[T][]any
+}
+
+// New creates a new FSM stored at key, which starts in the initial state until its first transition
+// The ruleset is copied so later changes to it do not affect the FSM
+func New[T comparable](client Scripter, key string, initialState T, rs statetrooper.Ruleset[T], opts ...Option) (*FSM[T], error) {
+	initial, err := json.Marshal(initialState)
+	if err != nil {
+		return nil, err
+	}
+
+	fsm := FSM[T]{
+		client:  client,
+		keys:    []string{key, key + ":history"},
+		initial: initial,
+		config: config{
+			maxHistory:   10,
+			timeProvider: time.Now,
+		},
+		sources: make(map[T][]any),
+	}
+
+	for _, opt := range opts {
+		opt(&fsm.config)
+	}
+
+	for fromState, toStates := range rs {
+		from, err := json.Marshal(fromState)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, toState := range toStates {
+			fsm.sources[toState] = append(fsm.sources[toState], string(from))
+		}
+	}
+
+	return &fsm, nil
+}
+
+// Transition atomically transitions the entity from its current state in Redis to the target state
+// If the transition is invalid, a statetrooper.TransitionError is returned and the state is not changed
+func (fsm *FSM[T]) Transition(ctx context.Context, targetState T, metadata map[string]string) (T, error) {
+	var current T
+
+	target, err := json.Marshal(targetState)
+	if err != nil {
+		return current, err
+	}
+
+	timestamp, err := json.Marshal(fsm.timeProvider())
+	if err != nil {
+		return current, err
+	}
+
+	md, err := json.Marshal(metadata)
+	if err != nil {
+		return current, err
+	}
+
+	args := append(
+		[]any{string(fsm.initial), string(target), string(timestamp), string(md), strconv.Itoa(fsm.maxHistory)},
+		fsm.sources[targetState]...,
+	)
+
+	res, err := fsm.client.Eval(ctx, transitionScript, fsm.keys, args...)
+	if err != nil {
+		return current, fmt.Errorf("redistore: failed to transition: %w", err)
+	}
+
+	values, err := parseResult(res, 3)
+	if err != nil {
+		return current, err
+	}
+
+	ok, err := toInt(values[0])
+	if err != nil {
+		return current, err
+	}
+
+	err = decodeState(values[1], &current)
+	if err != nil {
+		return current, err
+	}
+
+	if ok == 0 {
+		return current, statetrooper.TransitionError[T]{
+			FromState: current,
+			ToState:   targetState,
+		}
+	}
+
+	return current, nil
+}
+
+// CurrentState returns the current state of the entity
+func (fsm *FSM[T]) CurrentState(ctx context.Context) (T, error) {
+	state, _, err := fsm.state(ctx)
+
+	return state, err
+}
+
+// Version returns the number of successful transitions of the entity
+func (fsm *FSM[T]) Version(ctx context.Context) (uint64, error) {
+	_, version, err := fsm.state(ctx)
+
+	return version, err
+}
+
+// CanTransition checks if a transition from the current state of the entity to the target state is valid
+// The result may be outdated as soon as it is returned if other processes transition the entity
+func (fsm *FSM[T]) CanTransition(ctx context.Context, targetState T) (bool, error) {
+	current, _, err := fsm.state(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	encoded, err := json.Marshal(current)
+	if err != nil {
+		return false, err
+	}
+
+	for _, source := range fsm.sources[targetState] {
+		if source == string(encoded) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// Transitions returns the transition history of the entity ordered from oldest to newest
+func (fsm *FSM[T]) Transitions(ctx context.Context) ([]statetrooper.Transition[T], error) {
+	res, err := fsm.client.Eval(ctx, historyScript, fsm.keys)
+	if err != nil {
+		return nil, fmt.Errorf("redistore: failed to read history: %w", err)
+	}
+
+	values, err := parseResult(res, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	transitions := make([]statetrooper.Transition[T], 0, len(values))
+
+	for _, value := range values {
+		var transition statetrooper.Transition[T]
+
+		err = decodeState(value, &transition)
+		if err != nil {
+			return nil, err
+		}
+
+		transitions = append(transitions, transition)
+	}
+
+	return transitions, nil
+}
+
+// state returns the current state and version of the entity
+func (fsm *FSM[T]) state(ctx context.Context) (T, uint64, error) {
+	var current T
+
+	res, err := fsm.client.Eval(ctx, stateScript, fsm.keys, string(fsm.initial))
+	if err != nil {
+		return current, 0, fmt.Errorf("redistore: failed to read state: %w", err)
+	}
+
+	values, err := parseResult(res, 2)
+	if err != nil {
+		return current, 0, err
+	}
+
+	err = decodeState(values[0], &current)
+	if err != nil {
+		return current, 0, err
+	}
+
+	version, err := toInt(values[1])
+	if err != nil {
+		return current, 0, err
+	}
+
+	return current, uint64(version), nil
+}
+
+// parseResult checks that a script returned a table of at least n values
+func parseResult(res any, n int) ([]any, error) {
+	values, ok := res.([]any)
+	if !ok || len(values) < n {
+		return nil, fmt.Errorf("redistore: unexpected script result %v", res)
+	}
+
+	return values, nil
+}
+
+// toInt converts an integer returned by a script
+func toInt(value any) (int64, error) {
+	switch v := value.(type) {
+	case int64:
+		return v, nil
+	case string:
+		return strconv.ParseInt(v, 10, 64)
+	}
+
+	return 0, fmt.Errorf("redistore: unexpected integer %v", value)
+}
+
+// decodeState decodes a JSON value returned by a script
+func decodeState(value any, dst any) error {
+	switch v := value.(type) {
+	case string:
+		return json.Unmarshal([]byte(v), dst)
+	case []byte:
+		return json.Unmarshal(v, dst)
+	}
+
+	return fmt.Errorf("redistore: unexpected value %v", value)
+}
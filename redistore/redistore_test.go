@@ -0,0 +1,219 @@
+package redistore
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hishamk/statetrooper"
+)
+
+// fakeRedis emulates the scripts used by the FSM against an in-memory hash and list
+type fakeRedis struct {
+	mu      sync.Mutex
+	hashes  map[string]map[string]string
+	lists   map[string][]string
+	scripts []string
+}
+
+func newFakeRedis() *fakeRedis {
+	return &fakeRedis{
+		hashes: map[string]map[string]string{},
+		lists:  map[string][]string{},
+	}
+}
+
+func (r *fakeRedis) Eval(_ context.Context, script string, keys []string, args ...any) (any, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.scripts = append(r.scripts, script)
+
+	hash := r.hashes[keys[0]]
+	if hash == nil {
+		hash = map[string]string{}
+		r.hashes[keys[0]] = hash
+	}
+
+	version, _ := strconv.ParseInt(hash["version"], 10, 64)
+
+	current, ok := hash["state"]
+	if !ok && len(args) > 0 {
+		current = args[0].(string)
+	}
+
+	switch script {
+	case stateScript:
+		return []any{current, version}, nil
+	case historyScript:
+		values := make([]any, 0, len(r.lists[keys[1]]))
+		for _, value := range r.lists[keys[1]] {
+			values = append(values, value)
+		}
+
+		return values, nil
+	case transitionScript:
+		allowed := false
+		for _, source := range args[5:] {
+			if source.(string) == current {
+				allowed = true
+			}
+		}
+
+		if !allowed {
+			return []any{int64(0), current, version}, nil
+		}
+
+		version++
+		hash["version"] = strconv.FormatInt(version, 10)
+		hash["state"] = args[1].(string)
+
+		maxHistory, _ := strconv.Atoi(args[4].(string))
+		if maxHistory != 0 {
+			entry := `{"from_state":` + current + `,"to_state":` + args[1].(string) +
+				`,"timestamp":` + args[2].(string) + `,"event_time":` + args[2].(string) + `,"metadata":` + args[3].(string) + `}`
+
+			list := append(r.lists[keys[1]], entry)
+			if maxHistory > 0 && len(list) > maxHistory {
+				list = list[len(list)-maxHistory:]
+			}
+
+			r.lists[keys[1]] = list
+		}
+
+		return []any{int64(1), args[1].(string), version}, nil
+	}
+
+	return nil, errors.New("unknown script")
+}
+
+type jobStatus string
+
+const (
+	jobPending   jobStatus = "pending"
+	jobRunning   jobStatus = "running"
+	jobSucceeded jobStatus = "succeeded"
+	jobFailed    jobStatus = "failed"
+)
+
+var jobRules = statetrooper.Ruleset[jobStatus]{
+	jobPending: {jobRunning},
+	jobRunning: {jobSucceeded, jobFailed},
+}
+
+func Test_transition(t *testing.T) {
+	ctx := context.Background()
+	redis := newFakeRedis()
+	staticTime := time.Date(2023, 6, 18, 0, 0, 0, 0, time.UTC)
+
+	fsm, err := New[jobStatus](redis, "{job:1}", jobPending, jobRules, WithMaxHistory(1), WithTimeProvider(func() time.Time {
+		return staticTime
+	}))
+	if err != nil {
+		t.Fatalf("New() returned an error: %v", err)
+	}
+
+	tests := []struct {
+		targetState jobStatus
+		expected    jobStatus
+		wantErr     bool
+	}{
+		{jobSucceeded, jobPending, true}, // Invalid state transition
+		{jobRunning, jobRunning, false},  // Valid state transition
+		{jobRunning, jobRunning, true},   // Invalid state transition (already in target state)
+		{jobFailed, jobFailed, false},    // Valid state transition
+		{jobSucceeded, jobFailed, true},  // Invalid state transition (no transition from current state to target state)
+	}
+
+	for _, test := range tests {
+		newState, err := fsm.Transition(ctx, test.targetState, map[string]string{"worker": "w1"})
+		if (err != nil) != test.wantErr {
+			t.Errorf("Transition(%v) returned error: %v, wantErr: %v", test.targetState, err, test.wantErr)
+		}
+
+		var transitionErr statetrooper.TransitionError[jobStatus]
+		if test.wantErr && !errors.As(err, &transitionErr) {
+			t.Errorf("Transition(%v) returned an unexpected error type: %v", test.targetState, err)
+		}
+
+		if newState != test.expected {
+			t.Errorf("Transition(%v) returned state %v, expected %v", test.targetState, newState, test.expected)
+		}
+	}
+
+	// Another instance for the same key sees the same state
+	other, _ := New[jobStatus](redis, "{job:1}", jobPending, jobRules)
+
+	state, err := other.CurrentState(ctx)
+	if err != nil || state != jobFailed {
+		t.Errorf("CurrentState() = %v, %v, expected %v", state, err, jobFailed)
+	}
+
+	version, err := other.Version(ctx)
+	if err != nil || version != 2 {
+		t.Errorf("Version() = %v, %v, expected 2", version, err)
+	}
+
+	transitions, err := other.Transitions(ctx)
+	if err != nil {
+		t.Fatalf("Transitions() returned an error: %v", err)
+	}
+
+	expected := statetrooper.Transition[jobStatus]{
+		FromState: jobRunning,
+		ToState:   jobFailed,
+		Timestamp: staticTime,
+		EventTime: staticTime,
+		Metadata:  map[string]string{"worker": "w1"},
+	}
+
+	if len(transitions) != 1 || transitions[0].FromState != expected.FromState || transitions[0].ToState != expected.ToState ||
+		!transitions[0].Timestamp.Equal(expected.Timestamp) || transitions[0].Metadata["worker"] != "w1" {
+		t.Errorf("Transitions() = %v, expected [%v]", transitions, expected)
+	}
+}
+
+func Test_canTransition(t *testing.T) {
+	ctx := context.Background()
+
+	fsm, err := New[jobStatus](newFakeRedis(), "{job:2}", jobPending, jobRules)
+	if err != nil {
+		t.Fatalf("New() returned an error: %v", err)
+	}
+
+	tests := []struct {
+		targetState jobStatus
+		expected    bool
+	}{
+		{jobRunning, true},
+		{jobSucceeded, false},
+		{jobPending, false},
+	}
+
+	for _, test := range tests {
+		result, err := fsm.CanTransition(ctx, test.targetState)
+		if err != nil {
+			t.Errorf("CanTransition(%v) returned an error: %v", test.targetState, err)
+		}
+
+		if result != test.expected {
+			t.Errorf("CanTransition(%v) = %v, expected %v", test.targetState, result, test.expected)
+		}
+	}
+}
+
+func Test_scripterError(t *testing.T) {
+	scripter := ScripterFunc(func(context.Context, string, []string, ...any) (any, error) {
+		return nil, errors.New("connection refused")
+	})
+
+	fsm, _ := New[jobStatus](scripter, "{job:3}", jobPending, jobRules)
+
+	_, err := fsm.Transition(context.Background(), jobRunning, nil)
+	if err == nil {
+		t.Errorf("Transition() did not return the scripter error")
+	}
+}
@@ -0,0 +1,51 @@
+package statetrooper
+
+import "time"
+
+// RejectedAttempt is a transition attempt that failed before being committed, e.g. because the ruleset
+// doesn't allow it, the authorizer denied it or the metadata validator rejected it
+// Reason is the error the attempt failed with
+type RejectedAttempt[T comparable] struct {
+	FromState T         `json:"from_state"`
+	ToState   T         `json:"to_state"`
+	Actor     string    `json:"actor,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	Reason    string    `json:"reason"`
+}
+
+// WithRejectedAttempts keeps the last n rejected transition attempts, with their reason, actor and time,
+// in a buffer separate from the history, e.g. to find out who tried to make illegal moves
+// DEFAULT: 0, rejected attempts aren't kept
+func WithRejectedAttempts[T comparable](n int) FSMOption[T] {
+	return func(fsm *FSM[T]) {
+		fsm.maxRejected = n
+	}
+}
+
+// RejectedAttempts returns the rejected transition attempts kept with WithRejectedAttempts, oldest first
+func (fsm *FSM[T]) RejectedAttempts() []RejectedAttempt[T] {
+	fsm.rlock()
+	defer fsm.runlock()
+
+	return append([]RejectedAttempt[T](nil), fsm.rejected...)
+}
+
+// reject records a rejected transition attempt if enabled
+// The caller must hold the lock
+func (fsm *FSM[T]) reject(targetState T, actor string, err error) {
+	if fsm.maxRejected <= 0 {
+		return
+	}
+
+	if len(fsm.rejected) >= fsm.maxRejected {
+		fsm.rejected = append(fsm.rejected[:0], fsm.rejected[len(fsm.rejected)-fsm.maxRejected+1:]...)
+	}
+
+	fsm.rejected = append(fsm.rejected, RejectedAttempt[T]{
+		FromState: fsm.currentState,
+		ToState:   targetState,
+		Actor:     actor,
+		Timestamp: fsm.now(),
+		Reason:    err.Error(),
+	})
+}
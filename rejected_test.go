@@ -0,0 +1,51 @@
+package statetrooper
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_rejectedAttempts(t *testing.T) {
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10,
+		WithTimeProvider[CustomStateEnum](func() time.Time { return now }),
+		WithRejectedAttempts[CustomStateEnum](2),
+		WithMetadataValidator[CustomStateEnum](func(from, to CustomStateEnum, md map[string]string) error {
+			if md["ticket"] == "" {
+				return errors.New("ticket is required")
+			}
+
+			return nil
+		}),
+	)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+
+	fsm.Transition(CustomStateEnumC, nil, WithActor("user:1"))
+	fsm.Transition(CustomStateEnumD, nil, WithActor("user:2"))
+	fsm.Transition(CustomStateEnumB, nil, WithActor("user:3"))
+
+	// a committed transition isn't recorded
+	if _, err := fsm.Transition(CustomStateEnumB, map[string]string{"ticket": "OPS-1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rejected := fsm.RejectedAttempts()
+	if len(rejected) != 2 {
+		t.Fatalf("expected the last 2 rejected attempts, got %d", len(rejected))
+	}
+
+	if rejected[0].Actor != "user:2" || rejected[0].ToState != CustomStateEnumD || !rejected[0].Timestamp.Equal(now) {
+		t.Errorf("unexpected rejected attempt: %+v", rejected[0])
+	}
+
+	if rejected[1].Actor != "user:3" || !strings.Contains(rejected[1].Reason, "ticket is required") {
+		t.Errorf("unexpected rejected attempt: %+v", rejected[1])
+	}
+
+	if len(fsm.Transitions()) != 1 {
+		t.Errorf("expected the rejected attempts to be kept out of the history")
+	}
+}
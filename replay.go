@@ -0,0 +1,59 @@
+package statetrooper
+
+import "fmt"
+
+// ReplayTransitions rebuilds the FSM from a stored history, e.g. an event log
+// Starting from the current state, each transition must start from the state reached by the previous one
+// and be allowed by the ruleset, except for the forced, reset and compacted records the FSM makes itself,
+// as with WithStrictUnmarshal. If the history is inconsistent, a ReplayError pointing at the first
+// offending transition is returned and the FSM is not changed
+// Otherwise the transitions are appended to the history as they are, the FSM moves to the last target state
+// and the version is incremented once per transition. Subscribers are not notified of replayed transitions
+func (fsm *FSM[T]) ReplayTransitions(history []Transition[T]) error {
+	fsm.lock()
+	defer fsm.unlock()
+
+	state := fsm.currentState
+
+	for i := range history {
+		transition := history[i]
+
+		var err error
+
+		switch {
+		case transition.FromState != state:
+			err = fmt.Errorf("transition starts from %v but the state machine is in %v", transition.FromState, state)
+		case !fsm.allowsRecorded(&transition):
+			err = TransitionError[T]{FromState: transition.FromState, ToState: transition.ToState}
+		}
+
+		if err != nil {
+			return ReplayError[T]{Index: i, Transition: transition, Err: err}
+		}
+
+		state = transition.ToState
+	}
+
+	if fsm.maxHistory != 0 {
+		for _, transition := range history {
+			if err := fsm.recordTransition(transition); err != nil {
+				return err
+			}
+		}
+	}
+
+	fsm.setState(state)
+	fsm.version += uint64(len(history))
+
+	for _, transition := range history {
+		fsm.entered(transition.ToState)
+	}
+
+	if len(history) > 0 {
+		fsm.enter(history[len(history)-1].Timestamp, false)
+	}
+
+	fsm.armTimers()
+
+	return nil
+}
@@ -0,0 +1,142 @@
+package statetrooper
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_replayTransitions(t *testing.T) {
+	ts := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	history := []Transition[CustomStateEnum]{
+		{FromState: CustomStateEnumA, ToState: CustomStateEnumB, Timestamp: ts, EventTime: ts},
+		{FromState: CustomStateEnumB, ToState: CustomStateEnumC, Timestamp: ts.Add(time.Hour), EventTime: ts.Add(time.Hour)},
+	}
+
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumC)
+
+	if err := fsm.ReplayTransitions(history); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if fsm.CurrentState() != CustomStateEnumC {
+		t.Errorf("expected current state %v, got %v", CustomStateEnumC, fsm.CurrentState())
+	}
+
+	if fsm.Version() != 2 {
+		t.Errorf("expected version 2, got %d", fsm.Version())
+	}
+
+	if !reflect.DeepEqual(fsm.Transitions(), history) {
+		t.Errorf("expected history %v, got %v", history, fsm.Transitions())
+	}
+}
+
+func Test_replayTransitionsInconsistent(t *testing.T) {
+	tests := []struct {
+		name    string
+		history []Transition[CustomStateEnum]
+		index   int
+		invalid bool
+	}{
+		{
+			name: "hop not allowed",
+			history: []Transition[CustomStateEnum]{
+				{FromState: CustomStateEnumA, ToState: CustomStateEnumB},
+				{FromState: CustomStateEnumB, ToState: CustomStateEnumD},
+			},
+			index:   1,
+			invalid: true,
+		},
+		{
+			name: "gap in history",
+			history: []Transition[CustomStateEnum]{
+				{FromState: CustomStateEnumB, ToState: CustomStateEnumC},
+			},
+			index: 0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+			fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+			fsm.AddRule(CustomStateEnumB, CustomStateEnumC)
+
+			err := fsm.ReplayTransitions(test.history)
+
+			var replayErr ReplayError[CustomStateEnum]
+			if !errors.As(err, &replayErr) {
+				t.Fatalf("expected a ReplayError, got %v", err)
+			}
+
+			if replayErr.Index != test.index {
+				t.Errorf("expected index %d, got %d", test.index, replayErr.Index)
+			}
+
+			if invalid := errors.As(err, &TransitionError[CustomStateEnum]{}); invalid != test.invalid {
+				t.Errorf("expected wrapped TransitionError %v, got %v", test.invalid, err)
+			}
+
+			if fsm.CurrentState() != CustomStateEnumA || fsm.Version() != 0 || len(fsm.Transitions()) != 0 {
+				t.Errorf("expected the FSM to be unchanged")
+			}
+		})
+	}
+}
+
+func Test_replayTransitionsOwnRecords(t *testing.T) {
+	source := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	source.AddRule(CustomStateEnumA, CustomStateEnumB)
+	source.AddRule(CustomStateEnumB, CustomStateEnumA)
+
+	if _, err := source.ForceTransition(CustomStateEnumC, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := source.Reset(CustomStateEnumA, WithResetTransition(nil)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, state := range []CustomStateEnum{CustomStateEnumB, CustomStateEnumA, CustomStateEnumB} {
+		if _, err := source.Transition(state, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if err := source.CompactHistory(0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the reset record starts from C, so the replay starts there too
+	history := source.Transitions()
+
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumC, 10, WithStrictUnmarshal[CustomStateEnum]())
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumA)
+
+	if err := fsm.ValidateHistory(source.CurrentState(), history); err != nil {
+		t.Fatalf("expected the history to be valid, got %v", err)
+	}
+
+	if err := fsm.ReplayTransitions(history); err != nil {
+		t.Fatalf("expected the FSM's own records to be replayed, got %v", err)
+	}
+
+	if fsm.CurrentState() != CustomStateEnumB {
+		t.Errorf("expected current state %v, got %v", CustomStateEnumB, fsm.CurrentState())
+	}
+
+	// a forced record is replayed too
+	forced := []Transition[CustomStateEnum]{
+		{FromState: CustomStateEnumB, ToState: CustomStateEnumD, Metadata: map[string]string{MetadataForced: "true"}},
+	}
+
+	if err := fsm.ReplayTransitions(forced); err != nil {
+		t.Errorf("expected the forced record to be replayed, got %v", err)
+	}
+}
@@ -0,0 +1,96 @@
+package statetrooper
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// WithMaxHistoryAge trims the transitions older than maxAge from the history, regardless of
+// the maximum history size, passing them to the eviction handler if set
+// Expired transitions are trimmed whenever a transition is recorded and when PruneHistory is called,
+// e.g. periodically for entities that rarely transition
+// DEFAULT: transitions are only trimmed by count
+func WithMaxHistoryAge[T comparable](maxAge time.Duration) FSMOption[T] {
+	return func(fsm *FSM[T]) {
+		fsm.maxHistoryAge = maxAge
+	}
+}
+
+// PruneHistory trims the transitions older than the age set with WithMaxHistoryAge from the history
+// It returns the eviction handler's recovered panics, the transitions being trimmed regardless
+func (fsm *FSM[T]) PruneHistory() error {
+	fsm.lock()
+	defer fsm.unlock()
+
+	fsm.evictionPanics = fsm.evictionPanics[:0]
+
+	err := fsm.trimExpired()
+	if err != nil {
+		return err
+	}
+
+	return errors.Join(fsm.evictionPanics...)
+}
+
+// trimExpired trims the transitions older than the maximum history age
+// The history being ordered by timestamp, the trimming stops at the first transition that hasn't expired
+// The caller must hold the lock
+func (fsm *FSM[T]) trimExpired() error {
+	if fsm.maxHistoryAge <= 0 {
+		return nil
+	}
+
+	cutoff := fsm.timeProvider().Add(-fsm.maxHistoryAge)
+
+	size, expired, err := fsm.countExpired(cutoff)
+	if err != nil {
+		return fmt.Errorf("failed to read transition history: %w", err)
+	}
+
+	if expired == 0 {
+		return nil
+	}
+
+	var evicted func(Transition[T])
+	if fsm.evictionHandler != nil {
+		evicted = fsm.evict
+	}
+
+	err = fsm.history().Trim(size-expired, evicted)
+	if err != nil {
+		return fmt.Errorf("failed to trim transition history: %w", err)
+	}
+
+	return nil
+}
+
+// countExpired returns the size of the history and the number of its oldest transitions recorded before cutoff
+// The in-memory history is read from the oldest transition up to the first one that hasn't expired, without copying it
+// The caller must hold the lock
+func (fsm *FSM[T]) countExpired(cutoff time.Time) (size, expired int, err error) {
+	if fsm.historyStore == nil {
+		fsm.memoryHistory.each(func(transition Transition[T]) bool {
+			if !transition.Timestamp.Before(cutoff) {
+				return false
+			}
+
+			expired++
+
+			return true
+		})
+
+		return fsm.memoryHistory.Len(), expired, nil
+	}
+
+	transitions, err := fsm.historyStore.List()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for expired < len(transitions) && transitions[expired].Timestamp.Before(cutoff) {
+		expired++
+	}
+
+	return len(transitions), expired, nil
+}
@@ -0,0 +1,81 @@
+package statetrooper
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_withMaxHistoryAge(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var evicted []Transition[CustomStateEnum]
+
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10,
+		WithTimeProvider[CustomStateEnum](func() time.Time {
+			return now
+		}),
+		WithMaxHistoryAge[CustomStateEnum](24*time.Hour),
+		WithEvictionHandler[CustomStateEnum](func(transition Transition[CustomStateEnum]) {
+			evicted = append(evicted, transition)
+		}),
+	)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumC)
+	fsm.AddRule(CustomStateEnumC, CustomStateEnumD)
+
+	for _, state := range []CustomStateEnum{CustomStateEnumB, CustomStateEnumC} {
+		if _, err := fsm.Transition(state, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		now = now.Add(12 * time.Hour)
+	}
+
+	now = now.Add(time.Hour)
+
+	if _, err := fsm.Transition(CustomStateEnumD, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	transitions := fsm.Transitions()
+	if len(transitions) != 2 || transitions[0].ToState != CustomStateEnumC {
+		t.Errorf("expected the expired transition to be trimmed, got %+v", transitions)
+	}
+
+	if len(evicted) != 1 || evicted[0].ToState != CustomStateEnumB {
+		t.Errorf("expected the expired transition to be evicted, got %+v", evicted)
+	}
+
+	now = now.Add(48 * time.Hour)
+
+	if err := fsm.PruneHistory(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if transitions := fsm.Transitions(); len(transitions) != 0 {
+		t.Errorf("expected the history to be empty, got %+v", transitions)
+	}
+
+	if len(evicted) != 3 {
+		t.Errorf("expected 3 evicted transitions, got %d", len(evicted))
+	}
+}
+
+func Test_withMaxHistoryAgeAllocations(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10, WithMaxHistoryAge[CustomStateEnum](time.Hour))
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumA)
+
+	targets := []CustomStateEnum{CustomStateEnumB, CustomStateEnumA}
+	i := 0
+
+	// the history isn't copied to find the expired transitions
+	allocs := testing.AllocsPerRun(100, func() {
+		_, _ = fsm.Transition(targets[i%2], nil)
+		i++
+	})
+
+	if allocs != 0 {
+		t.Errorf("Transition allocated %v times, expected 0", allocs)
+	}
+}
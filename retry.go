@@ -0,0 +1,112 @@
+package statetrooper
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// RetryPolicy configures the exponential backoff of TransitionWithRetry
+// Zero values are replaced with their defaults
+type RetryPolicy struct {
+	// InitialInterval is the wait before the first retry DEFAULT: 100ms
+	InitialInterval time.Duration
+	// MaxInterval caps the wait between retries DEFAULT: 10s
+	MaxInterval time.Duration
+	// Multiplier increases the wait after each retry DEFAULT: 2
+	Multiplier float64
+	// MaxAttempts is the maximum number of attempts DEFAULT: 0, retry until ctx is done
+	MaxAttempts int
+}
+
+// withDefaults returns the policy with zero values replaced with their defaults
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.InitialInterval <= 0 {
+		p.InitialInterval = 100 * time.Millisecond
+	}
+
+	if p.MaxInterval <= 0 {
+		p.MaxInterval = 10 * time.Second
+	}
+
+	if p.Multiplier < 1 {
+		p.Multiplier = 2
+	}
+
+	return p
+}
+
+// Permanent marks an error returned by a metadata validator as a permanent rejection,
+// so that TransitionWithRetry gives up instead of retrying
+func Permanent(err error) error {
+	return PermanentError{Err: err}
+}
+
+// TransitionWithRetry transitions the entity to the target state, retrying with exponential backoff while
+// the transition is rejected by the metadata validator or throttled by a rate limit, e.g. when the validator
+// checks external conditions that become true a few seconds later
+// Other errors, such as a TransitionError, validator errors marked with Permanent and validator panics
+// are returned at once
+// When ctx is done or the maximum number of attempts is reached, a RetryError wrapping the last
+// rejection is returned
+func (fsm *FSM[T]) TransitionWithRetry(
+	ctx context.Context,
+	targetState T,
+	metadata map[string]string,
+	policy RetryPolicy,
+	opts ...TransitionOption,
+) (T, error) {
+	policy = policy.withDefaults()
+	interval := policy.InitialInterval
+
+	for attempt := 1; ; attempt++ {
+		state, err := fsm.Transition(targetState, metadata, opts...)
+		if err == nil || !retryable[T](err) {
+			return state, err
+		}
+
+		if policy.MaxAttempts > 0 && attempt >= policy.MaxAttempts {
+			return state, RetryError{Attempts: attempt, Err: err}
+		}
+
+		wait := interval
+
+		var rateErr RateLimitError[T]
+		if errors.As(err, &rateErr) && rateErr.RetryAfter > wait {
+			wait = rateErr.RetryAfter
+		}
+
+		timer := fsm.clock.NewTimer(wait)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return state, RetryError{Attempts: attempt, Err: err, Cause: ctx.Err()}
+		case <-timer.Chan():
+		}
+
+		interval = time.Duration(float64(interval) * policy.Multiplier)
+		if interval > policy.MaxInterval {
+			interval = policy.MaxInterval
+		}
+	}
+}
+
+// retryable reports whether the error is a rejection by the metadata validator that isn't permanent
+// or a rate limit
+func retryable[T comparable](err error) bool {
+	var permanentErr PermanentError
+	if errors.As(err, &permanentErr) {
+		return false
+	}
+
+	var panicErr PanicError
+	if errors.As(err, &panicErr) {
+		return false
+	}
+
+	var metadataErr MetadataError[T]
+
+	return errors.As(err, &metadataErr) || errors.Is(err, ErrRateLimited)
+}
@@ -0,0 +1,96 @@
+package statetrooper
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func Test_transitionWithRetry(t *testing.T) {
+	var attempts int
+
+	paid := errors.New("payment pending")
+
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10,
+		WithMetadataValidator[CustomStateEnum](func(from, to CustomStateEnum, md map[string]string) error {
+			attempts++
+			if attempts < 3 {
+				return paid
+			}
+
+			return nil
+		}),
+	)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+
+	policy := RetryPolicy{InitialInterval: time.Millisecond}
+
+	state, err := fsm.TransitionWithRetry(context.Background(), CustomStateEnumB, nil, policy)
+	if err != nil || state != CustomStateEnumB {
+		t.Fatalf("expected the transition to succeed on retry, got %v, %v", state, err)
+	}
+
+	if attempts != 3 {
+		t.Errorf("expected 3 attempts, got %d", attempts)
+	}
+
+	// an invalid transition isn't retried
+	attempts = 0
+
+	_, err = fsm.TransitionWithRetry(context.Background(), CustomStateEnumC, nil, policy)
+
+	var transitionErr TransitionError[CustomStateEnum]
+	if !errors.As(err, &transitionErr) || attempts != 0 {
+		t.Errorf("expected a TransitionError without retries, got %v after %d attempts", err, attempts)
+	}
+}
+
+func Test_transitionWithRetryGivesUp(t *testing.T) {
+	var attempts int
+
+	pending := errors.New("payment pending")
+	declined := errors.New("payment declined")
+
+	reject := pending
+
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10,
+		WithMetadataValidator[CustomStateEnum](func(from, to CustomStateEnum, md map[string]string) error {
+			attempts++
+
+			return reject
+		}),
+	)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+
+	// the maximum number of attempts is reached
+	_, err := fsm.TransitionWithRetry(context.Background(), CustomStateEnumB, nil,
+		RetryPolicy{InitialInterval: time.Millisecond, MaxAttempts: 3})
+
+	var retryErr RetryError
+	if !errors.As(err, &retryErr) || retryErr.Attempts != 3 || !errors.Is(err, pending) || attempts != 3 {
+		t.Errorf("expected a RetryError after 3 attempts, got %v after %d attempts", err, attempts)
+	}
+
+	// ctx is done
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err = fsm.TransitionWithRetry(ctx, CustomStateEnumB, nil, RetryPolicy{InitialInterval: time.Millisecond})
+	if !errors.Is(err, context.DeadlineExceeded) || !errors.Is(err, pending) {
+		t.Errorf("expected a RetryError caused by the deadline, got %v", err)
+	}
+
+	// a permanent rejection isn't retried
+	attempts = 0
+	reject = Permanent(declined)
+
+	_, err = fsm.TransitionWithRetry(context.Background(), CustomStateEnumB, nil, RetryPolicy{})
+	if !errors.Is(err, declined) || errors.As(err, &retryErr) || attempts != 1 {
+		t.Errorf("expected the permanent rejection at once, got %v after %d attempts", err, attempts)
+	}
+
+	if fsm.CurrentState() != CustomStateEnumA {
+		t.Errorf("expected the FSM to be unchanged")
+	}
+}
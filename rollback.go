@@ -0,0 +1,47 @@
+package statetrooper
+
+import "strconv"
+
+// MetadataRollbackOf is the metadata key marking rollbacks, set to the version reached by the transition
+// they reverse
+const MetadataRollbackOf = "rollback_of"
+
+// Rollback reverses the most recent transition, provided the ruleset allows the reverse edge,
+// recording it as a new transition with MetadataRollbackOf set to the version the reversed transition
+// reached, so corrections keep the causal link to the transition they undo
+// ErrNothingToRollback is returned if the history doesn't hold the transition to the current state,
+// e.g. when the history is disabled
+func (fsm *FSM[T]) Rollback(metadata map[string]string, opts ...TransitionOption) (T, error) {
+	return fsm.rollback(metadata, opts, false)
+}
+
+// ForceRollback reverses the most recent transition like Rollback, even if the ruleset doesn't allow
+// the reverse edge. The rollback is also recorded with MetadataForced set to "true"
+func (fsm *FSM[T]) ForceRollback(metadata map[string]string, opts ...TransitionOption) (T, error) {
+	return fsm.rollback(metadata, opts, true)
+}
+
+// rollback reverses the most recent transition
+func (fsm *FSM[T]) rollback(metadata map[string]string, opts []TransitionOption, forced bool) (T, error) {
+	fsm.lock()
+	defer fsm.unlock()
+
+	last, ok, err := fsm.lastTransition()
+	if err != nil {
+		return fsm.currentState, err
+	}
+
+	if !ok || last.ToState != fsm.currentState || fsm.version == 0 {
+		return fsm.currentState, ErrNothingToRollback
+	}
+
+	metadata = withMetadata(metadata, MetadataRollbackOf, strconv.FormatUint(fsm.version, 10))
+
+	if forced {
+		opts = append(opts[:len(opts):len(opts)], func(o *transitionOptions) {
+			o.forced = true
+		})
+	}
+
+	return fsm.transition(last.FromState, metadata, opts)
+}
@@ -0,0 +1,48 @@
+package statetrooper
+
+import (
+	"errors"
+	"testing"
+)
+
+func Test_rollback(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumA)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumC)
+
+	if _, err := fsm.Rollback(nil); !errors.Is(err, ErrNothingToRollback) {
+		t.Errorf("expected ErrNothingToRollback, got %v", err)
+	}
+
+	fsm.Transition(CustomStateEnumB, nil)
+
+	state, err := fsm.Rollback(map[string]string{"by": "support"})
+	if err != nil || state != CustomStateEnumA {
+		t.Fatalf("expected the rollback to %v, got %v, %v", CustomStateEnumA, state, err)
+	}
+
+	transitions := fsm.Transitions()
+	if md := transitions[1].Metadata; md[MetadataRollbackOf] != "1" || md["by"] != "support" {
+		t.Errorf("unexpected rollback metadata: %v", md)
+	}
+
+	// the reverse edge C->B isn't allowed
+	fsm.Transition(CustomStateEnumB, nil)
+	fsm.Transition(CustomStateEnumC, nil)
+
+	var transitionErr TransitionError[CustomStateEnum]
+	if _, err := fsm.Rollback(nil); !errors.As(err, &transitionErr) {
+		t.Fatalf("expected a TransitionError, got %v", err)
+	}
+
+	state, err = fsm.ForceRollback(nil)
+	if err != nil || state != CustomStateEnumB {
+		t.Fatalf("expected the forced rollback to %v, got %v, %v", CustomStateEnumB, state, err)
+	}
+
+	transitions = fsm.Transitions()
+	if md := transitions[len(transitions)-1].Metadata; md[MetadataRollbackOf] != "4" || md[MetadataForced] != "true" {
+		t.Errorf("unexpected forced rollback metadata: %v", md)
+	}
+}
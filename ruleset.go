@@ -0,0 +1,153 @@
+package statetrooper
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+)
+
+// Ruleset represents the valid transitions from each state to its allowed target states
+// A ruleset can be built once, validated and shared read-only by many FSMs using NewFSMWithRuleset
+type Ruleset[T comparable] map[T][]T
+
+// Validate checks that the ruleset defines at least one rule and has no duplicate rules
+func (rs Ruleset[T]) Validate() error {
+	if len(rs) == 0 {
+		return fmt.Errorf("no rules defined")
+	}
+
+	for fromState, toStates := range rs {
+		seen := make(map[T]bool, len(toStates))
+
+		for _, toState := range toStates {
+			if seen[toState] {
+				return DuplicateRuleError[T]{
+					FromState: fromState,
+					ToState:   toState,
+				}
+			}
+
+			seen[toState] = true
+		}
+	}
+
+	return nil
+}
+
+// hasState checks if the state is defined in the ruleset either as a source or a target state
+func (rs Ruleset[T]) hasState(state T) bool {
+	if _, ok := rs[state]; ok {
+		return true
+	}
+
+	for _, toStates := range rs {
+		for _, toState := range toStates {
+			if toState == state {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// clone returns a deep copy of the ruleset
+func (rs Ruleset[T]) clone() Ruleset[T] {
+	cloned := make(Ruleset[T], len(rs))
+
+	for fromState, toStates := range rs {
+		cloned[fromState] = append([]T(nil), toStates...)
+	}
+
+	return cloned
+}
+
+// add adds a rule unless it is already defined
+func (rs Ruleset[T]) add(fromState T, toState T) {
+	for _, existing := range rs[fromState] {
+		if existing == toState {
+			return
+		}
+	}
+
+	rs[fromState] = append(rs[fromState], toState)
+}
+
+// reachable returns the set of states that can be reached from the given state, including itself
+func (rs Ruleset[T]) reachable(from T) map[T]bool {
+	visited := map[T]bool{from: true}
+	queue := []T{from}
+
+	for len(queue) > 0 {
+		state := queue[0]
+		queue = queue[1:]
+
+		for _, toState := range rs[state] {
+			if !visited[toState] {
+				visited[toState] = true
+				queue = append(queue, toState)
+			}
+		}
+	}
+
+	return visited
+}
+
+// hash returns a deterministic SHA-256 fingerprint of the states and edges of the ruleset
+// States are identified by their string representation and the order of the rules doesn't matter
+func (rs Ruleset[T]) hash() string {
+	lines := make(map[string]struct{})
+
+	for from, targets := range rs {
+		lines[fmt.Sprintf("state %q", toString(from))] = struct{}{}
+
+		for _, to := range targets {
+			lines[fmt.Sprintf("state %q", toString(to))] = struct{}{}
+			lines[fmt.Sprintf("edge %q %q", toString(from), toString(to))] = struct{}{}
+		}
+	}
+
+	sorted := make([]string, 0, len(lines))
+	for line := range lines {
+		sorted = append(sorted, line)
+	}
+
+	sort.Strings(sorted)
+
+	h := sha256.New()
+	for _, line := range sorted {
+		h.Write([]byte(line))
+		h.Write([]byte{'\n'})
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// sortedStates returns the states of the ruleset, sources and targets, sorted by name
+func (rs Ruleset[T]) sortedStates() []T {
+	seen := make(map[T]struct{}, len(rs))
+
+	var states []T
+
+	add := func(state T) {
+		if _, ok := seen[state]; !ok {
+			seen[state] = struct{}{}
+			states = append(states, state)
+		}
+	}
+
+	for from, targets := range rs {
+		add(from)
+
+		for _, to := range targets {
+			add(to)
+		}
+	}
+
+	sort.Slice(states, func(i, j int) bool {
+		return toString(states[i]) < toString(states[j])
+	})
+
+	return states
+}
@@ -0,0 +1,98 @@
+package statetrooper
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func Test_rulesetHasState(t *testing.T) {
+	rs := Ruleset[CustomStateEnum]{
+		CustomStateEnumA: {CustomStateEnumB},
+		CustomStateEnumB: {CustomStateEnumC},
+	}
+
+	tests := []struct {
+		state    CustomStateEnum
+		expected bool
+	}{
+		{CustomStateEnumA, true},  // Source state
+		{CustomStateEnumB, true},  // Source and target state
+		{CustomStateEnumC, true},  // Target state only
+		{CustomStateEnumD, false}, // Unknown state
+	}
+
+	for _, test := range tests {
+		result := rs.hasState(test.state)
+		if result != test.expected {
+			t.Errorf("hasState(%v) = %v, expected %v", test.state, result, test.expected)
+		}
+	}
+}
+
+func Test_rulesetClone(t *testing.T) {
+	rs := Ruleset[CustomStateEnum]{
+		CustomStateEnumA: {CustomStateEnumB},
+	}
+
+	cloned := rs.clone()
+
+	if !reflect.DeepEqual(rs, cloned) {
+		t.Errorf("clone() = %v, expected %v", cloned, rs)
+	}
+
+	cloned[CustomStateEnumA][0] = CustomStateEnumC
+	cloned[CustomStateEnumB] = []CustomStateEnum{CustomStateEnumC}
+
+	if rs[CustomStateEnumA][0] != CustomStateEnumB || len(rs) != 1 {
+		t.Errorf("clone() shares storage with the original ruleset: %v", rs)
+	}
+}
+
+func Test_rulesetValidate(t *testing.T) {
+	tests := []struct {
+		rs      Ruleset[CustomStateEnum]
+		wantErr bool
+	}{
+		{Ruleset[CustomStateEnum]{}, true}, // No rules
+		{Ruleset[CustomStateEnum]{CustomStateEnumA: {CustomStateEnumB, CustomStateEnumC}}, false},
+		{Ruleset[CustomStateEnum]{CustomStateEnumA: {CustomStateEnumB, CustomStateEnumB}}, true}, // Duplicate rule
+	}
+
+	for _, test := range tests {
+		err := test.rs.Validate()
+		if (err != nil) != test.wantErr {
+			t.Errorf("Validate(%v) returned error: %v, wantErr: %v", test.rs, err, test.wantErr)
+		}
+	}
+
+	err := Ruleset[CustomStateEnum]{CustomStateEnumA: {CustomStateEnumB, CustomStateEnumB}}.Validate()
+
+	var duplicateErr DuplicateRuleError[CustomStateEnum]
+	if !errors.As(err, &duplicateErr) || duplicateErr.FromState != CustomStateEnumA || duplicateErr.ToState != CustomStateEnumB {
+		t.Errorf("Validate() returned an unexpected error: %v", err)
+	}
+}
+
+func Test_rulesetHash(t *testing.T) {
+	rs := Ruleset[CustomStateEnum]{
+		CustomStateEnumA: {CustomStateEnumB, CustomStateEnumC},
+		CustomStateEnumB: {CustomStateEnumC},
+	}
+
+	reordered := Ruleset[CustomStateEnum]{
+		CustomStateEnumB: {CustomStateEnumC},
+		CustomStateEnumA: {CustomStateEnumC, CustomStateEnumB},
+	}
+
+	if rs.hash() != reordered.hash() {
+		t.Errorf("expected the hash to ignore the order of the rules")
+	}
+
+	changed := rs.clone()
+	changed.add(CustomStateEnumC, CustomStateEnumD)
+
+	if rs.hash() == changed.hash() {
+		t.Errorf("expected the hash to change with the rules")
+	}
+}
@@ -0,0 +1,111 @@
+package statetrooper
+
+// MetadataCompensates is the metadata key marking compensating transitions, set to the edge they compensate
+// e.g. "charged->charging"
+const MetadataCompensates = "compensates"
+
+// MetadataCompensationReason is the metadata key recording the error that caused a compensating transition
+const MetadataCompensationReason = "compensation_reason"
+
+// SetCompensation registers the compensating transition of an edge: when the action of a transition
+// from fromState to toState made with TransitionWithAction fails, the FSM transitions to compensatingState,
+// e.g. from charged to refunded
+// The ruleset must allow the transition from toState to compensatingState, otherwise a TransitionError
+// is returned
+func (fsm *FSM[T]) SetCompensation(fromState, toState, compensatingState T) error {
+	fsm.lock()
+	defer fsm.unlock()
+
+	if !fsm.canTransition(&toState, &compensatingState) {
+		return TransitionError[T]{
+			FromState: toState,
+			ToState:   compensatingState,
+		}
+	}
+
+	if fsm.compensations == nil {
+		fsm.compensations = make(map[edge[T]]T)
+	}
+
+	fsm.compensations[edge[T]{from: fromState, to: toState}] = compensatingState
+
+	return nil
+}
+
+// ClearCompensation removes the compensating transition of an edge
+func (fsm *FSM[T]) ClearCompensation(fromState, toState T) {
+	fsm.lock()
+	defer fsm.unlock()
+
+	delete(fsm.compensations, edge[T]{from: fromState, to: toState})
+}
+
+// TransitionWithAction transitions the entity to the target state, then runs the action, e.g. charging
+// a payment, with the edge it transitioned along. The action runs without the FSM being locked
+// The action runs once the transition is committed, even if a subscriber or hook failed afterwards, whose
+// errors are returned along with the action's
+// If the action fails and a compensating transition is registered for the edge with SetCompensation,
+// the FSM makes it from the state the transition ended in, which differs from toState if automatic edges
+// moved it further, with MetadataCompensates and MetadataCompensationReason set in its metadata, so both
+// transitions are recorded in the history. The compensation is skipped if the FSM has transitioned
+// in the meantime. A failed action is reported with a CompensationError
+func (fsm *FSM[T]) TransitionWithAction(
+	targetState T,
+	metadata map[string]string,
+	action func(from, to T) error,
+	opts ...TransitionOption,
+) (T, error) {
+	fsm.lock()
+
+	fromState := fsm.currentState
+	version := fsm.version
+
+	// the transition is committed if the version changed, errors being those of post-commit callbacks
+	state, hookErr := fsm.transition(targetState, metadata, opts)
+	if fsm.version == version {
+		fsm.unlock()
+
+		return state, hookErr
+	}
+
+	version = fsm.version
+
+	fsm.unlock()
+
+	actionErr := action(fromState, targetState)
+	if actionErr == nil {
+		return state, hookErr
+	}
+
+	fsm.lock()
+	defer fsm.unlock()
+
+	compErr := CompensationError[T]{FromState: fromState, ToState: targetState, Err: actionErr}
+
+	compensatingState, ok := fsm.compensations[edge[T]{from: fromState, to: targetState}]
+	if !ok {
+		return fsm.currentState, appendError(hookErr, compErr)
+	}
+
+	if fsm.version != version {
+		compErr.CompensationErr = VersionMismatchError{Expected: version, Actual: fsm.version}
+
+		return fsm.currentState, appendError(hookErr, compErr)
+	}
+
+	md := withMetadata(metadata, MetadataCompensates, toString(fromState)+"->"+toString(targetState))
+	md[MetadataCompensationReason] = actionErr.Error()
+
+	compVersion := fsm.version
+
+	state, err := fsm.transition(compensatingState, md, nil)
+	if fsm.version == compVersion {
+		compErr.CompensationErr = err
+
+		return state, appendError(hookErr, compErr)
+	}
+
+	compErr.Compensated = true
+
+	return state, appendError(appendError(hookErr, compErr), err)
+}
@@ -0,0 +1,134 @@
+package statetrooper
+
+import (
+	"errors"
+	"testing"
+)
+
+func Test_transitionWithAction(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumC)
+
+	if err := fsm.SetCompensation(CustomStateEnumA, CustomStateEnumB, CustomStateEnumD); err == nil {
+		t.Errorf("expected an error for a compensation the ruleset doesn't allow")
+	}
+
+	if err := fsm.SetCompensation(CustomStateEnumA, CustomStateEnumB, CustomStateEnumC); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	declined := errors.New("card declined")
+
+	_, err := fsm.TransitionWithAction(CustomStateEnumB, nil, func(from, to CustomStateEnum) error {
+		if from != CustomStateEnumA || to != CustomStateEnumB {
+			t.Errorf("unexpected edge %v->%v", from, to)
+		}
+
+		return declined
+	})
+
+	var compErr CompensationError[CustomStateEnum]
+	if !errors.As(err, &compErr) || !compErr.Compensated || !errors.Is(err, declined) {
+		t.Fatalf("expected a compensated CompensationError, got %v", err)
+	}
+
+	transitions := fsm.Transitions()
+	if len(transitions) != 2 || fsm.CurrentState() != CustomStateEnumC {
+		t.Fatalf("expected both transitions to be recorded, got %v", transitions)
+	}
+
+	md := transitions[1].Metadata
+	if md[MetadataCompensates] != "A->B" || md[MetadataCompensationReason] != "card declined" {
+		t.Errorf("unexpected compensation metadata: %v", md)
+	}
+}
+
+func Test_transitionWithActionWithoutCompensation(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+
+	// a successful action keeps the transition
+	state, err := fsm.TransitionWithAction(CustomStateEnumB, nil, func(from, to CustomStateEnum) error {
+		return nil
+	})
+	if err != nil || state != CustomStateEnumB {
+		t.Fatalf("unexpected result: %v, %v", state, err)
+	}
+
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumC)
+
+	// without a compensation, the failed action is reported and the transition kept
+	_, err = fsm.TransitionWithAction(CustomStateEnumC, nil, func(from, to CustomStateEnum) error {
+		return errors.New("failed")
+	})
+
+	var compErr CompensationError[CustomStateEnum]
+	if !errors.As(err, &compErr) || compErr.Compensated || compErr.CompensationErr != nil {
+		t.Errorf("expected an uncompensated CompensationError, got %v", err)
+	}
+
+	if fsm.CurrentState() != CustomStateEnumC {
+		t.Errorf("expected the transition to be kept, got %v", fsm.CurrentState())
+	}
+
+	// the action isn't run for a failed transition
+	_, err = fsm.TransitionWithAction(CustomStateEnumA, nil, func(from, to CustomStateEnum) error {
+		t.Errorf("unexpected action")
+
+		return nil
+	})
+
+	var transitionErr TransitionError[CustomStateEnum]
+	if !errors.As(err, &transitionErr) {
+		t.Errorf("expected a TransitionError, got %v", err)
+	}
+}
+
+func Test_transitionWithActionAfterHookError(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumC, CustomStateEnumD)
+	fsm.AddRule(CustomStateEnumC, CustomStateEnumD)
+
+	fsm.Subscribe(func(Transition[CustomStateEnum]) {
+		panic("boom")
+	})
+
+	if err := fsm.SetAutomatic(CustomStateEnumB, CustomStateEnumC, 0, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := fsm.SetCompensation(CustomStateEnumA, CustomStateEnumB, CustomStateEnumD); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ran := false
+
+	state, err := fsm.TransitionWithAction(CustomStateEnumB, nil, func(from, to CustomStateEnum) error {
+		ran = true
+
+		return errors.New("failed")
+	})
+
+	// the action runs although the subscriber failed after the commit
+	if !ran {
+		t.Fatalf("expected the action to run for a committed transition")
+	}
+
+	var compErr CompensationError[CustomStateEnum]
+	if !errors.As(err, &compErr) || !compErr.Compensated {
+		t.Fatalf("expected a compensated CompensationError, got %v", err)
+	}
+
+	var panicErr PanicError
+	if !errors.As(err, &panicErr) {
+		t.Errorf("expected the subscriber's panic to be returned, got %v", err)
+	}
+
+	// the compensation is made from the state the automatic edge moved the FSM to
+	transitions := fsm.Transitions()
+	if state != CustomStateEnumD || len(transitions) != 3 || transitions[2].FromState != CustomStateEnumC {
+		t.Errorf("expected the FSM to be compensated from %v, got %v and %v", CustomStateEnumC, state, transitions)
+	}
+}
@@ -0,0 +1,91 @@
+package statetrooper
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// WithScheduleSkippedHandler sets a function called when a scheduled transition is skipped because
+// the FSM transitioned since it was scheduled or the transition has become invalid
+// err is a VersionMismatchError or the error returned by the transition
+// DEFAULT: nil
+func WithScheduleSkippedHandler[T comparable](handler func(targetState T, err error)) FSMOption[T] {
+	return func(fsm *FSM[T]) {
+		fsm.scheduleSkippedHandler = handler
+	}
+}
+
+// ScheduleTransition transitions the entity to the target state at the given time, provided the FSM
+// hasn't transitioned in the meantime. Otherwise the scheduled transition is skipped and the handler
+// set with WithScheduleSkippedHandler is called
+// The transition must be valid when scheduled, otherwise a TransitionError is returned
+// The scheduled transition is cancelled by calling cancel or when ctx is done
+func (fsm *FSM[T]) ScheduleTransition(
+	ctx context.Context,
+	targetState T,
+	at time.Time,
+	metadata map[string]string,
+	opts ...TransitionOption,
+) (cancel func(), err error) {
+	fsm.lock()
+	defer fsm.unlock()
+
+	if !fsm.canTransition(&fsm.currentState, &targetState) {
+		return nil, TransitionError[T]{
+			FromState: fsm.currentState,
+			ToState:   targetState,
+		}
+	}
+
+	version := fsm.version
+
+	var once sync.Once
+
+	done := make(chan struct{})
+
+	timer := fsm.clock.AfterFunc(at.Sub(fsm.clock.Now()), func() {
+		fired := false
+
+		once.Do(func() {
+			fired = true
+			close(done)
+		})
+
+		if fired {
+			fsm.runScheduled(version, targetState, metadata, opts)
+		}
+	})
+
+	cancel = func() {
+		once.Do(func() {
+			timer.Stop()
+			close(done)
+		})
+	}
+
+	if ctx.Done() != nil {
+		go func() {
+			select {
+			case <-ctx.Done():
+				cancel()
+			case <-done:
+			}
+		}()
+	}
+
+	return cancel, nil
+}
+
+// runScheduled makes a scheduled transition if the FSM is still at the version it was scheduled at
+// A transition committed despite a failing subscriber or hook isn't reported as skipped
+func (fsm *FSM[T]) runScheduled(version uint64, targetState T, metadata map[string]string, opts []TransitionOption) {
+	committed := false
+
+	_, err := fsm.lockAndTransition(&version, targetState, metadata, append(opts, WithCommitted(&committed)))
+	if err != nil && !committed && fsm.scheduleSkippedHandler != nil {
+		fsm.runHook(func() {
+			fsm.scheduleSkippedHandler(targetState, err)
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package statetrooper
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func newScheduleTestFSM(opts ...FSMOption[CustomStateEnum]) *FSM[CustomStateEnum] {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10, opts...)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB, CustomStateEnumC)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumC)
+
+	return fsm
+}
+
+func Test_scheduleTransition(t *testing.T) {
+	clock := newFakeClock(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC))
+	fsm := newScheduleTestFSM(WithClock[CustomStateEnum](clock))
+
+	_, err := fsm.ScheduleTransition(context.Background(), CustomStateEnumB, clock.Now().Add(time.Hour), map[string]string{"scheduled": "true"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	clock.Advance(59 * time.Minute)
+
+	if fsm.CurrentState() != CustomStateEnumA {
+		t.Fatalf("expected the transition to wait until it is due, got %v", fsm.CurrentState())
+	}
+
+	clock.Advance(time.Minute)
+
+	if fsm.CurrentState() != CustomStateEnumB {
+		t.Fatalf("expected the scheduled transition to %v, got %v", CustomStateEnumB, fsm.CurrentState())
+	}
+
+	if md := fsm.Transitions()[0].Metadata; md["scheduled"] != "true" {
+		t.Errorf("expected the metadata to be recorded, got %v", md)
+	}
+
+	if _, err := fsm.ScheduleTransition(context.Background(), CustomStateEnumA, clock.Now(), nil); !errors.As(err, &TransitionError[CustomStateEnum]{}) {
+		t.Errorf("expected a TransitionError, got %v", err)
+	}
+}
+
+func Test_scheduleTransitionSkipped(t *testing.T) {
+	var skipped []error
+
+	clock := newFakeClock(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC))
+	fsm := newScheduleTestFSM(
+		WithClock[CustomStateEnum](clock),
+		WithScheduleSkippedHandler(func(target CustomStateEnum, err error) {
+			skipped = append(skipped, err)
+		}),
+	)
+
+	_, err := fsm.ScheduleTransition(context.Background(), CustomStateEnumC, clock.Now().Add(time.Hour), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := fsm.Transition(CustomStateEnumB, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	clock.Advance(time.Hour)
+
+	if fsm.CurrentState() != CustomStateEnumB {
+		t.Errorf("expected the scheduled transition to be skipped, got %v", fsm.CurrentState())
+	}
+
+	if len(skipped) != 1 || !errors.Is(skipped[0], ErrVersionMismatch) {
+		t.Errorf("expected the skip handler to be called with a version mismatch, got %v", skipped)
+	}
+}
+
+func Test_scheduleTransitionHookError(t *testing.T) {
+	var skipped []error
+
+	clock := newFakeClock(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC))
+	fsm := newScheduleTestFSM(
+		WithClock[CustomStateEnum](clock),
+		WithScheduleSkippedHandler(func(target CustomStateEnum, err error) {
+			skipped = append(skipped, err)
+		}),
+	)
+
+	fsm.Subscribe(func(Transition[CustomStateEnum]) {
+		panic("subscriber failed")
+	})
+
+	if _, err := fsm.ScheduleTransition(context.Background(), CustomStateEnumB, clock.Now().Add(time.Hour), nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	clock.Advance(time.Hour)
+
+	if fsm.CurrentState() != CustomStateEnumB {
+		t.Fatalf("expected the scheduled transition to %v, got %v", CustomStateEnumB, fsm.CurrentState())
+	}
+
+	if len(skipped) != 0 {
+		t.Errorf("expected a committed transition not to be reported as skipped, got %v", skipped)
+	}
+}
+
+func Test_scheduleTransitionCancelled(t *testing.T) {
+	clock := newFakeClock(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC))
+	fsm := newScheduleTestFSM(WithClock[CustomStateEnum](clock))
+
+	cancel, err := fsm.ScheduleTransition(context.Background(), CustomStateEnumB, clock.Now().Add(time.Hour), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cancel()
+
+	ctx, cancelCtx := context.WithCancel(context.Background())
+
+	if _, err := fsm.ScheduleTransition(ctx, CustomStateEnumC, clock.Now().Add(time.Hour), nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cancelCtx()
+
+	// the context is watched on another goroutine, which stops the timer
+	if !clock.waitIdle(time.Second) {
+		t.Fatalf("expected the cancelled context to stop the timer")
+	}
+
+	clock.Advance(time.Hour)
+
+	if fsm.CurrentState() != CustomStateEnumA {
+		t.Errorf("expected the scheduled transitions to be cancelled, got %v", fsm.CurrentState())
+	}
+}
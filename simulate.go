@@ -0,0 +1,109 @@
+package statetrooper
+
+// SimulationResult is the outcome of simulating a sequence of transitions with Simulate
+type SimulationResult[T comparable] struct {
+	// States are the states the FSM would go through, including those reached along automatic edges,
+	// starting with the current state and ending with the last state reached before an invalid transition, if any
+	States []T
+
+	// Valid reports whether every transition of the path would succeed
+	Valid bool
+}
+
+// FinalState returns the state the FSM would end up in
+func (r SimulationResult[T]) FinalState() T {
+	return r.States[len(r.States)-1]
+}
+
+// Simulate checks whether the sequence of transitions to each state of the path would succeed from
+// the current state, without changing the FSM, e.g. to validate a workflow plan before executing it
+// Transitions are checked against the ruleset and the metadata validator, which is given the default
+// metadata as the path carries none; the validator must therefore be free of side effects, as must the guards
+// of automatic edges, which are followed after each transition as SetAutomatic describes, their states being
+// part of the result. Panics in the validator or guards are recovered and fail the transition or skip the edge
+// Other checks made by Transition, such as the authorizer or rate limits, aren't simulated
+// If a transition would fail, the result holds the states reached until then and a SimulationError
+// wrapping the TransitionError or MetadataError is returned
+func (fsm *FSM[T]) Simulate(path []T) (SimulationResult[T], error) {
+	fsm.rlock()
+	defer fsm.runlock()
+
+	result := SimulationResult[T]{
+		States: append(make([]T, 0, len(path)+1), fsm.currentState),
+	}
+
+	state := fsm.currentState
+
+	for i, targetState := range path {
+		if !fsm.canTransition(&state, &targetState) {
+			if fsm.idempotentSameState && targetState == state {
+				continue
+			}
+
+			return result, SimulationError[T]{
+				Index: i,
+				Err:   TransitionError[T]{FromState: state, ToState: targetState},
+			}
+		}
+
+		if fsm.metadataValidator != nil {
+			err := fsm.validateMetadata(state, targetState, copyMetadata(fsm.defaultMetadata))
+			if err != nil {
+				return result, SimulationError[T]{
+					Index: i,
+					Err:   MetadataError[T]{FromState: state, ToState: targetState, Err: err},
+				}
+			}
+		}
+
+		state = targetState
+		result.States = append(result.States, state)
+
+		for hops := 0; hops < fsm.automaticCount; hops++ {
+			next, ok := fsm.simulateHop(state)
+			if !ok {
+				break
+			}
+
+			state = next
+			result.States = append(result.States, state)
+		}
+	}
+
+	result.Valid = true
+
+	return result, nil
+}
+
+// simulateHop returns the state the first automatic edge of the state that its guard, the ruleset and
+// the metadata validator allow leads to, if any
+// The caller must hold the lock
+func (fsm *FSM[T]) simulateHop(from T) (T, bool) {
+	for _, e := range fsm.automatic[from] {
+		to := e.to
+
+		if e.guard != nil {
+			allowed := false
+
+			if panicErr := fsm.runHook(func() {
+				allowed = e.guard(from, to)
+			}); panicErr != nil || !allowed {
+				continue
+			}
+		}
+
+		if !fsm.canTransition(&from, &to) {
+			continue
+		}
+
+		if fsm.metadataValidator != nil {
+			if fsm.validateMetadata(from, to, withMetadata(fsm.defaultMetadata, MetadataAutomatic, "true")) != nil {
+				continue
+			}
+		}
+
+		return to, true
+	}
+
+	return from, false
+}
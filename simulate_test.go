@@ -0,0 +1,94 @@
+package statetrooper
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func Test_simulate(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10,
+		WithMetadataValidator(func(from, to CustomStateEnum, metadata map[string]string) error {
+			if to == CustomStateEnumD {
+				return errors.New("D requires approval")
+			}
+
+			return nil
+		}))
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumC, CustomStateEnumD)
+
+	result, err := fsm.Simulate([]CustomStateEnum{CustomStateEnumB, CustomStateEnumC})
+	if err != nil || !result.Valid || result.FinalState() != CustomStateEnumC {
+		t.Errorf("Simulate() = %+v, %v, expected a valid path to %v", result, err, CustomStateEnumC)
+	}
+
+	result, err = fsm.Simulate([]CustomStateEnum{CustomStateEnumB, CustomStateEnumA})
+
+	var simulationErr SimulationError[CustomStateEnum]
+	if !errors.As(err, &simulationErr) || simulationErr.Index != 1 || !errors.As(err, &TransitionError[CustomStateEnum]{}) {
+		t.Errorf("expected a SimulationError wrapping a TransitionError at step 1, got %v", err)
+	}
+
+	if result.Valid || !reflect.DeepEqual(result.States, []CustomStateEnum{CustomStateEnumA, CustomStateEnumB}) {
+		t.Errorf("unexpected result %+v", result)
+	}
+
+	_, err = fsm.Simulate([]CustomStateEnum{CustomStateEnumB, CustomStateEnumD})
+	if !errors.As(err, &MetadataError[CustomStateEnum]{}) {
+		t.Errorf("expected a MetadataError, got %v", err)
+	}
+
+	if fsm.CurrentState() != CustomStateEnumA || fsm.Version() != 0 || len(fsm.Transitions()) != 0 {
+		t.Errorf("expected Simulate not to change the FSM, got %v", fsm)
+	}
+}
+
+func Test_simulateAutomaticEdges(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumC, CustomStateEnumD)
+	fsm.AddRule(CustomStateEnumC, CustomStateEnumA)
+
+	// the panicking guard's edge is skipped, the other one taken
+	if err := fsm.SetAutomatic(CustomStateEnumB, CustomStateEnumD, 1, func(from, to CustomStateEnum) bool {
+		panic("boom")
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := fsm.SetAutomatic(CustomStateEnumB, CustomStateEnumC, 0, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	result, err := fsm.Simulate([]CustomStateEnum{CustomStateEnumB, CustomStateEnumA})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []CustomStateEnum{CustomStateEnumA, CustomStateEnumB, CustomStateEnumC, CustomStateEnumA}
+	if !reflect.DeepEqual(result.States, expected) {
+		t.Errorf("expected states %v, got %v", expected, result.States)
+	}
+
+	// the simulation ends where the transition does
+	state, _ := fsm.Transition(CustomStateEnumB, nil)
+	if state != result.States[2] {
+		t.Errorf("expected the transition to end in %v, got %v", result.States[2], state)
+	}
+}
+
+func Test_simulatePanickingValidator(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10,
+		WithMetadataValidator(func(from, to CustomStateEnum, metadata map[string]string) error {
+			panic("boom")
+		}))
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+
+	_, err := fsm.Simulate([]CustomStateEnum{CustomStateEnumB})
+
+	var panicErr PanicError
+	if !errors.As(err, &panicErr) {
+		t.Errorf("expected the recovered panic to fail the simulation, got %v", err)
+	}
+}
@@ -0,0 +1,149 @@
+package statetrooper
+
+import "time"
+
+// SLABreach describes an FSM that stayed in a state for longer than the state's SLA
+type SLABreach[T comparable] struct {
+	State     T
+	SLA       time.Duration
+	EnteredAt time.Time
+	Version   uint64
+}
+
+// WithSLABreachHandler sets a function called when the FSM stays in a state for longer than the SLA
+// set with SetStateSLA. It is called once per stay in the state and doesn't change the state
+// The handler runs on its own goroutine and must not block; use a buffered channel to receive breaches
+// elsewhere
+// DEFAULT: nil
+func WithSLABreachHandler[T comparable](handler func(SLABreach[T])) FSMOption[T] {
+	return func(fsm *FSM[T]) {
+		fsm.slaBreachHandler = handler
+	}
+}
+
+// SetStateSLA sets the maximum time the FSM is expected to stay in the state
+// If the FSM is already in the state, the time already spent in it counts towards the SLA
+func (fsm *FSM[T]) SetStateSLA(state T, sla time.Duration) {
+	fsm.lock()
+	defer fsm.unlock()
+
+	if fsm.slas == nil {
+		fsm.slas = make(map[T]time.Duration)
+	}
+
+	fsm.slas[state] = sla
+
+	if fsm.currentState == state {
+		fsm.armSLA()
+	}
+}
+
+// ClearStateSLA removes the SLA of the state
+func (fsm *FSM[T]) ClearStateSLA(state T) {
+	fsm.lock()
+	defer fsm.unlock()
+
+	delete(fsm.slas, state)
+
+	if fsm.currentState == state {
+		fsm.armSLA()
+	}
+}
+
+// DwellTime returns how long the FSM has been in its current state
+func (fsm *FSM[T]) DwellTime() time.Duration {
+	fsm.rlock()
+	defer fsm.runlock()
+
+	return fsm.dwellTime()
+}
+
+// dwellTime returns how long the FSM has been in its current state
+// The monotonic clock is used when the state was entered in this process on the real clock, so that
+// wall clock jumps don't distort the duration. Otherwise the time provider is used and negative
+// durations are reported as 0
+// The caller must hold the lock
+func (fsm *FSM[T]) dwellTime() time.Duration {
+	if !fsm.enteredMono.IsZero() {
+		return time.Since(fsm.enteredMono)
+	}
+
+	d := fsm.timeProvider().Sub(fsm.enteredAt)
+	if d < 0 {
+		return 0
+	}
+
+	return d
+}
+
+// enter records the time the current state was entered, taking a monotonic clock reading
+// if now is set and the FSM runs on the real clock with the default time provider
+// The caller must hold the lock
+func (fsm *FSM[T]) enter(tn time.Time, now bool) {
+	fsm.enteredAt = tn
+	fsm.enteredMono = time.Time{}
+
+	if now && !fsm.customTimeProvider && (fsm.clock == nil || fsm.clock == RealClock) {
+		fsm.enteredMono = time.Now()
+	}
+}
+
+// armTimers starts the timeout and SLA timers of the current state
+// The caller must hold the lock
+func (fsm *FSM[T]) armTimers() {
+	fsm.armTimeout()
+	fsm.armSLA()
+}
+
+// restoreEnteredAt sets the time the current state was entered from the newest transition in the history,
+// or to now if the history is empty
+// The caller must hold the lock
+func (fsm *FSM[T]) restoreEnteredAt() error {
+	last, ok, err := fsm.lastTransition()
+	if err != nil {
+		return err
+	}
+
+	if ok && last.ToState == fsm.currentState {
+		fsm.enter(last.Timestamp, false)
+	} else {
+		// the FSM may be a zero value being unmarshaled
+		fsm.setDefaults()
+		fsm.enter(fsm.now(), true)
+	}
+
+	return nil
+}
+
+// armSLA cancels the pending SLA timer and starts the SLA timer of the current state, if any
+// The caller must hold the lock
+func (fsm *FSM[T]) armSLA() {
+	if fsm.slaTimer != nil {
+		fsm.slaTimer.Stop()
+		fsm.slaTimer = nil
+	}
+
+	sla, ok := fsm.slas[fsm.currentState]
+	if !ok || fsm.slaBreachHandler == nil {
+		return
+	}
+
+	breach := SLABreach[T]{
+		State:     fsm.currentState,
+		SLA:       sla,
+		EnteredAt: fsm.enteredAt,
+		Version:   fsm.version,
+	}
+
+	fsm.slaTimer = fsm.clock.AfterFunc(sla-fsm.dwellTime(), func() {
+		fsm.rlock()
+		breached := fsm.version == breach.Version
+		fsm.runlock()
+
+		if breached {
+			fsm.runHook(func() {
+				fsm.slaBreachHandler(breach)
+			})
+		}
+	})
+}
@@ -0,0 +1,95 @@
+package statetrooper
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_setStateSLA(t *testing.T) {
+	breaches := make(chan SLABreach[CustomStateEnum], 1)
+
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10, WithSLABreachHandler(func(breach SLABreach[CustomStateEnum]) {
+		breaches <- breach
+	}))
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumC)
+
+	fsm.SetStateSLA(CustomStateEnumB, 10*time.Millisecond)
+
+	if _, err := fsm.Transition(CustomStateEnumB, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	select {
+	case breach := <-breaches:
+		if breach.State != CustomStateEnumB || breach.SLA != 10*time.Millisecond || breach.Version != 1 {
+			t.Errorf("unexpected breach: %+v", breach)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected an SLA breach")
+	}
+
+	if fsm.CurrentState() != CustomStateEnumB {
+		t.Errorf("expected the SLA breach not to change the state, got %v", fsm.CurrentState())
+	}
+
+	if dwell := fsm.DwellTime(); dwell < 10*time.Millisecond {
+		t.Errorf("expected a dwell time of at least 10ms, got %v", dwell)
+	}
+}
+
+func Test_setStateSLANotBreached(t *testing.T) {
+	breaches := make(chan SLABreach[CustomStateEnum], 1)
+
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10, WithSLABreachHandler(func(breach SLABreach[CustomStateEnum]) {
+		breaches <- breach
+	}))
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumC)
+
+	fsm.SetStateSLA(CustomStateEnumB, 20*time.Millisecond)
+	fsm.SetStateSLA(CustomStateEnumC, 20*time.Millisecond)
+	fsm.ClearStateSLA(CustomStateEnumC)
+
+	if _, err := fsm.Transition(CustomStateEnumB, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := fsm.Transition(CustomStateEnumC, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	select {
+	case breach := <-breaches:
+		t.Errorf("unexpected breach: %+v", breach)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func Test_dwellTimeWallClockJump(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+
+	if _, err := fsm.Transition(CustomStateEnumB, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// simulate the wall clock having been an hour ahead when the state was entered
+	fsm.enteredAt = fsm.enteredAt.Add(time.Hour)
+
+	if dwell := fsm.DwellTime(); dwell < 0 || dwell > time.Minute {
+		t.Errorf("expected the dwell time to follow the monotonic clock, got %v", dwell)
+	}
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	fsm = NewFSM[CustomStateEnum](CustomStateEnumA, 10, WithTimeProvider[CustomStateEnum](func() time.Time {
+		return now
+	}))
+
+	now = now.Add(-time.Hour)
+
+	if dwell := fsm.DwellTime(); dwell != 0 {
+		t.Errorf("expected a dwell time of 0 after the clock went back, got %v", dwell)
+	}
+}
@@ -0,0 +1,94 @@
+package statetrooper
+
+// SnapshotFormatVersion is the version of the Snapshot format produced by this package
+// It is incremented whenever the format changes in a way older versions can't restore
+const SnapshotFormatVersion = 1
+
+// Snapshot captures the state of an FSM for persistence
+// Its JSON representation is a stable, documented format independent from the FSM's own JSON marshaling
+type Snapshot[T comparable] struct {
+	// FormatVersion is the SnapshotFormatVersion the snapshot was taken with
+	FormatVersion int `json:"format_version"`
+	// State is the current state
+	State T `json:"state"`
+	// Version is the number of successful transitions
+	Version uint64 `json:"version"`
+	// RulesetHash is the fingerprint of the ruleset the snapshot was taken under
+	RulesetHash string `json:"ruleset_hash"`
+	// Transitions is the transition history from oldest to newest
+	Transitions []Transition[T] `json:"transitions"`
+	// Entries counts the entries into each state, see TimesEntered
+	Entries []EntryCount[T] `json:"entries,omitempty"`
+}
+
+// Snapshot captures the current state, version, history and ruleset fingerprint of the FSM
+func (fsm *FSM[T]) Snapshot() (Snapshot[T], error) {
+	fsm.rlock()
+	defer fsm.runlock()
+
+	transitions, err := fsm.history().List()
+	if err != nil {
+		return Snapshot[T]{}, err
+	}
+
+	return Snapshot[T]{
+		FormatVersion: SnapshotFormatVersion,
+		State:         fsm.currentState,
+		Version:       fsm.version,
+		RulesetHash:   fsm.ruleset.hash(),
+		Transitions:   fsm.localizeAll(transitions, true),
+		Entries:       fsm.entryCounts(),
+	}, nil
+}
+
+// Restore replaces the current state, version and history of the FSM with the snapshot
+// A SnapshotFormatError is returned for snapshots taken with a newer format and a RulesetMismatchError
+// for snapshots taken under a different ruleset, unless the snapshot has no ruleset fingerprint or
+// state migrations are set
+// If the snapshot holds more transitions than the FSM keeps, the ones selected by WithImportTruncation are restored
+func (fsm *FSM[T]) Restore(snapshot Snapshot[T]) error {
+	fsm.lock()
+	defer fsm.unlock()
+
+	return fsm.restore(snapshot)
+}
+
+// restore replaces the current state, version and history of the FSM with the snapshot
+// The caller must hold the lock
+func (fsm *FSM[T]) restore(snapshot Snapshot[T]) error {
+	if snapshot.FormatVersion > SnapshotFormatVersion {
+		return SnapshotFormatError{FormatVersion: snapshot.FormatVersion}
+	}
+
+	if snapshot.RulesetHash != "" && fsm.migrations == nil {
+		if hash := fsm.ruleset.hash(); hash != snapshot.RulesetHash {
+			return RulesetMismatchError{Expected: hash, Actual: snapshot.RulesetHash}
+		}
+	}
+
+	if fsm.strictUnmarshal {
+		err := fsm.validateImport(snapshot.State, snapshot.Transitions)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := fsm.replaceHistory(fsm.localizeAll(fsm.truncateImported(snapshot.Transitions), false))
+	if err != nil {
+		return err
+	}
+
+	fsm.setState(snapshot.State)
+	fsm.version = snapshot.Version
+	fsm.restoreEntries(snapshot.Entries, snapshot.Transitions)
+
+	err = fsm.restoreEnteredAt()
+	if err != nil {
+		return err
+	}
+
+	err = fsm.migrate()
+	fsm.armTimers()
+
+	return err
+}
@@ -0,0 +1,102 @@
+package statetrooper
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func newSnapshotTestFSM(maxHistory int) *FSM[CustomStateEnum] {
+	ts := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, maxHistory, WithTimeProvider[CustomStateEnum](func() time.Time {
+		return ts
+	}))
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumC)
+	fsm.AddRule(CustomStateEnumC, CustomStateEnumD)
+
+	return fsm
+}
+
+func Test_snapshotRestore(t *testing.T) {
+	fsm := newSnapshotTestFSM(10)
+
+	for _, state := range []CustomStateEnum{CustomStateEnumB, CustomStateEnumC} {
+		if _, err := fsm.Transition(state, map[string]string{"to": string(state)}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	snapshot, err := fsm.Snapshot()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if snapshot.FormatVersion != SnapshotFormatVersion || snapshot.State != CustomStateEnumC || snapshot.Version != 2 {
+		t.Errorf("unexpected snapshot: %+v", snapshot)
+	}
+
+	// the snapshot survives a JSON round-trip
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var decoded Snapshot[CustomStateEnum]
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	restored := newSnapshotTestFSM(10)
+	if err := restored.Restore(decoded); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if restored.CurrentState() != CustomStateEnumC || restored.Version() != 2 {
+		t.Errorf("expected state %v at version 2, got %v at version %d", CustomStateEnumC, restored.CurrentState(), restored.Version())
+	}
+
+	if !reflect.DeepEqual(restored.Transitions(), fsm.Transitions()) {
+		t.Errorf("expected history %v, got %v", fsm.Transitions(), restored.Transitions())
+	}
+
+	// only the most recent transitions are kept
+	small := newSnapshotTestFSM(1)
+	if err := small.Restore(snapshot); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if transitions := small.Transitions(); len(transitions) != 1 || transitions[0].ToState != CustomStateEnumC {
+		t.Errorf("expected the last transition to be kept, got %v", transitions)
+	}
+}
+
+func Test_restoreIncompatible(t *testing.T) {
+	snapshot, err := newSnapshotTestFSM(10).Snapshot()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	other := newSnapshotTestFSM(10)
+	other.AddRule(CustomStateEnumD, CustomStateEnumA)
+
+	err = other.Restore(snapshot)
+	if !errors.Is(err, ErrRulesetMismatch) {
+		t.Errorf("expected ErrRulesetMismatch, got %v", err)
+	}
+
+	// snapshots without a fingerprint are not checked
+	snapshot.RulesetHash = ""
+	if err := other.Restore(snapshot); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	snapshot.FormatVersion = SnapshotFormatVersion + 1
+
+	var formatErr SnapshotFormatError
+	if err := other.Restore(snapshot); !errors.As(err, &formatErr) {
+		t.Errorf("expected a SnapshotFormatError, got %v", err)
+	}
+}
@@ -0,0 +1,142 @@
+package statetrooper
+
+import (
+	"database/sql/driver"
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// StateColumn stores a state in a database column, implementing driver.Valuer and sql.Scanner
+// States whose type implements encoding.TextMarshaler are stored as text, otherwise states with an
+// underlying string, integer or bool type are stored as such
+// When Ruleset is set, scanning a state not defined in the ruleset returns an UnknownStateError
+type StateColumn[T comparable] struct {
+	State   T
+	Ruleset Ruleset[T]
+}
+
+// StateColumn returns a column holding the FSM's current state and validating scanned states against
+// its ruleset
+func (fsm *FSM[T]) StateColumn() StateColumn[T] {
+	fsm.lock()
+	defer fsm.unlock()
+
+	// the column shares the ruleset, so AddRule must copy it before modifying it
+	fsm.sharedRuleset = true
+
+	return StateColumn[T]{
+		State:   fsm.currentState,
+		Ruleset: fsm.ruleset,
+	}
+}
+
+// Value implements driver.Valuer
+func (c StateColumn[T]) Value() (driver.Value, error) {
+	if m, ok := any(c.State).(encoding.TextMarshaler); ok {
+		text, err := m.MarshalText()
+		if err != nil {
+			return nil, err
+		}
+
+		return string(text), nil
+	}
+
+	v := reflect.ValueOf(c.State)
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if v.Uint() > 1<<63-1 {
+			return nil, fmt.Errorf("statetrooper: state %v overflows int64", c.State)
+		}
+
+		return int64(v.Uint()), nil
+	case reflect.Bool:
+		return v.Bool(), nil
+	}
+
+	return nil, fmt.Errorf("statetrooper: unsupported state type %T", c.State)
+}
+
+// Scan implements sql.Scanner
+func (c *StateColumn[T]) Scan(src any) error {
+	var state T
+
+	err := scanState(&state, src)
+	if err != nil {
+		return err
+	}
+
+	if c.Ruleset != nil && !c.Ruleset.hasState(state) {
+		return UnknownStateError[T]{State: state}
+	}
+
+	c.State = state
+
+	return nil
+}
+
+// scanState converts a value read from the database into the state
+func scanState[T comparable](state *T, src any) error {
+	var text string
+
+	switch s := src.(type) {
+	case string:
+		text = s
+	case []byte:
+		text = string(s)
+	case int64:
+		text = strconv.FormatInt(s, 10)
+	case bool:
+		text = strconv.FormatBool(s)
+	default:
+		return fmt.Errorf("statetrooper: cannot scan %T into %T", src, *state)
+	}
+
+	if u, ok := any(state).(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(text))
+	}
+
+	v := reflect.ValueOf(state).Elem()
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(text)
+
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(text, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("statetrooper: cannot scan %q into %T: %w", text, *state, err)
+		}
+
+		v.SetInt(n)
+
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(text, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("statetrooper: cannot scan %q into %T: %w", text, *state, err)
+		}
+
+		v.SetUint(n)
+
+		return nil
+	case reflect.Bool:
+		b, err := strconv.ParseBool(text)
+		if err != nil {
+			return fmt.Errorf("statetrooper: cannot scan %q into %T: %w", text, *state, err)
+		}
+
+		v.SetBool(b)
+
+		return nil
+	}
+
+	return fmt.Errorf("statetrooper: cannot scan %T into %T", src, *state)
+}
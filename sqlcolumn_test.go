@@ -0,0 +1,56 @@
+package statetrooper
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+)
+
+var (
+	_ driver.Valuer = StateColumn[string]{}
+	_ sql.Scanner   = (*StateColumn[string])(nil)
+)
+
+func Test_stateColumn(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+
+	value, err := fsm.StateColumn().Value()
+	if err != nil || value != "A" {
+		t.Errorf("Value() = %v, %v, expected A", value, err)
+	}
+
+	column := fsm.StateColumn()
+
+	if err := column.Scan([]byte("B")); err != nil || column.State != CustomStateEnumB {
+		t.Errorf("Scan() = %v, state %v, expected %v", err, column.State, CustomStateEnumB)
+	}
+
+	if err := column.Scan("D"); !errors.As(err, &UnknownStateError[CustomStateEnum]{}) {
+		t.Errorf("expected an UnknownStateError, got %v", err)
+	}
+
+	if err := column.Scan(nil); err == nil {
+		t.Errorf("expected an error scanning NULL")
+	}
+}
+
+func Test_stateColumnInteger(t *testing.T) {
+	type priority uint8
+
+	column := StateColumn[priority]{State: 3}
+
+	value, err := column.Value()
+	if err != nil || value != int64(3) {
+		t.Errorf("Value() = %v, %v, expected 3", value, err)
+	}
+
+	if err := column.Scan(int64(7)); err != nil || column.State != 7 {
+		t.Errorf("Scan() = %v, state %v, expected 7", err, column.State)
+	}
+
+	if err := column.Scan(int64(300)); err == nil {
+		t.Errorf("expected an error scanning an out of range state")
+	}
+}
@@ -27,42 +27,236 @@ SOFTWARE.
 package statetrooper
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 )
 
 // Transition represents information about a state transition
+// Timestamp is the processing time as provided by the FSM's time provider
+// EventTime is the time at which the event that caused the transition occurred
+// and is equal to Timestamp unless overridden with WithEventTime
+// Actor and Reason are set with WithActor and WithReason
 type Transition[T comparable] struct {
-	FromState T                 `json:"from_state"`
-	ToState   T                 `json:"to_state"`
-	Timestamp time.Time         `json:"timestamp"`
-	Metadata  map[string]string `json:"metadata"`
+	FromState T                 `json:"from_state" yaml:"from_state"`
+	ToState   T                 `json:"to_state" yaml:"to_state"`
+	Timestamp time.Time         `json:"timestamp" yaml:"timestamp"`
+	EventTime time.Time         `json:"event_time" yaml:"event_time"`
+	Actor     string            `json:"actor,omitempty" yaml:"actor,omitempty"`
+	Reason    string            `json:"reason,omitempty" yaml:"reason,omitempty"`
+	Metadata  map[string]string `json:"metadata" yaml:"metadata"`
+	// Version is the FSM's version once the transition was committed, numbering the records of the history
+	// independently of trimming and compaction. It is zero for records the FSM didn't make, e.g. replayed ones
+	Version uint64 `json:"version,omitempty" yaml:"version,omitempty"`
 }
 
+// MetadataForced is the metadata key marking the transitions made with ForceTransition
+const MetadataForced = "forced"
+
+// UnlimitedHistory can be passed as maxHistory to keep the full transition history without trimming
+const UnlimitedHistory = -1
+
 // FSMOption is a function that sets an option on the FSM
 type FSMOption[T comparable] func(*FSM[T])
 
+// TransitionOption is a function that sets an option on a single transition
+type TransitionOption func(*transitionOptions)
+
+// transitionOptions holds the options for a single transition
+type transitionOptions struct {
+	eventTime time.Time
+	timestamp time.Time
+	backdated bool
+	actor     string
+	reason    string
+
+	// idempotencyKey deduplicates the transition
+	idempotencyKey string
+
+	// ctx is passed to the authorizer
+	ctx context.Context
+
+	// forced bypasses the ruleset, it is only set by ForceTransition
+	forced bool
+
+	// automaticHops is the number of automatic transitions chained before this one, see SetAutomatic
+	automaticHops int
+
+	// committed is set once the transition is committed, telling post-commit errors apart
+	committed *bool
+}
+
 // FSM represents the finite state machine for managing states
 type FSM[T comparable] struct {
 	currentState T
-	transitions  []Transition[T]
-	ruleset      map[T][]T
-	mu           sync.Mutex
+	ruleset      Ruleset[T]
+	mu           sync.RWMutex
 	maxHistory   int
 
+	// noLocking disables the lock for single goroutine use DEFAULT: false
+	noLocking bool
+
+	// compiled is the index of the ruleset once sealed with Seal DEFAULT: nil
+	compiled *compiledRuleset[T]
+
+	// sharedRuleset is set when the ruleset is shared with other FSMs and must be copied before being modified
+	sharedRuleset bool
+
+	// version is incremented on every successful transition
+	version uint64
+
+	// clock provides the timers of timeouts, SLAs, scheduled transitions and retries DEFAULT: RealClock
+	clock Clock
+
 	// timeProvider is used to provide the current time for transitions DEFAULT: time.Now
 	timeProvider func() time.Time
+
+	// historyStore stores the transition history DEFAULT: memoryHistory
+	historyStore  HistoryStore[T]
+	memoryHistory MemoryHistoryStore[T]
+
+	// rateLimit and edgeRateLimits throttle transitions of the FSM and of single edges DEFAULT: nil
+	rateLimit      *tokenBucket
+	edgeRateLimits map[edge[T]]*tokenBucket
+
+	// idempotencyKeys are the keys of recent transitions, remembered for idempotencyWindow
+	idempotencyKeys   map[string]idempotentResult[T]
+	idempotencyWindow time.Duration
+	// idempotencyOrder holds the keys in the order they were remembered, so they expire oldest first
+	idempotencyOrder []rememberedKey
+
+	// auditWriter receives every committed transition as a JSON line DEFAULT: nil
+	auditWriter io.Writer
+	auditPolicy AuditFailurePolicy
+
+	// durations are the durations of the last transition calls, see WithTransitionStats DEFAULT: nil
+	durations *durationWindow
+
+	// metrics receives internal timings DEFAULT: nil
+	metrics Metrics
+
+	// evictionHandler is called with each transition dropped from the history DEFAULT: nil
+	evictionHandler func(Transition[T])
+	evictionPanics  []error
+
+	// checkRulesetHash rejects imported data produced under a different ruleset DEFAULT: false
+	checkRulesetHash bool
+
+	// idempotentSameState makes transitions to the current state succeed without effect DEFAULT: false
+	idempotentSameState bool
+
+	// strictUnmarshal verifies imported data against the ruleset DEFAULT: false
+	strictUnmarshal bool
+
+	// importTruncation selects the transitions kept when imported data exceeds maxHistory DEFAULT: KeepNewest
+	importTruncation HistoryTruncation
+
+	// rulesInJSON embeds the rules and the maximum history size in the JSON export DEFAULT: false
+	rulesInJSON bool
+
+	// defaultMetadata is merged into the metadata of every transition DEFAULT: nil
+	defaultMetadata map[string]string
+
+	// authorizer rejects transitions the actor isn't allowed to make DEFAULT: nil
+	authorizer func(ctx context.Context, actor string, from, to T) error
+
+	// rejected are the last maxRejected rejected transition attempts DEFAULT: none are kept
+	rejected    []RejectedAttempt[T]
+	maxRejected int
+
+	// metadataValidator rejects transitions whose metadata is invalid DEFAULT: nil
+	metadataValidator func(from, to T, metadata map[string]string) error
+
+	// redactedKeys are the metadata keys whose values are redacted in exports DEFAULT: nil
+	redactedKeys map[string]struct{}
+
+	// timeouts are the automatic transitions out of states set with SetStateTimeout DEFAULT: nil
+	timeouts     map[T]stateTimeout[T]
+	timeoutTimer Timer
+
+	// slas are the maximum times the FSM is expected to stay in states DEFAULT: nil
+	slas             map[T]time.Duration
+	slaTimer         Timer
+	slaBreachHandler func(SLABreach[T])
+
+	// enteredAt is the time the current state was entered and enteredMono its monotonic clock reading,
+	// which is zero if the state wasn't entered in this process on the real clock
+	enteredAt   time.Time
+	enteredMono time.Time
+
+	// customTimeProvider is set by WithTimeProvider, in which case dwell times follow the time provider
+	customTimeProvider bool
+
+	// location normalizes the recorded timestamps, see WithTimestampLocation
+	location *time.Location
+
+	// compaction is set by WithHistoryCompaction, compactKeepLast being the newest transitions left as they are
+	compaction      bool
+	compactKeepLast int
+	// compactClean is set once the in-memory history has no run left to compact, see trackRuns
+	compactClean bool
+
+	// maxHistoryAge is the age past which transitions are trimmed from the history, or 0 for no limit
+	maxHistoryAge time.Duration
+
+	// entries counts the entries into each state, see TimesEntered
+	entries map[T]int
+
+	// scheduleSkippedHandler is called when a scheduled transition is skipped DEFAULT: nil
+	scheduleSkippedHandler func(targetState T, err error)
+
+	// deferred are the transitions waiting to become allowed, see DeferUntilValid DEFAULT: nil
+	deferred               []deferredTransition[T]
+	deferredID             uint64
+	deferredDroppedHandler func(targetState T, err error)
+
+	// compensations are the compensating transitions of edges, see SetCompensation DEFAULT: nil
+	compensations map[edge[T]]T
+
+	// weights are the weights of rules used by random walks, see SetRuleWeight DEFAULT: nil
+	weights map[edge[T]]float64
+
+	// automatic are the automatic edges of each state ordered by priority, see SetAutomatic DEFAULT: nil
+	automatic      map[T][]automaticEdge[T]
+	automaticCount int
+
+	// links are the transitions of other FSMs triggered by entering states, see Link DEFAULT: nil
+	links     []link[T]
+	linkID    uint64
+	linkGraph linkGraph
+
+	// migrations maps states removed from the ruleset to their replacements DEFAULT: nil
+	migrations map[T]T
+
+	// subscribers are called with every committed transition
+	subscribers  []subscriber[T]
+	subscriberID uint64
+
+	// hookPool runs the subscribers asynchronously, see WithAsyncSubscribers DEFAULT: nil
+	hookPool *hookPool[T]
+	// pendingHooks are the subscriber calls queued on the hook pool once the lock is released
+	pendingHooks []hookJob[T]
+
+	// edgeHooks are called with the committed transitions of their edge, see OnTransition DEFAULT: nil
+	edgeHooks map[edge[T]][]edgeHook[T]
+
+	// binding writes the current state to the bound entity, see Bind DEFAULT: nil
+	binding func(T)
 }
 
 // NewFSM creates a new instance of FSM with predefined transitions
+// maxHistory is the number of transitions kept in the history
+// 0 disables the history and UnlimitedHistory keeps every transition
 func NewFSM[T comparable](initialState T, maxHistory int, opts ...FSMOption[T]) *FSM[T] {
 	fsm := FSM[T]{
 		currentState: initialState,
-		ruleset:      make(map[T][]T),
+		ruleset:      make(Ruleset[T]),
 		maxHistory:   maxHistory,
 	}
 
@@ -72,29 +266,205 @@ func NewFSM[T comparable](initialState T, maxHistory int, opts ...FSMOption[T])
 
 	fsm.setDefaults()
 
+	// preallocate the history so that steady state transitions don't allocate
+	if fsm.historyStore == nil && fsm.maxHistory > 0 {
+		fsm.memoryHistory.buf = make([]Transition[T], fsm.maxHistory)
+	}
+
+	fsm.enter(fsm.now(), true)
+	fsm.entered(initialState)
+
 	return &fsm
 }
 
+// NewFSMWithRuleset creates a new instance of FSM using a shared ruleset
+// The ruleset is not copied, so a single ruleset can back thousands of FSMs without duplicating it
+// The ruleset must not be modified once shared; AddRule on an FSM created this way
+// modifies a private copy of the ruleset instead
+func NewFSMWithRuleset[T comparable](initialState T, maxHistory int, rs Ruleset[T], opts ...FSMOption[T]) *FSM[T] {
+	fsm := NewFSM[T](initialState, maxHistory, opts...)
+
+	fsm.ruleset = rs
+	fsm.sharedRuleset = true
+
+	return fsm
+}
+
 // WithTimeProvider sets the time provider for the FSM
 // The time provider is used to provide the current time for transitions
 // DEFAULT: time.Now
 // This is useful for testing or when you want to set a time with a specific timezone
+// Timers keep using the FSM's clock, use WithClock to drive them too
 func WithTimeProvider[T comparable](provider func() time.Time) FSMOption[T] {
 	return func(fsm *FSM[T]) {
 		fsm.timeProvider = provider
+		fsm.customTimeProvider = true
+	}
+}
+
+// WithRulesInJSON embeds the rules and the maximum history size in the JSON produced by MarshalJSON,
+// making it self-contained, and makes UnmarshalJSON replace the FSM's rules with the embedded ones
+// Embedded rules are always loaded into an FSM without rules, e.g. a zero value
+// DEFAULT: the JSON export doesn't include the rules
+func WithRulesInJSON[T comparable]() FSMOption[T] {
+	return func(fsm *FSM[T]) {
+		fsm.rulesInJSON = true
+	}
+}
+
+// HistoryTruncation selects which transitions are kept when imported data holds more than the FSM keeps
+type HistoryTruncation int
+
+const (
+	// KeepNewest keeps the most recent transitions
+	KeepNewest HistoryTruncation = iota
+	// KeepOldest keeps the earliest transitions
+	KeepOldest
+)
+
+// WithImportTruncation sets which transitions UnmarshalJSON and Restore keep when the imported
+// history holds more transitions than the maximum history size
+// DEFAULT: KeepNewest, since the recent transitions are the ones audits care about
+func WithImportTruncation[T comparable](policy HistoryTruncation) FSMOption[T] {
+	return func(fsm *FSM[T]) {
+		fsm.importTruncation = policy
+	}
+}
+
+// WithIdempotentSameState makes a transition to the current state a successful no-op instead of
+// returning a TransitionError, so transitions retried by at-least-once message delivery don't need
+// special casing. Nothing is recorded and the version is not incremented
+// Self-transitions declared in the ruleset are still recorded as usual
+// DEFAULT: transitions to the current state fail unless declared in the ruleset
+func WithIdempotentSameState[T comparable]() FSMOption[T] {
+	return func(fsm *FSM[T]) {
+		fsm.idempotentSameState = true
+	}
+}
+
+// WithDefaultMetadata sets metadata merged into the metadata of every transition,
+// e.g. the entity ID, service name or deployment version
+// Values passed to Transition take precedence over the defaults
+// DEFAULT: nil
+func WithDefaultMetadata[T comparable](metadata map[string]string) FSMOption[T] {
+	return func(fsm *FSM[T]) {
+		fsm.defaultMetadata = mergeMetadata(fsm.defaultMetadata, metadata)
+	}
+}
+
+// WithMetadataValidator sets a function validating the metadata of every transition, including forced ones,
+// e.g. to enforce required keys centrally
+// If it returns an error, the transition is rejected with a MetadataError wrapping it
+// The validator is called while the FSM is locked and must not call back into the FSM
+// DEFAULT: nil, metadata is not validated
+func WithMetadataValidator[T comparable](validator func(from, to T, metadata map[string]string) error) FSMOption[T] {
+	return func(fsm *FSM[T]) {
+		fsm.metadataValidator = validator
+	}
+}
+
+// validateMetadata calls the metadata validator, returning its error or the panic recovered from it
+// The caller must hold the lock
+func (fsm *FSM[T]) validateMetadata(from, to T, metadata map[string]string) error {
+	var err error
+
+	if panicErr := fsm.runHook(func() {
+		err = fsm.metadataValidator(from, to, metadata)
+	}); panicErr != nil {
+		return panicErr
+	}
+
+	return err
+}
+
+// WithRulesetHashCheck makes UnmarshalJSON reject data exported under a different ruleset
+// with a RulesetMismatchError, e.g. histories saved under an older workflow definition
+// Data without a ruleset fingerprint is accepted, and so is any data when state migrations are set
+// DEFAULT: the ruleset fingerprint is not checked
+func WithRulesetHashCheck[T comparable]() FSMOption[T] {
+	return func(fsm *FSM[T]) {
+		fsm.checkRulesetHash = true
+	}
+}
+
+// WithEvictionHandler sets a handler that is called with the oldest transition
+// whenever it is dropped from the history because maxHistory has been reached
+// This is useful for writing evicted transitions to a database or log so that
+// a complete audit trail is kept without unbounded memory usage
+// The handler is called synchronously while the FSM is locked and must not call back into the FSM
+func WithEvictionHandler[T comparable](handler func(Transition[T])) FSMOption[T] {
+	return func(fsm *FSM[T]) {
+		fsm.evictionHandler = handler
+	}
+}
+
+// WithEventTime sets the time at which the event that caused the transition occurred
+// e.g. the event time of a Kafka message
+// The processing time is still recorded using the FSM's time provider
+// DEFAULT: the processing time
+func WithEventTime(eventTime time.Time) TransitionOption {
+	return func(opts *transitionOptions) {
+		opts.eventTime = eventTime
+	}
+}
+
+// WithActor records who or what made the transition, e.g. "user:42"
+func WithActor(actor string) TransitionOption {
+	return func(opts *transitionOptions) {
+		opts.actor = actor
+	}
+}
+
+// WithReason records why the transition was made, e.g. "manual override"
+func WithReason(reason string) TransitionOption {
+	return func(opts *transitionOptions) {
+		opts.reason = reason
+	}
+}
+
+// WithTimestampOverride records the transition with the given processing time instead of
+// the time provided by the FSM's time provider
+// The event time defaults to the overridden timestamp
+// DEFAULT: the FSM's time provider
+func WithTimestampOverride(timestamp time.Time) TransitionOption {
+	return func(opts *transitionOptions) {
+		opts.timestamp = timestamp
+	}
+}
+
+// WithBackdate records the transition at a historical time, e.g. when importing legacy lifecycle data
+// Both the timestamp and, unless set with WithEventTime, the event time are set to the given time
+// The time must not be before the timestamp of the newest transition in the history, otherwise
+// a TimestampOrderError is returned and the transition is not made
+func WithBackdate(timestamp time.Time) TransitionOption {
+	return func(opts *transitionOptions) {
+		opts.timestamp = timestamp
+		opts.backdated = true
+	}
+}
+
+// WithCommitted sets committed to true once the transition is committed, telling an error returned
+// after the commit, e.g. by a subscriber or hook, apart from a rejected transition
+func WithCommitted(committed *bool) TransitionOption {
+	return func(opts *transitionOptions) {
+		opts.committed = committed
 	}
 }
 
 // CanTransition checks if a transition from the current state to the target state is valid
 func (fsm *FSM[T]) CanTransition(targetState T) bool {
-	fsm.mu.Lock()
-	defer fsm.mu.Unlock()
+	fsm.rlock()
+	defer fsm.runlock()
 
 	return fsm.canTransition(&fsm.currentState, &targetState)
 }
 
 // canTransition checks if a transition from one state to another state is valid
 func (fsm *FSM[T]) canTransition(fromState *T, toState *T) bool {
+	if fsm.compiled != nil {
+		return fsm.compiled.canTransition(*fromState, *toState)
+	}
+
 	validTransitions, ok := fsm.ruleset[*fromState]
 	if !ok {
 		return false
@@ -109,68 +479,422 @@ func (fsm *FSM[T]) canTransition(fromState *T, toState *T) bool {
 	return false
 }
 
+// AllowedTransitions returns the states the FSM can transition to from the current state
+func (fsm *FSM[T]) AllowedTransitions() []T {
+	fsm.rlock()
+	defer fsm.runlock()
+
+	return fsm.allowedTransitions()
+}
+
+// allowedTransitions returns a copy of the target states allowed from the current state
+func (fsm *FSM[T]) allowedTransitions() []T {
+	return append([]T{}, fsm.ruleset[fsm.currentState]...)
+}
+
 // AddRule adds a valid transition between two states
+// AddRule panics if the FSM has been sealed with Seal
 func (fsm *FSM[T]) AddRule(fromState T, toState ...T) {
-	fsm.mu.Lock()
-	defer fsm.mu.Unlock()
+	fsm.lock()
+	defer fsm.unlock()
+
+	if fsm.compiled != nil {
+		panic("statetrooper: AddRule called on a sealed FSM")
+	}
+
+	if fsm.sharedRuleset {
+		fsm.ruleset = fsm.ruleset.clone()
+		fsm.sharedRuleset = false
+	}
 
 	fsm.ruleset[fromState] = append(fsm.ruleset[fromState], toState...)
 }
 
+// ReplaceRules validates the given ruleset and atomically replaces the FSM's rules with it
+// The current state must be defined in the new ruleset, otherwise an UnknownStateError is returned
+// and the existing rules are kept
+// The ruleset is copied so later changes to it do not affect the FSM
+func (fsm *FSM[T]) ReplaceRules(rs Ruleset[T]) error {
+	fsm.lock()
+	defer fsm.unlock()
+
+	if !rs.hasState(fsm.currentState) {
+		return UnknownStateError[T]{State: fsm.currentState}
+	}
+
+	fsm.ruleset = rs.clone()
+	fsm.sharedRuleset = false
+
+	if fsm.compiled != nil {
+		fsm.compiled = fsm.ruleset.compile()
+	}
+
+	return nil
+}
+
 // Transition transitions the entity from the current state to the target state
 // if the transition is invalid, an error is returned and the current state is not changed
-func (fsm *FSM[T]) Transition(targetState T, metadata map[string]string) (T, error) {
-	fsm.mu.Lock()
-	defer fsm.mu.Unlock()
+// Transition options can be used to override per call settings such as the event time
+func (fsm *FSM[T]) Transition(targetState T, metadata map[string]string, opts ...TransitionOption) (T, error) {
+	return fsm.lockAndTransition(nil, targetState, metadata, opts)
+}
+
+// ForceTransition transitions the entity to the target state even if the ruleset doesn't allow it
+// e.g. for support teams to un-stick an entity without losing the audit trail
+// The transition is recorded with MetadataForced set to "true" in its metadata
+func (fsm *FSM[T]) ForceTransition(targetState T, metadata map[string]string, opts ...TransitionOption) (T, error) {
+	opts = append(opts[:len(opts):len(opts)], func(o *transitionOptions) {
+		o.forced = true
+	})
+
+	return fsm.lockAndTransition(nil, targetState, metadata, opts)
+}
+
+// TransitionIfVersion transitions the entity to the target state only if the FSM's version
+// matches the expected version, e.g. the version of a copy read by another process
+// If the versions differ, a VersionMismatchError is returned and the current state is not changed
+func (fsm *FSM[T]) TransitionIfVersion(expectedVersion uint64, targetState T, metadata map[string]string, opts ...TransitionOption) (T, error) {
+	return fsm.lockAndTransition(&expectedVersion, targetState, metadata, opts)
+}
+
+// lockAndTransition acquires the lock and transitions the entity, reporting the timings if metrics are enabled
+// The version is only checked if expectedVersion is set
+func (fsm *FSM[T]) lockAndTransition(expectedVersion *uint64, targetState T, metadata map[string]string, opts []TransitionOption) (T, error) {
+	if fsm.metrics == nil {
+		fsm.lock()
+		defer fsm.unlock()
+
+		return fsm.checkedTransition(expectedVersion, targetState, metadata, opts)
+	}
+
+	start := time.Now()
+
+	fsm.lock()
+	fsm.metrics.ObserveLockWait(time.Since(start))
+
+	newState, err := fsm.checkedTransition(expectedVersion, targetState, metadata, opts)
+	fsm.unlock()
+
+	fsm.metrics.ObserveTransition(time.Since(start), err)
+
+	return newState, err
+}
+
+// checkedTransition checks the expected version, if set, before transitioning
+// The caller must hold the lock
+func (fsm *FSM[T]) checkedTransition(expectedVersion *uint64, targetState T, metadata map[string]string, opts []TransitionOption) (T, error) {
+	if expectedVersion != nil && *expectedVersion != fsm.version {
+		return fsm.currentState, VersionMismatchError{
+			Expected: *expectedVersion,
+			Actual:   fsm.version,
+		}
+	}
+
+	return fsm.transition(targetState, metadata, opts)
+}
+
+// parseTransitionOptions applies the transition options
+func parseTransitionOptions(opts []TransitionOption) transitionOptions {
+	var options transitionOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return options
+}
+
+// transition transitions the entity from the current state to the target state
+// The caller must hold the lock
+func (fsm *FSM[T]) transition(
+	targetState T,
+	metadata map[string]string,
+	opts []TransitionOption,
+) (state T, err error) {
+	// parsing the options separately keeps them from escaping to the heap when none are given
+	var options transitionOptions
+	if len(opts) > 0 {
+		options = parseTransitionOptions(opts)
+	}
+
+	if fsm.durations != nil {
+		start := time.Now()
+
+		defer func() {
+			fsm.durations.observe(time.Since(start))
+		}()
+	}
+
+	// attempts failing before the commit are recorded as rejected if enabled
+	committed := false
+
+	if fsm.maxRejected > 0 {
+		defer func() {
+			if err != nil && !committed {
+				fsm.reject(targetState, options.actor, err)
+			}
+		}()
+	}
+
+	if options.actor == "" && options.ctx != nil {
+		options.actor = ActorFromContext(options.ctx)
+	}
+
+	if fsm.authorizer != nil {
+		if err := fsm.authorize(targetState, &options); err != nil {
+			return fsm.currentState, err
+		}
+	}
+
+	// the key is only looked up once the caller is authorized, so it can't be reused to learn the result
+	if options.idempotencyKey != "" {
+		if state, ok := fsm.deduplicate(options.idempotencyKey, fsm.timeProvider()); ok {
+			return state, nil
+		}
+	}
+
+	if !options.forced && !fsm.canTransition(&fsm.currentState, &targetState) {
+		// a retried transition that already happened is a no-op
+		if fsm.idempotentSameState && targetState == fsm.currentState {
+			return fsm.currentState, nil
+		}
 
-	if !fsm.canTransition(&fsm.currentState, &targetState) {
 		return fsm.currentState, TransitionError[T]{
 			FromState: fsm.currentState,
 			ToState:   targetState,
 		}
 	}
 
+	if len(fsm.defaultMetadata) > 0 {
+		metadata = mergeMetadata(fsm.defaultMetadata, metadata)
+	}
+
+	if fsm.metadataValidator != nil {
+		if err := fsm.validateMetadata(fsm.currentState, targetState, metadata); err != nil {
+			return fsm.currentState, MetadataError[T]{
+				FromState: fsm.currentState,
+				ToState:   targetState,
+				Err:       err,
+			}
+		}
+	}
+
+	if options.forced {
+		metadata = withMetadata(metadata, MetadataForced, "true")
+	}
+
+	if options.idempotencyKey != "" {
+		metadata = withMetadata(metadata, MetadataIdempotencyKey, options.idempotencyKey)
+	}
+
+	tn := fsm.localize(options.timestamp)
+	if tn.IsZero() {
+		tn = fsm.now()
+	}
+
+	if options.backdated {
+		last, ok, err := fsm.lastTransition()
+		if err != nil {
+			return fsm.currentState, fmt.Errorf("failed to read transition history: %w", err)
+		}
+
+		if ok && tn.Before(last.Timestamp) {
+			return fsm.currentState, TimestampOrderError{Timestamp: tn, Previous: last.Timestamp}
+		}
+	}
+
+	// the tokens are taken once the transition commits
+	var buckets [2]*tokenBucket
+
+	if fsm.rateLimit != nil || fsm.edgeRateLimits != nil {
+		buckets, err = fsm.throttle(fsm.currentState, targetState, fsm.timeProvider())
+		if err != nil {
+			return fsm.currentState, err
+		}
+	}
+
+	if len(fsm.links) > 0 {
+		err := fsm.checkLinks(targetState)
+		if err != nil {
+			return fsm.currentState, err
+		}
+	}
+
+	eventTime := fsm.localize(options.eventTime)
+	if eventTime.IsZero() {
+		eventTime = tn
+	}
+
+	transition := Transition[T]{
+		FromState: fsm.currentState,
+		ToState:   targetState,
+		Timestamp: tn,
+		EventTime: eventTime,
+		Actor:     options.actor,
+		Reason:    options.reason,
+		Metadata:  metadata,
+		Version:   fsm.version + 1,
+	}
+
+	if fsm.auditWriter != nil {
+		err := fsm.audit(transition)
+		if err != nil {
+			return fsm.currentState, err
+		}
+	}
+
 	// Track the transition
+	if fsm.maxHistory != 0 {
+		err := fsm.recordTransition(transition)
+		if err != nil {
+			if fsm.auditWriter != nil {
+				fsm.auditAborted(transition)
+			}
+
+			return fsm.currentState, err
+		}
+	}
+
+	fsm.setState(targetState)
+	fsm.version++
+	fsm.enter(tn, options.timestamp.IsZero())
+	fsm.entered(targetState)
+	committed = true
+	takeTokens(buckets)
+
+	if options.committed != nil {
+		*options.committed = true
+	}
+
+	if options.idempotencyKey != "" {
+		fsm.rememberKey(options.idempotencyKey, targetState, fsm.timeProvider())
+	}
+
+	fsm.armTimers()
+
+	// errors of callbacks run after the commit are combined and returned along with the new state
+	for _, panicErr := range fsm.evictionPanics {
+		err = appendError(err, panicErr)
+	}
+
+	err = appendError(err, fsm.notify(transition))
+
+	if len(fsm.edgeHooks) > 0 {
+		err = appendError(err, fsm.runEdgeHooks(transition))
+	}
+
+	if len(fsm.links) > 0 {
+		err = appendError(err, fsm.fireLinks(targetState))
+	}
+
+	// automatic edges chain from the new state, the transition returning the state the chain ends in
+	if len(fsm.automatic) > 0 {
+		err = appendError(err, fsm.advance(options))
+		targetState = fsm.currentState
+	}
+
+	// deferred transitions allowed from the new state are applied right after it
+	if len(fsm.deferred) > 0 {
+		fsm.applyDeferred()
+	}
+
+	return targetState, err
+}
+
+// recordTransition appends the transition to the history, evicting the oldest transitions
+// beforehand if maxHistory has been reached
+func (fsm *FSM[T]) recordTransition(transition Transition[T]) error {
+	store := fsm.history()
+	fsm.evictionPanics = fsm.evictionPanics[:0]
+
+	err := fsm.trimExpired()
+	if err != nil {
+		return err
+	}
+
+	err = fsm.autoCompact()
+	if err != nil {
+		return fmt.Errorf("failed to compact transition history: %w", err)
+	}
+
 	if fsm.maxHistory > 0 {
-		// Check if we need to remove the oldest transition
-		if len(fsm.transitions) >= fsm.maxHistory {
-			fsm.transitions = fsm.transitions[1:]
+		var evicted func(Transition[T])
+		if fsm.evictionHandler != nil {
+			evicted = fsm.evict
 		}
 
-		tn := fsm.timeProvider()
+		err := store.Trim(fsm.maxHistory-1, evicted)
+		if err != nil {
+			return fmt.Errorf("failed to trim transition history: %w", err)
+		}
+	}
 
-		fsm.transitions = append(
-			fsm.transitions,
-			Transition[T]{
-				FromState: fsm.currentState,
-				ToState:   targetState,
-				Timestamp: tn,
-				Metadata:  metadata,
-			})
+	err = store.Append(transition)
+	if err != nil {
+		return fmt.Errorf("failed to record transition: %w", err)
+	}
+
+	if fsm.compaction {
+		fsm.trackRuns()
+	}
+
+	return nil
+}
+
+// evict passes a transition dropped from the history to the eviction handler
+func (fsm *FSM[T]) evict(transition Transition[T]) {
+	if err := fsm.runHook(func() {
+		fsm.evictionHandler(transition)
+	}); err != nil {
+		fsm.evictionPanics = append(fsm.evictionPanics, err)
 	}
+}
 
-	fsm.currentState = targetState
+// history returns the store used for the transition history
+func (fsm *FSM[T]) history() HistoryStore[T] {
+	if fsm.historyStore != nil {
+		return fsm.historyStore
+	}
 
-	return fsm.currentState, nil
+	return &fsm.memoryHistory
 }
 
 // CurrentState returns the current state of the FSM
 func (fsm *FSM[T]) CurrentState() T {
-	fsm.mu.Lock()
-	defer fsm.mu.Unlock()
+	fsm.rlock()
+	defer fsm.runlock()
 
 	return fsm.currentState
 }
 
+// RulesetHash returns a deterministic fingerprint of the states and edges of the FSM's ruleset
+// It is included in the exported JSON and snapshots, so data produced under a different ruleset can be detected
+func (fsm *FSM[T]) RulesetHash() string {
+	fsm.rlock()
+	defer fsm.runlock()
+
+	return fsm.ruleset.hash()
+}
+
+// Version returns the number of successful transitions of the FSM
+// The version is exported and imported along with the state, so copies of the FSM can be conflict checked
+func (fsm *FSM[T]) Version() uint64 {
+	fsm.rlock()
+	defer fsm.runlock()
+
+	return fsm.version
+}
+
 // Transitions returns a slice of all transitions
+// If the history store fails to list the transitions, nil is returned
 func (fsm *FSM[T]) Transitions() []Transition[T] {
-	fsm.mu.Lock()
-	defer fsm.mu.Unlock()
+	fsm.rlock()
+	defer fsm.runlock()
 
 	// return a copy of the transitions
-	transitions := make([]Transition[T], len(fsm.transitions))
-
-	copy(transitions, fsm.transitions)
+	transitions, err := fsm.history().List()
+	if err != nil {
+		return nil
+	}
 
 	return transitions
 }
@@ -178,9 +902,15 @@ func (fsm *FSM[T]) Transitions() []Transition[T] {
 // GenerateMermaidRulesDiagram generates a Mermaid.js diagram from the FSM's rules
 // In order to generate a diagram, T must be a string or have a String() method
 func (fsm *FSM[T]) GenerateMermaidRulesDiagram() (string, error) {
-	fsm.mu.Lock()
-	defer fsm.mu.Unlock()
+	fsm.rlock()
+	defer fsm.runlock()
+
+	return fsm.mermaidRulesDiagram()
+}
 
+// mermaidRulesDiagram generates a Mermaid diagram from the FSM's rules
+// The caller must hold the lock
+func (fsm *FSM[T]) mermaidRulesDiagram() (string, error) {
 	if fsm.ruleset == nil {
 		return "", fmt.Errorf("no ruleset defined")
 	}
@@ -200,7 +930,7 @@ func (fsm *FSM[T]) GenerateMermaidRulesDiagram() (string, error) {
 	nodes := make([]string, 0, len(fsm.ruleset))
 
 	for state := range fsm.ruleset {
-		nodes = append(nodes, toString(state))
+		nodes = append(nodes, mermaidNode(toString(state)))
 	}
 
 	// Sort nodes
@@ -211,7 +941,7 @@ func (fsm *FSM[T]) GenerateMermaidRulesDiagram() (string, error) {
 
 	for fromState, toStates := range fsm.ruleset {
 		for _, toState := range toStates {
-			edges = append(edges, fmt.Sprintf("%s --> %s;\n", toString(fromState), toString(toState)))
+			edges = append(edges, fmt.Sprintf("%s --> %s;\n", mermaidNode(toString(fromState)), mermaidNode(toString(toState))))
 		}
 	}
 
@@ -225,16 +955,19 @@ func (fsm *FSM[T]) GenerateMermaidRulesDiagram() (string, error) {
 }
 
 // GenerateMermaidTransitionHistoryDiagram generates a Mermaid.js diagram from the FSM's transition history
+// Edges are labeled with the transition order numbers, followed by the labels selected with
+// WithTimestampLabels, WithDurationLabels and WithMetadataLabels
 // In order to generate a diagram, the type T must be a string or have a String() method
-func (fsm *FSM[T]) GenerateMermaidTransitionHistoryDiagram() (string, error) {
-	fsm.mu.Lock()
-	defer fsm.mu.Unlock()
+func (fsm *FSM[T]) GenerateMermaidTransitionHistoryDiagram(opts ...DiagramOption) (string, error) {
+	fsm.rlock()
+	defer fsm.runlock()
 
-	if fsm.transitions == nil {
-		return "", fmt.Errorf("no transition history")
+	transitions, err := fsm.history().List()
+	if err != nil {
+		return "", err
 	}
 
-	if len(fsm.transitions) == 0 {
+	if len(transitions) == 0 {
 		return "", fmt.Errorf("no transition history")
 	}
 
@@ -243,22 +976,21 @@ func (fsm *FSM[T]) GenerateMermaidTransitionHistoryDiagram() (string, error) {
 		return "", fmt.Errorf("type T is not a string or does not have a String() method")
 	}
 
+	o := parseDiagramOptions(opts)
+
 	diagram := "graph TD;\n"
 
 	// Add nodes for each unique state in the transition history
 	uniqueStates := make(map[T]bool)
-	for _, transition := range fsm.transitions {
-		fromState := transition.FromState
-		toState := transition.ToState
-
-		uniqueStates[fromState] = true
-		uniqueStates[toState] = true
+	for _, transition := range transitions {
+		uniqueStates[transition.FromState] = true
+		uniqueStates[transition.ToState] = true
 	}
 
 	nodes := make([]string, 0, len(uniqueStates))
 
 	for state := range uniqueStates {
-		nodes = append(nodes, fmt.Sprintf("%s;\n", toString(state)))
+		nodes = append(nodes, fmt.Sprintf("%s;\n", mermaidNode(toString(state))))
 	}
 
 	// Sort nodes
@@ -266,14 +998,18 @@ func (fsm *FSM[T]) GenerateMermaidTransitionHistoryDiagram() (string, error) {
 
 	// Add edges with transition order numbers
 
-	edges := make([]string, 0, len(fsm.transitions))
+	edges := make([]string, 0, len(transitions))
 
-	for i, transition := range fsm.transitions {
-		fromState := transition.FromState
-		toState := transition.ToState
+	for i, transition := range transitions {
 		transitionNum := i + 1
 
-		edges = append(edges, fmt.Sprintf("%s -->|%d| %s;\n", toString(fromState), transitionNum, toString(toState)))
+		label := strconv.Itoa(transitionNum)
+		if o.historyLabels() {
+			label = fsm.historyLabel(o, transitions, i)
+		}
+
+		edges = append(edges, fmt.Sprintf("%s -->|%s| %s;\n",
+			mermaidNode(toString(transition.FromState)), label, mermaidNode(toString(transition.ToState))))
 	}
 
 	sort.Strings(edges)
@@ -287,17 +1023,37 @@ func (fsm *FSM[T]) GenerateMermaidTransitionHistoryDiagram() (string, error) {
 
 // MarshalJSON serializes the FSM to JSON
 func (fsm *FSM[T]) MarshalJSON() ([]byte, error) {
-	fsm.mu.Lock()
-	defer fsm.mu.Unlock()
+	fsm.rlock()
+	defer fsm.runlock()
 
 	type FSMExport struct {
-		CurrentState T               `json:"current_state"`
-		Transitions  []Transition[T] `json:"transitions"`
+		CurrentState T                 `json:"current_state"`
+		Version      uint64            `json:"version"`
+		RulesetHash  string            `json:"ruleset_hash"`
+		MaxHistory   *int              `json:"max_history,omitempty"`
+		Rules        []ruleEncoding[T] `json:"rules,omitempty"`
+		Transitions  []Transition[T]   `json:"transitions"`
+		Entries      []EntryCount[T]   `json:"entries,omitempty"`
+	}
+
+	transitions, err := fsm.history().List()
+	if err != nil {
+		return nil, err
 	}
 
 	export := FSMExport{
 		CurrentState: fsm.currentState,
-		Transitions:  fsm.transitions,
+		Version:      fsm.version,
+		RulesetHash:  fsm.ruleset.hash(),
+		Transitions:  fsm.redactAll(fsm.localizeAll(transitions, true)),
+		Entries:      fsm.entryCounts(),
+	}
+
+	if fsm.rulesInJSON {
+		maxHistory := fsm.maxHistory
+
+		export.MaxHistory = &maxHistory
+		export.Rules = fsm.ruleEncodings()
 	}
 
 	return json.Marshal(export)
@@ -305,12 +1061,33 @@ func (fsm *FSM[T]) MarshalJSON() ([]byte, error) {
 
 // UnmarshalJSON deserializes the FSM from JSON
 func (fsm *FSM[T]) UnmarshalJSON(data []byte) error {
-	fsm.mu.Lock()
-	defer fsm.mu.Unlock()
+	fsm.lock()
+	defer fsm.unlock()
+
+	return fsm.unmarshalJSON(data, 0)
+}
+
+// UnmarshalJSONWithWatermark deserializes the FSM from JSON like UnmarshalJSON
+// but first compares the version of the snapshot against the watermark provided by the store
+// If the snapshot version is lower than the watermark, a StaleSnapshotError is returned
+// and the FSM is not changed, so the caller can trigger a reconciliation instead
+func (fsm *FSM[T]) UnmarshalJSONWithWatermark(data []byte, watermark uint64) error {
+	fsm.lock()
+	defer fsm.unlock()
+
+	return fsm.unmarshalJSON(data, watermark)
+}
 
+// unmarshalJSON deserializes the FSM from JSON rejecting snapshots older than the watermark
+func (fsm *FSM[T]) unmarshalJSON(data []byte, watermark uint64) error {
 	type FSMImport struct {
-		CurrentState T               `json:"current_state"`
-		Transitions  []Transition[T] `json:"transitions"`
+		CurrentState T                 `json:"current_state"`
+		Version      uint64            `json:"version"`
+		RulesetHash  string            `json:"ruleset_hash"`
+		MaxHistory   *int              `json:"max_history"`
+		Rules        []ruleEncoding[T] `json:"rules"`
+		Transitions  []Transition[T]   `json:"transitions"`
+		Entries      []EntryCount[T]   `json:"entries"`
 	}
 
 	var importData FSMImport
@@ -319,12 +1096,85 @@ func (fsm *FSM[T]) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
-	fsm.currentState = importData.CurrentState
+	if importData.Version < watermark {
+		return StaleSnapshotError{
+			Version:   importData.Version,
+			Watermark: watermark,
+		}
+	}
+
+	// embedded rules are loaded if asked for or if the FSM has none, e.g. a zero value
+	if importData.Rules != nil && (fsm.rulesInJSON || len(fsm.ruleset) == 0) {
+		fsm.setDefaults()
+		fsm.setRuleEncodings(importData.Rules)
+
+		if importData.MaxHistory != nil {
+			fsm.maxHistory = *importData.MaxHistory
+		}
+	}
+
+	if fsm.checkRulesetHash && fsm.migrations == nil && importData.RulesetHash != "" {
+		if hash := fsm.ruleset.hash(); hash != importData.RulesetHash {
+			return RulesetMismatchError{Expected: hash, Actual: importData.RulesetHash}
+		}
+	}
+
+	if fsm.strictUnmarshal {
+		err = fsm.validateImport(importData.CurrentState, importData.Transitions)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = fsm.replaceHistory(fsm.localizeAll(fsm.truncateImported(importData.Transitions), true))
+	if err != nil {
+		return err
+	}
+
+	fsm.setState(importData.CurrentState)
+	fsm.version = importData.Version
+	fsm.restoreEntries(importData.Entries, importData.Transitions)
 
-	if len(importData.Transitions) < fsm.maxHistory {
-		fsm.transitions = importData.Transitions
-	} else {
-		fsm.transitions = importData.Transitions[:fsm.maxHistory]
+	err = fsm.restoreEnteredAt()
+	if err != nil {
+		return err
+	}
+
+	err = fsm.migrate()
+	fsm.armTimers()
+
+	return err
+}
+
+// truncateImported returns the imported transitions the FSM keeps according to the truncation policy
+func (fsm *FSM[T]) truncateImported(transitions []Transition[T]) []Transition[T] {
+	if fsm.maxHistory < 0 || len(transitions) <= fsm.maxHistory {
+		return transitions
+	}
+
+	if fsm.importTruncation == KeepOldest {
+		return transitions[:fsm.maxHistory]
+	}
+
+	return transitions[len(transitions)-fsm.maxHistory:]
+}
+
+// replaceHistory replaces the existing history with the given transitions
+// The caller must hold the lock
+func (fsm *FSM[T]) replaceHistory(transitions []Transition[T]) error {
+	store := fsm.history()
+	fsm.compactClean = false
+
+	err := store.Trim(0, nil)
+	if err != nil {
+		return fmt.Errorf("failed to trim transition history: %w", err)
+	}
+
+	for _, transition := range transitions {
+		err = store.Append(transition)
+		if err != nil {
+			return fmt.Errorf("failed to record transition: %w", err)
+		}
 	}
 
 	return nil
@@ -332,8 +1182,8 @@ func (fsm *FSM[T]) UnmarshalJSON(data []byte) error {
 
 // String returns a string representation of the FSM
 func (fsm *FSM[T]) String() string {
-	fsm.mu.Lock()
-	defer fsm.mu.Unlock()
+	fsm.rlock()
+	defer fsm.runlock()
 
 	sb := strings.Builder{}
 
@@ -345,7 +1195,8 @@ func (fsm *FSM[T]) String() string {
 	}
 
 	sb.WriteString("Transitions:\n")
-	for _, transition := range fsm.transitions {
+	transitions, _ := fsm.history().List()
+	for _, transition := range fsm.redactAll(transitions) {
 		sb.WriteString(fmt.Sprintf("\t%v\n", transition))
 	}
 
@@ -356,9 +1207,18 @@ func (fsm *FSM[T]) setDefaults() {
 	if fsm.timeProvider == nil {
 		fsm.timeProvider = time.Now
 	}
+
+	if fsm.clock == nil {
+		fsm.clock = RealClock
+	}
 }
 
 // String returns a string representation of the Transition
 func (t *Transition[T]) String() string {
-	return fmt.Sprintf("Transition from %v to %v at %v with metadata %v", t.FromState, t.ToState, t.Timestamp, t.Metadata)
+	if t.Actor == "" && t.Reason == "" {
+		return fmt.Sprintf("Transition from %v to %v at %v (event time %v) with metadata %v", t.FromState, t.ToState, t.Timestamp, t.EventTime, t.Metadata)
+	}
+
+	return fmt.Sprintf("Transition from %v to %v at %v (event time %v) by %q for %q with metadata %v",
+		t.FromState, t.ToState, t.Timestamp, t.EventTime, t.Actor, t.Reason, t.Metadata)
 }
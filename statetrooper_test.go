@@ -26,8 +26,10 @@ package statetrooper
 
 import (
 	"encoding/json"
+	"errors"
 	"reflect"
 	"sort"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -139,14 +141,16 @@ func Test_transitionTracking(t *testing.T) {
 		t.Errorf("Transition(%v, %v) returned an error: %v", fsm.currentState, CustomStateEnumC, err)
 	}
 
+	transitions := fsm.Transitions()
+
 	// Verify the number of entries in the transition tracker
-	if len(fsm.transitions) != 2 {
-		t.Errorf("Transition tracker does not contain the expected number of entries. Got %d, expected 2", len(fsm.transitions))
+	if len(transitions) != 2 {
+		t.Errorf("Transition tracker does not contain the expected number of entries. Got %d, expected 2", len(transitions))
 	}
 
 	// Get the transition timestamps in order
-	timestamps := make([]time.Time, 0, len(fsm.transitions))
-	for _, t := range fsm.transitions {
+	timestamps := make([]time.Time, 0, len(transitions))
+	for _, t := range transitions {
 		timestamps = append(timestamps, t.Timestamp)
 	}
 	sort.Slice(timestamps, func(i, j int) bool {
@@ -174,7 +178,7 @@ func Test_transitionTracking(t *testing.T) {
 		},
 	}
 
-	for i, tr := range fsm.transitions {
+	for i, tr := range transitions {
 		expected := expectedTransitions[i]
 
 		if tr.FromState != expected.FromState {
@@ -197,6 +201,78 @@ func Test_transitionTracking(t *testing.T) {
 	}
 }
 
+func Test_replaceRules(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+
+	// The current state is not part of the new ruleset
+	err := fsm.ReplaceRules(Ruleset[CustomStateEnum]{
+		CustomStateEnumB: {CustomStateEnumC},
+	})
+
+	var unknownErr UnknownStateError[CustomStateEnum]
+	if !errors.As(err, &unknownErr) || unknownErr.State != CustomStateEnumA {
+		t.Errorf("ReplaceRules() returned an unexpected error: %v", err)
+	}
+
+	if !fsm.CanTransition(CustomStateEnumB) {
+		t.Errorf("ReplaceRules() changed the rules on a failed validation")
+	}
+
+	rs := Ruleset[CustomStateEnum]{
+		CustomStateEnumA: {CustomStateEnumC},
+		CustomStateEnumC: {CustomStateEnumD},
+	}
+
+	err = fsm.ReplaceRules(rs)
+	if err != nil {
+		t.Fatalf("ReplaceRules() returned an error: %v", err)
+	}
+
+	// Changes to the ruleset after replacing must not affect the FSM
+	rs[CustomStateEnumA] = append(rs[CustomStateEnumA], CustomStateEnumB)
+
+	if fsm.CanTransition(CustomStateEnumB) {
+		t.Errorf("CanTransition(%v) = true after the rule was replaced", CustomStateEnumB)
+	}
+
+	_, err = fsm.Transition(CustomStateEnumC, nil)
+	if err != nil {
+		t.Errorf("Transition(%v, %v) returned an error: %v", fsm.currentState, CustomStateEnumC, err)
+	}
+}
+
+func Test_newFSMWithRuleset(t *testing.T) {
+	rs := Ruleset[CustomStateEnum]{
+		CustomStateEnumA: {CustomStateEnumB},
+		CustomStateEnumB: {CustomStateEnumC},
+	}
+
+	fsm1 := NewFSMWithRuleset[CustomStateEnum](CustomStateEnumA, 10, rs)
+	fsm2 := NewFSMWithRuleset[CustomStateEnum](CustomStateEnumA, 10, rs)
+
+	_, err := fsm1.Transition(CustomStateEnumB, nil)
+	if err != nil {
+		t.Errorf("Transition(%v, %v) returned an error: %v", fsm1.currentState, CustomStateEnumB, err)
+	}
+
+	// Adding a rule to one FSM must not affect the shared ruleset or other FSMs
+	fsm2.AddRule(CustomStateEnumA, CustomStateEnumD)
+
+	if !fsm2.CanTransition(CustomStateEnumD) {
+		t.Errorf("CanTransition(%v) = false after adding the rule", CustomStateEnumD)
+	}
+
+	if len(rs[CustomStateEnumA]) != 1 {
+		t.Errorf("AddRule() modified the shared ruleset: %v", rs)
+	}
+
+	fromState, toState := CustomStateEnumA, CustomStateEnumD
+	if fsm1.canTransition(&fromState, &toState) {
+		t.Errorf("AddRule() on one FSM added the rule to another FSM sharing the ruleset")
+	}
+}
+
 func Test_concurrencyRaceCondition(t *testing.T) {
 	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
 	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
@@ -364,8 +440,101 @@ func Test_unmarshalJSON(t *testing.T) {
 		Timestamp: tp,
 		Metadata:  map[string]string{"reason": "Transition from stateA to stateB"},
 	}
-	if !reflect.DeepEqual(fsm.transitions, []Transition[string]{expectedTransition}) {
-		t.Errorf("Unexpected transitions. Expected: %v, Got: %v", []Transition[string]{expectedTransition}, fsm.transitions)
+	if !reflect.DeepEqual(fsm.Transitions(), []Transition[string]{expectedTransition}) {
+		t.Errorf("Unexpected transitions. Expected: %v, Got: %v", []Transition[string]{expectedTransition}, fsm.Transitions())
+	}
+}
+
+func Test_unmarshalJSONWithWatermark(t *testing.T) {
+	source := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	source.AddRule(CustomStateEnumA, CustomStateEnumB)
+	source.AddRule(CustomStateEnumB, CustomStateEnumC)
+
+	source.Transition(CustomStateEnumB, nil)
+	source.Transition(CustomStateEnumC, nil)
+
+	data, err := json.Marshal(source)
+	if err != nil {
+		t.Fatalf("MarshalJSON failed: %v", err)
+	}
+
+	tests := []struct {
+		watermark uint64
+		wantErr   bool
+	}{
+		{0, false}, // No watermark
+		{1, false}, // Snapshot is ahead of the watermark
+		{2, false}, // Snapshot is at the watermark
+		{3, true},  // Snapshot is behind the watermark
+	}
+
+	for _, test := range tests {
+		fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+
+		err := fsm.UnmarshalJSONWithWatermark(data, test.watermark)
+		if (err != nil) != test.wantErr {
+			t.Errorf("UnmarshalJSONWithWatermark(%d) returned error: %v, wantErr: %v", test.watermark, err, test.wantErr)
+		}
+
+		if test.wantErr {
+			if !errors.Is(err, ErrStaleSnapshot) {
+				t.Errorf("UnmarshalJSONWithWatermark(%d) returned an unexpected error type: %v", test.watermark, err)
+			}
+
+			if fsm.currentState != CustomStateEnumA {
+				t.Errorf("UnmarshalJSONWithWatermark(%d) changed the current state to %v on a stale snapshot", test.watermark, fsm.currentState)
+			}
+
+			continue
+		}
+
+		if fsm.currentState != CustomStateEnumC || fsm.version != 2 {
+			t.Errorf("UnmarshalJSONWithWatermark(%d) loaded state %v version %d, expected %v version 2", test.watermark, fsm.currentState, fsm.version, CustomStateEnumC)
+		}
+	}
+}
+
+func Test_unmarshalJSONTruncation(t *testing.T) {
+	source := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	source.AddRule(CustomStateEnumA, CustomStateEnumB)
+	source.AddRule(CustomStateEnumB, CustomStateEnumC)
+	source.AddRule(CustomStateEnumC, CustomStateEnumD)
+
+	source.Transition(CustomStateEnumB, nil)
+	source.Transition(CustomStateEnumC, nil)
+	source.Transition(CustomStateEnumD, nil)
+
+	data, err := json.Marshal(source)
+	if err != nil {
+		t.Fatalf("MarshalJSON failed: %v", err)
+	}
+
+	tests := []struct {
+		opts     []FSMOption[CustomStateEnum]
+		wantFrom []CustomStateEnum
+	}{
+		{nil, []CustomStateEnum{CustomStateEnumB, CustomStateEnumC}},
+		{[]FSMOption[CustomStateEnum]{WithImportTruncation[CustomStateEnum](KeepNewest)}, []CustomStateEnum{CustomStateEnumB, CustomStateEnumC}},
+		{[]FSMOption[CustomStateEnum]{WithImportTruncation[CustomStateEnum](KeepOldest)}, []CustomStateEnum{CustomStateEnumA, CustomStateEnumB}},
+	}
+
+	for i, test := range tests {
+		fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 2, test.opts...)
+
+		if err := json.Unmarshal(data, fsm); err != nil {
+			t.Fatalf("UnmarshalJSON failed: %v", err)
+		}
+
+		transitions := fsm.Transitions()
+		if len(transitions) != len(test.wantFrom) {
+			t.Fatalf("test %d: expected %d transitions, got %d", i, len(test.wantFrom), len(transitions))
+		}
+
+		for j, transition := range transitions {
+			if transition.FromState != test.wantFrom[j] {
+				t.Errorf("test %d: expected transition %d to start from %v, got %v", i, j, test.wantFrom[j], transition.FromState)
+			}
+		}
 	}
 }
 
@@ -414,6 +583,145 @@ func Test_withCustomTimeProvider(t *testing.T) {
 	}
 }
 
+func Test_unlimitedHistory(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, UnlimitedHistory)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumA)
+
+	numTransitions := 1000
+
+	for i := 0; i < numTransitions/2; i++ {
+		fsm.Transition(CustomStateEnumB, nil)
+		fsm.Transition(CustomStateEnumA, nil)
+	}
+
+	transitions := fsm.Transitions()
+	if len(transitions) != numTransitions {
+		t.Errorf("Transitions() returned an unexpected number of transitions: %v, expected %v", len(transitions), numTransitions)
+	}
+
+	if transitions[0].FromState != CustomStateEnumA || transitions[0].ToState != CustomStateEnumB {
+		t.Errorf("Transitions() did not keep the oldest transition: %v", transitions[0])
+	}
+
+	data, err := json.Marshal(fsm)
+	if err != nil {
+		t.Fatalf("MarshalJSON failed: %v", err)
+	}
+
+	imported := NewFSM[CustomStateEnum](CustomStateEnumA, UnlimitedHistory)
+
+	err = json.Unmarshal(data, imported)
+	if err != nil {
+		t.Fatalf("UnmarshalJSON failed: %v", err)
+	}
+
+	if len(imported.Transitions()) != numTransitions {
+		t.Errorf("UnmarshalJSON imported an unexpected number of transitions: %v, expected %v", len(imported.Transitions()), numTransitions)
+	}
+}
+
+func Test_noHistory(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 0)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+
+	fsm.Transition(CustomStateEnumB, nil)
+
+	if len(fsm.Transitions()) != 0 {
+		t.Errorf("Transitions() returned an unexpected number of transitions: %v, expected 0", len(fsm.Transitions()))
+	}
+}
+
+func Test_withEventTime(t *testing.T) {
+	var (
+		processingTime = time.Date(2021, 1, 1, 0, 0, 5, 0, time.UTC)
+		eventTime      = time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	)
+
+	fsm := NewFSM[CustomStateEnum](
+		CustomStateEnumA,
+		10,
+		WithTimeProvider[CustomStateEnum](func() time.Time {
+			return processingTime
+		}),
+	)
+
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumC)
+
+	_, err := fsm.Transition(CustomStateEnumB, nil, WithEventTime(eventTime))
+	if err != nil {
+		t.Errorf("Transition(%v, %v) returned an error: %v", fsm.currentState, CustomStateEnumB, err)
+	}
+
+	_, err = fsm.Transition(CustomStateEnumC, nil)
+	if err != nil {
+		t.Errorf("Transition(%v, %v) returned an error: %v", fsm.currentState, CustomStateEnumC, err)
+	}
+
+	transitions := fsm.Transitions()
+
+	tests := []struct {
+		timestamp time.Time
+		eventTime time.Time
+	}{
+		{processingTime, eventTime},      // Overridden event time
+		{processingTime, processingTime}, // Event time defaults to processing time
+	}
+
+	for i, test := range tests {
+		if !transitions[i].Timestamp.Equal(test.timestamp) {
+			t.Errorf("Transition %d has an unexpected timestamp: %v, expected %v", i, transitions[i].Timestamp, test.timestamp)
+		}
+
+		if !transitions[i].EventTime.Equal(test.eventTime) {
+			t.Errorf("Transition %d has an unexpected event time: %v, expected %v", i, transitions[i].EventTime, test.eventTime)
+		}
+	}
+}
+
+func Test_withEvictionHandler(t *testing.T) {
+	var evicted []Transition[CustomStateEnum]
+
+	fsm := NewFSM[CustomStateEnum](
+		CustomStateEnumA,
+		2,
+		WithEvictionHandler[CustomStateEnum](func(tr Transition[CustomStateEnum]) {
+			evicted = append(evicted, tr)
+		}),
+	)
+
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumC)
+	fsm.AddRule(CustomStateEnumC, CustomStateEnumD)
+
+	fsm.Transition(CustomStateEnumB, map[string]string{"step": "1"})
+	fsm.Transition(CustomStateEnumC, map[string]string{"step": "2"})
+
+	if len(evicted) != 0 {
+		t.Errorf("eviction handler called before history was full: %v", evicted)
+	}
+
+	fsm.Transition(CustomStateEnumD, map[string]string{"step": "3"})
+
+	if len(evicted) != 1 {
+		t.Fatalf("eviction handler called %d times, expected 1", len(evicted))
+	}
+
+	if evicted[0].FromState != CustomStateEnumA || evicted[0].ToState != CustomStateEnumB {
+		t.Errorf("eviction handler received unexpected transition: %v", evicted[0])
+	}
+
+	if evicted[0].Metadata["step"] != "1" {
+		t.Errorf("eviction handler received unexpected metadata: %v", evicted[0].Metadata)
+	}
+
+	transitions := fsm.Transitions()
+	if len(transitions) != 2 {
+		t.Errorf("Transitions() returned an unexpected number of transitions: %v", len(transitions))
+	}
+}
+
 func Benchmark_singleTransition(b *testing.B) {
 	// CustomEntity represents a custom entity with its current state
 	type CustomEntity struct {
@@ -438,6 +746,22 @@ func Benchmark_singleTransition(b *testing.B) {
 	}
 }
 
+func Benchmark_singleTransitionNoLocking(b *testing.B) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10, WithNoLocking[CustomStateEnum]())
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumA)
+
+	targets := []CustomStateEnum{CustomStateEnumB, CustomStateEnumA}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := fsm.Transition(targets[i%2], nil)
+		if err != nil {
+			b.Errorf("Transition returned an error: %v", err)
+		}
+	}
+}
+
 func Benchmark_twoTransitions(b *testing.B) {
 	// CustomEntity represents a custom entity with its current state
 	type CustomEntity struct {
@@ -481,6 +805,27 @@ func Benchmark_accessCurrentState(b *testing.B) {
 	}
 }
 
+func Benchmark_accessCurrentStateConcurrently(b *testing.B) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumA)
+
+	wg := sync.WaitGroup{}
+	wg.Add(b.N)
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		go func() {
+			defer wg.Done()
+
+			_ = fsm.CurrentState()
+		}()
+	}
+
+	wg.Wait()
+}
+
 func Benchmark_accessTransitions(b *testing.B) {
 	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
 	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
@@ -597,3 +942,493 @@ func Benchmark_String(b *testing.B) {
 		_ = fsm.String()
 	}
 }
+
+func Test_transitionIfVersion(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumC)
+
+	if fsm.Version() != 0 {
+		t.Fatalf("expected version 0, got %d", fsm.Version())
+	}
+
+	if _, err := fsm.TransitionIfVersion(0, CustomStateEnumB, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// a stale version is rejected
+	_, err := fsm.TransitionIfVersion(0, CustomStateEnumC, nil)
+	if !errors.Is(err, ErrVersionMismatch) {
+		t.Fatalf("expected ErrVersionMismatch, got %v", err)
+	}
+
+	expected := VersionMismatchError{Expected: 0, Actual: 1}
+	if !reflect.DeepEqual(err, expected) {
+		t.Errorf("expected %v, got %v", expected, err)
+	}
+
+	if fsm.CurrentState() != CustomStateEnumB || fsm.Version() != 1 {
+		t.Errorf("expected the FSM to be unchanged, got %v at version %d", fsm.CurrentState(), fsm.Version())
+	}
+
+	// invalid transitions don't increment the version
+	if _, err := fsm.TransitionIfVersion(1, CustomStateEnumA, nil); err == nil {
+		t.Fatal("expected a transition error")
+	}
+
+	if _, err := fsm.TransitionIfVersion(1, CustomStateEnumC, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := json.Marshal(fsm)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	restored := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	if err := json.Unmarshal(data, restored); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if restored.Version() != 2 {
+		t.Errorf("expected the version to round-trip, got %d", restored.Version())
+	}
+}
+
+func Test_rulesetHashCheck(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+
+	data, err := json.Marshal(fsm)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var export struct {
+		RulesetHash string `json:"ruleset_hash"`
+	}
+	if err := json.Unmarshal(data, &export); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if export.RulesetHash != fsm.RulesetHash() {
+		t.Errorf("expected the exported hash %s to match %s", export.RulesetHash, fsm.RulesetHash())
+	}
+
+	// the hash isn't checked by default
+	unchecked := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	unchecked.AddRule(CustomStateEnumA, CustomStateEnumC)
+
+	if err := json.Unmarshal(data, unchecked); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	checked := NewFSM[CustomStateEnum](CustomStateEnumA, 10, WithRulesetHashCheck[CustomStateEnum]())
+	checked.AddRule(CustomStateEnumA, CustomStateEnumC)
+
+	if err := json.Unmarshal(data, checked); !errors.Is(err, ErrRulesetMismatch) {
+		t.Errorf("expected ErrRulesetMismatch, got %v", err)
+	}
+
+	matching := NewFSM[CustomStateEnum](CustomStateEnumA, 10, WithRulesetHashCheck[CustomStateEnum]())
+	matching.AddRule(CustomStateEnumA, CustomStateEnumB)
+
+	if err := json.Unmarshal(data, matching); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func Test_forceTransition(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+
+	metadata := map[string]string{"ticket": "OPS-42"}
+
+	newState, err := fsm.ForceTransition(CustomStateEnumD, metadata)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if newState != CustomStateEnumD || fsm.CurrentState() != CustomStateEnumD {
+		t.Errorf("expected current state %v, got %v", CustomStateEnumD, fsm.CurrentState())
+	}
+
+	if fsm.Version() != 1 {
+		t.Errorf("expected version 1, got %d", fsm.Version())
+	}
+
+	expected := map[string]string{"ticket": "OPS-42", MetadataForced: "true"}
+	if md := fsm.Transitions()[0].Metadata; !reflect.DeepEqual(md, expected) {
+		t.Errorf("expected metadata %v, got %v", expected, md)
+	}
+
+	if _, ok := metadata[MetadataForced]; ok {
+		t.Errorf("expected the caller's metadata to be left untouched")
+	}
+
+	// regular transitions are still checked against the ruleset
+	if _, err := fsm.Transition(CustomStateEnumA, nil); err == nil {
+		t.Errorf("expected a transition error")
+	}
+}
+
+func Test_withIdempotentSameState(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10, WithIdempotentSameState[CustomStateEnum]())
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+
+	for i := 0; i < 2; i++ {
+		newState, err := fsm.Transition(CustomStateEnumB, nil)
+		if err != nil {
+			t.Fatalf("attempt %d: unexpected error: %v", i, err)
+		}
+
+		if newState != CustomStateEnumB {
+			t.Errorf("attempt %d: expected state %v, got %v", i, CustomStateEnumB, newState)
+		}
+	}
+
+	if fsm.Version() != 1 || len(fsm.Transitions()) != 1 {
+		t.Errorf("expected the retry to have no effect, got version %d and %d transitions", fsm.Version(), len(fsm.Transitions()))
+	}
+
+	// other invalid transitions still fail
+	if _, err := fsm.Transition(CustomStateEnumA, nil); err == nil {
+		t.Errorf("expected a transition error")
+	}
+
+	strict := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	if _, err := strict.Transition(CustomStateEnumA, nil); err == nil {
+		t.Errorf("expected a transition error without the option")
+	}
+}
+
+func Test_withNoLocking(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10, WithNoLocking[CustomStateEnum]())
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+
+	if !fsm.CanTransition(CustomStateEnumB) {
+		t.Fatalf("expected the transition to be allowed")
+	}
+
+	if _, err := fsm.Transition(CustomStateEnumB, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if fsm.CurrentState() != CustomStateEnumB || fsm.Version() != 1 || len(fsm.Transitions()) != 1 {
+		t.Errorf("unexpected state %v, version %d and %d transitions", fsm.CurrentState(), fsm.Version(), len(fsm.Transitions()))
+	}
+
+	if _, err := fsm.Transition(CustomStateEnumA, nil); err == nil {
+		t.Errorf("expected a transition error")
+	}
+}
+
+func Test_withRulesInJSON(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 5, WithRulesInJSON[CustomStateEnum]())
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumC)
+
+	if _, err := fsm.Transition(CustomStateEnumB, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := json.Marshal(fsm)
+	if err != nil {
+		t.Fatalf("MarshalJSON failed: %v", err)
+	}
+
+	var decoded FSM[CustomStateEnum]
+
+	err = json.Unmarshal(data, &decoded)
+	if err != nil {
+		t.Fatalf("UnmarshalJSON failed: %v", err)
+	}
+
+	if decoded.maxHistory != 5 || decoded.RulesetHash() != fsm.RulesetHash() || len(decoded.Transitions()) != 1 {
+		t.Errorf("expected the rules and maximum history size to be imported, got %v", &decoded)
+	}
+
+	if _, err := decoded.Transition(CustomStateEnumC, nil); err != nil {
+		t.Errorf("expected the imported rules to allow the transition: %v", err)
+	}
+
+	// without the option, the rules of an FSM that has some are kept
+	other := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	other.AddRule(CustomStateEnumB, CustomStateEnumD)
+
+	err = json.Unmarshal(data, other)
+	if err != nil {
+		t.Fatalf("UnmarshalJSON failed: %v", err)
+	}
+
+	if other.CanTransition(CustomStateEnumC) || !other.CanTransition(CustomStateEnumD) || other.maxHistory != 10 {
+		t.Errorf("expected the FSM's rules to be kept")
+	}
+
+	plain, _ := json.Marshal(other)
+	if strings.Contains(string(plain), `"rules"`) {
+		t.Errorf("expected the rules to be omitted without the option: %s", plain)
+	}
+}
+
+func Test_withStrictUnmarshal(t *testing.T) {
+	newFSM := func() *FSM[CustomStateEnum] {
+		fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10, WithStrictUnmarshal[CustomStateEnum]())
+		fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+		fsm.AddRule(CustomStateEnumB, CustomStateEnumC)
+
+		return fsm
+	}
+
+	source := newFSM()
+	source.Transition(CustomStateEnumB, nil)
+	source.Transition(CustomStateEnumC, nil)
+
+	data, err := json.Marshal(source)
+	if err != nil {
+		t.Fatalf("MarshalJSON failed: %v", err)
+	}
+
+	if err := json.Unmarshal(data, newFSM()); err != nil {
+		t.Fatalf("expected a valid export to be imported: %v", err)
+	}
+
+	// an unknown current state
+	unknown := strings.Replace(string(data), `"current_state":"C"`, `"current_state":"Z"`, 1)
+
+	var stateErr UnknownStateError[CustomStateEnum]
+	if err := json.Unmarshal([]byte(unknown), newFSM()); !errors.As(err, &stateErr) || stateErr.State != "Z" {
+		t.Errorf("expected an UnknownStateError, got %v", err)
+	}
+
+	// a transition that isn't allowed by the rules
+	snapshot, _ := source.Snapshot()
+	snapshot.Transitions[1].FromState = CustomStateEnumA
+
+	var replayErr ReplayError[CustomStateEnum]
+	if err := newFSM().Restore(snapshot); !errors.As(err, &replayErr) || replayErr.Index != 1 {
+		t.Errorf("expected a ReplayError at index 1, got %v", err)
+	}
+
+	// a history that doesn't lead to the current state
+	snapshot, _ = source.Snapshot()
+	snapshot.State = CustomStateEnumB
+
+	if err := newFSM().Restore(snapshot); !errors.As(err, &replayErr) || replayErr.Index != 1 {
+		t.Errorf("expected a ReplayError at index 1, got %v", err)
+	}
+
+	// forced transitions are not checked against the rules
+	forced := newFSM()
+	forced.ForceTransition(CustomStateEnumC, nil)
+	snapshot, _ = forced.Snapshot()
+
+	if err := newFSM().Restore(snapshot); err != nil {
+		t.Errorf("expected a forced transition to be imported: %v", err)
+	}
+
+	// without the option, the data is imported as it is
+	lenient := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	lenient.AddRule(CustomStateEnumA, CustomStateEnumB)
+	lenient.AddRule(CustomStateEnumB, CustomStateEnumC)
+
+	if err := json.Unmarshal([]byte(unknown), lenient); err != nil {
+		t.Errorf("expected the data to be imported without the option: %v", err)
+	}
+}
+
+func Test_selfTransition(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10, WithIdempotentSameState[CustomStateEnum]())
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumA, CustomStateEnumB)
+
+	for _, attempt := range []string{"2", "3"} {
+		if _, err := fsm.Transition(CustomStateEnumA, map[string]string{"attempt": attempt}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	// declared self-transitions are recorded even with the idempotent option
+	transitions := fsm.Transitions()
+	if len(transitions) != 2 || fsm.Version() != 2 {
+		t.Fatalf("expected 2 recorded self-transitions, got %d at version %d", len(transitions), fsm.Version())
+	}
+
+	for i, attempt := range []string{"2", "3"} {
+		tr := transitions[i]
+		if tr.FromState != CustomStateEnumA || tr.ToState != CustomStateEnumA || tr.Metadata["attempt"] != attempt {
+			t.Errorf("unexpected transition %d: %v", i, tr)
+		}
+	}
+
+	diagram, err := fsm.GenerateMermaidRulesDiagram()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !strings.Contains(diagram, "A --> A;") {
+		t.Errorf("expected the diagram to contain the self-loop, got:\n%s", diagram)
+	}
+}
+
+func Test_transitionOptions(t *testing.T) {
+	ts := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	override := ts.Add(-time.Hour)
+
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10, WithTimeProvider[CustomStateEnum](func() time.Time {
+		return ts
+	}))
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+
+	_, err := fsm.Transition(CustomStateEnumB, map[string]string{"ticket": "42"},
+		WithActor("user:42"),
+		WithReason("manual override"),
+		WithTimestampOverride(override))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := Transition[CustomStateEnum]{
+		FromState: CustomStateEnumA,
+		ToState:   CustomStateEnumB,
+		Timestamp: override,
+		EventTime: override,
+		Actor:     "user:42",
+		Reason:    "manual override",
+		Metadata:  map[string]string{"ticket": "42"},
+		Version:   1,
+	}
+
+	if got := fsm.Transitions()[0]; !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+
+	data, err := json.Marshal(fsm)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	restored := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	if err := json.Unmarshal(data, restored); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := restored.Transitions()[0]; got.Actor != expected.Actor || got.Reason != expected.Reason {
+		t.Errorf("expected the actor and reason to round-trip, got %v", got)
+	}
+}
+
+func Test_withBackdate(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumC)
+	fsm.AddRule(CustomStateEnumC, CustomStateEnumD)
+
+	created := time.Date(2019, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	if _, err := fsm.Transition(CustomStateEnumB, nil, WithBackdate(created)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// transitions can't be recorded before the newest one
+	_, err := fsm.Transition(CustomStateEnumC, nil, WithBackdate(created.Add(-time.Minute)))
+
+	expected := TimestampOrderError{Timestamp: created.Add(-time.Minute), Previous: created}
+	if !reflect.DeepEqual(err, expected) {
+		t.Fatalf("expected %v, got %v", expected, err)
+	}
+
+	if fsm.CurrentState() != CustomStateEnumB {
+		t.Errorf("expected the state to be unchanged, got %v", fsm.CurrentState())
+	}
+
+	// equal timestamps are accepted
+	if _, err := fsm.Transition(CustomStateEnumC, nil, WithBackdate(created)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	eventTime := created.Add(time.Hour)
+	if _, err := fsm.Transition(CustomStateEnumD, nil, WithBackdate(created.Add(2*time.Hour)), WithEventTime(eventTime)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	transitions := fsm.Transitions()
+	if transitions[0].Timestamp != created || transitions[0].EventTime != created {
+		t.Errorf("expected the first transition at %v, got %v", created, transitions[0])
+	}
+
+	if transitions[2].Timestamp != created.Add(2*time.Hour) || transitions[2].EventTime != eventTime {
+		t.Errorf("expected the event time to be kept, got %v", transitions[2])
+	}
+}
+
+func Test_withMetadataValidator(t *testing.T) {
+	errMissing := errors.New("requested_by is required")
+
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10, WithMetadataValidator(func(from, to CustomStateEnum, md map[string]string) error {
+		if to == CustomStateEnumB && md["requested_by"] == "" {
+			return errMissing
+		}
+
+		return nil
+	}))
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB, CustomStateEnumC)
+
+	_, err := fsm.Transition(CustomStateEnumB, nil)
+
+	var metadataErr MetadataError[CustomStateEnum]
+	if !errors.As(err, &metadataErr) || !errors.Is(err, errMissing) {
+		t.Fatalf("expected a MetadataError wrapping the validation error, got %v", err)
+	}
+
+	if metadataErr.FromState != CustomStateEnumA || metadataErr.ToState != CustomStateEnumB {
+		t.Errorf("unexpected error states: %v", metadataErr)
+	}
+
+	if fsm.CurrentState() != CustomStateEnumA || len(fsm.Transitions()) != 0 {
+		t.Errorf("expected the rejected transition to have no effect")
+	}
+
+	// forced transitions are validated as well
+	if _, err := fsm.ForceTransition(CustomStateEnumB, nil); !errors.Is(err, errMissing) {
+		t.Errorf("expected the forced transition to be rejected, got %v", err)
+	}
+
+	if _, err := fsm.Transition(CustomStateEnumB, map[string]string{"requested_by": "Mahmoud"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func Test_withDefaultMetadata(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10, WithDefaultMetadata[CustomStateEnum](map[string]string{
+		"service": "orders",
+		"version": "1.0",
+	}))
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumC)
+
+	metadata := map[string]string{"version": "1.1", "requested_by": "John"}
+
+	if _, err := fsm.Transition(CustomStateEnumB, metadata); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := fsm.Transition(CustomStateEnumC, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	transitions := fsm.Transitions()
+
+	expected := map[string]string{"service": "orders", "version": "1.1", "requested_by": "John"}
+	if !reflect.DeepEqual(transitions[0].Metadata, expected) {
+		t.Errorf("expected metadata %v, got %v", expected, transitions[0].Metadata)
+	}
+
+	expected = map[string]string{"service": "orders", "version": "1.0"}
+	if !reflect.DeepEqual(transitions[1].Metadata, expected) {
+		t.Errorf("expected metadata %v, got %v", expected, transitions[1].Metadata)
+	}
+
+	if len(metadata) != 2 {
+		t.Errorf("expected the caller's metadata to be left untouched, got %v", metadata)
+	}
+}
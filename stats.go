@@ -0,0 +1,92 @@
+package statetrooper
+
+import (
+	"sort"
+	"time"
+)
+
+// TransitionStats aggregates the durations of the last transition calls, see WithTransitionStats
+type TransitionStats struct {
+	// Count is the number of calls the statistics are computed over
+	Count int
+	Min   time.Duration
+	Max   time.Duration
+	Mean  time.Duration
+	P50   time.Duration
+	P90   time.Duration
+	P99   time.Duration
+}
+
+// WithTransitionStats measures how long each transition call takes once the FSM is locked, including
+// the authorizer, the validator, the commit and the hooks, and keeps the last n durations for
+// TransitionStats. Durations are measured using the monotonic clock, independently of the time provider
+// Rejected attempts are measured too, while the lock wait is reported by Metrics
+// DEFAULT: 0, durations aren't measured
+func WithTransitionStats[T comparable](n int) FSMOption[T] {
+	return func(fsm *FSM[T]) {
+		if n > 0 {
+			fsm.durations = &durationWindow{buf: make([]time.Duration, 0, n)}
+		}
+	}
+}
+
+// TransitionStats returns the aggregated durations of the last transition calls kept with WithTransitionStats
+// The zero value is returned if no durations were measured
+func (fsm *FSM[T]) TransitionStats() TransitionStats {
+	fsm.rlock()
+	defer fsm.runlock()
+
+	if fsm.durations == nil || len(fsm.durations.buf) == 0 {
+		return TransitionStats{}
+	}
+
+	sorted := append([]time.Duration(nil), fsm.durations.buf...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+	}
+
+	return TransitionStats{
+		Count: len(sorted),
+		Min:   sorted[0],
+		Max:   sorted[len(sorted)-1],
+		Mean:  total / time.Duration(len(sorted)),
+		P50:   percentile(sorted, 0.50),
+		P90:   percentile(sorted, 0.90),
+		P99:   percentile(sorted, 0.99),
+	}
+}
+
+// durationWindow keeps the last cap(buf) durations
+type durationWindow struct {
+	buf  []time.Duration
+	next int
+}
+
+// observe adds a duration, replacing the oldest one once the window is full
+func (w *durationWindow) observe(d time.Duration) {
+	if len(w.buf) < cap(w.buf) {
+		w.buf = append(w.buf, d)
+
+		return
+	}
+
+	w.buf[w.next] = d
+	w.next = (w.next + 1) % len(w.buf)
+}
+
+// percentile returns the nearest-rank percentile p of the sorted durations
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(p*float64(len(sorted))+0.5) - 1
+	if rank < 0 {
+		rank = 0
+	}
+
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+
+	return sorted[rank]
+}
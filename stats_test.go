@@ -0,0 +1,56 @@
+package statetrooper
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_withTransitionStats(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10, WithTransitionStats[CustomStateEnum](3))
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumA)
+
+	if stats := fsm.TransitionStats(); stats.Count != 0 {
+		t.Errorf("expected no statistics, got %+v", stats)
+	}
+
+	// a slow subscriber shows up in the durations
+	fsm.Subscribe(func(Transition[CustomStateEnum]) {
+		time.Sleep(5 * time.Millisecond)
+	})
+
+	for i := 0; i < 5; i++ {
+		fsm.Transition(CustomStateEnumA, nil)
+	}
+
+	stats := fsm.TransitionStats()
+	if stats.Count != 3 {
+		t.Errorf("expected the last 3 durations to be kept, got %d", stats.Count)
+	}
+
+	if stats.Min < 5*time.Millisecond || stats.Min > stats.P50 || stats.P50 > stats.P99 || stats.P99 != stats.Max {
+		t.Errorf("unexpected statistics: %+v", stats)
+	}
+}
+
+func Test_percentile(t *testing.T) {
+	sorted := make([]time.Duration, 100)
+	for i := range sorted {
+		sorted[i] = time.Duration(i+1) * time.Millisecond
+	}
+
+	tests := []struct {
+		p        float64
+		expected time.Duration
+	}{
+		{0.50, 50 * time.Millisecond},
+		{0.90, 90 * time.Millisecond},
+		{0.99, 99 * time.Millisecond},
+		{1, 100 * time.Millisecond},
+	}
+
+	for _, test := range tests {
+		if got := percentile(sorted, test.p); got != test.expected {
+			t.Errorf("percentile(%v) = %v, expected %v", test.p, got, test.expected)
+		}
+	}
+}
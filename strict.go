@@ -0,0 +1,84 @@
+package statetrooper
+
+import "fmt"
+
+// WithStrictUnmarshal makes UnmarshalJSON and Restore verify imported data against the ruleset,
+// as a defense against corrupted or hand-edited persisted data
+// The current state must be defined in the ruleset, otherwise an UnknownStateError is returned
+// Each transition must be allowed by the ruleset and start from the state reached by the previous one,
+// and the last one must lead to the current state, otherwise a ReplayError pointing at the first
+// offending transition is returned. Transitions recorded by ForceTransition and Reset and compacted records aren't checked
+// against the ruleset, nor are those involving states mapped by WithStateMigrations
+// DEFAULT: imported data is not verified
+func WithStrictUnmarshal[T comparable]() FSMOption[T] {
+	return func(fsm *FSM[T]) {
+		fsm.strictUnmarshal = true
+	}
+}
+
+// ValidateHistory verifies a state and the transitions leading to it against the ruleset as WithStrictUnmarshal
+// does, e.g. to check the transitions made since an entity was loaded before saving it
+func (fsm *FSM[T]) ValidateHistory(state T, transitions []Transition[T]) error {
+	fsm.rlock()
+	defer fsm.runlock()
+
+	return fsm.validateImport(state, transitions)
+}
+
+// validateImport verifies the imported current state and history against the ruleset
+// The caller must hold the lock
+func (fsm *FSM[T]) validateImport(state T, transitions []Transition[T]) error {
+	if !fsm.knownState(state) {
+		return UnknownStateError[T]{State: state}
+	}
+
+	for i := range transitions {
+		transition := transitions[i]
+
+		var err error
+
+		switch {
+		case i > 0 && transition.FromState != transitions[i-1].ToState:
+			err = fmt.Errorf("transition starts from %v but the previous transition led to %v",
+				transition.FromState, transitions[i-1].ToState)
+		case i == len(transitions)-1 && transition.ToState != state:
+			err = fmt.Errorf("transition leads to %v but the current state is %v", transition.ToState, state)
+		case !fsm.allowsRecorded(&transition):
+			err = TransitionError[T]{FromState: transition.FromState, ToState: transition.ToState}
+		}
+
+		if err != nil {
+			return ReplayError[T]{Index: i, Transition: transition, Err: err}
+		}
+	}
+
+	return nil
+}
+
+// allowsRecorded checks if the ruleset allows a recorded transition
+// Forced, reset and compacted records are exempt, as are transitions from or to migrated states,
+// since they were made or summarized outside the current ruleset
+func (fsm *FSM[T]) allowsRecorded(transition *Transition[T]) bool {
+	switch {
+	case transition.Metadata[MetadataForced] == "true" || transition.Metadata[MetadataReset] == "true":
+		return true
+	case transition.Metadata[MetadataCompacted] != "":
+		return true
+	case fsm.migrated(transition.FromState) || fsm.migrated(transition.ToState):
+		return true
+	}
+
+	return fsm.canTransition(&transition.FromState, &transition.ToState)
+}
+
+// knownState checks if the state is defined in the ruleset or mapped by a state migration
+func (fsm *FSM[T]) knownState(state T) bool {
+	return fsm.migrated(state) || fsm.ruleset.hasState(state)
+}
+
+// migrated checks if the state is mapped by a state migration
+func (fsm *FSM[T]) migrated(state T) bool {
+	_, ok := fsm.migrations[state]
+
+	return ok
+}
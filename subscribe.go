@@ -0,0 +1,141 @@
+package statetrooper
+
+// subscriber is a callback registered with Subscribe
+type subscriber[T comparable] struct {
+	id uint64
+	fn func(Transition[T])
+}
+
+// Subscribe registers fn to be called with every committed transition
+// Metadata keys set with WithRedactedMetadataKeys are redacted
+// fn is called synchronously, in commit order, while the FSM is locked, so it must not call
+// back into the FSM and should hand off any slow work, unless WithAsyncSubscribers is set
+// A panic in fn is recovered and returned by Transition as a PanicError, the transition being committed
+// The returned function unregisters fn
+func (fsm *FSM[T]) Subscribe(fn func(Transition[T])) (unsubscribe func()) {
+	fsm.lock()
+	defer fsm.unlock()
+
+	fsm.subscriberID++
+	id := fsm.subscriberID
+
+	fsm.subscribers = append(fsm.subscribers, subscriber[T]{id: id, fn: fn})
+
+	return func() {
+		fsm.lock()
+		defer fsm.unlock()
+
+		for i, sub := range fsm.subscribers {
+			if sub.id == id {
+				fsm.subscribers = append(fsm.subscribers[:i:i], fsm.subscribers[i+1:]...)
+
+				return
+			}
+		}
+	}
+}
+
+// notify passes a committed transition to the subscribers, returning the panics recovered from them
+// The caller must hold the lock
+func (fsm *FSM[T]) notify(transition Transition[T]) error {
+	if len(fsm.subscribers) == 0 {
+		return nil
+	}
+
+	transition = fsm.redact(transition)
+
+	var err error
+
+	for _, sub := range fsm.subscribers {
+		fn := sub.fn
+
+		if fsm.hookPool != nil {
+			fsm.pendingHooks = append(fsm.pendingHooks, newSubscriberJob(fn, transition))
+
+			continue
+		}
+
+		err = appendError(err, fsm.runHook(func() {
+			fn(transition)
+		}))
+	}
+
+	return err
+}
+
+// edgeHook is a callback registered with OnTransition
+type edgeHook[T comparable] struct {
+	id uint64
+	fn func(Transition[T]) error
+}
+
+// OnTransition registers fn to be called with every committed transition from fromState to toState,
+// keeping edge-specific side effects out of a global subscriber
+// Edge hooks run after the subscribers, in registration order, under the same conditions as subscribers
+// Every hook of the edge runs even if one fails. The errors, including recovered panics, are returned
+// by Transition in a HookError, the transition itself being committed regardless
+// The returned function unregisters fn
+func (fsm *FSM[T]) OnTransition(fromState, toState T, fn func(Transition[T]) error) (unregister func()) {
+	fsm.lock()
+	defer fsm.unlock()
+
+	fsm.subscriberID++
+	id := fsm.subscriberID
+	e := edge[T]{from: fromState, to: toState}
+
+	if fsm.edgeHooks == nil {
+		fsm.edgeHooks = make(map[edge[T]][]edgeHook[T])
+	}
+
+	fsm.edgeHooks[e] = append(fsm.edgeHooks[e], edgeHook[T]{id: id, fn: fn})
+
+	return func() {
+		fsm.lock()
+		defer fsm.unlock()
+
+		hooks := fsm.edgeHooks[e]
+
+		for i, hook := range hooks {
+			if hook.id == id {
+				fsm.edgeHooks[e] = append(hooks[:i:i], hooks[i+1:]...)
+
+				return
+			}
+		}
+	}
+}
+
+// runEdgeHooks passes a committed transition to the hooks of its edge
+// The caller must hold the lock
+func (fsm *FSM[T]) runEdgeHooks(transition Transition[T]) error {
+	hooks := fsm.edgeHooks[edge[T]{from: transition.FromState, to: transition.ToState}]
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	transition = fsm.redact(transition)
+
+	var errs []error
+
+	for _, hook := range hooks {
+		fn := hook.fn
+
+		var err error
+
+		if panicErr := fsm.runHook(func() {
+			err = fn(transition)
+		}); panicErr != nil {
+			err = panicErr
+		}
+
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return HookError[T]{FromState: transition.FromState, ToState: transition.ToState, Errs: errs}
+	}
+
+	return nil
+}
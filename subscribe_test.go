@@ -0,0 +1,176 @@
+package statetrooper
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_subscribe(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 0)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumC)
+
+	var first, second []CustomStateEnum
+
+	unsubscribe := fsm.Subscribe(func(tr Transition[CustomStateEnum]) {
+		first = append(first, tr.ToState)
+	})
+	fsm.Subscribe(func(tr Transition[CustomStateEnum]) {
+		second = append(second, tr.ToState)
+	})
+
+	if _, err := fsm.Transition(CustomStateEnumB, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// rejected transitions are not published
+	if _, err := fsm.Transition(CustomStateEnumD, nil); !errors.As(err, &TransitionError[CustomStateEnum]{}) {
+		t.Fatalf("expected a transition error, got %v", err)
+	}
+
+	unsubscribe()
+
+	if _, err := fsm.Transition(CustomStateEnumC, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if expected := []CustomStateEnum{CustomStateEnumB}; !reflect.DeepEqual(first, expected) {
+		t.Errorf("expected first subscriber to receive %v, got %v", expected, first)
+	}
+
+	if expected := []CustomStateEnum{CustomStateEnumB, CustomStateEnumC}; !reflect.DeepEqual(second, expected) {
+		t.Errorf("expected second subscriber to receive %v, got %v", expected, second)
+	}
+}
+
+func Test_onTransition(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumA)
+
+	var calls []string
+
+	fsm.Subscribe(func(Transition[CustomStateEnum]) {
+		calls = append(calls, "global")
+	})
+
+	failed := errors.New("failed")
+
+	fsm.OnTransition(CustomStateEnumA, CustomStateEnumB, func(Transition[CustomStateEnum]) error {
+		calls = append(calls, "first")
+
+		return failed
+	})
+
+	unregister := fsm.OnTransition(CustomStateEnumA, CustomStateEnumB, func(Transition[CustomStateEnum]) error {
+		calls = append(calls, "second")
+
+		return nil
+	})
+
+	_, err := fsm.Transition(CustomStateEnumB, nil)
+
+	var hookErr HookError[CustomStateEnum]
+	if !errors.As(err, &hookErr) || !errors.Is(err, failed) {
+		t.Fatalf("expected a HookError, got %v", err)
+	}
+
+	if fsm.CurrentState() != CustomStateEnumB {
+		t.Errorf("expected the transition to be committed despite the failed hook")
+	}
+
+	if strings.Join(calls, ",") != "global,first,second" {
+		t.Errorf("unexpected call order: %v", calls)
+	}
+
+	// other edges don't run the hooks
+	calls = nil
+
+	if _, err := fsm.Transition(CustomStateEnumA, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if strings.Join(calls, ",") != "global" {
+		t.Errorf("unexpected calls: %v", calls)
+	}
+
+	unregister()
+
+	calls = nil
+	fsm.Transition(CustomStateEnumB, nil)
+
+	if strings.Join(calls, ",") != "global,first" {
+		t.Errorf("expected the unregistered hook not to be called, got %v", calls)
+	}
+}
+
+func Test_panickingCallbacks(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 1,
+		WithEvictionHandler[CustomStateEnum](func(Transition[CustomStateEnum]) {
+			panic("eviction")
+		}),
+	)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumC)
+
+	var called bool
+
+	fsm.Subscribe(func(Transition[CustomStateEnum]) {
+		panic("subscriber")
+	})
+
+	fsm.Subscribe(func(Transition[CustomStateEnum]) {
+		called = true
+	})
+
+	fsm.OnTransition(CustomStateEnumB, CustomStateEnumC, func(Transition[CustomStateEnum]) error {
+		panic("edge hook")
+	})
+
+	_, err := fsm.Transition(CustomStateEnumB, nil)
+
+	var panicErr PanicError
+	if !errors.As(err, &panicErr) || panicErr.Value != "subscriber" || len(panicErr.Stack) == 0 {
+		t.Fatalf("expected the subscriber's PanicError, got %v", err)
+	}
+
+	if !called || fsm.CurrentState() != CustomStateEnumB {
+		t.Errorf("expected the transition to be committed and the other subscribers to be called")
+	}
+
+	// the eviction handler, the subscriber and the edge hook panic, the errors are joined
+	_, err = fsm.Transition(CustomStateEnumC, nil)
+
+	var hookErr HookError[CustomStateEnum]
+	if !errors.As(err, &hookErr) || !strings.Contains(err.Error(), "eviction") || !strings.Contains(err.Error(), "subscriber") {
+		t.Errorf("expected the joined errors, got %v", err)
+	}
+
+	if fsm.CurrentState() != CustomStateEnumC {
+		t.Errorf("expected the transition to be committed")
+	}
+}
+
+func Test_panickingValidator(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10,
+		WithMetadataValidator[CustomStateEnum](func(from, to CustomStateEnum, md map[string]string) error {
+			panic("validator")
+		}),
+	)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+
+	_, err := fsm.Transition(CustomStateEnumB, nil)
+
+	var metadataErr MetadataError[CustomStateEnum]
+	var panicErr PanicError
+
+	if !errors.As(err, &metadataErr) || !errors.As(err, &panicErr) {
+		t.Fatalf("expected a MetadataError wrapping a PanicError, got %v", err)
+	}
+
+	if fsm.CurrentState() != CustomStateEnumA {
+		t.Errorf("expected the transition to be rejected")
+	}
+}
@@ -0,0 +1,40 @@
+package templates
+
+import "github.com/hishamk/statetrooper"
+
+// DocumentState is a state of the document approval lifecycle
+type DocumentState string
+
+// States of the document approval lifecycle
+const (
+	DocumentDraft            DocumentState = "draft"
+	DocumentSubmitted        DocumentState = "submitted"
+	DocumentInReview         DocumentState = "in_review"
+	DocumentChangesRequested DocumentState = "changes_requested"
+	DocumentApproved         DocumentState = "approved"
+	DocumentRejected         DocumentState = "rejected"
+	DocumentWithdrawn        DocumentState = "withdrawn"
+	DocumentPublished        DocumentState = "published"
+	DocumentArchived         DocumentState = "archived"
+)
+
+// DocumentApproval is the lifecycle of a document going through review: draft, submitted, in review,
+// then approved and published. Reviewers can request changes, sending the document back to its author,
+// or reject it. Submitted documents can be withdrawn, and drafts reopened from rejected or withdrawn documents
+// Every document ends up archived
+func DocumentApproval() Template[DocumentState] {
+	return Template[DocumentState]{
+		Initial:  DocumentDraft,
+		Terminal: []DocumentState{DocumentArchived},
+		rules: statetrooper.Ruleset[DocumentState]{
+			DocumentDraft:            {DocumentSubmitted, DocumentArchived},
+			DocumentSubmitted:        {DocumentInReview, DocumentWithdrawn},
+			DocumentInReview:         {DocumentApproved, DocumentRejected, DocumentChangesRequested},
+			DocumentChangesRequested: {DocumentDraft, DocumentSubmitted},
+			DocumentApproved:         {DocumentPublished, DocumentArchived},
+			DocumentRejected:         {DocumentDraft, DocumentArchived},
+			DocumentWithdrawn:        {DocumentDraft, DocumentArchived},
+			DocumentPublished:        {DocumentArchived},
+		},
+	}
+}
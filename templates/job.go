@@ -0,0 +1,31 @@
+package templates
+
+import "github.com/hishamk/statetrooper"
+
+// JobState is a state of the job lifecycle
+type JobState string
+
+// States of the job lifecycle
+const (
+	JobPending   JobState = "pending"
+	JobRunning   JobState = "running"
+	JobRetrying  JobState = "retrying"
+	JobSucceeded JobState = "succeeded"
+	JobFailed    JobState = "failed"
+	JobCancelled JobState = "cancelled"
+)
+
+// Job is the lifecycle of a background job or task: pending, running, then succeeded or failed
+// A failed run can be retried, the job waiting in retrying until it runs again or gives up
+// Jobs can be cancelled until they finish
+func Job() Template[JobState] {
+	return Template[JobState]{
+		Initial:  JobPending,
+		Terminal: []JobState{JobSucceeded, JobFailed, JobCancelled},
+		rules: statetrooper.Ruleset[JobState]{
+			JobPending:  {JobRunning, JobCancelled},
+			JobRunning:  {JobSucceeded, JobFailed, JobRetrying, JobCancelled},
+			JobRetrying: {JobRunning, JobFailed, JobCancelled},
+		},
+	}
+}
@@ -0,0 +1,37 @@
+package templates
+
+import "github.com/hishamk/statetrooper"
+
+// OrderState is a state of the order lifecycle
+type OrderState string
+
+// States of the order lifecycle
+const (
+	OrderCreated   OrderState = "created"
+	OrderPaid      OrderState = "paid"
+	OrderPacked    OrderState = "packed"
+	OrderShipped   OrderState = "shipped"
+	OrderDelivered OrderState = "delivered"
+	OrderReturned  OrderState = "returned"
+	OrderCanceled  OrderState = "canceled"
+	OrderRefunded  OrderState = "refunded"
+	OrderCompleted OrderState = "completed"
+)
+
+// Order is the lifecycle of an e-commerce order: created, paid, packed, shipped then delivered
+// Orders can be canceled until shipped, refunded once paid, and returned once shipped
+// Delivered orders are completed once they can no longer be returned
+func Order() Template[OrderState] {
+	return Template[OrderState]{
+		Initial:  OrderCreated,
+		Terminal: []OrderState{OrderCanceled, OrderRefunded, OrderCompleted},
+		rules: statetrooper.Ruleset[OrderState]{
+			OrderCreated:   {OrderPaid, OrderCanceled},
+			OrderPaid:      {OrderPacked, OrderRefunded},
+			OrderPacked:    {OrderShipped, OrderRefunded},
+			OrderShipped:   {OrderDelivered, OrderReturned},
+			OrderDelivered: {OrderReturned, OrderCompleted},
+			OrderReturned:  {OrderRefunded},
+		},
+	}
+}
@@ -0,0 +1,41 @@
+package templates
+
+import "github.com/hishamk/statetrooper"
+
+// PaymentState is a state of the payment lifecycle
+type PaymentState string
+
+// States of the payment lifecycle
+const (
+	PaymentPending           PaymentState = "pending"
+	PaymentAuthorized        PaymentState = "authorized"
+	PaymentCaptured          PaymentState = "captured"
+	PaymentPartiallyRefunded PaymentState = "partially_refunded"
+	PaymentRefunded          PaymentState = "refunded"
+	PaymentDisputed          PaymentState = "disputed"
+	PaymentChargedBack       PaymentState = "charged_back"
+	PaymentVoided            PaymentState = "voided"
+	PaymentFailed            PaymentState = "failed"
+	PaymentCanceled          PaymentState = "canceled"
+	PaymentSettled           PaymentState = "settled"
+)
+
+// Payment is the lifecycle of a card payment: pending, authorized, then captured
+// Authorizations can be voided, captured payments refunded in one or several parts or disputed
+// A dispute is either won, the payment going back to captured, or lost, the payment being charged back
+// Captured payments are settled once they can no longer be refunded nor disputed
+func Payment() Template[PaymentState] {
+	return Template[PaymentState]{
+		Initial: PaymentPending,
+		Terminal: []PaymentState{
+			PaymentRefunded, PaymentChargedBack, PaymentVoided, PaymentFailed, PaymentCanceled, PaymentSettled,
+		},
+		rules: statetrooper.Ruleset[PaymentState]{
+			PaymentPending:           {PaymentAuthorized, PaymentFailed, PaymentCanceled},
+			PaymentAuthorized:        {PaymentCaptured, PaymentVoided, PaymentFailed},
+			PaymentCaptured:          {PaymentPartiallyRefunded, PaymentRefunded, PaymentDisputed, PaymentSettled},
+			PaymentPartiallyRefunded: {PaymentPartiallyRefunded, PaymentRefunded, PaymentDisputed, PaymentSettled},
+			PaymentDisputed:          {PaymentCaptured, PaymentChargedBack},
+		},
+	}
+}
@@ -0,0 +1,39 @@
+/*
+Package templates provides ready-made rulesets for common lifecycles, to instantiate as is or extend.
+
+	fsm := templates.Job().NewFSM(100)
+
+	rs := templates.Order().Ruleset()
+	rs[templates.OrderShipped] = append(rs[templates.OrderShipped], "lost")
+
+Each function returns a new Template, whose rulesets can be modified without affecting other callers.
+*/
+package templates
+
+import "github.com/hishamk/statetrooper"
+
+// Template is a lifecycle with its initial and terminal states
+type Template[T comparable] struct {
+	// Initial is the state new entities start in
+	Initial T
+	// Terminal are the states without allowed targets
+	Terminal []T
+
+	rules statetrooper.Ruleset[T]
+}
+
+// Ruleset returns a copy of the rules of the lifecycle, to extend or use with NewFSMWithRuleset
+func (t Template[T]) Ruleset() statetrooper.Ruleset[T] {
+	rs := make(statetrooper.Ruleset[T], len(t.rules))
+	for from, targets := range t.rules {
+		rs[from] = append([]T(nil), targets...)
+	}
+
+	return rs
+}
+
+// NewFSM creates an FSM in the initial state with a copy of the rules, keeping maxHistory transitions
+// Rules added with AddRule extend the lifecycle of this FSM only
+func (t Template[T]) NewFSM(maxHistory int, opts ...statetrooper.FSMOption[T]) *statetrooper.FSM[T] {
+	return statetrooper.NewFSMWithRuleset[T](t.Initial, maxHistory, t.Ruleset(), opts...)
+}
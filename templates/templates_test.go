@@ -0,0 +1,137 @@
+package templates
+
+import (
+	"testing"
+
+	"github.com/hishamk/statetrooper"
+)
+
+// checkTemplate checks that the ruleset is valid, that every state is reachable from the initial state,
+// that the terminal states are exactly the states without targets, and that every state can reach one
+func checkTemplate[T comparable](t *testing.T, tmpl Template[T]) {
+	t.Helper()
+
+	rs := tmpl.Ruleset()
+
+	if err := rs.Validate(); err != nil {
+		t.Fatalf("Validate() returned an error: %v", err)
+	}
+
+	terminal := make(map[T]bool, len(tmpl.Terminal))
+	for _, state := range tmpl.Terminal {
+		terminal[state] = true
+
+		if len(rs[state]) > 0 {
+			t.Errorf("terminal state %v has targets %v", state, rs[state])
+		}
+	}
+
+	states := make(map[T]bool)
+	for from, targets := range rs {
+		states[from] = true
+
+		for _, to := range targets {
+			states[to] = true
+
+			if len(rs[to]) == 0 && !terminal[to] {
+				t.Errorf("state %v has no targets but isn't terminal", to)
+			}
+		}
+	}
+
+	reached := reachable(rs, tmpl.Initial)
+	for state := range states {
+		if !reached[state] {
+			t.Errorf("state %v isn't reachable from %v", state, tmpl.Initial)
+		}
+
+		canEnd := false
+		for end := range reachable(rs, state) {
+			canEnd = canEnd || terminal[end]
+		}
+
+		if !canEnd {
+			t.Errorf("state %v can't reach a terminal state", state)
+		}
+	}
+}
+
+// reachable returns the states reachable from the state, including itself
+func reachable[T comparable](rs statetrooper.Ruleset[T], from T) map[T]bool {
+	visited := map[T]bool{from: true}
+	queue := []T{from}
+
+	for len(queue) > 0 {
+		state := queue[0]
+		queue = queue[1:]
+
+		for _, to := range rs[state] {
+			if !visited[to] {
+				visited[to] = true
+				queue = append(queue, to)
+			}
+		}
+	}
+
+	return visited
+}
+
+// checkPath checks that a new FSM of the template can go through the path
+func checkPath[T comparable](t *testing.T, tmpl Template[T], path ...T) {
+	t.Helper()
+
+	result, err := tmpl.NewFSM(10).Simulate(path)
+	if err != nil || !result.Valid {
+		t.Errorf("Simulate(%v) = %v, %v", path, result.States, err)
+	}
+}
+
+func TestOrder(t *testing.T) {
+	checkTemplate(t, Order())
+	checkPath(t, Order(), OrderPaid, OrderPacked, OrderShipped, OrderDelivered, OrderCompleted)
+	checkPath(t, Order(), OrderPaid, OrderPacked, OrderShipped, OrderReturned, OrderRefunded)
+	checkPath(t, Order(), OrderCanceled)
+}
+
+func TestPayment(t *testing.T) {
+	checkTemplate(t, Payment())
+	checkPath(t, Payment(), PaymentAuthorized, PaymentCaptured, PaymentPartiallyRefunded, PaymentRefunded)
+	checkPath(t, Payment(), PaymentAuthorized, PaymentCaptured, PaymentDisputed, PaymentCaptured, PaymentSettled)
+	checkPath(t, Payment(), PaymentAuthorized, PaymentVoided)
+}
+
+func TestJob(t *testing.T) {
+	checkTemplate(t, Job())
+	checkPath(t, Job(), JobRunning, JobRetrying, JobRunning, JobSucceeded)
+	checkPath(t, Job(), JobRunning, JobRetrying, JobFailed)
+	checkPath(t, Job(), JobCancelled)
+}
+
+func TestDocumentApproval(t *testing.T) {
+	checkTemplate(t, DocumentApproval())
+	checkPath(t, DocumentApproval(), DocumentSubmitted, DocumentInReview, DocumentApproved, DocumentPublished, DocumentArchived)
+	checkPath(t, DocumentApproval(), DocumentSubmitted, DocumentInReview, DocumentChangesRequested, DocumentSubmitted)
+	checkPath(t, DocumentApproval(), DocumentSubmitted, DocumentWithdrawn, DocumentDraft)
+}
+
+func TestTemplateExtend(t *testing.T) {
+	rs := Order().Ruleset()
+	rs[OrderShipped] = append(rs[OrderShipped], "lost")
+
+	if targets := Order().Ruleset()[OrderShipped]; len(targets) != 2 {
+		t.Errorf("expected extending a ruleset not to change the template, got %v", targets)
+	}
+
+	extended, other := Job().NewFSM(10), Job().NewFSM(10)
+	extended.AddRule(JobFailed, JobPending)
+
+	for _, fsm := range []*statetrooper.FSM[JobState]{extended, other} {
+		if err := fsm.Reset(JobFailed); err != nil {
+			t.Fatalf("Reset() returned an error: %v", err)
+		}
+	}
+
+	if !extended.CanTransition(JobPending) || other.CanTransition(JobPending) {
+		t.Error("expected extending an FSM to change its lifecycle only")
+	}
+}
@@ -0,0 +1,76 @@
+package statetrooper
+
+import "time"
+
+// MetadataTimeout is the metadata key holding the timeout that triggered a transition made by SetStateTimeout
+const MetadataTimeout = "timeout"
+
+// stateTimeout is an automatic transition out of a state
+type stateTimeout[T comparable] struct {
+	d        time.Duration
+	fallback T
+}
+
+// SetStateTimeout makes the FSM transition to the fallback state if it stays in the state for longer than d,
+// e.g. to expire abandoned carts. A transition out of the state before then cancels the timeout
+// The timeout transition is recorded with MetadataTimeout set to d in its metadata
+// If the FSM is already in the state, the timeout starts now
+// The ruleset must allow the transition to the fallback state, otherwise a TransitionError is returned
+func (fsm *FSM[T]) SetStateTimeout(state T, d time.Duration, fallback T) error {
+	fsm.lock()
+	defer fsm.unlock()
+
+	if !fsm.canTransition(&state, &fallback) {
+		return TransitionError[T]{
+			FromState: state,
+			ToState:   fallback,
+		}
+	}
+
+	if fsm.timeouts == nil {
+		fsm.timeouts = make(map[T]stateTimeout[T])
+	}
+
+	fsm.timeouts[state] = stateTimeout[T]{d: d, fallback: fallback}
+
+	if fsm.currentState == state {
+		fsm.armTimeout()
+	}
+
+	return nil
+}
+
+// ClearStateTimeout removes the timeout of the state, cancelling it if the FSM is in the state
+func (fsm *FSM[T]) ClearStateTimeout(state T) {
+	fsm.lock()
+	defer fsm.unlock()
+
+	delete(fsm.timeouts, state)
+
+	if fsm.currentState == state {
+		fsm.armTimeout()
+	}
+}
+
+// armTimeout cancels the pending timeout and starts the timeout of the current state, if any
+// The caller must hold the lock
+func (fsm *FSM[T]) armTimeout() {
+	if fsm.timeoutTimer != nil {
+		fsm.timeoutTimer.Stop()
+		fsm.timeoutTimer = nil
+	}
+
+	timeout, ok := fsm.timeouts[fsm.currentState]
+	if !ok {
+		return
+	}
+
+	// the version identifies the stay in the state, so a timer firing after the FSM moved on has no effect
+	version := fsm.version
+
+	fsm.timeoutTimer = fsm.clock.AfterFunc(timeout.d, func() {
+		_, _ = fsm.TransitionIfVersion(version, timeout.fallback, map[string]string{
+			MetadataTimeout: timeout.d.String(),
+		})
+	})
+}
@@ -0,0 +1,74 @@
+package statetrooper
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func Test_setStateTimeout(t *testing.T) {
+	clock := newFakeClock(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC))
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10, WithClock[CustomStateEnum](clock))
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumC, CustomStateEnumD)
+
+	if err := fsm.SetStateTimeout(CustomStateEnumB, time.Hour, CustomStateEnumD); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := fsm.Transition(CustomStateEnumB, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	clock.Advance(59 * time.Minute)
+
+	if fsm.CurrentState() != CustomStateEnumB {
+		t.Fatalf("expected the FSM to stay in %v until the timeout, got %v", CustomStateEnumB, fsm.CurrentState())
+	}
+
+	clock.Advance(time.Minute)
+
+	if fsm.CurrentState() != CustomStateEnumD {
+		t.Fatalf("expected the timeout to move the FSM to %v, got %v", CustomStateEnumD, fsm.CurrentState())
+	}
+
+	transitions := fsm.Transitions()
+	if last := transitions[len(transitions)-1]; last.Metadata[MetadataTimeout] != "1h0m0s" {
+		t.Errorf("expected the timeout to be recorded, got %v", last.Metadata)
+	}
+}
+
+func Test_setStateTimeoutCancelled(t *testing.T) {
+	clock := newFakeClock(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC))
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10, WithClock[CustomStateEnum](clock))
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumC, CustomStateEnumD)
+
+	if err := fsm.SetStateTimeout(CustomStateEnumB, time.Hour, CustomStateEnumD); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := fsm.Transition(CustomStateEnumB, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := fsm.Transition(CustomStateEnumC, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	clock.Advance(time.Hour)
+
+	if fsm.CurrentState() != CustomStateEnumC || fsm.Version() != 2 {
+		t.Errorf("expected the timeout to be cancelled, got %v at version %d", fsm.CurrentState(), fsm.Version())
+	}
+}
+
+func Test_setStateTimeoutInvalidFallback(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+
+	err := fsm.SetStateTimeout(CustomStateEnumA, time.Second, CustomStateEnumC)
+	if !errors.As(err, &TransitionError[CustomStateEnum]{}) {
+		t.Errorf("expected a TransitionError, got %v", err)
+	}
+}
@@ -1,6 +1,9 @@
 package statetrooper
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+)
 
 func stringable(t interface{}) bool {
 	if _, ok := t.(string); ok {
@@ -26,3 +29,56 @@ func toString(t interface{}) string {
 
 	return fmt.Sprintf("%v", t)
 }
+
+// withMetadata returns a copy of metadata with the key set to value, leaving the caller's map untouched
+func withMetadata(metadata map[string]string, key, value string) map[string]string {
+	md := make(map[string]string, len(metadata)+1)
+	for k, v := range metadata {
+		md[k] = v
+	}
+
+	md[key] = value
+
+	return md
+}
+
+// copyMetadata returns a copy of metadata, nil if metadata is nil
+func copyMetadata(metadata map[string]string) map[string]string {
+	if metadata == nil {
+		return nil
+	}
+
+	md := make(map[string]string, len(metadata))
+	for k, v := range metadata {
+		md[k] = v
+	}
+
+	return md
+}
+
+// mergeMetadata returns a new map holding the defaults overridden by the metadata
+func mergeMetadata(defaults, metadata map[string]string) map[string]string {
+	md := make(map[string]string, len(defaults)+len(metadata))
+	for k, v := range defaults {
+		md[k] = v
+	}
+
+	for k, v := range metadata {
+		md[k] = v
+	}
+
+	return md
+}
+
+// appendError combines two errors, returning the other one if either is nil so that no error is allocated
+// when there is nothing to combine
+func appendError(err, other error) error {
+	switch {
+	case other == nil:
+		return err
+	case err == nil:
+		return other
+	default:
+		return errors.Join(err, other)
+	}
+}
@@ -0,0 +1,368 @@
+/*
+Package webhook calls registered URLs with every committed transition of a state machine,
+so consumers can subscribe to state changes without writing Go.
+
+Each webhook receives a POST request whose body is the transition record serialized as JSON.
+When a secret is set, the body is signed with HMAC-SHA256 and the hex encoded signature is sent
+in the X-Statetrooper-Signature header as "sha256=<signature>". Receivers should compute the
+signature of the raw body with the shared secret and compare it using hmac.Equal.
+
+Deliveries are queued and sent in commit order by a background worker, so slow or unavailable
+receivers don't hold the state machine's lock. Failed deliveries are retried with an
+exponential backoff:
+
+	dispatcher := webhook.NewDispatcher[OrderStatusEnum](webhook.WithRetries(5, time.Second))
+	defer dispatcher.Close(context.Background())
+
+	dispatcher.Register(webhook.Webhook[OrderStatusEnum]{
+		URL:    "https://example.com/hooks/shipped",
+		Secret: []byte(secret),
+		Edges:  []webhook.Edge[OrderStatusEnum]{{From: StatusPacked, To: StatusShipped}},
+	})
+
+	detach := dispatcher.Attach(order.State)
+	defer detach()
+*/
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/hishamk/statetrooper"
+)
+
+// SignatureHeader is the header carrying the HMAC-SHA256 signature of the request body
+const SignatureHeader = "X-Statetrooper-Signature"
+
+var (
+	// ErrQueueFull is reported when a delivery is dropped because the queue is full
+	ErrQueueFull = errors.New("webhook queue is full")
+	// ErrClosed is reported when a transition is committed after the dispatcher has been closed
+	ErrClosed = errors.New("webhook dispatcher is closed")
+)
+
+// Edge is a transition between two states
+type Edge[T comparable] struct {
+	From T
+	To   T
+}
+
+// Webhook is a URL called with the committed transitions
+type Webhook[T comparable] struct {
+	// URL receives a POST request for each transition
+	URL string
+	// Secret signs the request body with HMAC-SHA256 when set
+	Secret []byte
+	// Edges limits the webhook to the given transitions, every transition is sent when empty
+	Edges []Edge[T]
+}
+
+// matches checks whether the webhook subscribes to the transition
+func (w *Webhook[T]) matches(transition statetrooper.Transition[T]) bool {
+	if len(w.Edges) == 0 {
+		return true
+	}
+
+	for _, edge := range w.Edges {
+		if edge.From == transition.FromState && edge.To == transition.ToState {
+			return true
+		}
+	}
+
+	return false
+}
+
+// DeliveryError is reported when a webhook can't be delivered after all retries
+type DeliveryError struct {
+	URL      string
+	Attempts int
+	Err      error
+}
+
+func (err *DeliveryError) Error() string {
+	return fmt.Sprintf("failed to deliver webhook to %s after %d attempts: %v", err.URL, err.Attempts, err.Err)
+}
+
+func (err *DeliveryError) Unwrap() error {
+	return err.Err
+}
+
+// Option is a function that sets an option on the Dispatcher
+type Option func(*config)
+
+// config holds the options for the Dispatcher
+type config struct {
+	client       *http.Client
+	retries      int
+	backoff      time.Duration
+	queueSize    int
+	errorHandler func(error)
+}
+
+// WithHTTPClient sets the client used to call the webhooks
+// DEFAULT: a client with a 10 seconds timeout
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *config) {
+		c.client = client
+	}
+}
+
+// WithRetries sets the number of retries of a failed delivery and the backoff before the first retry
+// The backoff doubles after each retry
+// DEFAULT: 3 retries starting with a 500ms backoff
+func WithRetries(retries int, backoff time.Duration) Option {
+	return func(c *config) {
+		c.retries = retries
+		c.backoff = backoff
+	}
+}
+
+// WithQueueSize sets the number of deliveries that can be queued before new ones are dropped
+// DEFAULT: 1024
+func WithQueueSize(size int) Option {
+	return func(c *config) {
+		c.queueSize = size
+	}
+}
+
+// WithErrorHandler sets a function called when a delivery fails or is dropped
+// The handler may be called concurrently from the delivery worker and from Transition
+// DEFAULT: errors are ignored
+func WithErrorHandler(handler func(error)) Option {
+	return func(c *config) {
+		c.errorHandler = handler
+	}
+}
+
+// delivery is a request queued for a webhook
+type delivery struct {
+	url    string
+	secret []byte
+	body   []byte
+}
+
+// Dispatcher delivers committed transitions to the registered webhooks
+type Dispatcher[T comparable] struct {
+	cfg config
+
+	mu     sync.Mutex
+	hooks  []Webhook[T]
+	closed bool
+
+	queue chan delivery
+	done  chan struct{}
+
+	// ctx is cancelled to abandon the pending deliveries
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewDispatcher creates a Dispatcher and starts its delivery worker
+// Close must be called to stop the worker
+func NewDispatcher[T comparable](opts ...Option) *Dispatcher[T] {
+	cfg := config{
+		client:    &http.Client{Timeout: 10 * time.Second},
+		retries:   3,
+		backoff:   500 * time.Millisecond,
+		queueSize: 1024,
+	}
+
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	d := &Dispatcher[T]{
+		cfg:    cfg,
+		queue:  make(chan delivery, cfg.queueSize),
+		done:   make(chan struct{}),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	go d.run()
+
+	return d
+}
+
+// Register adds a webhook called with the subsequently committed transitions
+func (d *Dispatcher[T]) Register(hook Webhook[T]) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.hooks = append(d.hooks, hook)
+}
+
+// Attach queues a delivery to each matching webhook for every transition committed by the FSM
+// until the returned function is called
+func (d *Dispatcher[T]) Attach(fsm *statetrooper.FSM[T]) (detach func()) {
+	return fsm.Subscribe(d.enqueue)
+}
+
+// Close stops accepting transitions and waits for the queued deliveries to be sent
+// Pending retries are abandoned once ctx is done
+func (d *Dispatcher[T]) Close(ctx context.Context) error {
+	d.mu.Lock()
+	if !d.closed {
+		d.closed = true
+		close(d.queue)
+	}
+	d.mu.Unlock()
+
+	select {
+	case <-d.done:
+		return nil
+	case <-ctx.Done():
+		d.cancel()
+		<-d.done
+
+		return ctx.Err()
+	}
+}
+
+// enqueue queues a delivery of the transition to each matching webhook
+func (d *Dispatcher[T]) enqueue(transition statetrooper.Transition[T]) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed {
+		d.report(ErrClosed)
+
+		return
+	}
+
+	var body []byte
+
+	for i := range d.hooks {
+		hook := &d.hooks[i]
+		if !hook.matches(transition) {
+			continue
+		}
+
+		if body == nil {
+			var err error
+
+			body, err = json.Marshal(transition)
+			if err != nil {
+				d.report(fmt.Errorf("failed to marshal transition: %w", err))
+
+				return
+			}
+		}
+
+		select {
+		case d.queue <- delivery{url: hook.URL, secret: hook.Secret, body: body}:
+		default:
+			d.report(fmt.Errorf("dropped webhook to %s: %w", hook.URL, ErrQueueFull))
+		}
+	}
+}
+
+// run sends the queued deliveries until the queue is closed
+func (d *Dispatcher[T]) run() {
+	defer close(d.done)
+	defer d.cancel()
+
+	for dl := range d.queue {
+		if err := d.deliver(dl); err != nil {
+			d.report(err)
+		}
+	}
+}
+
+// deliver sends a delivery, retrying with an exponential backoff
+func (d *Dispatcher[T]) deliver(dl delivery) error {
+	backoff := d.cfg.backoff
+
+	var err error
+
+	attempts := 0
+
+	for attempts <= d.cfg.retries {
+		if attempts > 0 {
+			select {
+			case <-time.After(backoff):
+			case <-d.ctx.Done():
+				return &DeliveryError{URL: dl.url, Attempts: attempts, Err: err}
+			}
+
+			backoff *= 2
+		}
+
+		attempts++
+
+		var retry bool
+
+		retry, err = d.send(dl)
+		if err == nil {
+			return nil
+		}
+
+		if !retry {
+			break
+		}
+	}
+
+	return &DeliveryError{URL: dl.url, Attempts: attempts, Err: err}
+}
+
+// send makes a single request and reports whether a failure may be retried
+func (d *Dispatcher[T]) send(dl delivery) (bool, error) {
+	req, err := http.NewRequestWithContext(d.ctx, http.MethodPost, dl.url, bytes.NewReader(dl.body))
+	if err != nil {
+		return false, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	if len(dl.secret) > 0 {
+		req.Header.Set(SignatureHeader, "sha256="+Sign(dl.secret, dl.body))
+	}
+
+	resp, err := d.cfg.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+
+	err = fmt.Errorf("unexpected status %s", resp.Status)
+
+	// client errors other than rate limiting won't succeed on retry
+	retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+
+	return retry, err
+}
+
+// report passes an error to the error handler
+func (d *Dispatcher[T]) report(err error) {
+	if d.cfg.errorHandler != nil {
+		d.cfg.errorHandler(err)
+	}
+}
+
+// Sign returns the hex encoded HMAC-SHA256 signature of body
+// Receivers can use it to verify the X-Statetrooper-Signature header
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
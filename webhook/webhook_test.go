@@ -0,0 +1,167 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hishamk/statetrooper"
+)
+
+type orderStatus string
+
+const (
+	statusCreated orderStatus = "created"
+	statusPaid    orderStatus = "paid"
+	statusShipped orderStatus = "shipped"
+)
+
+func newOrderFSM() *statetrooper.FSM[orderStatus] {
+	fsm := statetrooper.NewFSM[orderStatus](statusCreated, 10)
+	fsm.AddRule(statusCreated, statusPaid)
+	fsm.AddRule(statusPaid, statusShipped)
+
+	return fsm
+}
+
+// receiver records the requests received by a webhook endpoint
+type receiver struct {
+	mu         sync.Mutex
+	bodies     [][]byte
+	signatures []string
+	failures   int
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.failures > 0 {
+		r.failures--
+		w.WriteHeader(http.StatusServiceUnavailable)
+
+		return
+	}
+
+	body, _ := io.ReadAll(req.Body)
+	r.bodies = append(r.bodies, body)
+	r.signatures = append(r.signatures, req.Header.Get(SignatureHeader))
+}
+
+func Test_dispatcher(t *testing.T) {
+	all := &receiver{failures: 1}
+	shipped := &receiver{}
+
+	allServer := httptest.NewServer(all)
+	defer allServer.Close()
+
+	shippedServer := httptest.NewServer(shipped)
+	defer shippedServer.Close()
+
+	dispatcher := NewDispatcher[orderStatus](WithRetries(2, time.Millisecond))
+	dispatcher.Register(Webhook[orderStatus]{URL: allServer.URL, Secret: []byte("secret")})
+	dispatcher.Register(Webhook[orderStatus]{
+		URL:   shippedServer.URL,
+		Edges: []Edge[orderStatus]{{From: statusPaid, To: statusShipped}},
+	})
+
+	fsm := newOrderFSM()
+	dispatcher.Attach(fsm)
+
+	for _, state := range []orderStatus{statusPaid, statusShipped} {
+		if _, err := fsm.Transition(state, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if err := dispatcher.Close(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(all.bodies) != 2 {
+		t.Fatalf("expected 2 deliveries after a retry, got %d", len(all.bodies))
+	}
+
+	for i, body := range all.bodies {
+		if expected := "sha256=" + Sign([]byte("secret"), body); all.signatures[i] != expected {
+			t.Errorf("expected signature %s, got %s", expected, all.signatures[i])
+		}
+	}
+
+	if len(shipped.bodies) != 1 {
+		t.Fatalf("expected 1 filtered delivery, got %d", len(shipped.bodies))
+	}
+
+	if shipped.signatures[0] != "" {
+		t.Errorf("expected no signature without a secret, got %s", shipped.signatures[0])
+	}
+
+	var transition statetrooper.Transition[orderStatus]
+	if err := json.Unmarshal(shipped.bodies[0], &transition); err != nil {
+		t.Fatalf("failed to decode delivery: %v", err)
+	}
+
+	if transition.FromState != statusPaid || transition.ToState != statusShipped {
+		t.Errorf("unexpected transition: %+v", transition)
+	}
+}
+
+func Test_dispatcherDeliveryError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	var (
+		mu   sync.Mutex
+		errs []error
+	)
+
+	dispatcher := NewDispatcher[orderStatus](WithRetries(3, time.Millisecond), WithErrorHandler(func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		errs = append(errs, err)
+	}))
+	dispatcher.Register(Webhook[orderStatus]{URL: server.URL})
+
+	fsm := newOrderFSM()
+	dispatcher.Attach(fsm)
+
+	if _, err := fsm.Transition(statusPaid, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := dispatcher.Close(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// transitions committed after closing are reported
+	if _, err := fsm.Transition(statusShipped, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 errors, got %v", errs)
+	}
+
+	var deliveryErr *DeliveryError
+	if !errors.As(errs[0], &deliveryErr) {
+		t.Fatalf("expected a delivery error, got %v", errs[0])
+	}
+
+	// client errors are not retried
+	if deliveryErr.Attempts != 1 {
+		t.Errorf("expected 1 attempt, got %d", deliveryErr.Attempts)
+	}
+
+	if !errors.Is(errs[1], ErrClosed) {
+		t.Errorf("expected ErrClosed, got %v", errs[1])
+	}
+}
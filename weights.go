@@ -0,0 +1,184 @@
+package statetrooper
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// SetRuleWeight sets the weight of the rule from fromState to toState, used by NextStateWeightedRandom
+// and SimulateRandomWalk, e.g. the number of times the transition happened in production
+// Rules without a weight have a weight of 1 and a weight of 0 excludes the rule from random walks
+// A TransitionError is returned if the ruleset doesn't allow the transition
+func (fsm *FSM[T]) SetRuleWeight(fromState, toState T, weight float64) error {
+	fsm.lock()
+	defer fsm.unlock()
+
+	return fsm.setRuleWeight(fromState, toState, weight)
+}
+
+// SetRuleWeights sets the weights of many rules, e.g. computed with RuleWeightsFromHistories
+// No weight is set if any rule isn't allowed by the ruleset or has an invalid weight
+func (fsm *FSM[T]) SetRuleWeights(weights map[Rule[T]]float64) error {
+	fsm.lock()
+	defer fsm.unlock()
+
+	for rule, weight := range weights {
+		err := fsm.checkRuleWeight(rule.From, rule.To, weight)
+		if err != nil {
+			return err
+		}
+	}
+
+	for rule, weight := range weights {
+		_ = fsm.setRuleWeight(rule.From, rule.To, weight)
+	}
+
+	return nil
+}
+
+// RuleWeight returns the weight of the rule from fromState to toState, 1 if it wasn't set
+func (fsm *FSM[T]) RuleWeight(fromState, toState T) float64 {
+	fsm.rlock()
+	defer fsm.runlock()
+
+	return fsm.ruleWeight(fromState, toState)
+}
+
+// RuleWeightsFromHistories counts the transitions of the histories per rule, to use their historical
+// frequencies as weights with SetRuleWeights. Compacted records count for the transitions they summarize
+func RuleWeightsFromHistories[T comparable](histories [][]Transition[T]) map[Rule[T]]float64 {
+	weights := make(map[Rule[T]]float64)
+
+	for _, history := range histories {
+		for _, transition := range history {
+			weights[Rule[T]{From: transition.FromState, To: transition.ToState}] += float64(compactedCount(transition))
+		}
+	}
+
+	return weights
+}
+
+// NextStateWeightedRandom picks an allowed target state of the current state at random, with a probability
+// proportional to the weight of its rule, without transitioning. Only the ruleset is considered, not the
+// authorizer nor the metadata validator. A nil rng uses the default source of math/rand
+// ok is false if the current state has no allowed target state with a positive weight
+func (fsm *FSM[T]) NextStateWeightedRandom(rng *rand.Rand) (state T, ok bool) {
+	fsm.rlock()
+	defer fsm.runlock()
+
+	return fsm.nextWeightedRandom(fsm.currentState, rng)
+}
+
+// SimulateRandomWalk walks the rules as a Markov chain from the current state for at most steps transitions,
+// each picked as with NextStateWeightedRandom, without changing the FSM, e.g. to load test downstream
+// systems with realistic state sequences
+// The returned states start with the current state; the walk stops early at a state it can't leave
+func (fsm *FSM[T]) SimulateRandomWalk(steps int, rng *rand.Rand) []T {
+	fsm.rlock()
+	defer fsm.runlock()
+
+	states := []T{fsm.currentState}
+	state := fsm.currentState
+
+	for i := 0; i < steps; i++ {
+		next, ok := fsm.nextWeightedRandom(state, rng)
+		if !ok {
+			break
+		}
+
+		state = next
+		states = append(states, state)
+	}
+
+	return states
+}
+
+// nextWeightedRandom picks an allowed target state of the state at random, weighted by the weights of the rules
+// The caller must hold the lock
+func (fsm *FSM[T]) nextWeightedRandom(state T, rng *rand.Rand) (T, bool) {
+	var (
+		zero  T
+		total float64
+	)
+
+	targets := fsm.ruleset[state]
+
+	for _, to := range targets {
+		total += fsm.ruleWeight(state, to)
+	}
+
+	if total <= 0 {
+		return zero, false
+	}
+
+	var r float64
+	if rng != nil {
+		r = rng.Float64() * total
+	} else {
+		r = rand.Float64() * total
+	}
+
+	for _, to := range targets {
+		weight := fsm.ruleWeight(state, to)
+		if weight <= 0 {
+			continue
+		}
+
+		if r < weight {
+			return to, true
+		}
+
+		r -= weight
+	}
+
+	// rounding errors can leave r slightly above the last positive weight
+	for i := len(targets) - 1; i >= 0; i-- {
+		if fsm.ruleWeight(state, targets[i]) > 0 {
+			return targets[i], true
+		}
+	}
+
+	return zero, false
+}
+
+// ruleWeight returns the weight of the rule, 1 if it wasn't set
+// The caller must hold the lock
+func (fsm *FSM[T]) ruleWeight(fromState, toState T) float64 {
+	if weight, ok := fsm.weights[edge[T]{from: fromState, to: toState}]; ok {
+		return weight
+	}
+
+	return 1
+}
+
+// setRuleWeight sets the weight of the rule after checking it
+// The caller must hold the lock
+func (fsm *FSM[T]) setRuleWeight(fromState, toState T, weight float64) error {
+	err := fsm.checkRuleWeight(fromState, toState, weight)
+	if err != nil {
+		return err
+	}
+
+	if fsm.weights == nil {
+		fsm.weights = make(map[edge[T]]float64)
+	}
+
+	fsm.weights[edge[T]{from: fromState, to: toState}] = weight
+
+	return nil
+}
+
+// checkRuleWeight checks that the ruleset allows the rule and that the weight is a finite non-negative number
+// The caller must hold the lock
+func (fsm *FSM[T]) checkRuleWeight(fromState, toState T, weight float64) error {
+	if !fsm.canTransition(&fromState, &toState) {
+		return TransitionError[T]{FromState: fromState, ToState: toState}
+	}
+
+	if weight < 0 || math.IsNaN(weight) || math.IsInf(weight, 1) {
+		return fmt.Errorf("invalid weight %v for the rule from %v to %v", weight, fromState, toState)
+	}
+
+	return nil
+}
@@ -0,0 +1,126 @@
+package statetrooper
+
+import (
+	"errors"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func Test_nextStateWeightedRandom(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB, CustomStateEnumC, CustomStateEnumD)
+
+	if err := fsm.SetRuleWeight(CustomStateEnumA, CustomStateEnumB, 3); err != nil {
+		t.Fatalf("SetRuleWeight() returned an error: %v", err)
+	}
+
+	if err := fsm.SetRuleWeight(CustomStateEnumA, CustomStateEnumD, 0); err != nil {
+		t.Fatalf("SetRuleWeight() returned an error: %v", err)
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	counts := make(map[CustomStateEnum]int)
+
+	for i := 0; i < 4000; i++ {
+		state, ok := fsm.NextStateWeightedRandom(rng)
+		if !ok {
+			t.Fatal("NextStateWeightedRandom() returned no state")
+		}
+
+		counts[state]++
+	}
+
+	if counts[CustomStateEnumD] != 0 {
+		t.Errorf("expected D with a weight of 0 never to be picked, got %d", counts[CustomStateEnumD])
+	}
+
+	if ratio := float64(counts[CustomStateEnumB]) / float64(counts[CustomStateEnumC]); ratio < 2.7 || ratio > 3.3 {
+		t.Errorf("expected B to be picked about 3 times as often as C, got %v", counts)
+	}
+
+	if fsm.CurrentState() != CustomStateEnumA {
+		t.Errorf("expected the FSM not to transition, got %v", fsm.CurrentState())
+	}
+
+	if _, ok := NewFSM[CustomStateEnum](CustomStateEnumB, 10).NextStateWeightedRandom(nil); ok {
+		t.Error("expected no state for a state without allowed targets")
+	}
+}
+
+func Test_setRuleWeightErrors(t *testing.T) {
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB)
+
+	var transitionErr TransitionError[CustomStateEnum]
+	if err := fsm.SetRuleWeight(CustomStateEnumB, CustomStateEnumA, 1); !errors.As(err, &transitionErr) {
+		t.Errorf("SetRuleWeight() returned %v, expected a TransitionError", err)
+	}
+
+	if err := fsm.SetRuleWeight(CustomStateEnumA, CustomStateEnumB, -1); err == nil {
+		t.Error("SetRuleWeight() with a negative weight returned no error")
+	}
+
+	err := fsm.SetRuleWeights(map[Rule[CustomStateEnum]]float64{
+		{From: CustomStateEnumA, To: CustomStateEnumB}: 5,
+		{From: CustomStateEnumB, To: CustomStateEnumA}: 1,
+	})
+	if !errors.As(err, &transitionErr) {
+		t.Errorf("SetRuleWeights() returned %v, expected a TransitionError", err)
+	}
+
+	if weight := fsm.RuleWeight(CustomStateEnumA, CustomStateEnumB); weight != 1 {
+		t.Errorf("RuleWeight() = %v, expected no weight to be set", weight)
+	}
+}
+
+func Test_simulateRandomWalk(t *testing.T) {
+	histories := [][]Transition[CustomStateEnum]{
+		{{FromState: CustomStateEnumA, ToState: CustomStateEnumB}, {FromState: CustomStateEnumB, ToState: CustomStateEnumC}},
+		{{FromState: CustomStateEnumA, ToState: CustomStateEnumB}, {FromState: CustomStateEnumB, ToState: CustomStateEnumA}},
+	}
+
+	weights := RuleWeightsFromHistories(histories)
+
+	expectedWeights := map[Rule[CustomStateEnum]]float64{
+		{From: CustomStateEnumA, To: CustomStateEnumB}: 2,
+		{From: CustomStateEnumB, To: CustomStateEnumC}: 1,
+		{From: CustomStateEnumB, To: CustomStateEnumA}: 1,
+	}
+	if !reflect.DeepEqual(weights, expectedWeights) {
+		t.Fatalf("RuleWeightsFromHistories() = %v, expected %v", weights, expectedWeights)
+	}
+
+	fsm := NewFSM[CustomStateEnum](CustomStateEnumA, 10)
+	fsm.AddRule(CustomStateEnumA, CustomStateEnumB, CustomStateEnumD)
+	fsm.AddRule(CustomStateEnumB, CustomStateEnumC, CustomStateEnumA)
+
+	if err := fsm.SetRuleWeights(weights); err != nil {
+		t.Fatalf("SetRuleWeights() returned an error: %v", err)
+	}
+
+	if err := fsm.SetRuleWeight(CustomStateEnumA, CustomStateEnumD, 0); err != nil {
+		t.Fatalf("SetRuleWeight() returned an error: %v", err)
+	}
+
+	walk := fsm.SimulateRandomWalk(100, rand.New(rand.NewSource(1)))
+
+	if walk[0] != CustomStateEnumA || walk[len(walk)-1] != CustomStateEnumC {
+		t.Errorf("expected the walk to start from A and stop at C, got %v", walk)
+	}
+
+	for i := 1; i < len(walk); i++ {
+		if !fsm.canTransition(&walk[i-1], &walk[i]) {
+			t.Errorf("the walk made a transition the rules don't allow: %v -> %v", walk[i-1], walk[i])
+		}
+	}
+
+	again := fsm.SimulateRandomWalk(100, rand.New(rand.NewSource(1)))
+	if !reflect.DeepEqual(walk, again) {
+		t.Errorf("expected the same walk with the same seed, got %v and %v", walk, again)
+	}
+
+	if walk := fsm.SimulateRandomWalk(0, nil); !reflect.DeepEqual(walk, []CustomStateEnum{CustomStateEnumA}) {
+		t.Errorf("SimulateRandomWalk(0) = %v, expected only the current state", walk)
+	}
+}